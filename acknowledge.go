@@ -0,0 +1,106 @@
+package streamdeck
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"time"
+)
+
+// Acknowledgement gives feedback on a display deck for a press on a paired device with no display of its own,
+// such as a Pedal, see Manager.PairPedal.  It is called from the pedal's event goroutine, so it mustn't take long.
+type Acknowledgement func(display *Device, pedalButton int)
+
+type pedalPairing struct {
+	display string
+	acks    []Acknowledgement
+}
+
+// PairPedal pairs a Pedal with a display deck, both given by serial number, so that each press of the pedal is
+// acknowledged on the deck by the given Acknowledgements, such as FlashLinkedButton.  Either can be opened before
+// or after pairing, and the acknowledgements are skipped while the deck isn't open.  Pairing again replaces the
+// pairing; any device can be paired, not only a Pedal, though only devices opened by the Manager are acknowledged.
+func (m *Manager) PairPedal(pedalSerial, displaySerial string, acks ...Acknowledgement) error {
+	if pedalSerial == displaySerial {
+		return errors.New("A device can't acknowledge its own presses")
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.pairings == nil {
+		m.pairings = make(map[string]pedalPairing)
+	}
+	m.pairings[pedalSerial] = pedalPairing{display: displaySerial, acks: append([]Acknowledgement(nil), acks...)}
+	return nil
+}
+
+// UnpairPedal stops acknowledging presses of a pedal paired with PairPedal
+func (m *Manager) UnpairPedal(pedalSerial string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.pairings, pedalSerial)
+}
+
+// acknowledge is the button listener of every device the Manager opens, passing presses of paired devices to
+// their acknowledgements
+func (m *Manager) acknowledge(btnIndex int, d *Device, err error, pressed bool) {
+	if err != nil || !pressed {
+		return
+	}
+	m.lock.Lock()
+	pairing, ok := m.pairings[d.GetSerial()]
+	display := m.devices[pairing.display]
+	m.lock.Unlock()
+	if !ok || display == nil {
+		return
+	}
+	for _, ack := range pairing.acks {
+		ack(display, btnIndex)
+	}
+}
+
+// FlashLinkedButton is an Acknowledgement flashing a button of the display deck in a colour for a moment: the
+// button linked to the pedal button pressed, or the button with the same number if it isn't in links.  The
+// button then goes back to showing whatever was last written to it, including anything written during the flash.
+func FlashLinkedButton(links map[int]int, colour color.Color, duration time.Duration) Acknowledgement {
+	return func(display *Device, pedalButton int) {
+		btnIndex := pedalButton
+		if linked, ok := links[pedalButton]; ok {
+			btnIndex = linked
+		}
+		display.flashButton(btnIndex, colour, duration)
+	}
+}
+
+// flashButton shows a colour on a button for a while, without recording it in the shadow, and then sends the
+// shadow's image for the button again
+func (d *Device) flashButton(btnIndex int, colour color.Color, duration time.Duration) error {
+	if !d.HasImageCapability() {
+		return errors.New("Button doesn't have image capability")
+	}
+	mapped := int(d.mapButtonIn(uint(btnIndex)))
+	if mapped < 0 || mapped >= int(d.deviceType.numberOfButtons) {
+		return fmt.Errorf("Invalid key index: %d", btnIndex)
+	}
+	flash, err := getImageForButton(getSolidColourImage(colour, d.deviceType.imageSize.X), d.deviceType.imageFormat, d.deviceType.quirks)
+	if err != nil {
+		return err
+	}
+	if err := d.sendToButton(mapped, flash); err != nil {
+		return err
+	}
+	time.AfterFunc(duration, func() {
+		d.shadow.lock.Lock()
+		encoded, ok := d.shadow.buttons[mapped]
+		d.shadow.lock.Unlock()
+		if !ok {
+			// Nothing was written before, so the button goes back to black
+			blank, err := d.blankFrame(d.deviceType.imageSize)
+			if err != nil {
+				return
+			}
+			encoded = blank
+		}
+		d.sendToButton(mapped, encoded)
+	})
+	return nil
+}
@@ -0,0 +1,269 @@
+// Package atem controls Blackmagic ATEM switchers over their UDP protocol: cuts, auto transitions and program and
+// preview selection, with tally fed back to show on buttons.
+//
+// The ATEM protocol isn't documented by Blackmagic; this follows the common understanding of it from the open
+// source implementations, and only handles the few commands needed here.
+package atem
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/actionhandlers/tally"
+)
+
+// TallyPrefix is the prefix of the State keys set by BindTally, see tally.StateKey
+const TallyPrefix = "atem"
+
+// Packet header flags
+const (
+	flagAckRequest    = 0x01
+	flagHello         = 0x02
+	flagRetransmit    = 0x04
+	flagResendReq     = 0x08
+	flagAckReply      = 0x10
+	headerLength      = 12
+	connectionTimeout = 5 * time.Second
+)
+
+// Client is a connection to an ATEM switcher
+type Client struct {
+	conn net.Conn
+
+	lock      sync.Mutex
+	session   uint16
+	packetID  uint16
+	state     *streamdeck.State
+	tally     []tally.Tally // The last tally, for BindTally to fill in the State with
+	connected chan struct{}
+	done      chan struct{}
+}
+
+// Dial connects to an ATEM switcher at the given IP address, waiting until it has sent its initial state
+func Dial(address string) (*Client, error) {
+	return dial(net.JoinHostPort(address, "9910"))
+}
+
+// dial connects to a switcher at an address including the port
+func dial(hostport string) (*Client, error) {
+	conn, err := net.Dial("udp", hostport)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:      conn,
+		session:   uint16(rand.Intn(0x7fff)),
+		connected: make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	hello := c.header(flagHello, 8, 0)
+	hello = append(hello, 0x01, 0, 0, 0, 0, 0, 0, 0)
+	if _, err := conn.Write(hello); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.readLoop()
+
+	select {
+	case <-c.connected:
+		return c, nil
+	case <-c.done:
+		return nil, errors.New("ATEM connection refused")
+	case <-time.After(connectionTimeout):
+		conn.Close()
+		return nil, errors.New("ATEM didn't answer")
+	}
+}
+
+// BindTally keeps keys in a StreamDeck's State up to date with the tally of every input, so that buttons can
+// show it with tally.Binding(atem.TallyPrefix, input)
+func (c *Client) BindTally(state *streamdeck.State) {
+	c.lock.Lock()
+	c.state = state
+	current := c.tally
+	c.lock.Unlock()
+	// The switcher sent the tally as it connected, and only sends it again when it changes
+	for i, t := range current {
+		state.Set(tally.StateKey(TallyPrefix, i+1), t)
+	}
+}
+
+// Cut cuts preview to program on a mix effect bus (counting from zero)
+func (c *Client) Cut(me int) error {
+	return c.sendCommand("DCut", []byte{byte(me), 0, 0, 0})
+}
+
+// Auto runs the selected transition on a mix effect bus
+func (c *Client) Auto(me int) error {
+	return c.sendCommand("DAut", []byte{byte(me), 0, 0, 0})
+}
+
+// SetProgram cuts a source straight to program on a mix effect bus; sources are numbered as on the switcher,
+// with inputs from 1
+func (c *Client) SetProgram(me int, source int) error {
+	return c.sendCommand("CPgI", []byte{byte(me), 0, byte(source >> 8), byte(source)})
+}
+
+// SetPreview puts a source on preview on a mix effect bus
+func (c *Client) SetPreview(me int, source int) error {
+	return c.sendCommand("CPvI", []byte{byte(me), 0, byte(source >> 8), byte(source)})
+}
+
+// Done is closed when the connection to the switcher is lost
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close disconnects from the switcher
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// header builds a packet header; the length covers the header as well as the payload
+func (c *Client) header(flags uint16, payloadLength int, ackID uint16) []byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	h := make([]byte, headerLength)
+	binary.BigEndian.PutUint16(h[0:], flags<<11|uint16(headerLength+payloadLength))
+	binary.BigEndian.PutUint16(h[2:], c.session)
+	binary.BigEndian.PutUint16(h[4:], ackID)
+	if flags&flagAckRequest != 0 {
+		c.packetID = (c.packetID + 1) & 0x7fff
+		binary.BigEndian.PutUint16(h[10:], c.packetID)
+	}
+	return h
+}
+
+func (c *Client) sendCommand(name string, data []byte) error {
+	cmd := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint16(cmd[0:], uint16(8+len(data)))
+	copy(cmd[4:], name)
+	cmd = append(cmd, data...)
+	packet := append(c.header(flagAckRequest, len(cmd), 0), cmd...)
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+func (c *Client) readLoop() {
+	defer close(c.done)
+	buf := make([]byte, 2048)
+	initialised := false
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(connectionTimeout))
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			c.conn.Close()
+			return
+		}
+		if n < headerLength {
+			continue
+		}
+		packet := buf[:n]
+		flags := binary.BigEndian.Uint16(packet) >> 11
+		remoteID := binary.BigEndian.Uint16(packet[10:])
+
+		c.lock.Lock()
+		c.session = binary.BigEndian.Uint16(packet[2:])
+		c.lock.Unlock()
+
+		if flags&flagHello != 0 {
+			c.conn.Write(c.header(flagAckReply, 0, 0))
+			continue
+		}
+		if flags&flagAckRequest != 0 {
+			c.conn.Write(c.header(flagAckReply, 0, remoteID))
+		}
+		c.parseCommands(packet[headerLength:])
+
+		// The switcher's initial state dump ends with an empty packet
+		if !initialised && n == headerLength {
+			initialised = true
+			close(c.connected)
+		}
+	}
+}
+
+// parseCommands looks through the commands in a packet for the tally
+func (c *Client) parseCommands(data []byte) {
+	for len(data) >= 8 {
+		length := int(binary.BigEndian.Uint16(data))
+		if length < 8 || length > len(data) {
+			return
+		}
+		name := string(data[4:8])
+		if name == "TlIn" {
+			c.updateTally(data[8:length])
+		}
+		data = data[length:]
+	}
+}
+
+// updateTally reads a "TlIn" command; a count of inputs, then a byte each with bit 0 set for program and bit 1
+// for preview
+func (c *Client) updateTally(data []byte) {
+	if len(data) < 2 {
+		return
+	}
+	count := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+count {
+		return
+	}
+	current := make([]tally.Tally, count)
+	for i := range current {
+		if data[2+i]&0x01 != 0 {
+			current[i] = tally.Program
+		} else if data[2+i]&0x02 != 0 {
+			current[i] = tally.Preview
+		}
+	}
+	c.lock.Lock()
+	state := c.state
+	c.tally = current
+	c.lock.Unlock()
+	if state == nil {
+		return
+	}
+	for i, t := range current {
+		state.Set(tally.StateKey(TallyPrefix, i+1), t)
+	}
+}
+
+// Action runs something on the switcher when the button is pressed
+type Action struct {
+	run func() error
+}
+
+func (action *Action) Pressed(btn streamdeck.Button) {
+	action.run()
+}
+
+// Run runs the action, returning any error sending it
+func (action *Action) Run(btn streamdeck.Button) error {
+	return action.run()
+}
+
+// NewCutAction creates an Action cutting preview to program on a mix effect bus (counting from zero)
+func NewCutAction(client *Client, me int) *Action {
+	return &Action{run: func() error { return client.Cut(me) }}
+}
+
+// NewAutoAction creates an Action running the selected transition on a mix effect bus
+func NewAutoAction(client *Client, me int) *Action {
+	return &Action{run: func() error { return client.Auto(me) }}
+}
+
+// NewProgramAction creates an Action cutting a source straight to program on a mix effect bus
+func NewProgramAction(client *Client, me int, source int) *Action {
+	return &Action{run: func() error { return client.SetProgram(me, source) }}
+}
+
+// NewPreviewAction creates an Action putting a source on preview on a mix effect bus
+func NewPreviewAction(client *Client, me int, source int) *Action {
+	return &Action{run: func() error { return client.SetPreview(me, source) }}
+}
@@ -0,0 +1,176 @@
+package atem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/actionhandlers/tally"
+)
+
+// fakeATEM is the switcher's end of a connection
+type fakeATEM struct {
+	t      *testing.T
+	conn   *net.UDPConn
+	client *net.UDPAddr
+	sent   uint16 // Packet ID of the last packet sent
+}
+
+func (f *fakeATEM) read() (flags uint16, session uint16, ackID uint16, packetID uint16, payload []byte) {
+	f.t.Helper()
+	buf := make([]byte, 2048)
+	f.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, addr, err := f.conn.ReadFromUDP(buf)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.client = addr
+	p := buf[:n]
+	if length := int(binary.BigEndian.Uint16(p) & 0x7ff); length != n {
+		f.t.Errorf("A %d byte packet says it is %d bytes", n, length)
+	}
+	return binary.BigEndian.Uint16(p) >> 11, binary.BigEndian.Uint16(p[2:]), binary.BigEndian.Uint16(p[4:]),
+		binary.BigEndian.Uint16(p[10:]), p[headerLength:]
+}
+
+func (f *fakeATEM) send(flags uint16, payload []byte) {
+	f.t.Helper()
+	h := make([]byte, headerLength)
+	binary.BigEndian.PutUint16(h, flags<<11|uint16(headerLength+len(payload)))
+	binary.BigEndian.PutUint16(h[2:], 0x8123)
+	if flags&flagAckRequest != 0 {
+		f.sent++
+		binary.BigEndian.PutUint16(h[10:], f.sent)
+	}
+	if _, err := f.conn.WriteToUDP(append(h, payload...), f.client); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// sendAcked sends a packet wanting an acknowledgement, and checks it comes
+func (f *fakeATEM) sendAcked(payload []byte) {
+	f.t.Helper()
+	f.send(flagAckRequest, payload)
+	flags, _, ackID, _, _ := f.read()
+	if flags != flagAckReply || ackID != f.sent {
+		f.t.Errorf("Packet %d was answered with flags %#x, acknowledging %d", f.sent, flags, ackID)
+	}
+}
+
+func command(name string, data ...byte) []byte {
+	cmd := make([]byte, 8)
+	binary.BigEndian.PutUint16(cmd, uint16(8+len(data)))
+	copy(cmd[4:], name)
+	return append(cmd, data...)
+}
+
+// connect has a client connect to a fake switcher, which sends some tally in its initial state
+func connect(t *testing.T) (*Client, *fakeATEM) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeATEM{t: t, conn: conn}
+	connected := make(chan *Client)
+	go func() {
+		c, err := dial(conn.LocalAddr().String())
+		if err != nil {
+			t.Error(err)
+		}
+		connected <- c
+	}()
+
+	flags, _, _, _, payload := f.read()
+	if flags != flagHello || len(payload) != 8 || payload[0] != 0x01 {
+		t.Fatalf("The client said hello with flags %#x and % x", flags, payload)
+	}
+	f.send(flagHello, []byte{0x02, 0, 0, 0, 0, 0, 0, 0})
+	if flags, _, _, _, _ = f.read(); flags != flagAckReply {
+		t.Errorf("Hello was answered with flags %#x", flags)
+	}
+	// The initial state, with something which isn't tally first, then its end
+	f.sendAcked(append(command("_ver", 0, 2, 0, 30), command("TlIn", 0, 4, 0x01, 0x02, 0x03, 0x00)...))
+	f.sendAcked(nil)
+
+	select {
+	case c := <-connected:
+		if c == nil {
+			t.FailNow()
+		}
+		return c, f
+	case <-time.After(5 * time.Second):
+		t.Fatal("The client didn't finish connecting")
+	}
+	return nil, nil
+}
+
+func TestTally(t *testing.T) {
+	c, f := connect(t)
+	defer f.conn.Close()
+	defer c.Close()
+
+	state := streamdeck.NewState()
+	c.BindTally(state)
+	check := func(when string, want []tally.Tally) {
+		t.Helper()
+		for i, w := range want {
+			if got, _ := state.Get(tally.StateKey(TallyPrefix, i+1)); got != w {
+				t.Errorf("%s, input %d has tally %v, not %v", when, i+1, got, w)
+			}
+		}
+	}
+	// Program wins over preview
+	check("After connecting", []tally.Tally{tally.Program, tally.Preview, tally.Program, tally.Off})
+
+	changed := make(chan struct{}, 4)
+	state.Watch(tally.StateKey(TallyPrefix, 4), func(interface{}) { changed <- struct{}{} })
+	f.sendAcked(command("TlIn", 0, 4, 0x00, 0x01, 0x00, 0x02))
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The tally didn't change")
+	}
+	check("After a change", []tally.Tally{tally.Off, tally.Program, tally.Off, tally.Preview})
+
+	// Commands which are cut short are ignored
+	f.sendAcked(command("TlIn", 0, 9, 0x01))
+	check("After a short command", []tally.Tally{tally.Off, tally.Program, tally.Off, tally.Preview})
+}
+
+func TestCommands(t *testing.T) {
+	c, f := connect(t)
+	defer f.conn.Close()
+
+	var lastID uint16
+	for _, tc := range []struct {
+		action *Action
+		want   []byte
+	}{
+		{NewCutAction(c, 0), command("DCut", 0, 0, 0, 0)},
+		{NewAutoAction(c, 1), command("DAut", 1, 0, 0, 0)},
+		{NewProgramAction(c, 0, 3), command("CPgI", 0, 0, 0, 3)},
+		{NewPreviewAction(c, 1, 0x0bc2), command("CPvI", 1, 0, 0x0b, 0xc2)}, // Color bars, 3010
+	} {
+		if err := tc.action.Run(nil); err != nil {
+			t.Fatal(err)
+		}
+		flags, session, _, packetID, payload := f.read()
+		if flags != flagAckRequest || session != 0x8123 || packetID != lastID+1 {
+			t.Errorf("%s was sent with flags %#x, session %#x, packet ID %d", tc.want[4:8], flags, session, packetID)
+		}
+		if !bytes.Equal(payload, tc.want) {
+			t.Errorf("Sent % x, not % x", payload, tc.want)
+		}
+		lastID = packetID
+	}
+
+	c.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Error("Closing the client didn't end it")
+	}
+}
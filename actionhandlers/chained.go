@@ -1,9 +1,47 @@
 package actionhandlers
 
-import streamdeck "github.com/SKAARHOJ/go-streamdeck"
+import (
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// FallibleAction is an action which can report that it failed, so that a ChainedAction can stop there
+type FallibleAction interface {
+	streamdeck.ButtonActionHandler
+	Run(streamdeck.Button) error
+}
+
+// ChainOption is an option for NewChainedAction
+type ChainOption func(*ChainedAction)
+
+// WithDelayBetween waits between each of the actions; the chain is then run in the background, so that the
+// button events aren't held up
+func WithDelayBetween(d time.Duration) ChainOption {
+	return func(act *ChainedAction) {
+		act.delay = d
+	}
+}
+
+// WithAbortOnError stops the chain at the first FallibleAction which fails
+func WithAbortOnError() ChainOption {
+	return func(act *ChainedAction) {
+		act.abortOnError = true
+	}
+}
+
+// WithErrorHandler passes any errors from FallibleActions in the chain to f
+func WithErrorHandler(f func(error)) ChainOption {
+	return func(act *ChainedAction) {
+		act.errorHandler = f
+	}
+}
 
 type ChainedAction struct {
-	actions []streamdeck.ButtonActionHandler
+	actions      []streamdeck.ButtonActionHandler
+	delay        time.Duration
+	abortOnError bool
+	errorHandler func(error)
 }
 
 func (act *ChainedAction) AddAction(newaction streamdeck.ButtonActionHandler) {
@@ -11,15 +49,52 @@ func (act *ChainedAction) AddAction(newaction streamdeck.ButtonActionHandler) {
 }
 
 func (act *ChainedAction) Pressed(btn streamdeck.Button) {
-	for _, a := range act.actions {
-		a.Pressed(btn)
+	if act.delay > 0 {
+		go act.Run(btn)
+		return
 	}
+	act.Run(btn)
 }
 
-func NewEmptyChainedAction() *ChainedAction {
-	return &ChainedAction{}
+// Run runs each of the actions in turn, returning the first error from a FallibleAction
+func (act *ChainedAction) Run(btn streamdeck.Button) error {
+	var firstErr error
+	for i, a := range act.actions {
+		if i > 0 && act.delay > 0 {
+			time.Sleep(act.delay)
+		}
+		f, ok := a.(FallibleAction)
+		if !ok {
+			a.Pressed(btn)
+			continue
+		}
+		err := f.Run(btn)
+		if err == nil {
+			continue
+		}
+		if act.errorHandler != nil {
+			act.errorHandler(err)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if act.abortOnError {
+			break
+		}
+	}
+	return firstErr
+}
+
+func NewEmptyChainedAction(opts ...ChainOption) *ChainedAction {
+	act := &ChainedAction{}
+	for _, opt := range opts {
+		opt(act)
+	}
+	return act
 }
 
-func NewChainedAction(actions []streamdeck.ButtonActionHandler) *ChainedAction {
-	return &ChainedAction{actions: actions}
+func NewChainedAction(actions []streamdeck.ButtonActionHandler, opts ...ChainOption) *ChainedAction {
+	act := NewEmptyChainedAction(opts...)
+	act.actions = actions
+	return act
 }
@@ -0,0 +1,62 @@
+package actionhandlers
+
+import streamdeck "github.com/SKAARHOJ/go-streamdeck"
+
+// PageAction switches the StreamDeck to a page when the button is pressed
+type PageAction struct {
+	sd   *streamdeck.StreamDeck
+	page string
+}
+
+func (action *PageAction) Pressed(btn streamdeck.Button) {
+	action.sd.SetPage(action.page)
+}
+
+// Run switches page, returning an error if there is no such page
+func (action *PageAction) Run(btn streamdeck.Button) error {
+	return action.sd.SetPage(action.page)
+}
+
+func NewPageAction(sd *streamdeck.StreamDeck, page string) *PageAction {
+	return &PageAction{sd: sd, page: page}
+}
+
+// BackAction leaves the current folder when the button is pressed, see StreamDeck.Back
+type BackAction struct {
+	sd *streamdeck.StreamDeck
+}
+
+func (action *BackAction) Pressed(btn streamdeck.Button) {
+	action.sd.Back()
+}
+
+func NewBackAction(sd *streamdeck.StreamDeck) *BackAction {
+	return &BackAction{sd: sd}
+}
+
+// BrightnessAction changes the brightness by a step, up or down, when the button is pressed
+type BrightnessAction struct {
+	sd    *streamdeck.StreamDeck
+	delta int
+}
+
+func (action *BrightnessAction) Pressed(btn streamdeck.Button) {
+	action.sd.StepBrightness(action.delta)
+}
+
+func NewBrightnessStepAction(sd *streamdeck.StreamDeck, delta int) *BrightnessAction {
+	return &BrightnessAction{sd: sd, delta: delta}
+}
+
+// ScreensaverAction toggles the screensaver when the button is pressed
+type ScreensaverAction struct {
+	sd *streamdeck.StreamDeck
+}
+
+func (action *ScreensaverAction) Pressed(btn streamdeck.Button) {
+	action.sd.ToggleScreensaver()
+}
+
+func NewScreensaverAction(sd *streamdeck.StreamDeck) *ScreensaverAction {
+	return &ScreensaverAction{sd: sd}
+}
@@ -0,0 +1,19 @@
+package actionhandlers
+
+import streamdeck "github.com/SKAARHOJ/go-streamdeck"
+
+// FolderAction opens a page as a folder when the button is pressed, see StreamDeck.OpenFolder
+type FolderAction struct {
+	sd   *streamdeck.StreamDeck
+	page string
+}
+
+// Pressed opens the folder
+func (action *FolderAction) Pressed(btn streamdeck.Button) {
+	action.sd.OpenFolder(action.page)
+}
+
+// NewFolderAction creates a FolderAction which opens the named page, which must have been added with AddPage
+func NewFolderAction(sd *streamdeck.StreamDeck, page string) *FolderAction {
+	return &FolderAction{sd: sd, page: page}
+}
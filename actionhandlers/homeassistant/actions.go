@@ -0,0 +1,62 @@
+package homeassistant
+
+import (
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/buttons"
+)
+
+// StateKeyPrefix is the prefix of the State keys set by BindState, followed by the entity ID
+const StateKeyPrefix = "homeassistant."
+
+// Action calls a Home Assistant service when the button is pressed
+type Action struct {
+	client   *Client
+	domain   string
+	service  string
+	entityID string
+	data     map[string]interface{}
+}
+
+func (action *Action) Pressed(btn streamdeck.Button) {
+	go action.Run(btn)
+}
+
+// Run calls the service and waits for Home Assistant to answer, returning any error
+func (action *Action) Run(btn streamdeck.Button) error {
+	return action.client.CallService(action.domain, action.service, action.entityID, action.data)
+}
+
+// NewServiceAction creates an Action calling any service, such as "scene" "turn_on"; entityID may be empty and
+// data may be nil
+func NewServiceAction(client *Client, domain string, service string, entityID string, data map[string]interface{}) *Action {
+	return &Action{client: client, domain: domain, service: service, entityID: entityID, data: data}
+}
+
+// NewToggleAction creates an Action toggling an entity, such as a light or a switch
+func NewToggleAction(client *Client, entityID string) *Action {
+	return NewServiceAction(client, domainOf(entityID), "toggle", entityID, nil)
+}
+
+// BindToggle keeps a ToggleButton showing whether an entity is on, for example a light's on and off icons
+func BindToggle(client *Client, entityID string, btn *buttons.ToggleButton) {
+	client.Watch(entityID, func(s EntityState) {
+		btn.SetState(s.State == "on")
+	})
+}
+
+// BindText keeps the text of a button showing the state of an entity, followed by its unit if it has one (so
+// a temperature sensor shows as "21.5°C")
+func BindText(client *Client, entityID string, btn *buttons.TextButton) {
+	client.Watch(entityID, func(s EntityState) {
+		btn.SetText(s.State + s.Unit())
+	})
+}
+
+// BindState keeps a key in a StreamDeck's State (StateKeyPrefix followed by the entity ID) set to the state of an
+// entity as a string, so that buttons can show it with BindState, for example
+// WhenEquals("homeassistant.light.kitchen", "on", border)
+func BindState(client *Client, entityID string, state *streamdeck.State) {
+	client.Watch(entityID, func(s EntityState) {
+		state.Set(StateKeyPrefix+entityID, s.State)
+	})
+}
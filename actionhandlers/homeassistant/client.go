@@ -0,0 +1,242 @@
+// Package homeassistant controls Home Assistant through its WebSocket API: calling services when buttons are
+// pressed, and mirroring entity states onto buttons, such as a light's on/off icon or a temperature label
+package homeassistant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SKAARHOJ/go-streamdeck/internal/websocket"
+)
+
+// EntityState is the state of an entity, as Home Assistant reports it
+type EntityState struct {
+	EntityID   string                 `json:"entity_id"`
+	State      string                 `json:"state"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// Unit returns the entity's unit of measurement, such as "°C", or "" if it has none
+func (s EntityState) Unit() string {
+	unit, _ := s.Attributes["unit_of_measurement"].(string)
+	return unit
+}
+
+type result struct {
+	ID      int             `json:"id"`
+	Type    string          `json:"type"`
+	Success bool            `json:"success"`
+	Result  json.RawMessage `json:"result"`
+	Error   *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Event *struct {
+		Data struct {
+			EntityID string       `json:"entity_id"`
+			NewState *EntityState `json:"new_state"`
+		} `json:"data"`
+	} `json:"event"`
+}
+
+// Client is a connection to Home Assistant
+type Client struct {
+	conn *websocket.Conn
+
+	lock     sync.Mutex
+	nextID   int
+	pending  map[int]chan result
+	watchers map[string][]func(EntityState)
+	states   map[string]EntityState
+	done     chan struct{}
+}
+
+// Dial connects to Home Assistant's WebSocket API, for example at "ws://homeassistant.local:8123/api/websocket",
+// authenticating with a long-lived access token (created on the user's profile page in Home Assistant)
+func Dial(url string, token string) (*Client, error) {
+	conn, err := websocket.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	var m result
+	if err := readMessage(conn, &m); err != nil || m.Type != "auth_required" {
+		conn.Close()
+		return nil, errors.New("Home Assistant didn't ask for authentication")
+	}
+	if err := writeMessage(conn, map[string]interface{}{"type": "auth", "access_token": token}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := readMessage(conn, &m); err != nil || m.Type != "auth_ok" {
+		conn.Close()
+		return nil, errors.New("Home Assistant refused the connection; is the access token right?")
+	}
+
+	c := &Client{
+		conn:     conn,
+		pending:  make(map[int]chan result),
+		watchers: make(map[string][]func(EntityState)),
+		states:   make(map[string]EntityState),
+		done:     make(chan struct{}),
+	}
+	go c.readLoop()
+
+	if _, err := c.command(map[string]interface{}{"type": "subscribe_events", "event_type": "state_changed"}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	data, err := c.command(map[string]interface{}{"type": "get_states"})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var states []EntityState
+	json.Unmarshal(data, &states)
+	c.lock.Lock()
+	for _, s := range states {
+		c.states[s.EntityID] = s
+	}
+	c.lock.Unlock()
+	return c, nil
+}
+
+// CallService calls a service, such as "light" "toggle", on an entity; entityID may be empty for services which
+// don't target one, and data may be nil
+func (c *Client) CallService(domain string, service string, entityID string, data map[string]interface{}) error {
+	cmd := map[string]interface{}{"type": "call_service", "domain": domain, "service": service}
+	if entityID != "" {
+		cmd["target"] = map[string]interface{}{"entity_id": entityID}
+	}
+	if data != nil {
+		cmd["service_data"] = data
+	}
+	_, err := c.command(cmd)
+	return err
+}
+
+// GetState returns the last known state of an entity
+func (c *Client) GetState(entityID string) (EntityState, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s, ok := c.states[entityID]
+	return s, ok
+}
+
+// Watch registers a function to be called with the state of an entity whenever it changes; it is called
+// straight away with the current state, if Home Assistant knows the entity
+func (c *Client) Watch(entityID string, f func(EntityState)) {
+	c.lock.Lock()
+	c.watchers[entityID] = append(c.watchers[entityID], f)
+	s, ok := c.states[entityID]
+	c.lock.Unlock()
+	if ok {
+		f(s)
+	}
+}
+
+// Done is closed when the connection to Home Assistant is lost
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close disconnects from Home Assistant
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// command sends a command and waits for its result
+func (c *Client) command(cmd map[string]interface{}) (json.RawMessage, error) {
+	c.lock.Lock()
+	c.nextID++
+	id := c.nextID
+	ch := make(chan result, 1)
+	c.pending[id] = ch
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+	}()
+
+	cmd["id"] = id
+	if err := writeMessage(c.conn, cmd); err != nil {
+		return nil, err
+	}
+	select {
+	case r := <-ch:
+		if !r.Success {
+			if r.Error != nil {
+				return nil, fmt.Errorf("Home Assistant %s failed (%s): %s", cmd["type"], r.Error.Code, r.Error.Message)
+			}
+			return nil, fmt.Errorf("Home Assistant %s failed", cmd["type"])
+		}
+		return r.Result, nil
+	case <-c.done:
+		return nil, errors.New("Connection to Home Assistant lost")
+	case <-time.After(10 * time.Second):
+		return nil, fmt.Errorf("Home Assistant %s timed out", cmd["type"])
+	}
+}
+
+func (c *Client) readLoop() {
+	defer close(c.done)
+	for {
+		var m result
+		if err := readMessage(c.conn, &m); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				continue
+			}
+			return
+		}
+		switch m.Type {
+		case "result":
+			c.lock.Lock()
+			ch := c.pending[m.ID]
+			c.lock.Unlock()
+			if ch != nil {
+				ch <- m
+			}
+		case "event":
+			if m.Event == nil || m.Event.Data.NewState == nil {
+				continue
+			}
+			s := *m.Event.Data.NewState
+			c.lock.Lock()
+			c.states[s.EntityID] = s
+			watchers := c.watchers[s.EntityID]
+			c.lock.Unlock()
+			for _, f := range watchers {
+				f(s)
+			}
+		}
+	}
+}
+
+// domainOf gives the domain of an entity, such as "light" for "light.kitchen"
+func domainOf(entityID string) string {
+	if i := strings.Index(entityID, "."); i >= 0 {
+		return entityID[:i]
+	}
+	return entityID
+}
+
+func readMessage(conn *websocket.Conn, m *result) error {
+	data, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, m)
+}
+
+func writeMessage(conn *websocket.Conn, m map[string]interface{}) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(data)
+}
@@ -0,0 +1,201 @@
+package homeassistant
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/buttons"
+	"github.com/SKAARHOJ/go-streamdeck/internal/websocket"
+)
+
+// fakeHomeAssistant accepts the token "token", knows a kitchen light and a thermometer, and has no "missing"
+// services
+type fakeHomeAssistant struct {
+	l        net.Listener
+	commands chan map[string]interface{}
+	conns    chan *websocket.Conn
+}
+
+func newFakeHomeAssistant(t *testing.T) *fakeHomeAssistant {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeHomeAssistant{l: l, commands: make(chan map[string]interface{}, 10), conns: make(chan *websocket.Conn, 1)}
+	go f.serve()
+	return f
+}
+
+func (f *fakeHomeAssistant) url() string {
+	return "ws://" + f.l.Addr().String() + "/api/websocket"
+}
+
+func send(c *websocket.Conn, m map[string]interface{}) {
+	data, _ := json.Marshal(m)
+	c.WriteMessage(data)
+}
+
+func (f *fakeHomeAssistant) serve() {
+	conn, err := f.l.Accept()
+	if err != nil {
+		return
+	}
+	c, err := websocket.Accept(conn)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer c.Close()
+
+	send(c, map[string]interface{}{"type": "auth_required", "ha_version": "2024.1.0"})
+	var auth map[string]interface{}
+	if data, err := c.ReadMessage(); err != nil || json.Unmarshal(data, &auth) != nil {
+		return
+	}
+	if auth["type"] != "auth" || auth["access_token"] != "token" {
+		send(c, map[string]interface{}{"type": "auth_invalid", "message": "Invalid access token"})
+		return
+	}
+	send(c, map[string]interface{}{"type": "auth_ok", "ha_version": "2024.1.0"})
+	f.conns <- c
+
+	for {
+		data, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd map[string]interface{}
+		if json.Unmarshal(data, &cmd) != nil {
+			continue
+		}
+		f.commands <- cmd
+		r := map[string]interface{}{"id": cmd["id"], "type": "result", "success": true, "result": nil}
+		switch {
+		case cmd["type"] == "get_states":
+			r["result"] = []EntityState{
+				{EntityID: "light.kitchen", State: "off"},
+				{EntityID: "sensor.temperature", State: "21.5", Attributes: map[string]interface{}{"unit_of_measurement": "°C"}},
+			}
+		case cmd["domain"] == "missing":
+			r["success"] = false
+			r["error"] = map[string]string{"code": "not_found", "message": "Service not found."}
+		}
+		send(c, r)
+	}
+}
+
+// dial connects to a fake Home Assistant, returning the fake's end of the connection
+func dial(t *testing.T) (*Client, *fakeHomeAssistant, *websocket.Conn) {
+	f := newFakeHomeAssistant(t)
+	c, err := Dial(f.url(), "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Connecting subscribes to state changes, and gets the states as they are
+	for _, want := range []string{"subscribe_events", "get_states"} {
+		if cmd := <-f.commands; cmd["type"] != want {
+			t.Errorf("Home Assistant was sent %v, not %s", cmd, want)
+		}
+	}
+	return c, f, <-f.conns
+}
+
+func TestDialToken(t *testing.T) {
+	f := newFakeHomeAssistant(t)
+	defer f.l.Close()
+	if _, err := Dial(f.url(), "wrong"); err == nil {
+		t.Error("The wrong token was accepted")
+	}
+}
+
+func TestStates(t *testing.T) {
+	c, f, server := dial(t)
+	defer f.l.Close()
+	defer c.Close()
+
+	if s, ok := c.GetState("sensor.temperature"); !ok || s.State+s.Unit() != "21.5°C" {
+		t.Errorf("The temperature is %+v", s)
+	}
+	if _, ok := c.GetState("light.garage"); ok {
+		t.Error("An unknown entity has a state")
+	}
+
+	btn := buttons.NewTextToggleButton("On", "Off")
+	btn.SetState(true)
+	BindToggle(c, "light.kitchen", btn)
+	if btn.IsOn() {
+		t.Error("BindToggle didn't fill in the state straight away")
+	}
+	state := streamdeck.NewState()
+	BindState(c, "light.kitchen", state)
+	changed := make(chan interface{}, 1)
+	state.Watch(StateKeyPrefix+"light.kitchen", func(value interface{}) { changed <- value })
+
+	send(server, map[string]interface{}{"id": 1, "type": "event", "event": map[string]interface{}{
+		"event_type": "state_changed",
+		"data": map[string]interface{}{"entity_id": "light.kitchen",
+			"new_state": map[string]interface{}{"entity_id": "light.kitchen", "state": "on"}},
+	}})
+	select {
+	case value := <-changed:
+		if value != "on" {
+			t.Errorf("The light's state is %v", value)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The light's state didn't change")
+	}
+	if !btn.IsOn() {
+		t.Error("BindToggle didn't follow the state")
+	}
+	if s, _ := c.GetState("light.kitchen"); s.State != "on" {
+		t.Errorf("The light's state is %+v", s)
+	}
+}
+
+func TestActions(t *testing.T) {
+	c, f, server := dial(t)
+	defer f.l.Close()
+
+	for _, tc := range []struct {
+		action *Action
+		want   string
+	}{
+		{NewToggleAction(c, "light.kitchen"),
+			`{"domain":"light","service":"toggle","target":{"entity_id":"light.kitchen"},"type":"call_service"}`},
+		{NewServiceAction(c, "scene", "turn_on", "scene.evening", map[string]interface{}{"transition": 2}),
+			`{"domain":"scene","service":"turn_on","service_data":{"transition":2},"target":{"entity_id":"scene.evening"},"type":"call_service"}`},
+		{NewServiceAction(c, "homeassistant", "restart", "", nil),
+			`{"domain":"homeassistant","service":"restart","type":"call_service"}`},
+	} {
+		if err := tc.action.Run(nil); err != nil {
+			t.Fatal(err)
+		}
+		cmd := <-f.commands
+		if _, ok := cmd["id"].(float64); !ok {
+			t.Errorf("%v has no ID", cmd)
+		}
+		delete(cmd, "id")
+		if got, _ := json.Marshal(cmd); string(got) != tc.want {
+			t.Errorf("Home Assistant was sent %s, not %s", got, tc.want)
+		}
+	}
+
+	err := NewServiceAction(c, "missing", "thing", "", nil).Run(nil)
+	if err == nil || err.Error() != "Home Assistant call_service failed (not_found): Service not found." {
+		t.Errorf("A failed service call gave %v", err)
+	}
+	<-f.commands
+
+	server.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Losing the connection wasn't noticed")
+	}
+	if err := NewToggleAction(c, "light.kitchen").Run(nil); err == nil {
+		t.Error("A service call after losing the connection succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+package actionhandlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// KeyboardAction presses a key combination, as if typed on the computer's keyboard, to trigger shortcuts in other
+// applications.  It uses xdotool on Linux (which must be installed, and needs X11), System Events via osascript
+// on macOS, and WScript.Shell via PowerShell on Windows.
+type KeyboardAction struct {
+	combo keyCombo
+	err   func(error)
+}
+
+// keyCombo is a key along with the modifiers held down while pressing it, all in lower case
+type keyCombo struct {
+	modifiers []string
+	key       string
+}
+
+var keyModifiers = map[string]string{
+	"ctrl":    "ctrl",
+	"control": "ctrl",
+	"shift":   "shift",
+	"alt":     "alt",
+	"option":  "alt",
+	"cmd":     "super",
+	"command": "super",
+	"super":   "super",
+	"win":     "super",
+	"meta":    "super",
+}
+
+// NewKeyboardAction creates a KeyboardAction from a combination such as "ctrl+shift+s" or "alt+f4".  Modifiers are
+// ctrl, shift, alt and super (also called cmd or win); named keys are enter, tab, space, esc, backspace, delete,
+// up, down, left, right, home, end, pageup, pagedown and f1 to f12.  The plus key itself can be given as "plus"
+// or as a trailing "+", as in "ctrl+plus" or "ctrl++".
+func NewKeyboardAction(combo string) (*KeyboardAction, error) {
+	combo = strings.ToLower(strings.TrimSpace(combo))
+	if combo == "+" || strings.HasSuffix(combo, "++") {
+		combo = combo[:len(combo)-1] + "plus"
+	}
+	parts := strings.Split(combo, "+")
+	var kc keyCombo
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if i < len(parts)-1 {
+			modifier, ok := keyModifiers[part]
+			if !ok {
+				return nil, fmt.Errorf("Unknown key modifier %q", part)
+			}
+			kc.modifiers = append(kc.modifiers, modifier)
+			continue
+		}
+		if part == "" {
+			return nil, errors.New("Key combination has no key")
+		}
+		if len([]rune(part)) > 1 && !isNamedKey(part) {
+			return nil, fmt.Errorf("Unknown key %q", part)
+		}
+		if part == "plus" {
+			part = "+"
+		}
+		kc.key = part
+	}
+	return &KeyboardAction{combo: kc}, nil
+}
+
+// SetErrorHandler sets a function to be told when the key combination couldn't be sent
+func (action *KeyboardAction) SetErrorHandler(f func(error)) {
+	action.err = f
+}
+
+// Pressed sends the key combination
+func (action *KeyboardAction) Pressed(btn streamdeck.Button) {
+	err := action.Run(btn)
+	if err != nil && action.err != nil {
+		action.err(err)
+	}
+}
+
+// Run sends the key combination, returning any error, so that a ChainedAction can stop if it fails
+func (action *KeyboardAction) Run(btn streamdeck.Button) error {
+	return sendKeyCombo(action.combo)
+}
+
+var namedKeys = []string{
+	"enter", "tab", "space", "esc", "backspace", "delete", "up", "down", "left", "right",
+	"home", "end", "pageup", "pagedown", "plus",
+	"f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "f10", "f11", "f12",
+}
+
+func isNamedKey(key string) bool {
+	for _, k := range namedKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
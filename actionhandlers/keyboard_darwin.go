@@ -0,0 +1,42 @@
+//go:build darwin
+// +build darwin
+
+package actionhandlers
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// macKeyCodes are the virtual key codes for keys which can't be sent with "keystroke"
+var macKeyCodes = map[string]int{
+	"enter": 36, "tab": 48, "space": 49, "backspace": 51, "esc": 53, "delete": 117,
+	"left": 123, "right": 124, "down": 125, "up": 126,
+	"home": 115, "end": 119, "pageup": 116, "pagedown": 121,
+	"f1": 122, "f2": 120, "f3": 99, "f4": 118, "f5": 96, "f6": 97,
+	"f7": 98, "f8": 100, "f9": 101, "f10": 109, "f11": 103, "f12": 111,
+}
+
+var macModifiers = map[string]string{
+	"ctrl":  "control down",
+	"shift": "shift down",
+	"alt":   "option down",
+	"super": "command down",
+}
+
+func sendKeyCombo(kc keyCombo) error {
+	var modifiers []string
+	for _, m := range kc.modifiers {
+		modifiers = append(modifiers, macModifiers[m])
+	}
+
+	script := fmt.Sprintf("tell application \"System Events\" to keystroke %q", kc.key)
+	if code, ok := macKeyCodes[kc.key]; ok {
+		script = fmt.Sprintf("tell application \"System Events\" to key code %d", code)
+	}
+	if len(modifiers) > 0 {
+		script += " using {" + strings.Join(modifiers, ", ") + "}"
+	}
+	return exec.Command("osascript", "-e", script).Run()
+}
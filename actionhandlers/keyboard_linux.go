@@ -0,0 +1,39 @@
+//go:build linux
+// +build linux
+
+package actionhandlers
+
+import (
+	"os/exec"
+	"strings"
+)
+
+var xdotoolKeys = map[string]string{
+	"enter":     "Return",
+	"tab":       "Tab",
+	"space":     "space",
+	"esc":       "Escape",
+	"backspace": "BackSpace",
+	"delete":    "Delete",
+	"up":        "Up",
+	"down":      "Down",
+	"left":      "Left",
+	"right":     "Right",
+	"home":      "Home",
+	"end":       "End",
+	"pageup":    "Prior",
+	"pagedown":  "Next",
+	"+":         "plus", // As xdotool separates keys with it
+}
+
+func sendKeyCombo(kc keyCombo) error {
+	key, ok := xdotoolKeys[kc.key]
+	if !ok {
+		key = kc.key
+		if strings.HasPrefix(key, "f") && len(key) > 1 {
+			key = strings.ToUpper(key)
+		}
+	}
+	keys := append(append([]string(nil), kc.modifiers...), key)
+	return exec.Command("xdotool", "key", "--clearmodifiers", strings.Join(keys, "+")).Run()
+}
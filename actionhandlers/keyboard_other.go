@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package actionhandlers
+
+import "errors"
+
+func sendKeyCombo(kc keyCombo) error {
+	return errors.New("Keyboard emulation isn't supported on this platform")
+}
@@ -0,0 +1,45 @@
+//go:build windows
+// +build windows
+
+package actionhandlers
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+var sendKeysNames = map[string]string{
+	"enter": "{ENTER}", "tab": "{TAB}", "space": " ", "esc": "{ESC}", "backspace": "{BACKSPACE}",
+	"delete": "{DELETE}", "up": "{UP}", "down": "{DOWN}", "left": "{LEFT}", "right": "{RIGHT}",
+	"home": "{HOME}", "end": "{END}", "pageup": "{PGUP}", "pagedown": "{PGDN}",
+}
+
+var sendKeysModifiers = map[string]string{
+	"ctrl":  "^",
+	"shift": "+",
+	"alt":   "%",
+}
+
+func sendKeyCombo(kc keyCombo) error {
+	var keys string
+	for _, m := range kc.modifiers {
+		modifier, ok := sendKeysModifiers[m]
+		if !ok {
+			return errors.New("The Windows key can't be sent")
+		}
+		keys += modifier
+	}
+	if name, ok := sendKeysNames[kc.key]; ok {
+		keys += name
+	} else if strings.HasPrefix(kc.key, "f") && len(kc.key) > 1 {
+		keys += "{" + strings.ToUpper(kc.key) + "}"
+	} else if strings.ContainsAny(kc.key, "+^%~(){}[]") {
+		keys += "{" + kc.key + "}"
+	} else {
+		keys += kc.key
+	}
+	keys = strings.Replace(keys, "'", "''", -1)
+	script := "(New-Object -ComObject WScript.Shell).SendKeys('" + keys + "')"
+	return exec.Command("powershell", "-NoProfile", "-Command", script).Run()
+}
@@ -0,0 +1,36 @@
+package actionhandlers
+
+import (
+	"sync"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// MacroAction replays a macro on a device when the button is pressed, see Device.Replay; presses while it is
+// replaying are ignored
+type MacroAction struct {
+	lock    sync.Mutex
+	dev     *streamdeck.Device
+	macro   *streamdeck.Macro
+	running bool
+}
+
+func (action *MacroAction) Pressed(btn streamdeck.Button) {
+	action.lock.Lock()
+	defer action.lock.Unlock()
+	if action.running {
+		return
+	}
+	action.running = true
+	// Replaying takes as long as the macro, and mustn't hold up the device's own events meanwhile
+	go func() {
+		action.dev.Replay(action.macro)
+		action.lock.Lock()
+		action.running = false
+		action.lock.Unlock()
+	}()
+}
+
+func NewMacroAction(dev *streamdeck.Device, macro *streamdeck.Macro) *MacroAction {
+	return &MacroAction{dev: dev, macro: macro}
+}
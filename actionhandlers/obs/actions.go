@@ -0,0 +1,45 @@
+package obs
+
+import streamdeck "github.com/SKAARHOJ/go-streamdeck"
+
+// Action sends a request to OBS when the button is pressed
+type Action struct {
+	client      *Client
+	requestType string
+	data        interface{}
+}
+
+func (action *Action) Pressed(btn streamdeck.Button) {
+	go action.Run(btn)
+}
+
+// Run sends the request and waits for OBS to answer, returning any error
+func (action *Action) Run(btn streamdeck.Button) error {
+	_, err := action.client.Request(action.requestType, action.data)
+	return err
+}
+
+// NewRequestAction creates an Action sending any request, see the obs-websocket protocol documentation
+func NewRequestAction(client *Client, requestType string, data interface{}) *Action {
+	return &Action{client: client, requestType: requestType, data: data}
+}
+
+// NewSceneAction creates an Action switching the program scene
+func NewSceneAction(client *Client, sceneName string) *Action {
+	return NewRequestAction(client, "SetCurrentProgramScene", map[string]interface{}{"sceneName": sceneName})
+}
+
+// NewRecordToggleAction creates an Action starting or stopping recording
+func NewRecordToggleAction(client *Client) *Action {
+	return NewRequestAction(client, "ToggleRecord", nil)
+}
+
+// NewStreamToggleAction creates an Action starting or stopping streaming
+func NewStreamToggleAction(client *Client) *Action {
+	return NewRequestAction(client, "ToggleStream", nil)
+}
+
+// NewMuteToggleAction creates an Action muting or unmuting an input
+func NewMuteToggleAction(client *Client, inputName string) *Action {
+	return NewRequestAction(client, "ToggleInputMute", map[string]interface{}{"inputName": inputName})
+}
@@ -0,0 +1,286 @@
+// Package obs controls OBS Studio through obs-websocket (protocol version 5): switching scenes, toggling
+// recording and streaming, and muting inputs, with OBS's state fed back to show on buttons
+package obs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/internal/websocket"
+)
+
+// State keys set by BindState
+const (
+	StateScene     = "obs.scene"     // Name of the program scene
+	StateRecording = "obs.recording" // Whether recording, as a bool
+	StateStreaming = "obs.streaming" // Whether streaming, as a bool
+	StateMutedPfx  = "obs.muted."    // Followed by an input name, whether it is muted, as a bool
+)
+
+const (
+	opHello      = 0
+	opIdentify   = 1
+	opIdentified = 2
+	opEvent      = 5
+	opRequest    = 6
+	opResponse   = 7
+
+	subscribeAll = 2047 // Every event category except the high-volume ones
+)
+
+type message struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+}
+
+type response struct {
+	RequestID     string `json:"requestId"`
+	RequestStatus struct {
+		Result  bool   `json:"result"`
+		Code    int    `json:"code"`
+		Comment string `json:"comment"`
+	} `json:"requestStatus"`
+	ResponseData json.RawMessage `json:"responseData"`
+}
+
+// Client is a connection to OBS
+type Client struct {
+	conn *websocket.Conn
+
+	lock      sync.Mutex
+	nextID    int
+	pending   map[string]chan response
+	listeners map[string][]func(json.RawMessage)
+	done      chan struct{}
+}
+
+// Dial connects to obs-websocket, for example at "ws://localhost:4455"; password may be empty if authentication
+// is turned off in OBS
+func Dial(url string, password string) (*Client, error) {
+	conn, err := websocket.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	hello, err := readMessage(conn)
+	if err != nil || hello.Op != opHello {
+		conn.Close()
+		return nil, errors.New("OBS didn't say hello")
+	}
+	var h struct {
+		Authentication *struct {
+			Challenge string `json:"challenge"`
+			Salt      string `json:"salt"`
+		} `json:"authentication"`
+	}
+	json.Unmarshal(hello.D, &h)
+
+	identify := map[string]interface{}{"rpcVersion": 1, "eventSubscriptions": subscribeAll}
+	if h.Authentication != nil {
+		secret := sha256.Sum256([]byte(password + h.Authentication.Salt))
+		auth := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(secret[:]) + h.Authentication.Challenge))
+		identify["authentication"] = base64.StdEncoding.EncodeToString(auth[:])
+	}
+	if err := writeMessage(conn, opIdentify, identify); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	identified, err := readMessage(conn)
+	if err != nil || identified.Op != opIdentified {
+		conn.Close()
+		return nil, errors.New("OBS refused the connection; is the password right?")
+	}
+
+	c := &Client{
+		conn:      conn,
+		pending:   make(map[string]chan response),
+		listeners: make(map[string][]func(json.RawMessage)),
+		done:      make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// Request sends a request to OBS and waits for its response data
+func (c *Client) Request(requestType string, data interface{}) (json.RawMessage, error) {
+	c.lock.Lock()
+	c.nextID++
+	id := fmt.Sprint(c.nextID)
+	ch := make(chan response, 1)
+	c.pending[id] = ch
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+	}()
+
+	req := map[string]interface{}{"requestType": requestType, "requestId": id}
+	if data != nil {
+		req["requestData"] = data
+	}
+	if err := writeMessage(c.conn, opRequest, req); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		if !resp.RequestStatus.Result {
+			return nil, fmt.Errorf("OBS %s failed (%d): %s", requestType, resp.RequestStatus.Code, resp.RequestStatus.Comment)
+		}
+		return resp.ResponseData, nil
+	case <-c.done:
+		return nil, errors.New("Connection to OBS lost")
+	case <-time.After(5 * time.Second):
+		return nil, fmt.Errorf("OBS %s timed out", requestType)
+	}
+}
+
+// OnEvent registers a function to be called with the event data of every event of the given type
+func (c *Client) OnEvent(eventType string, f func(data json.RawMessage)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.listeners[eventType] = append(c.listeners[eventType], f)
+}
+
+// Done is closed when the connection to OBS is lost
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close disconnects from OBS
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// BindState keeps keys in a StreamDeck's State up to date with OBS (see StateScene and friends), so that buttons
+// can show it with BindState, for example WhenEquals(obs.StateRecording, true, redBorder)
+func (c *Client) BindState(state *streamdeck.State) error {
+	c.OnEvent("CurrentProgramSceneChanged", func(data json.RawMessage) {
+		var d struct {
+			SceneName string `json:"sceneName"`
+		}
+		if json.Unmarshal(data, &d) == nil {
+			state.Set(StateScene, d.SceneName)
+		}
+	})
+	outputState := func(key string) func(json.RawMessage) {
+		return func(data json.RawMessage) {
+			var d struct {
+				OutputActive bool `json:"outputActive"`
+			}
+			if json.Unmarshal(data, &d) == nil {
+				state.Set(key, d.OutputActive)
+			}
+		}
+	}
+	c.OnEvent("RecordStateChanged", outputState(StateRecording))
+	c.OnEvent("StreamStateChanged", outputState(StateStreaming))
+	c.OnEvent("InputMuteStateChanged", func(data json.RawMessage) {
+		var d struct {
+			InputName  string `json:"inputName"`
+			InputMuted bool   `json:"inputMuted"`
+		}
+		if json.Unmarshal(data, &d) == nil {
+			state.Set(StateMutedPfx+d.InputName, d.InputMuted)
+		}
+	})
+
+	// Fill in the state as it is now; after this, the events keep it up to date
+	data, err := c.Request("GetCurrentProgramScene", nil)
+	if err != nil {
+		return err
+	}
+	var scene struct {
+		CurrentProgramSceneName string `json:"currentProgramSceneName"`
+	}
+	json.Unmarshal(data, &scene)
+	state.Set(StateScene, scene.CurrentProgramSceneName)
+
+	for requestType, key := range map[string]string{"GetRecordStatus": StateRecording, "GetStreamStatus": StateStreaming} {
+		data, err := c.Request(requestType, nil)
+		if err != nil {
+			return err
+		}
+		var status struct {
+			OutputActive bool `json:"outputActive"`
+		}
+		json.Unmarshal(data, &status)
+		state.Set(key, status.OutputActive)
+	}
+	return nil
+}
+
+// WatchInputMute fills in whether an input is muted now, for BindState to keep up to date afterwards
+func (c *Client) WatchInputMute(state *streamdeck.State, inputName string) error {
+	data, err := c.Request("GetInputMute", map[string]interface{}{"inputName": inputName})
+	if err != nil {
+		return err
+	}
+	var d struct {
+		InputMuted bool `json:"inputMuted"`
+	}
+	json.Unmarshal(data, &d)
+	state.Set(StateMutedPfx+inputName, d.InputMuted)
+	return nil
+}
+
+func (c *Client) readLoop() {
+	defer close(c.done)
+	for {
+		m, err := readMessage(c.conn)
+		if err != nil {
+			return
+		}
+		switch m.Op {
+		case opResponse:
+			var resp response
+			if json.Unmarshal(m.D, &resp) != nil {
+				continue
+			}
+			c.lock.Lock()
+			ch := c.pending[resp.RequestID]
+			c.lock.Unlock()
+			if ch != nil {
+				ch <- resp
+			}
+		case opEvent:
+			var e struct {
+				EventType string          `json:"eventType"`
+				EventData json.RawMessage `json:"eventData"`
+			}
+			if json.Unmarshal(m.D, &e) != nil {
+				continue
+			}
+			c.lock.Lock()
+			listeners := c.listeners[e.EventType]
+			c.lock.Unlock()
+			for _, f := range listeners {
+				f(e.EventData)
+			}
+		}
+	}
+}
+
+func readMessage(conn *websocket.Conn) (message, error) {
+	var m message
+	data, err := conn.ReadMessage()
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+func writeMessage(conn *websocket.Conn, op int, d interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{"op": op, "d": d})
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(data)
+}
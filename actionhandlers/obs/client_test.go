@@ -0,0 +1,226 @@
+package obs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/internal/websocket"
+)
+
+// request is a request the fake OBS received
+type request struct {
+	RequestType string                 `json:"requestType"`
+	RequestID   string                 `json:"requestId"`
+	RequestData map[string]interface{} `json:"requestData"`
+}
+
+// fakeOBS is obs-websocket with authentication on, answering requests from responses, or failing those it has
+// no response for
+type fakeOBS struct {
+	t         *testing.T
+	l         net.Listener
+	password  string
+	responses map[string]interface{}
+	requests  chan request
+	conns     chan *websocket.Conn
+}
+
+func newFakeOBS(t *testing.T, password string, responses map[string]interface{}) *fakeOBS {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeOBS{t: t, l: l, password: password, responses: responses,
+		requests: make(chan request, 10), conns: make(chan *websocket.Conn, 1)}
+	go f.serve()
+	return f
+}
+
+func (f *fakeOBS) url() string {
+	return "ws://" + f.l.Addr().String()
+}
+
+func (f *fakeOBS) serve() {
+	conn, err := f.l.Accept()
+	if err != nil {
+		return
+	}
+	c, err := websocket.Accept(conn)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer c.Close()
+
+	const challenge, salt = "+IxH4CnCiqpX1rM9scsNynZzbOe4KhDeYcTNS3PDaeY=", "lM1GncleQOaCu9lT1yeUZhFYnqhsLLP1G5lAGo3ixaI="
+	writeMessage(c, opHello, map[string]interface{}{"rpcVersion": 1,
+		"authentication": map[string]string{"challenge": challenge, "salt": salt}})
+	m, err := readMessage(c)
+	if err != nil || m.Op != opIdentify {
+		return
+	}
+	var identify struct {
+		Authentication string `json:"authentication"`
+	}
+	json.Unmarshal(m.D, &identify)
+	secret := sha256.Sum256([]byte(f.password + salt))
+	auth := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(secret[:]) + challenge))
+	if identify.Authentication != base64.StdEncoding.EncodeToString(auth[:]) {
+		return // As OBS closes the connection
+	}
+	writeMessage(c, opIdentified, map[string]interface{}{"negotiatedRpcVersion": 1})
+	f.conns <- c
+
+	for {
+		m, err := readMessage(c)
+		if err != nil {
+			return
+		}
+		var req request
+		if m.Op != opRequest || json.Unmarshal(m.D, &req) != nil {
+			f.t.Errorf("OBS was sent %+v", m)
+			continue
+		}
+		f.requests <- req
+		data, ok := f.responses[req.RequestType]
+		status := map[string]interface{}{"result": ok, "code": 100}
+		if !ok {
+			status = map[string]interface{}{"result": false, "code": 600, "comment": "No such thing"}
+		}
+		writeMessage(c, opResponse, map[string]interface{}{"requestType": req.RequestType, "requestId": req.RequestID,
+			"requestStatus": status, "responseData": data})
+	}
+}
+
+func TestDialAuthentication(t *testing.T) {
+	for _, tc := range []struct {
+		password string
+		ok       bool
+	}{{"secret", true}, {"wrong", false}, {"", false}} {
+		f := newFakeOBS(t, "secret", nil)
+		c, err := Dial(f.url(), tc.password)
+		if tc.ok && err != nil {
+			t.Errorf("With the right password: %s", err)
+		} else if !tc.ok && err == nil {
+			t.Errorf("Password %q was accepted", tc.password)
+		}
+		if c != nil {
+			c.Close()
+		}
+		f.l.Close()
+	}
+}
+
+func TestBindState(t *testing.T) {
+	f := newFakeOBS(t, "", map[string]interface{}{
+		"GetCurrentProgramScene": map[string]interface{}{"currentProgramSceneName": "Cam 1"},
+		"GetRecordStatus":        map[string]interface{}{"outputActive": true},
+		"GetStreamStatus":        map[string]interface{}{"outputActive": false},
+		"GetInputMute":           map[string]interface{}{"inputMuted": true},
+	})
+	defer f.l.Close()
+	c, err := Dial(f.url(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	server := <-f.conns
+
+	state := streamdeck.NewState()
+	if err := c.BindState(state); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WatchInputMute(state, "Mic"); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]interface{}{StateScene: "Cam 1", StateRecording: true, StateStreaming: false,
+		StateMutedPfx + "Mic": true} {
+		if got, _ := state.Get(key); got != want {
+			t.Errorf("%s is %v, not %v", key, got, want)
+		}
+	}
+
+	// Then events keep the state up to date
+	changed := make(chan string, 4)
+	for _, key := range []string{StateScene, StateRecording, StateMutedPfx + "Mic"} {
+		key := key
+		state.Watch(key, func(interface{}) { changed <- key })
+	}
+	for _, e := range []struct {
+		eventType string
+		data      map[string]interface{}
+		key       string
+		want      interface{}
+	}{
+		{"CurrentProgramSceneChanged", map[string]interface{}{"sceneName": "Cam 2"}, StateScene, "Cam 2"},
+		{"RecordStateChanged", map[string]interface{}{"outputActive": false, "outputState": "OBS_WEBSOCKET_OUTPUT_STOPPED"}, StateRecording, false},
+		{"InputMuteStateChanged", map[string]interface{}{"inputName": "Mic", "inputMuted": false}, StateMutedPfx + "Mic", false},
+	} {
+		writeMessage(server, opEvent, map[string]interface{}{"eventType": e.eventType, "eventIntent": 1, "eventData": e.data})
+		select {
+		case key := <-changed:
+			if got, _ := state.Get(key); key != e.key || got != e.want {
+				t.Errorf("%s changed %s to %v, not %s to %v", e.eventType, key, got, e.key, e.want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s changed nothing", e.eventType)
+		}
+	}
+}
+
+func TestActions(t *testing.T) {
+	f := newFakeOBS(t, "", map[string]interface{}{
+		"SetCurrentProgramScene": nil,
+		"ToggleRecord":           map[string]interface{}{"outputActive": true},
+		"ToggleStream":           map[string]interface{}{"outputActive": true},
+		"ToggleInputMute":        map[string]interface{}{"inputMuted": true},
+	})
+	defer f.l.Close()
+	c, err := Dial(f.url(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	server := <-f.conns
+
+	for _, tc := range []struct {
+		action      *Action
+		requestType string
+		data        string
+	}{
+		{NewSceneAction(c, "Cam 2"), "SetCurrentProgramScene", `{"sceneName":"Cam 2"}`},
+		{NewRecordToggleAction(c), "ToggleRecord", `null`},
+		{NewStreamToggleAction(c), "ToggleStream", `null`},
+		{NewMuteToggleAction(c, "Mic"), "ToggleInputMute", `{"inputName":"Mic"}`},
+	} {
+		if err := tc.action.Run(nil); err != nil {
+			t.Errorf("%s: %s", tc.requestType, err)
+		}
+		req := <-f.requests
+		data, _ := json.Marshal(req.RequestData)
+		if req.RequestType != tc.requestType || string(data) != tc.data {
+			t.Errorf("OBS was sent %s %s, not %s %s", req.RequestType, data, tc.requestType, tc.data)
+		}
+	}
+
+	err = NewRequestAction(c, "Unknown", nil).Run(nil)
+	if err == nil || err.Error() != "OBS Unknown failed (600): No such thing" {
+		t.Errorf("A failed request gave %v", err)
+	}
+	<-f.requests
+
+	server.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Losing the connection wasn't noticed")
+	}
+	if err := NewRecordToggleAction(c).Run(nil); err == nil {
+		t.Error("A request after losing the connection succeeded")
+	}
+}
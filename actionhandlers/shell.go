@@ -0,0 +1,105 @@
+package actionhandlers
+
+import (
+	"bytes"
+	"image/color"
+	"os/exec"
+	"strings"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// ShellResult is the outcome of running a ShellAction's command
+type ShellResult struct {
+	Output   string // Standard output, with surrounding whitespace trimmed
+	ExitCode int    // -1 if the command couldn't be run at all
+	Err      error
+}
+
+// ShellAction runs a command in the background each time the button is pressed, waits for it to finish, and shows
+// the result on the button that was pressed: the first line of output becomes the text of buttons which have
+// text, and the background (or the colour, for a ColourButton) turns green or red for success or failure.
+type ShellAction struct {
+	name          string
+	args          []string
+	showOutput    bool
+	successColour color.Color
+	failureColour color.Color
+	resultHandler func(streamdeck.Button, ShellResult)
+}
+
+// NewShellAction creates a ShellAction running the given command
+func NewShellAction(name string, args ...string) *ShellAction {
+	return &ShellAction{
+		name:          name,
+		args:          args,
+		showOutput:    true,
+		successColour: color.RGBA{0, 128, 0, 255},
+		failureColour: color.RGBA{192, 0, 0, 255},
+	}
+}
+
+// SetShowOutput sets whether the output replaces the text of the button; it does by default
+func (action *ShellAction) SetShowOutput(show bool) {
+	action.showOutput = show
+}
+
+// SetColours sets the colours shown for success and failure; nil leaves the colour alone
+func (action *ShellAction) SetColours(success, failure color.Color) {
+	action.successColour = success
+	action.failureColour = failure
+}
+
+// SetResultHandler sets a function to be given the result of each run, after the button has been updated
+func (action *ShellAction) SetResultHandler(f func(streamdeck.Button, ShellResult)) {
+	action.resultHandler = f
+}
+
+func (action *ShellAction) Pressed(btn streamdeck.Button) {
+	go action.Run(btn)
+}
+
+// Run runs the command and waits for it, then updates the button, returning the error if it failed
+func (action *ShellAction) Run(btn streamdeck.Button) error {
+	var stdout bytes.Buffer
+	cmd := exec.Command(action.name, action.args...)
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+
+	result := ShellResult{Output: strings.TrimSpace(stdout.String()), Err: err}
+	if err != nil {
+		result.ExitCode = -1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitErr.ExitCode()
+		}
+	}
+
+	action.showResult(btn, result)
+	if action.resultHandler != nil {
+		action.resultHandler(btn, result)
+	}
+	return err
+}
+
+func (action *ShellAction) showResult(btn streamdeck.Button, result ShellResult) {
+	colour := action.successColour
+	if result.Err != nil {
+		colour = action.failureColour
+	}
+
+	if b, ok := btn.(interface{ SetText(string) }); ok && action.showOutput {
+		text := result.Output
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[:i]
+		}
+		b.SetText(text)
+	}
+	if colour == nil {
+		return
+	}
+	if b, ok := btn.(interface{ SetBackgroundColor(color.Color) }); ok {
+		b.SetBackgroundColor(colour)
+	} else if b, ok := btn.(interface{ SetColour(color.Color) }); ok {
+		b.SetColour(colour)
+	}
+}
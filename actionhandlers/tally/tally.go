@@ -0,0 +1,46 @@
+// Package tally is the tally (on-air) feedback shared by the video switcher action packs: they set a key in a
+// StreamDeck's State for each input, and buttons show it with a red or green border
+package tally
+
+import (
+	"fmt"
+	"image/color"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/decorators"
+)
+
+// Tally is whether an input is on program (on air), on preview, or neither
+type Tally int
+
+const (
+	Off Tally = iota
+	Preview
+	Program
+)
+
+var (
+	programBorder = decorators.NewBorder(8, color.RGBA{255, 0, 0, 255})
+	previewBorder = decorators.NewBorder(8, color.RGBA{0, 255, 0, 255})
+)
+
+// StateKey gives the State key holding the tally of an input of a switcher, such as "vmix.tally.3"
+func StateKey(prefix string, input int) string {
+	return fmt.Sprintf("%s.tally.%d", prefix, input)
+}
+
+// Binding gives a StateBinding showing the tally of an input as a red (program) or green (preview) border
+func Binding(prefix string, input int) streamdeck.StateBinding {
+	return streamdeck.StateBinding{
+		Key: StateKey(prefix, input),
+		Decorator: func(value interface{}) streamdeck.ButtonDecorator {
+			switch value {
+			case Program:
+				return programBorder
+			case Preview:
+				return previewBorder
+			}
+			return nil
+		},
+	}
+}
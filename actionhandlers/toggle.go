@@ -0,0 +1,76 @@
+package actionhandlers
+
+import (
+	"sync"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// ToggleAction alternates between two actions on successive presses, starting with the on action.  Its state
+// can be shown on a button by passing, for example, a ToggleButton's SetState to SetStateChangeHandler.
+type ToggleAction struct {
+	lock          sync.Mutex
+	on            bool
+	onAction      streamdeck.ButtonActionHandler
+	offAction     streamdeck.ButtonActionHandler
+	changeHandler func(bool)
+}
+
+// IsOn returns whether the on action was the last to run
+func (act *ToggleAction) IsOn() bool {
+	act.lock.Lock()
+	defer act.lock.Unlock()
+	return act.on
+}
+
+// SetState sets the state without running either action, for example when an external system reports what the
+// real state is; the state change handler is still called
+func (act *ToggleAction) SetState(on bool) {
+	act.lock.Lock()
+	act.on = on
+	f := act.changeHandler
+	act.lock.Unlock()
+	if f != nil {
+		f(on)
+	}
+}
+
+// SetStateChangeHandler sets a function to be called with the new state whenever it changes
+func (act *ToggleAction) SetStateChangeHandler(f func(on bool)) {
+	act.lock.Lock()
+	defer act.lock.Unlock()
+	act.changeHandler = f
+}
+
+func (act *ToggleAction) Pressed(btn streamdeck.Button) {
+	act.Run(btn)
+}
+
+// Run flips the state and runs the matching action, returning its error if it is a FallibleAction
+func (act *ToggleAction) Run(btn streamdeck.Button) error {
+	act.lock.Lock()
+	act.on = !act.on
+	on := act.on
+	a := act.offAction
+	if on {
+		a = act.onAction
+	}
+	f := act.changeHandler
+	act.lock.Unlock()
+
+	if f != nil {
+		f(on)
+	}
+	if a == nil {
+		return nil
+	}
+	if fa, ok := a.(FallibleAction); ok {
+		return fa.Run(btn)
+	}
+	a.Pressed(btn)
+	return nil
+}
+
+func NewToggleAction(onAction streamdeck.ButtonActionHandler, offAction streamdeck.ButtonActionHandler) *ToggleAction {
+	return &ToggleAction{onAction: onAction, offAction: offAction}
+}
@@ -0,0 +1,159 @@
+// Package vmix controls vMix through its TCP API: cuts, transitions and input selection, with tally fed back to
+// show on buttons
+package vmix
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/actionhandlers/tally"
+)
+
+// TallyPrefix is the prefix of the State keys set by BindTally, see tally.StateKey
+const TallyPrefix = "vmix"
+
+// Client is a connection to vMix's TCP API
+type Client struct {
+	conn      net.Conn
+	writeLock sync.Mutex
+	lock      sync.Mutex
+	state     *streamdeck.State
+	inputs    int
+	done      chan struct{}
+}
+
+// Dial connects to vMix, for example at "192.168.1.10:8099"
+func Dial(address string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn, done: make(chan struct{})}
+	go c.readLoop()
+	return c, nil
+}
+
+// Function calls a vMix shortcut function, such as "Cut" or "PreviewInput", with parameters such as
+// {"Input": "3"}
+func (c *Client) Function(name string, params map[string]string) error {
+	line := "FUNCTION " + name
+	if len(params) > 0 {
+		q := url.Values{}
+		for k, v := range params {
+			q.Set(k, v)
+		}
+		line += " " + q.Encode()
+	}
+	return c.send(line)
+}
+
+// BindTally subscribes to tally, keeping keys in a StreamDeck's State up to date for every input, so that buttons
+// can show it with tally.Binding(vmix.TallyPrefix, input)
+func (c *Client) BindTally(state *streamdeck.State) error {
+	c.lock.Lock()
+	c.state = state
+	c.lock.Unlock()
+	return c.send("SUBSCRIBE TALLY")
+}
+
+// Done is closed when the connection to vMix is lost
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close disconnects from vMix
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) send(line string) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_, err := fmt.Fprintf(c.conn, "%s\r\n", line)
+	return err
+}
+
+func (c *Client) readLoop() {
+	defer close(c.done)
+	scanner := bufio.NewScanner(c.conn)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// Tally arrives as "TALLY OK 0120", with a digit for each input: 0 off, 1 program, 2 preview
+		if len(fields) == 3 && fields[0] == "TALLY" && fields[1] == "OK" {
+			c.updateTally(fields[2])
+		}
+	}
+}
+
+func (c *Client) updateTally(digits string) {
+	c.lock.Lock()
+	state := c.state
+	previous := c.inputs
+	c.inputs = len(digits)
+	c.lock.Unlock()
+	if state == nil {
+		return
+	}
+	for i, d := range digits {
+		t := tally.Off
+		switch d {
+		case '1':
+			t = tally.Program
+		case '2':
+			t = tally.Preview
+		}
+		state.Set(tally.StateKey(TallyPrefix, i+1), t)
+	}
+	for i := len(digits); i < previous; i++ {
+		state.Set(tally.StateKey(TallyPrefix, i+1), tally.Off)
+	}
+}
+
+// Action calls a vMix function when the button is pressed
+type Action struct {
+	client *Client
+	name   string
+	params map[string]string
+}
+
+func (action *Action) Pressed(btn streamdeck.Button) {
+	action.client.Function(action.name, action.params)
+}
+
+// Run calls the function, returning any error sending it
+func (action *Action) Run(btn streamdeck.Button) error {
+	return action.client.Function(action.name, action.params)
+}
+
+// NewFunctionAction creates an Action calling any vMix shortcut function
+func NewFunctionAction(client *Client, name string, params map[string]string) *Action {
+	return &Action{client: client, name: name, params: params}
+}
+
+// NewCutAction creates an Action cutting preview to program
+func NewCutAction(client *Client) *Action {
+	return NewFunctionAction(client, "Cut", nil)
+}
+
+// NewTransitionAction creates an Action running a transition, such as "Fade" or "Merge", over duration
+func NewTransitionAction(client *Client, transition string, duration time.Duration) *Action {
+	return NewFunctionAction(client, transition, map[string]string{
+		"Duration": fmt.Sprint(int(duration / time.Millisecond)),
+	})
+}
+
+// NewPreviewAction creates an Action putting an input (numbered from 1) on preview
+func NewPreviewAction(client *Client, input int) *Action {
+	return NewFunctionAction(client, "PreviewInput", map[string]string{"Input": fmt.Sprint(input)})
+}
+
+// NewProgramAction creates an Action cutting an input (numbered from 1) straight to program
+func NewProgramAction(client *Client, input int) *Action {
+	return NewFunctionAction(client, "CutDirect", map[string]string{"Input": fmt.Sprint(input)})
+}
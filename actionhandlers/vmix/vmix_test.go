@@ -0,0 +1,112 @@
+package vmix
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/actionhandlers/tally"
+)
+
+// connect has a client connect to a fake vMix, returning the fake's end of the connection
+func connect(t *testing.T) (*Client, net.Conn, *bufio.Reader) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	c, err := Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetDeadline(time.Now().Add(5 * time.Second))
+	return c, server, bufio.NewReader(server)
+}
+
+func TestFunctions(t *testing.T) {
+	c, server, r := connect(t)
+	defer server.Close()
+	defer c.Close()
+
+	for _, tc := range []struct {
+		action *Action
+		want   string
+	}{
+		{NewCutAction(c), "FUNCTION Cut"},
+		{NewTransitionAction(c, "Fade", 1500*time.Millisecond), "FUNCTION Fade Duration=1500"},
+		{NewPreviewAction(c, 3), "FUNCTION PreviewInput Input=3"},
+		{NewProgramAction(c, 12), "FUNCTION CutDirect Input=12"},
+		{NewFunctionAction(c, "SetText", map[string]string{"Input": "Title 1", "Value": "A&B"}),
+			"FUNCTION SetText Input=Title+1&Value=A%26B"},
+	} {
+		if err := tc.action.Run(nil); err != nil {
+			t.Fatal(err)
+		}
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != tc.want+"\r\n" {
+			t.Errorf("vMix was sent %q, not %q", line, tc.want+"\r\n")
+		}
+	}
+}
+
+func TestTally(t *testing.T) {
+	c, server, r := connect(t)
+	defer server.Close()
+	defer c.Close()
+
+	state := streamdeck.NewState()
+	if err := c.BindTally(state); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := r.ReadString('\n'); err != nil || line != "SUBSCRIBE TALLY\r\n" {
+		t.Fatalf("vMix was sent %q (%v)", line, err)
+	}
+
+	for _, tc := range []struct {
+		lines []string
+		want  []tally.Tally
+	}{
+		{[]string{"SUBSCRIBE OK TALLY", "TALLY OK 0120"}, []tally.Tally{tally.Off, tally.Program, tally.Preview, tally.Off}},
+		// Inputs which have gone are turned off
+		{[]string{"TALLY OK 21"}, []tally.Tally{tally.Preview, tally.Program, tally.Off, tally.Off}},
+		// Anything else is ignored
+		{[]string{"TALLY ER Failed", "FUNCTION OK Completed", "TALLY OK 1"}, []tally.Tally{tally.Program, tally.Off, tally.Off, tally.Off}},
+	} {
+		for _, line := range tc.lines {
+			fmt.Fprintf(server, "%s\r\n", line)
+		}
+		matches := func() bool {
+			for i, w := range tc.want {
+				if got, _ := state.Get(tally.StateKey(TallyPrefix, i+1)); got != w {
+					return false
+				}
+			}
+			return true
+		}
+		for deadline := time.Now().Add(5 * time.Second); !matches() && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+		for i, w := range tc.want {
+			if got, _ := state.Get(tally.StateKey(TallyPrefix, i+1)); got != w {
+				t.Errorf("After %q, input %d has tally %v, not %v", tc.lines, i+1, got, w)
+			}
+		}
+	}
+
+	server.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Error("Losing the connection wasn't noticed")
+	}
+}
@@ -0,0 +1,145 @@
+// Package audio turns audio into levels for meters: it takes PCM samples or loudness readings from the
+// application, as they arrive or from a channel, and applies meter ballistics before passing the level on to a
+// widgets.Meter or anything else that shows a level between 0 and 1.
+package audio
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/SKAARHOJ/go-streamdeck/widgets"
+)
+
+// Ballistics set how quickly a level rises and falls, as the time taken to cover about two thirds of a change
+type Ballistics struct {
+	Attack  time.Duration
+	Release time.Duration
+}
+
+// Common ballistics
+var (
+	PPMBallistics = Ballistics{Attack: 10 * time.Millisecond, Release: 650 * time.Millisecond}
+	VUBallistics  = Ballistics{Attack: 300 * time.Millisecond, Release: 300 * time.Millisecond}
+)
+
+// LevelInput follows the level of some audio, as a peak level in dBFS shown on a scale from the floor (0) up to
+// 0 dBFS (1).  Samples should keep arriving during silence, so that the level falls.
+type LevelInput struct {
+	lock       sync.Mutex
+	ballistics Ballistics
+	floor      float64
+	level      float64
+	last       time.Time
+	outputs    []func(float64)
+}
+
+// NewLevelInput creates a LevelInput with the given ballistics and a floor of -60 dBFS
+func NewLevelInput(b Ballistics) *LevelInput {
+	return &LevelInput{ballistics: b, floor: -60}
+}
+
+// SetFloor sets the level in dBFS at the bottom of the scale
+func (in *LevelInput) SetFloor(db float64) {
+	in.lock.Lock()
+	defer in.lock.Unlock()
+	if db < 0 {
+		in.floor = db
+	}
+}
+
+// AddOutput adds a function to be passed every new level, between 0 and 1
+func (in *LevelInput) AddOutput(f func(level float64)) {
+	in.lock.Lock()
+	defer in.lock.Unlock()
+	in.outputs = append(in.outputs, f)
+}
+
+// AddMeter shows the level on a Meter, which limits how often it is redrawn
+func (in *LevelInput) AddMeter(m *widgets.Meter) {
+	in.AddOutput(m.SetLevel)
+}
+
+// WriteInt16 takes a block of 16-bit PCM samples, of any number of interleaved channels
+func (in *LevelInput) WriteInt16(samples []int16) {
+	peak := 0
+	for _, s := range samples {
+		v := int(s)
+		if v < 0 {
+			v = -v
+		}
+		if v > peak {
+			peak = v
+		}
+	}
+	in.writePeak(float64(peak) / 32768)
+}
+
+// WriteFloat32 takes a block of floating point PCM samples, full scale being ±1
+func (in *LevelInput) WriteFloat32(samples []float32) {
+	peak := 0.0
+	for _, s := range samples {
+		if v := math.Abs(float64(s)); v > peak {
+			peak = v
+		}
+	}
+	in.writePeak(peak)
+}
+
+// WriteLoudness takes a level already measured by the application, in dBFS (or LUFS)
+func (in *LevelInput) WriteLoudness(db float64) {
+	in.write(db)
+}
+
+// Run takes blocks of floating point samples from a channel until it is closed
+func (in *LevelInput) Run(ch <-chan []float32) {
+	for samples := range ch {
+		in.WriteFloat32(samples)
+	}
+}
+
+func (in *LevelInput) writePeak(peak float64) {
+	if peak <= 0 {
+		in.write(math.Inf(-1))
+		return
+	}
+	in.write(20 * math.Log10(peak))
+}
+
+// write moves the level towards a reading, by as much as the ballistics allow in the time since the last one
+func (in *LevelInput) write(db float64) {
+	in.lock.Lock()
+	target := 1 - db/in.floor
+	if target < 0 {
+		target = 0
+	} else if target > 1 {
+		target = 1
+	}
+
+	now := time.Now()
+	tau := in.ballistics.Release
+	if target > in.level {
+		tau = in.ballistics.Attack
+	}
+	if in.last.IsZero() || tau <= 0 {
+		in.level = target
+	} else {
+		elapsed := now.Sub(in.last)
+		in.level += (target - in.level) * (1 - math.Exp(-float64(elapsed)/float64(tau)))
+	}
+	in.last = now
+	level := in.level
+	outputs := in.outputs
+	in.lock.Unlock()
+
+	for _, f := range outputs {
+		f(level)
+	}
+}
+
+// Level returns the current level, between 0 and 1
+func (in *LevelInput) Level() float64 {
+	in.lock.Lock()
+	defer in.lock.Unlock()
+	return in.level
+}
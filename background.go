@@ -0,0 +1,116 @@
+package streamdeck
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Background is drawn underneath every image written to a button, see SetButtonBackground
+type Background interface {
+	// BackgroundImage draws the background at the given size
+	BackgroundImage(size image.Point) image.Image
+}
+
+// ColourBackground is a Background of a single colour
+type ColourBackground struct {
+	Colour color.Color
+}
+
+func (bg ColourBackground) BackgroundImage(size image.Point) image.Image {
+	return image.NewUniform(bg.Colour)
+}
+
+// GradientBackground is a Background blending from one colour to another, from top to bottom, or from left to
+// right if it is horizontal
+type GradientBackground struct {
+	From       color.Color
+	To         color.Color
+	Horizontal bool
+}
+
+func (bg GradientBackground) BackgroundImage(size image.Point) image.Image {
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	steps := size.Y
+	if bg.Horizontal {
+		steps = size.X
+	}
+	r1, g1, b1, a1 := bg.From.RGBA()
+	r2, g2, b2, a2 := bg.To.RGBA()
+	for i := 0; i < steps; i++ {
+		t := 0.0
+		if steps > 1 {
+			t = float64(i) / float64(steps-1)
+		}
+		mix := func(from, to uint32) uint16 {
+			return uint16(float64(from) + (float64(to)-float64(from))*t)
+		}
+		c := color.RGBA64{mix(r1, r2), mix(g1, g2), mix(b1, b2), mix(a1, a2)}
+		line := image.Rect(0, i, size.X, i+1)
+		if bg.Horizontal {
+			line = image.Rect(i, 0, i+1, size.Y)
+		}
+		draw.Draw(img, line, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// ImageBackground is a Background of an image, scaled to fill the button; see FitFill
+type ImageBackground struct {
+	Image image.Image
+}
+
+func (bg ImageBackground) BackgroundImage(size image.Point) image.Image {
+	return fitImage(bg.Image, size, FitFill)
+}
+
+// SetButtonBackground sets a background for a button, which every image written to it afterwards is drawn over;
+// images show it through their transparent parts, so text is written over it with WriteTextToButton by giving
+// color.Transparent as the background colour.  The button is redrawn straight away with the image last written to
+// it, or with the background alone if it was last written a colour or an image already encoded.  A nil
+// background removes it.
+func (d *Device) SetButtonBackground(btnIndex int, bg Background) error {
+	if !d.HasImageCapability() {
+		return errors.New("Button doesn't have image capability")
+	}
+	mapped := int(d.mapButtonIn(uint(btnIndex)))
+	foreground := d.shadow.setBackground(mapped, bg)
+	if foreground == nil {
+		if bg == nil {
+			return nil
+		}
+		foreground = image.NewRGBA(image.Rectangle{Max: d.GetButtonImageSize(btnIndex)})
+	}
+	return d.WriteRawImageToButton(btnIndex, foreground)
+}
+
+// overBackground draws an image over a background, the result being the size of the image
+func overBackground(bg Background, img image.Image) image.Image {
+	b := img.Bounds()
+	res := image.NewRGBA(image.Rectangle{Max: b.Size()})
+	draw.Draw(res, res.Bounds(), bg.BackgroundImage(b.Size()), image.Point{}, draw.Src)
+	draw.Draw(res, res.Bounds(), img, b.Min, draw.Over)
+	return res
+}
+
+// setBackground sets a button's background, returning the foreground it was last written with, if any
+func (s *shadow) setBackground(btnIndex int, bg Background) image.Image {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if bg == nil {
+		delete(s.backgrounds, btnIndex)
+	} else {
+		if s.backgrounds == nil {
+			s.backgrounds = make(map[int]Background)
+		}
+		s.backgrounds[btnIndex] = bg
+	}
+	return s.foregrounds[btnIndex]
+}
+
+func (s *shadow) getBackground(btnIndex int) Background {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.backgrounds[btnIndex]
+}
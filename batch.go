@@ -0,0 +1,65 @@
+package streamdeck
+
+import (
+	"errors"
+	"image"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// WriteRawImagesToButtons writes several images at once, such as a whole page.  The images are resized and
+// encoded in parallel, on as many goroutines as GOMAXPROCS, and then written one after another; the first error
+// is returned, after writing the rest.  The options are as for WriteRawImageToButton.
+func (d *Device) WriteRawImagesToButtons(images map[int]image.Image, opts ...ImageOption) error {
+	if !d.HasImageCapability() {
+		return errors.New("Button doesn't have image capability")
+	}
+	fit := getImageOptions(opts).fit
+
+	type job struct {
+		btnIndex int
+		img      image.Image
+		encoded  []byte
+		err      error
+	}
+	jobs := make([]*job, 0, len(images))
+	for btnIndex, img := range images {
+		jobs = append(jobs, &job{btnIndex: btnIndex, img: img})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].btnIndex < jobs[j].btnIndex })
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	queue := make(chan *job)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				j.img = fitImage(j.img, d.GetButtonImageSize(j.btnIndex), fit)
+				j.encoded, j.err = d.encodeButtonImage(j.btnIndex, j.img)
+			}
+		}()
+	}
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+
+	var err error
+	for _, j := range jobs {
+		e := j.err
+		if e == nil {
+			e = d.writeToButton(int(d.mapButtonIn(uint(j.btnIndex))), j.encoded, j.img)
+		}
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package streamdeck
+
+// SetBrightness sets the brightness, as a percentage; while the screensaver is on, it is remembered for when
+// the screensaver turns off
+func (sd *StreamDeck) SetBrightness(brightness int) {
+	if brightness < 0 {
+		brightness = 0
+	} else if brightness > 100 {
+		brightness = 100
+	}
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	sd.brightness = brightness
+	if !sd.screensaver {
+		sd.dev.SetBrightness(brightness)
+	}
+}
+
+// GetBrightness returns the brightness last set, which is 100% to begin with
+func (sd *StreamDeck) GetBrightness() int {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	return sd.brightness
+}
+
+// StepBrightness changes the brightness by a number of percentage points, up or down
+func (sd *StreamDeck) StepBrightness(delta int) {
+	sd.SetBrightness(sd.GetBrightness() + delta)
+}
+
+// SetScreensaver blanks the deck, or brings it back.  While the screensaver is on, the first button press only
+// turns it off again, and isn't passed on to the button.
+func (sd *StreamDeck) SetScreensaver(on bool) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	sd.screensaver = on
+	if on {
+		sd.dev.SetBrightness(0)
+	} else {
+		sd.dev.SetBrightness(sd.brightness)
+	}
+}
+
+// ToggleScreensaver turns the screensaver on if it is off, and off if it is on
+func (sd *StreamDeck) ToggleScreensaver() {
+	sd.SetScreensaver(!sd.IsScreensaverOn())
+}
+
+// IsScreensaverOn returns whether the screensaver is on
+func (sd *StreamDeck) IsScreensaverOn() bool {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	return sd.screensaver
+}
+
+// wake turns off the screensaver, returning whether it was on
+func (sd *StreamDeck) wake() bool {
+	if !sd.IsScreensaverOn() {
+		return false
+	}
+	sd.SetScreensaver(false)
+	return true
+}
@@ -0,0 +1,79 @@
+package buttons
+
+import "sync"
+
+// RadioGroup ties together a set of ToggleButtons so that only one of them is on at a time, for example for
+// selecting a camera or a source.  Pressing a button selects it and de-selects the others, and the group's
+// selection changed handler is called; the buttons' own on and off action handlers are not used.
+type RadioGroup struct {
+	lock          sync.Mutex
+	buttons       []*ToggleButton
+	selected      int
+	changeHandler func(int, *ToggleButton)
+}
+
+// NewRadioGroup creates a new, empty, RadioGroup with nothing selected
+func NewRadioGroup() *RadioGroup {
+	return &RadioGroup{selected: -1}
+}
+
+// Add adds a button to the group, returning its position within the group
+func (g *RadioGroup) Add(btn *ToggleButton) int {
+	g.lock.Lock()
+	btn.group = g
+	g.buttons = append(g.buttons, btn)
+	i := len(g.buttons) - 1
+	g.lock.Unlock()
+	btn.SetState(false)
+	return i
+}
+
+// SetSelectionChangedHandler sets the function called when a press changes the selection; it is given the
+// position within the group of the newly-selected button, and the button itself
+func (g *RadioGroup) SetSelectionChangedHandler(f func(index int, btn *ToggleButton)) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.changeHandler = f
+}
+
+// GetSelected returns the position within the group of the selected button, or -1 if there isn't one
+func (g *RadioGroup) GetSelected() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.selected
+}
+
+// SetSelected selects a button without calling the selection changed handler, for example when an external
+// system reports what is really selected.  Use -1 to de-select everything.
+func (g *RadioGroup) SetSelected(index int) {
+	g.lock.Lock()
+	if index < -1 || index >= len(g.buttons) {
+		index = -1
+	}
+	g.selected = index
+	buttons := g.buttons
+	g.lock.Unlock()
+	for i, btn := range buttons {
+		btn.SetState(i == index)
+	}
+}
+
+func (g *RadioGroup) pressed(btn *ToggleButton) {
+	g.lock.Lock()
+	index := -1
+	for i, b := range g.buttons {
+		if b == btn {
+			index = i
+		}
+	}
+	changed := index != g.selected
+	f := g.changeHandler
+	g.lock.Unlock()
+	if !changed {
+		return
+	}
+	g.SetSelected(index)
+	if f != nil {
+		f(index, btn)
+	}
+}
@@ -0,0 +1,67 @@
+package buttons_test
+
+import (
+	"testing"
+
+	"github.com/SKAARHOJ/go-streamdeck/buttons"
+)
+
+func TestRadioGroup(t *testing.T) {
+	group := buttons.NewRadioGroup()
+	var btns []*buttons.ToggleButton
+	for i := 0; i < 3; i++ {
+		btn := buttons.NewTextToggleButton("On", "Off")
+		btn.SetState(true) // Adding a button to a group turns it off
+		if got := group.Add(btn); got != i {
+			t.Errorf("Button %d was added at %d", i, got)
+		}
+		btns = append(btns, btn)
+	}
+	var changes []int
+	group.SetSelectionChangedHandler(func(index int, btn *buttons.ToggleButton) {
+		if btn != btns[index] {
+			t.Errorf("Selecting %d was reported with the wrong button", index)
+		}
+		changes = append(changes, index)
+	})
+
+	for _, tc := range []struct {
+		do       string
+		index    int
+		selected int
+		changed  bool // Whether the handler was called
+	}{
+		{"start", 0, -1, false},
+		{"press", 1, 1, true},
+		{"press", 1, 1, false}, // Pressing the selected button leaves it selected
+		{"press", 2, 2, true},
+		{"set", 0, 0, false},
+		{"press", 0, 0, false},
+		{"set", -1, -1, false},
+		{"press", 0, 0, true},
+		{"set", 3, -1, false}, // Out of range de-selects
+		{"set", -2, -1, false},
+		{"set", 2, 2, false},
+	} {
+		changes = nil
+		switch tc.do {
+		case "press":
+			btns[tc.index].Pressed()
+		case "set":
+			group.SetSelected(tc.index)
+		}
+		if got := group.GetSelected(); got != tc.selected {
+			t.Errorf("After %s %d, %d is selected, not %d", tc.do, tc.index, got, tc.selected)
+		}
+		for i, btn := range btns {
+			if btn.IsOn() != (i == tc.selected) {
+				t.Errorf("After %s %d, button %d is on: %t", tc.do, tc.index, i, btn.IsOn())
+			}
+		}
+		if tc.changed && (len(changes) != 1 || changes[0] != tc.selected) {
+			t.Errorf("After %s %d, the selection changed handler was given %v", tc.do, tc.index, changes)
+		} else if !tc.changed && len(changes) != 0 {
+			t.Errorf("After %s %d, the selection changed handler was called with %v", tc.do, tc.index, changes)
+		}
+	}
+}
@@ -5,11 +5,8 @@ import (
 	"image/color"
 	"image/draw"
 
-	"golang.org/x/image/font/gofont/gomedium"
-
 	streamdeck "github.com/SKAARHOJ/go-streamdeck"
-	"github.com/golang/freetype"
-	"github.com/golang/freetype/truetype"
+	"github.com/SKAARHOJ/go-streamdeck/text"
 )
 
 // TextButton represents a button with text on it
@@ -92,72 +89,25 @@ func NewTextButtonWithColours(label string, textColour color.Color, backgroundCo
 	return btn
 }
 
-func getImageWithText(text string, textColour color.Color, backgroundColour color.Color, btnSize int) image.Image {
+func getImageWithText(label string, textColour color.Color, backgroundColour color.Color, btnSize int) image.Image {
 
 	size := float64(18)
 
-	myfont, err := truetype.Parse(gomedium.TTF)
-	if err != nil {
-		panic(err)
-	}
-
 	width := 0
 	for size = 1; size < 60; size++ {
-		width = getTextWidth(text, size)
+		width = text.Width(label, size)
 		if width > 90 {
 			size = size - 1
 			break
 		}
 	}
 
-	srcImg := image.NewUniform(textColour)
-
 	dstImg := image.NewRGBA(image.Rect(0, 0, btnSize, btnSize))
 	draw.Draw(dstImg, dstImg.Bounds(), image.NewUniform(backgroundColour), image.Point{0, 0}, draw.Src)
 
-	c := freetype.NewContext()
-	c.SetFont(myfont)
-	c.SetDst(dstImg)
-	c.SetSrc(srcImg)
-	c.SetFontSize(size)
-	c.SetClip(dstImg.Bounds())
-
 	x := int((btnSize - width) / 2) // Horizontally centre text
 	y := int(50 + (size / 3))       // Fudged vertical centre, erm, very "heuristic"
 
-	pt := freetype.Pt(x, y)
-	c.DrawString(text, pt)
-
-	/*
-		textWidth := 7 * len(text)
-		fmt.Println(textWidth)
-
-		f := &font.Drawer{
-			Dst:  dstImg,
-			Src:  src_img,
-			Face: basicfont.Face7x13,
-			Dot:  fixed.Point26_6{fixed.Int26_6(x * 64), fixed.Int26_6(y * 64)},
-		}
-		f.DrawString(text)
-	*/
+	text.Draw(dstImg, dstImg.Bounds(), x, y, size, label, textColour)
 	return dstImg
 }
-
-func getTextWidth(text string, size float64) int {
-
-	myfont, err := truetype.Parse(gomedium.TTF)
-	if err != nil {
-		panic(err)
-	}
-
-	// Calculate width of string
-	width := 0
-	face := truetype.NewFace(myfont, &truetype.Options{Size: size})
-	for _, x := range text {
-		awidth, _ := face.GlyphAdvance(rune(x))
-		iwidthf := int(float64(awidth) / 64)
-		width += iwidthf
-	}
-
-	return width
-}
@@ -0,0 +1,129 @@
+package buttons
+
+import (
+	"image"
+	"image/color"
+	"sync"
+
+	"github.com/disintegration/gift"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// ToggleButton represents a button with an on and an off state, showing a different face for each.  Pressing
+// it flips the state and hands-off to the on or the off ButtonActionHandler accordingly.
+type ToggleButton struct {
+	onFace        func(int) image.Image
+	offFace       func(int) image.Image
+	lock          sync.Mutex
+	on            bool
+	updateHandler func(streamdeck.Button)
+	btnIndex      int
+	onAction      streamdeck.ButtonActionHandler
+	offAction     streamdeck.ButtonActionHandler
+	group         *RadioGroup
+}
+
+// GetImageForButton is the interface implemention to get the button's image as an image.Image
+func (btn *ToggleButton) GetImageForButton(btnSize int) image.Image {
+	if btn.IsOn() {
+		return btn.onFace(btnSize)
+	}
+	return btn.offFace(btnSize)
+}
+
+// SetButtonIndex is the interface implemention to set which button on the Streamdeck this is
+func (btn *ToggleButton) SetButtonIndex(btnIndex int) {
+	btn.btnIndex = btnIndex
+}
+
+// GetButtonIndex is the interface implemention to get which button on the Streamdeck this is
+func (btn *ToggleButton) GetButtonIndex() int {
+	return btn.btnIndex
+}
+
+// IsOn returns the current state of the button
+func (btn *ToggleButton) IsOn() bool {
+	btn.lock.Lock()
+	defer btn.lock.Unlock()
+	return btn.on
+}
+
+// SetState sets the state of the button without calling either action handler, for example when an external
+// system reports what the real state is
+func (btn *ToggleButton) SetState(on bool) {
+	btn.lock.Lock()
+	changed := btn.on != on
+	btn.on = on
+	btn.lock.Unlock()
+	if changed && btn.updateHandler != nil {
+		btn.updateHandler(btn)
+	}
+}
+
+// RegisterUpdateHandler is the interface implemention to let the engine give this button a callback to
+// use to request that the button image is updated on the Streamdeck.
+func (btn *ToggleButton) RegisterUpdateHandler(f func(streamdeck.Button)) {
+	btn.updateHandler = f
+}
+
+// SetOnActionHandler sets the ButtonActionHandler called when the button is pressed into the on state
+func (btn *ToggleButton) SetOnActionHandler(a streamdeck.ButtonActionHandler) {
+	btn.onAction = a
+}
+
+// SetOffActionHandler sets the ButtonActionHandler called when the button is pressed into the off state
+func (btn *ToggleButton) SetOffActionHandler(a streamdeck.ButtonActionHandler) {
+	btn.offAction = a
+}
+
+// Pressed is the interface implementation for letting the engine notify that the button has been
+// pressed.  This flips the state, and hands-off to the on or off ButtonActionHandler if it has been set.  If the
+// button is part of a RadioGroup, the group handles the press instead.
+func (btn *ToggleButton) Pressed() {
+	if btn.group != nil {
+		btn.group.pressed(btn)
+		return
+	}
+	btn.lock.Lock()
+	btn.on = !btn.on
+	on := btn.on
+	btn.lock.Unlock()
+	if btn.updateHandler != nil {
+		btn.updateHandler(btn)
+	}
+	if on && btn.onAction != nil {
+		btn.onAction.Pressed(btn)
+	} else if !on && btn.offAction != nil {
+		btn.offAction.Pressed(btn)
+	}
+}
+
+// NewToggleButton creates a new ToggleButton, initially off, showing one of the two images depending on its state
+func NewToggleButton(onImage image.Image, offImage image.Image) *ToggleButton {
+	btn := &ToggleButton{onFace: resizedImageFace(onImage), offFace: resizedImageFace(offImage)}
+	return btn
+}
+
+// NewTextToggleButton creates a new ToggleButton, initially off, showing one of the two labels depending on its
+// state.  The on label is shown in black on a white background, and the off label in white on black.
+func NewTextToggleButton(onLabel string, offLabel string) *ToggleButton {
+	btn := &ToggleButton{
+		onFace: func(btnSize int) image.Image {
+			return getImageWithText(onLabel, color.Black, color.White, btnSize)
+		},
+		offFace: func(btnSize int) image.Image {
+			return getImageWithText(offLabel, color.White, color.Black, btnSize)
+		},
+	}
+	return btn
+}
+
+func resizedImageFace(img image.Image) func(int) image.Image {
+	return func(btnSize int) image.Image {
+		g := gift.New(gift.Resize(btnSize, btnSize, gift.LanczosResampling))
+		newimg := image.NewRGBA(image.Rect(0, 0, btnSize, btnSize))
+		g.Draw(newimg, img)
+		return newimg
+	}
+}
@@ -0,0 +1,65 @@
+package buttons_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/actionhandlers"
+	"github.com/SKAARHOJ/go-streamdeck/buttons"
+)
+
+func solid(c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestToggleButton(t *testing.T) {
+	btn := buttons.NewToggleButton(solid(color.White), solid(color.Black))
+	var ran []string
+	btn.SetOnActionHandler(actionhandlers.NewCustomAction(func(streamdeck.Button) { ran = append(ran, "on") }))
+	btn.SetOffActionHandler(actionhandlers.NewCustomAction(func(streamdeck.Button) { ran = append(ran, "off") }))
+	updates := 0
+	btn.RegisterUpdateHandler(func(streamdeck.Button) { updates++ })
+
+	for _, tc := range []struct {
+		do      string
+		on      bool
+		ran     string // The action run, if any
+		updated bool
+	}{
+		{"press", true, "on", true},
+		{"press", false, "off", true},
+		{"set on", true, "", true},
+		{"set on", true, "", false},
+		{"press", false, "off", true},
+		{"set off", false, "", false},
+	} {
+		ran, updates = nil, 0
+		switch tc.do {
+		case "press":
+			btn.Pressed()
+		case "set on":
+			btn.SetState(true)
+		case "set off":
+			btn.SetState(false)
+		}
+		if btn.IsOn() != tc.on {
+			t.Errorf("After %s, the button is on: %t", tc.do, btn.IsOn())
+		}
+		if (tc.ran == "" && len(ran) != 0) || (tc.ran != "" && (len(ran) != 1 || ran[0] != tc.ran)) {
+			t.Errorf("After %s, the actions run were %q, not %q", tc.do, ran, tc.ran)
+		}
+		if (updates > 0) != tc.updated {
+			t.Errorf("After %s, the button asked to be redrawn %d times", tc.do, updates)
+		}
+
+		r, _, _, _ := btn.GetImageForButton(72).At(36, 36).RGBA()
+		if (r > 0x8000) != tc.on {
+			t.Errorf("After %s, the button shows the wrong face", tc.do)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package streamdeck
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// blankFrameKey is everything a blank frame's encoding depends on; device types can share a name (such as one
+// registered from a device file), so they are told apart by product ID
+type blankFrameKey struct {
+	productID uint16
+	size      image.Point
+	format    string
+	quirks    Quirk
+}
+
+// blankFrames holds black frames already encoded for each type of device, so that clearing doesn't need to encode
+// anything
+var (
+	blankFramesLock sync.Mutex
+	blankFrames     = make(map[blankFrameKey][]byte)
+)
+
+// blankFrame gives a black frame of the given size encoded for this device, encoding it the first time only
+func (d *Device) blankFrame(size image.Point) ([]byte, error) {
+	key := blankFrameKey{d.deviceType.usbProductID, size, d.deviceType.imageFormat, d.deviceType.quirks}
+	blankFramesLock.Lock()
+	defer blankFramesLock.Unlock()
+	if frame, ok := blankFrames[key]; ok {
+		return frame, nil
+	}
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{0, 0}, draw.Src)
+	frame, err := getImageForButton(img, d.deviceType.imageFormat, d.deviceType.quirks)
+	if err != nil {
+		return nil, err
+	}
+	blankFrames[key] = frame
+	return frame, nil
+}
+
+// ClearButtons writes a black square to all buttons
+func (d *Device) ClearButtons() {
+	numButtons := int(d.deviceType.numberOfButtons)
+	for i := 0; i < numButtons; i++ {
+		d.ClearButton(i)
+	}
+}
+
+// ClearButton writes a black square to the given button
+func (d *Device) ClearButton(btnIndex int) error {
+	if !d.HasImageCapability() {
+		return errors.New("Button doesn't have image capability")
+	}
+	frame, err := d.blankFrame(d.deviceType.imageSize)
+	if err != nil {
+		return err
+	}
+	return d.rawWriteToButton(int(d.mapButtonIn(uint(btnIndex))), frame)
+}
+
+// ClearTouchscreen blanks the touchscreen (or info display)
+func (d *Device) ClearTouchscreen() error {
+	size := d.deviceType.touchscreenSize
+	if size == (image.Point{}) || d.deviceType.imageAreaHeaderFunc == nil {
+		return errors.New("Device doesn't have a touchscreen")
+	}
+	frame, err := d.blankFrame(size)
+	if err != nil {
+		return err
+	}
+	pos := d.deviceType.touchscreenPosition
+	return d.rawWriteToArea(pos.X, pos.Y, size.X, size.Y, frame)
+}
+
+// clearTouchscreen blanks the touchscreen if the device has one, and otherwise does nothing
+func (d *Device) clearTouchscreen() error {
+	if d.deviceType.touchscreenSize == (image.Point{}) || d.deviceType.imageAreaHeaderFunc == nil {
+		return nil
+	}
+	return d.ClearTouchscreen()
+}
@@ -0,0 +1,74 @@
+package streamdeck_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/jpeg"
+	"io/ioutil"
+	"testing"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+// TestBlankFrameSharedName clears a key on an XL, then on a deck registered from a device file under the XL's name
+// but with smaller keys, which must be cleared with a frame of its own size rather than the XL's
+func TestBlankFrameSharedName(t *testing.T) {
+	xl, xlMock, err := streamdecktest.Open(0x6c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xl.Close()
+
+	data, err := ioutil.ReadFile("examples/devicefile/xl-clone.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var def map[string]interface{}
+	if err := json.Unmarshal(data, &def); err != nil {
+		t.Fatal(err)
+	}
+	def["name"] = xl.GetName()
+	def["usbProductID"] = 0xfff1
+	def["imageWidth"] = 72
+	def["imageHeight"] = 72
+	data, err = json.Marshal(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := streamdeck.RegisterDevicetypeFromReader(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	smallMock := streamdecktest.NewMock(protocol.Layout{})
+	small, err := streamdeck.OpenWithInterface(smallMock, 0xfff1, "CLEAR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer small.Close()
+
+	for _, tc := range []struct {
+		d    *streamdeck.Device
+		mock *streamdecktest.Mock
+		size int
+	}{{xl, xlMock, 96}, {small, smallMock, 72}} {
+		tc.mock.ClearRecorded()
+		if err := tc.d.ClearButton(0); err != nil {
+			t.Fatal(err)
+		}
+		var frame []byte
+		for _, page := range tc.mock.WritesWithPrefix([]byte{0x02, 0x07, 0}) {
+			n := int(page[4]) | int(page[5])<<8
+			frame = append(frame, page[8:8+n]...)
+		}
+		config, err := jpeg.DecodeConfig(bytes.NewReader(frame))
+		if err != nil {
+			t.Fatalf("%s (%#x) was cleared with %d bytes which aren't a JPEG: %s", tc.d.GetName(), tc.d.GetUSBProductId(), len(frame), err)
+		}
+		if config.Width != tc.size || config.Height != tc.size {
+			t.Errorf("%s (%#x) was cleared with a %dx%d frame, not %dx%d", tc.d.GetName(), tc.d.GetUSBProductId(),
+				config.Width, config.Height, tc.size, tc.size)
+		}
+	}
+}
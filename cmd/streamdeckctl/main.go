@@ -0,0 +1,257 @@
+// Command streamdeckctl controls Stream Decks from the shell, for scripts and diagnostics:
+//
+//	streamdeckctl list                      List the attached devices
+//	streamdeckctl image <key> <file or URL> Set a key's image from a PNG, JPEG or GIF
+//	streamdeckctl brightness <percentage>   Set the brightness
+//	streamdeckctl clear                     Blank every key
+//	streamdeckctl selftest                  Run the self-test, showing events as they come
+//	streamdeckctl events                    Print events as JSON, one object per line, until interrupted
+//
+// The first device found is used, unless -serial is given; "events" follows every device unless -serial is given.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/gif"  // Decoders for key images
+	_ "image/jpeg" // ...
+	_ "image/png"  // ...
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+)
+
+// Event is a button, encoder or touch event, as printed by "events"
+type Event struct {
+	Serial  string `json:"serial"`
+	Type    string `json:"type"` // "button", "encoderPress", "encoderRotate", "touch" or "swipe"
+	Index   int    `json:"index"`
+	Pressed bool   `json:"pressed,omitempty"`
+	Pulses  int    `json:"pulses,omitempty"`
+	X       int    `json:"x,omitempty"`
+	Y       int    `json:"y,omitempty"`
+	XEnd    int    `json:"xEnd,omitempty"`
+	YEnd    int    `json:"yEnd,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+var serial = flag.String("serial", "", "serial number of the device to use")
+var step = flag.Duration("step", time.Second, "how long selftest shows each step for")
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "list":
+		err = list()
+	case "image":
+		err = setImage(args)
+	case "brightness":
+		err = setBrightness(args)
+	case "clear":
+		err = clearKeys()
+	case "selftest":
+		err = selfTest()
+	case "events":
+		err = events()
+	default:
+		err = fmt.Errorf("Unknown command %q", flag.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "streamdeckctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: streamdeckctl [flags] list | image <key> <file or URL> | brightness <percentage> | clear | selftest | events")
+	flag.PrintDefaults()
+}
+
+// open opens the device given by -serial, or the first one found
+func open() (*streamdeck.Device, error) {
+	if *serial != "" {
+		return streamdeck.OpenBySerial(*serial)
+	}
+	return streamdeck.Open()
+}
+
+func list() error {
+	for _, found := range streamdeck.Search() {
+		fmt.Printf("%s\t%s\t0x%04x\n", found.Serial, found.Name, found.ProductID)
+	}
+	return nil
+}
+
+func setImage(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("image needs a key and a file or URL")
+	}
+	btnIndex, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("Invalid key %q", args[0])
+	}
+	img, err := loadImage(args[1])
+	if err != nil {
+		return err
+	}
+	d, err := open()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if btnIndex < 0 || btnIndex >= int(d.GetNumberOfButtons()) {
+		return fmt.Errorf("%s has no key %d", d.GetName(), btnIndex)
+	}
+	return d.WriteRawImageToButton(btnIndex, img)
+}
+
+// loadImage reads an image from a file, or fetches it if it's given as a URL
+func loadImage(source string) (image.Image, error) {
+	var r io.Reader
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := http.Get(source)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Fetching %s: %s", source, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("Reading %s: %s", source, err)
+	}
+	return img, nil
+}
+
+func setBrightness(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("brightness needs a percentage")
+	}
+	pct, err := strconv.Atoi(strings.TrimSuffix(args[0], "%"))
+	if err != nil || pct < 0 || pct > 100 {
+		return fmt.Errorf("Brightness must be a percentage")
+	}
+	d, err := open()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	d.SetBrightness(pct)
+	return nil
+}
+
+func clearKeys() error {
+	d, err := open()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	d.ClearButtons()
+	return nil
+}
+
+func selfTest() error {
+	d, err := open()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	fmt.Printf("Testing %s (%s); press every key and turn every encoder\n", d.GetName(), d.GetSerial())
+	result := d.RunSelfTest(*step, func(line string) {
+		fmt.Println(line)
+	})
+	fmt.Printf("Keys pressed: %v\nEncoders pressed: %v\nEncoders rotated: %v\nTouches: %d\n",
+		distinct(result.ButtonsPressed), distinct(result.EncodersPressed), distinct(result.EncodersRotated), result.Touches)
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%d errors during the self-test", len(result.Errors))
+	}
+	return nil
+}
+
+// distinct gives the indexes which were seen, once each and in order
+func distinct(indexes []int) []int {
+	seen := map[int]bool{}
+	result := []int{}
+	for _, i := range indexes {
+		if !seen[i] {
+			seen[i] = true
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func events() error {
+	m := streamdeck.NewManager()
+	if *serial != "" {
+		if _, err := m.Open(*serial); err != nil {
+			return err
+		}
+	} else if err := m.OpenAll(); err != nil {
+		return err
+	}
+	defer m.Close()
+
+	var lock sync.Mutex
+	out := json.NewEncoder(os.Stdout)
+	emit := func(e Event) {
+		lock.Lock()
+		defer lock.Unlock()
+		out.Encode(e)
+	}
+	for _, d := range m.GetDevices() {
+		serial := d.GetSerial()
+		d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, pressed bool) {
+			e := Event{Serial: serial, Type: "button", Index: btnIndex, Pressed: pressed}
+			if err != nil {
+				e.Error = err.Error()
+			}
+			emit(e)
+		})
+		d.EncoderPress(func(encIndex int, d *streamdeck.Device, pressed bool) {
+			emit(Event{Serial: serial, Type: "encoderPress", Index: encIndex, Pressed: pressed})
+		})
+		d.EncoderRotate(func(encIndex int, d *streamdeck.Device, pulses int) {
+			emit(Event{Serial: serial, Type: "encoderRotate", Index: encIndex, Pulses: pulses})
+		})
+		d.TouchPush(func(d *streamdeck.Device, x, y uint16, hold bool) {
+			emit(Event{Serial: serial, Type: "touch", X: int(x), Y: int(y), Pressed: hold})
+		})
+		d.TouchSwipe(func(d *streamdeck.Device, xstart, ystart, xstop, ystop uint16) {
+			emit(Event{Serial: serial, Type: "swipe", X: int(xstart), Y: int(ystart), XEnd: int(xstop), YEnd: int(ystop)})
+		})
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+	return nil
+}
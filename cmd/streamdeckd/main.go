@@ -0,0 +1,81 @@
+// Command streamdeckd owns the attached Stream Decks and serves them with the HTTP API of the httpapi package, so
+// that several processes can share a deck instead of fighting over its HID handle; they use httpapi.Client, or
+// anything that speaks HTTP.  Decks attached later are opened as they are found.
+//
+//	streamdeckd -listen localhost:8080
+//	streamdeckd -listen /run/user/1000/streamdeckd.sock
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+	"github.com/SKAARHOJ/go-streamdeck/httpapi"
+)
+
+func main() {
+	listen := flag.String("listen", "localhost:8080", "address to listen on, or the path of a Unix socket")
+	rescan := flag.Duration("rescan", 5*time.Second, "how often to look for newly attached decks")
+	flag.Parse()
+
+	m := streamdeck.NewManager()
+	if err := m.OpenAll(); err != nil {
+		log.Print(err)
+	}
+	for _, d := range m.GetDevices() {
+		log.Printf("Opened %s (%s)", d.GetName(), d.GetSerial())
+	}
+
+	l, err := listener(*listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go http.Serve(l, httpapi.NewServer(m))
+	log.Printf("Listening on %s", *listen)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	ticker := time.NewTicker(*rescan)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			open(m)
+		case <-interrupt:
+			l.Close()
+			m.Close()
+			return
+		}
+	}
+}
+
+// listener listens on TCP, or on a Unix socket if the address is a path
+func listener(address string) (net.Listener, error) {
+	if !strings.HasPrefix(address, "/") && !strings.HasPrefix(address, ".") {
+		return net.Listen("tcp", address)
+	}
+	os.Remove(address) // Left behind if the last run didn't stop cleanly
+	return net.Listen("unix", address)
+}
+
+// open opens any decks which have been attached since the last look
+func open(m *streamdeck.Manager) {
+	for _, found := range streamdeck.Search() {
+		if m.GetDevice(found.Serial) != nil {
+			continue
+		}
+		d, err := m.Open(found.Serial)
+		if err != nil {
+			continue
+		}
+		log.Printf("Opened %s (%s)", d.GetName(), d.GetSerial())
+	}
+}
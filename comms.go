@@ -1,16 +1,18 @@
 package streamdeck
 
 import (
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"image"
 	"image/color"
+	"sync"
 	"time"
 
 	"github.com/disintegration/gift"
 	"github.com/karalabe/hid"
 	log "github.com/s00500/env_logger"
+
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
 )
 
 const vendorID = 0x0fd9
@@ -32,17 +34,119 @@ type deviceType struct {
 	buttonCols          uint
 	brightnessPacket    []byte
 	buttonReadOffset    uint
+	numberOfEncoders    uint
+	encoderReadOffset   uint
+	touchscreenSize     image.Point
+	touchscreenPosition image.Point
+	touchscreenInput    bool
+	quirks              Quirk
 	imageFormat         string
 	imagePayloadPerPage uint
 	imageHeaderFunc     func(bytesRemaining uint, btnIndex uint, pageNumber uint) []byte
 	imageAreaHeaderFunc func(bytesRemaining uint, x, y, width, height uint, pageNumber uint) []byte
 	serial              string
 	buttonMap           map[uint]int
+
+	touchscreenBrightnessPacket []byte
+	buttonGeometry              map[int]image.Rectangle
+	inputReportLength           uint
+	outputReportLength          uint
 }
 
 var deviceTypes []deviceType
 
-// RegisterDevicetype allows the declaration of a new type of device, intended for use by subpackage "devices"
+// Quirk flags a way in which a device differs from the common case, see DeviceDefinition.Quirks
+type Quirk uint
+
+const (
+	// QuirkHalfImagePages sends button images as two halves, rather than filling each page (original Streamdeck)
+	QuirkHalfImagePages Quirk = 1 << iota
+	// QuirkOpaqueImage removes any alpha channel before encoding, as transparent pixels otherwise show as black
+	QuirkOpaqueImage
+	// QuirkRotateAreaImage rotates images written to the display area by 180 degrees (Streamdeck Neo info display)
+	QuirkRotateAreaImage
+	// QuirkRotateImage180 rotates button images by 180 degrees
+	QuirkRotateImage180
+	// QuirkRotateImage90 rotates button images by 90 degrees
+	QuirkRotateImage90
+	// QuirkFlipImageVertical flips button images vertically, after any rotation
+	QuirkFlipImageVertical
+)
+
+// DeviceDefinition describes a type of Streamdeck, and is passed to RegisterDevice
+type DeviceDefinition struct {
+	Name                string      // Name of the device
+	ImageSize           image.Point // Width/height of a button image
+	USBProductID        uint16      // USB productID
+	ResetPacket         []byte      // Reset packet
+	NumberOfButtons     uint        // Number of buttons
+	ButtonRows          uint        // Number of rows
+	ButtonCols          uint        // Number of columns
+	BrightnessPacket    []byte      // Set brightness packet preamble
+	ButtonReadOffset    uint        // Offset of the first button in an input report
+	NumberOfEncoders    uint        // Number of encoders
+	EncoderReadOffset   uint        // Offset of the first encoder in an encoder input report
+	TouchscreenSize     image.Point // Width/height of the touchscreen or info display, if there is one
+	TouchscreenPosition image.Point // Top left of the touchscreen in the coordinates used by ImageAreaHeaderFunc
+	TouchscreenInput    bool        // Whether the touchscreen reports touches, rather than only being a display
+	Quirks              Quirk       // Device specific behaviour, see the Quirk constants
+	ImageFormat         string      // Image format, "JPEG" or "BMP"
+	ImagePayloadPerPage uint        // Amount of image payload allowed per USB packet
+	ButtonMap           map[uint]int
+
+	// ImageHeaderFunc returns the comms header for a page of a button image
+	ImageHeaderFunc func(bytesRemaining uint, btnIndex uint, pageNumber uint) []byte
+	// ImageAreaHeaderFunc returns the comms header for a page of an image written to a display area, if the device has one
+	ImageAreaHeaderFunc func(bytesRemaining uint, x, y, width, height uint, pageNumber uint) []byte
+	// TouchscreenBrightnessPacket is the preamble to set the touchscreen brightness, if it can be set separately from the buttons
+	TouchscreenBrightnessPacket []byte
+	// ButtonGeometry gives the visible area within the ImageSize frame for buttons which don't show the whole of it
+	ButtonGeometry map[int]image.Rectangle
+	// InputReportLength is the size of the device's HID input reports, if they are longer than the events need
+	InputReportLength uint
+	// OutputReportLength is the size of the device's HID output reports, which each page of an image must fit;
+	// if it is zero, it is ImagePayloadPerPage
+	OutputReportLength uint
+}
+
+// RegisterDevice allows the declaration of a new type of device, intended for use by subpackage "devices"
+func RegisterDevice(def DeviceDefinition) {
+	d := deviceType{
+		name:                def.Name,
+		imageSize:           def.ImageSize,
+		usbProductID:        def.USBProductID,
+		resetPacket:         def.ResetPacket,
+		numberOfButtons:     def.NumberOfButtons,
+		buttonRows:          def.ButtonRows,
+		buttonCols:          def.ButtonCols,
+		brightnessPacket:    def.BrightnessPacket,
+		buttonReadOffset:    def.ButtonReadOffset,
+		numberOfEncoders:    def.NumberOfEncoders,
+		encoderReadOffset:   def.EncoderReadOffset,
+		touchscreenSize:     def.TouchscreenSize,
+		touchscreenPosition: def.TouchscreenPosition,
+		touchscreenInput:    def.TouchscreenInput,
+		quirks:              def.Quirks,
+		imageFormat:         def.ImageFormat,
+		imagePayloadPerPage: def.ImagePayloadPerPage,
+		buttonMap:           def.ButtonMap,
+		imageHeaderFunc:     def.ImageHeaderFunc,
+		imageAreaHeaderFunc: def.ImageAreaHeaderFunc,
+
+		touchscreenBrightnessPacket: def.TouchscreenBrightnessPacket,
+		buttonGeometry:              def.ButtonGeometry,
+		inputReportLength:           def.InputReportLength,
+		outputReportLength:          def.OutputReportLength,
+	}
+	if d.outputReportLength == 0 {
+		d.outputReportLength = def.ImagePayloadPerPage
+	}
+	deviceTypes = append(deviceTypes, d)
+}
+
+// RegisterDevicetype allows the declaration of a new type of device
+//
+// Deprecated: use RegisterDevice, which takes a DeviceDefinition and can grow new fields without breaking callers
 func RegisterDevicetype(
 	name string,
 	imageSize image.Point,
@@ -59,35 +163,50 @@ func RegisterDevicetype(
 	imageHeaderFunc func(bytesRemaining uint, btnIndex uint, pageNumber uint) []byte,
 	imageAreaHeaderFunc func(bytesRemaining uint, x, y, width, height uint, pageNumber uint) []byte,
 ) {
-	d := deviceType{
-		name:                name,
-		imageSize:           imageSize,
-		usbProductID:        usbProductID,
-		resetPacket:         resetPacket,
-		numberOfButtons:     numberOfButtons,
-		buttonRows:          buttonRows,
-		buttonCols:          buttonCols,
-		brightnessPacket:    brightnessPacket,
-		buttonReadOffset:    buttonReadOffset,
-		imageFormat:         imageFormat,
-		imagePayloadPerPage: imagePayloadPerPage,
-		buttonMap:           buttonMap,
-		imageHeaderFunc:     imageHeaderFunc,
-		imageAreaHeaderFunc: imageAreaHeaderFunc,
-	}
-	deviceTypes = append(deviceTypes, d)
-}
-
-// Device is a struct which represents an actual Streamdeck device, and holds its reference to the USB HID device
+	RegisterDevice(DeviceDefinition{
+		Name:                name,
+		ImageSize:           imageSize,
+		USBProductID:        usbProductID,
+		ResetPacket:         resetPacket,
+		NumberOfButtons:     numberOfButtons,
+		ButtonRows:          buttonRows,
+		ButtonCols:          buttonCols,
+		BrightnessPacket:    brightnessPacket,
+		ButtonReadOffset:    buttonReadOffset,
+		ImageFormat:         imageFormat,
+		ImagePayloadPerPage: imagePayloadPerPage,
+		ButtonMap:           buttonMap,
+		ImageHeaderFunc:     imageHeaderFunc,
+		ImageAreaHeaderFunc: imageAreaHeaderFunc,
+	})
+}
+
+// Device is a struct which represents an actual Streamdeck device, and holds its reference to the USB HID device (or another DeviceInterface)
 type Device struct {
-	fd         *hid.Device
+	fd         DeviceInterface
 	deviceType deviceType
 
-	buttonPressListeners     []func(int, *Device, error, bool)
-	encoderPushListeners     []func(int, *Device, bool)
-	encoderRotationListeners []func(int, *Device, int)
-	touchPushListeners       []func(*Device, uint16, uint16, bool)
-	touchSwipeListeners      []func(*Device, uint16, uint16, uint16, uint16)
+	buttonMapLock sync.RWMutex // Guards the button map, and the rotation it is made from
+	buttonMap     map[uint]int // Set by SetButtonMap or SetRotation; nil for the definition's own
+	rotation      int          // Degrees clockwise, see SetRotation
+	writeLock     sync.Mutex   // Stops the pages of images written from different goroutines interleaving
+	pageBuffer    []byte       // Reused for every page of image written, guarded by writeLock
+	frameLock     sync.Mutex
+	frames        staleFrames
+
+	listenerLock             sync.Mutex // Listeners can be added and removed while events are being sent
+	buttonPressListeners     listenerList
+	encoderPushListeners     listenerList
+	encoderRotationListeners listenerList
+	touchPushListeners       listenerList
+	touchSwipeListeners      listenerList
+
+	middlewareLock sync.RWMutex
+	middleware     []Middleware
+	eventHandler   EventHandler // The middleware wrapped around deliver, once there is any
+
+	metrics deviceMetrics
+	shadow  shadow // What was last written, for Redraw
 }
 
 // Open a Streamdeck device, the most common entry point
@@ -137,7 +256,7 @@ func rawOpen(reset bool, serial string) (*Device, error) {
 					retval.deviceType.serial = device.Serial
 					dev, err := device.Open()
 					if err != nil {
-						return nil, err
+						return nil, permissionProblem(device, err)
 					}
 					retval.fd = dev
 					if reset {
@@ -167,18 +286,114 @@ func (d *Device) GetImageSize() image.Point {
 	return d.deviceType.imageSize
 }
 
+// GetButtonImageSize returns the visible size of the given button, which is the same as GetImageSize unless the button
+// is irregular.  The size is as the button is seen, turned with the deck (see SetRotation).
+func (d *Device) GetButtonImageSize(btnIndex int) image.Point {
+	// Geometry is by hardware button, like the button map
+	if area, ok := d.deviceType.buttonGeometry[d.mapButtonIn(uint(btnIndex))]; ok {
+		if rotation := d.GetRotation(); rotation == 90 || rotation == 270 {
+			return image.Point{X: area.Dy(), Y: area.Dx()}
+		}
+		return area.Size()
+	}
+	return d.deviceType.imageSize
+}
+
 func (d *Device) HasImageCapability() bool {
 	return d.deviceType.imageSize != image.Point{}
 }
 
+// GetTouchscreenSize returns the size of the touchscreen or info display, or an empty point if there is none
+func (d *Device) GetTouchscreenSize() image.Point {
+	return d.deviceType.touchscreenSize
+}
+
+// GetTouchscreenPosition returns where the touchscreen starts in the coordinates used by WriteRawImageToAreaUnscaled
+func (d *Device) GetTouchscreenPosition() image.Point {
+	return d.deviceType.touchscreenPosition
+}
+
+// GetQuirks returns the ways this device differs from the common case, see Quirk
+func (d *Device) GetQuirks() Quirk {
+	return d.deviceType.quirks
+}
+
+func (d *Device) hasQuirk(q Quirk) bool {
+	return d.deviceType.quirks&q != 0
+}
+
 func (d *Device) GetNumberOfButtons() uint {
 	return d.deviceType.numberOfButtons
 }
 
+// GetButtonRows returns the number of rows in the button grid, as the deck is seen (see SetRotation)
+func (d *Device) GetButtonRows() uint {
+	rows, _ := d.gridSize()
+	return uint(rows)
+}
+
+// GetButtonCols returns the number of columns in the button grid, as the deck is seen (see SetRotation)
+func (d *Device) GetButtonCols() uint {
+	_, cols := d.gridSize()
+	return uint(cols)
+}
+
+// ButtonAt returns the index of the button at the given row and column (counting from zero, top left), or -1 if there is no button there
+func (d *Device) ButtonAt(row, col int) int {
+	rows, cols := d.gridSize()
+	if row < 0 || col < 0 || row >= rows || col >= cols {
+		return -1
+	}
+	return row*cols + col
+}
+
+// ButtonPosition returns the row and column of the given button, or -1, -1 if it isn't part of the button grid (such as the Neo paging buttons)
+func (d *Device) ButtonPosition(btnIndex int) (int, int) {
+	rows, cols := d.gridSize()
+	if btnIndex < 0 || cols == 0 || btnIndex >= cols*rows {
+		return -1, -1
+	}
+	return btnIndex / cols, btnIndex % cols
+}
+
 func (d *Device) GetUSBProductId() int {
 	return int(d.deviceType.usbProductID)
 }
 
+// Capabilities describes the hardware features of a Streamdeck, so that applications can adapt their layout to the device
+type Capabilities struct {
+	HasKeys          bool
+	NumberOfKeys     uint
+	HasEncoders      bool
+	NumberOfEncoders uint
+	HasTouchStrip    bool
+	TouchStripSize   image.Point
+	HasNFC           bool
+	HasInfoDisplay   bool
+	InfoDisplaySize  image.Point
+	HasLEDRings      bool
+}
+
+// Capabilities returns the hardware features of this Streamdeck
+func (d *Device) Capabilities() Capabilities {
+	c := Capabilities{
+		HasKeys:          d.deviceType.numberOfButtons > 0,
+		NumberOfKeys:     d.deviceType.numberOfButtons,
+		HasEncoders:      d.deviceType.numberOfEncoders > 0,
+		NumberOfEncoders: d.deviceType.numberOfEncoders,
+	}
+	if d.deviceType.touchscreenSize != (image.Point{}) {
+		if d.deviceType.touchscreenInput {
+			c.HasTouchStrip = true
+			c.TouchStripSize = d.deviceType.touchscreenSize
+		} else {
+			c.HasInfoDisplay = true
+			c.InfoDisplaySize = d.deviceType.touchscreenSize
+		}
+	}
+	return c
+}
+
 // Close the device
 func (d *Device) Close() {
 	d.fd.Close()
@@ -187,26 +402,38 @@ func (d *Device) Close() {
 // SetBrightness sets the button brightness
 // pct is an integer between 0-100
 func (d *Device) SetBrightness(pct int) {
+	d.sendBrightness(d.deviceType.brightnessPacket, pct)
+}
+
+// SetTouchscreenBrightness sets the touchscreen (or info display) brightness separately from the buttons,
+// on devices which support it.  Where the display shares its backlight with the buttons, as on the
+// Streamdeck Plus, this sets the brightness of both, the same as SetBrightness.
+// pct is an integer between 0-100
+func (d *Device) SetTouchscreenBrightness(pct int) error {
+	if d.deviceType.touchscreenSize == (image.Point{}) {
+		return errors.New("Device doesn't have a touchscreen")
+	}
+	if d.deviceType.touchscreenBrightnessPacket == nil {
+		d.SetBrightness(pct)
+		return nil
+	}
+	d.sendBrightness(d.deviceType.touchscreenBrightnessPacket, pct)
+	return nil
+}
+
+func (d *Device) sendBrightness(preamble []byte, pct int) {
 	if pct < 0 {
 		pct = 0
 	}
 	if pct > 100 {
 		pct = 100
 	}
+	d.shadow.setBrightness(preamble, pct)
 
-	preamble := d.deviceType.brightnessPacket
 	payload := append(preamble, byte(pct))
 	d.fd.SendFeatureReport(payload)
 }
 
-// ClearButtons writes a black square to all buttons
-func (d *Device) ClearButtons() {
-	numButtons := int(d.deviceType.numberOfButtons)
-	for i := 0; i < numButtons; i++ {
-		d.WriteColorToButton(i, color.Black)
-	}
-}
-
 // WriteColorToButton writes a specified color to the given button
 func (d *Device) WriteColorToButton(btnIndex int, colour color.Color) error {
 	btnIndex = int(d.mapButtonIn(uint(btnIndex)))
@@ -215,7 +442,7 @@ func (d *Device) WriteColorToButton(btnIndex int, colour color.Color) error {
 	}
 
 	img := getSolidColourImage(colour, d.deviceType.imageSize.X)
-	imgForButton, err := getImageForButton(img, d.deviceType.imageFormat)
+	imgForButton, err := getImageForButton(img, d.deviceType.imageFormat, d.deviceType.quirks)
 	if err != nil {
 		return err
 	}
@@ -238,114 +465,60 @@ func (d *Device) WriteImageToButton(btnIndex int, filename string) error {
 }
 
 func (d *Device) eventListener() {
-	var buttonMask []bool
-	buttonMask = make([]bool, d.deviceType.numberOfButtons)
-
-	buttonTime := make([]time.Time, d.deviceType.numberOfButtons)
-	for i := range buttonTime {
-		buttonTime[i] = time.Now()
-	}
-
-	// All for Streamdeck Plus:
-	numberOfEncoders := 4
-	encoderReadOffset := 5
-	encoderButtonTime := make([]time.Time, numberOfEncoders)
-	for i := range encoderButtonTime {
-		encoderButtonTime[i] = time.Now()
-	}
-	var encoderButtonMask []bool
-	encoderButtonMask = make([]bool, numberOfEncoders)
+	layout := d.GetLayout()
+	decoder := protocol.NewDecoder(layout)
+	data := make([]byte, d.GetInputReportLength())
 
 	for {
-		data := make([]byte, 255) // d.deviceType.numberOfButtons+d.deviceType.buttonReadOffset
-		_, err := d.fd.Read(data)
+		n, err := d.fd.Read(data)
 		if err != nil {
+			d.recordReadError()
 			d.sendButtonPressEvent(-1, err)
 			break
 		}
 
-		if data[0] == 1 { // Seems like the first byte is always one for events...
-			if d.deviceType.name == "Streamdeck Plus" && data[1] > 0 {
-				switch data[1] {
-				case 2: // Touch
-					switch data[4] {
-					case 1: // Tap
-						xpos := binary.LittleEndian.Uint16(data[6:])
-						ypos := binary.LittleEndian.Uint16(data[8:])
-						d.sendTouchPushEvent(xpos, ypos, false)
-					case 2: // Press/Hold
-						xpos := binary.LittleEndian.Uint16(data[6:])
-						ypos := binary.LittleEndian.Uint16(data[8:])
-						d.sendTouchPushEvent(xpos, ypos, true)
-					case 3: // Swipe
-						xstart := binary.LittleEndian.Uint16(data[6:])
-						ystart := binary.LittleEndian.Uint16(data[8:])
-						xstop := binary.LittleEndian.Uint16(data[10:])
-						ystop := binary.LittleEndian.Uint16(data[12:])
-						/*						fmt.Printf("SWIPE: xstart=%d, ystart=%d, xstop=%d, ystop=%d; %s,%s\n", xstart, ystart, xstop, ystop,
-												su.Qstr((int(xstop)-int(xstart)) > 0, "Right", "Left"), su.Qstr((int(ystop)-int(ystart)) < 0, "Up", "Down"))
-						*/
-						d.sendTouchSwipeEvent(xstart, ystart, xstop, ystop)
-					}
-				case 3: // Encoders
-					switch data[4] {
-					case 1: // Rotate
-						for i := 0; i < numberOfEncoders; i++ {
-							if data[encoderReadOffset+i] > 0 {
-								rev := int(data[encoderReadOffset+i])
-								if rev > 127 {
-									rev = rev - 256
-								}
-								d.sendEncoderRotateEvent(i, rev)
-							}
-						}
-					case 0: // Press
-						for i := 0; i < numberOfEncoders; i++ {
-							if data[encoderReadOffset+i] == 1 {
-								if time.Now().After(encoderButtonTime[i].Add(time.Duration(time.Millisecond * 100))) { // Implement 100 ms debouncing on button presses.
-									if !encoderButtonMask[i] {
-										d.sendEncoderPushEvent(i, true)
-										encoderButtonTime[i] = time.Now()
-									}
-									encoderButtonMask[i] = true
-								}
-							} else {
-								if encoderButtonMask[i] {
-									d.sendEncoderPushEvent(i, false)
-									encoderButtonMask[i] = false // Putting it here instead of outside the condition because we ONLY want release events if there has been a Press event first (related to the fact that debouncing above can lead to ignored events)
-								}
-							}
-
-						}
-					}
-				}
-			} else {
-				// Standard button stuff
-				for i := uint(0); i < d.deviceType.numberOfButtons; i++ {
-					if data[d.deviceType.buttonReadOffset+i] == 1 {
-						if time.Now().After(buttonTime[i].Add(time.Duration(time.Millisecond * 100))) { // Implement 100 ms debouncing on button presses.
-							if !buttonMask[i] {
-								d.sendButtonPressEvent(d.mapButtonOut(i), nil)
-								buttonTime[i] = time.Now()
-							}
-							buttonMask[i] = true
-						}
-					} else {
-						if buttonMask[i] {
-							d.sendButtonReleaseEvent(d.mapButtonOut(i), nil)
-							buttonMask[i] = false // Putting it here instead of outside the condition because we ONLY want release events if there has been a Press event first (related to the fact that debouncing above can lead to ignored events)
-						}
-					}
-				}
+		// Only what was read is decoded, so that a short read can't be mistaken for every button being released
+		for _, e := range decoder.Decode(data[:n]) {
+			if e.Type == protocol.ButtonPress || e.Type == protocol.ButtonRelease {
+				e.Index = d.mapButtonOut(uint(e.Index))
 			}
+			d.dispatch(e)
 		}
 	}
 }
 
+// SetButtonMap replaces the mapping from hardware button numbers (the keys) to the button indexes used by this
+// package (the values), for example to number the buttons column-major; SetRotation sets it for a deck mounted
+// turned round.
+// Buttons missing from the map keep their hardware number, so an empty map turns off mapping altogether, and a
+// nil map goes back to the device's own (the original Streamdeck numbers its buttons right to left).
+func (d *Device) SetButtonMap(buttonMap map[uint]int) {
+	var newMap map[uint]int
+	if buttonMap != nil {
+		newMap = make(map[uint]int, len(buttonMap))
+		for k, v := range buttonMap {
+			newMap[k] = v
+		}
+	}
+	d.buttonMapLock.Lock()
+	d.buttonMap = newMap
+	d.buttonMapLock.Unlock()
+}
+
+// currentButtonMap returns the button map in effect; it must be called with buttonMapLock held
+func (d *Device) currentButtonMap() map[uint]int {
+	if d.buttonMap != nil {
+		return d.buttonMap
+	}
+	return d.deviceType.buttonMap
+}
+
 func (d *Device) mapButtonOut(btnIndex uint) int {
-	if d.deviceType.buttonMap != nil {
-		if _, exists := d.deviceType.buttonMap[btnIndex]; exists {
-			btnIndex = uint(d.deviceType.buttonMap[btnIndex])
+	d.buttonMapLock.RLock()
+	defer d.buttonMapLock.RUnlock()
+	if buttonMap := d.currentButtonMap(); buttonMap != nil {
+		if _, exists := buttonMap[btnIndex]; exists {
+			btnIndex = uint(buttonMap[btnIndex])
 		}
 	}
 
@@ -353,8 +526,10 @@ func (d *Device) mapButtonOut(btnIndex uint) int {
 }
 
 func (d *Device) mapButtonIn(btnIndex uint) int {
-	if d.deviceType.buttonMap != nil {
-		for out, match := range d.deviceType.buttonMap {
+	d.buttonMapLock.RLock()
+	defer d.buttonMapLock.RUnlock()
+	if buttonMap := d.currentButtonMap(); buttonMap != nil {
+		for out, match := range buttonMap {
 			if uint(match) == btnIndex {
 				return int(out)
 			}
@@ -364,65 +539,130 @@ func (d *Device) mapButtonIn(btnIndex uint) int {
 	return int(btnIndex)
 }
 
+// sendButtonPressEvent passes a press to the listeners, or with an error a failed read, which isn't counted as a
+// press
 func (d *Device) sendButtonPressEvent(btnIndex int, err error) {
-	for _, f := range d.buttonPressListeners {
-		f(btnIndex, d, err, true)
+	if err == nil {
+		d.recordEvent("buttonPress")
+	}
+	d.listenerLock.Lock()
+	listeners := d.buttonPressListeners.entries
+	d.listenerLock.Unlock()
+	for _, l := range listeners {
+		l.f.(func(int, *Device, error, bool))(btnIndex, d, err, true)
 	}
 }
 
 func (d *Device) sendButtonReleaseEvent(btnIndex int, err error) {
-	for _, f := range d.buttonPressListeners {
-		f(btnIndex, d, err, false)
+	d.recordEvent("buttonRelease")
+	d.listenerLock.Lock()
+	listeners := d.buttonPressListeners.entries
+	d.listenerLock.Unlock()
+	for _, l := range listeners {
+		l.f.(func(int, *Device, error, bool))(btnIndex, d, err, false)
 	}
 }
 
 func (d *Device) sendEncoderPushEvent(btnIndex int, pressed bool) {
-	for _, f := range d.encoderPushListeners {
-		f(btnIndex, d, pressed)
+	d.recordEvent("encoderPress")
+	d.listenerLock.Lock()
+	listeners := d.encoderPushListeners.entries
+	d.listenerLock.Unlock()
+	for _, l := range listeners {
+		l.f.(func(int, *Device, bool))(btnIndex, d, pressed)
 	}
 }
 
 func (d *Device) sendEncoderRotateEvent(btnIndex int, pulses int) {
-	for _, f := range d.encoderRotationListeners {
-		f(btnIndex, d, pulses)
+	d.recordEvent("encoderRotate")
+	d.listenerLock.Lock()
+	listeners := d.encoderRotationListeners.entries
+	d.listenerLock.Unlock()
+	for _, l := range listeners {
+		l.f.(func(int, *Device, int))(btnIndex, d, pulses)
 	}
 }
 
 func (d *Device) sendTouchPushEvent(xpos, ypos uint16, hold bool) {
-	for _, f := range d.touchPushListeners {
-		f(d, xpos, ypos, hold)
+	d.recordEvent("touch")
+	d.listenerLock.Lock()
+	listeners := d.touchPushListeners.entries
+	d.listenerLock.Unlock()
+	for _, l := range listeners {
+		l.f.(func(*Device, uint16, uint16, bool))(d, xpos, ypos, hold)
 	}
 }
 
 func (d *Device) sendTouchSwipeEvent(xstart, ystart, xstop, ystop uint16) {
-	for _, f := range d.touchSwipeListeners {
-		f(d, xstart, ystart, xstop, ystop)
+	d.recordEvent("swipe")
+	d.listenerLock.Lock()
+	listeners := d.touchSwipeListeners.entries
+	d.listenerLock.Unlock()
+	for _, l := range listeners {
+		l.f.(func(*Device, uint16, uint16, uint16, uint16))(d, xstart, ystart, xstop, ystop)
+	}
+}
+
+// listenerList holds the callbacks for one kind of event, each with an ID so that it can be removed again.  The
+// entries are replaced rather than changed when a callback is removed, so that events already being sent to them
+// aren't disturbed.
+type listenerList struct {
+	nextID  int
+	entries []listener
+}
+
+type listener struct {
+	id int
+	f  interface{} // Of the type for the kind of event
+}
+
+// addListener adds a callback to a list, returning a function that removes it again
+func (d *Device) addListener(l *listenerList, f interface{}) func() {
+	d.listenerLock.Lock()
+	defer d.listenerLock.Unlock()
+	id := l.nextID
+	l.nextID++
+	l.entries = append(l.entries, listener{id: id, f: f})
+	return func() {
+		d.listenerLock.Lock()
+		defer d.listenerLock.Unlock()
+		entries := make([]listener, 0, len(l.entries))
+		for _, e := range l.entries {
+			if e.id != id {
+				entries = append(entries, e)
+			}
+		}
+		l.entries = entries
 	}
 }
 
+// The functions registering callbacks return a function that removes the callback again, for listeners that
+// shouldn't last as long as the Device.  A callback may still be called for an event that was already being sent
+// when it was removed.
+
 // ButtonPress registers a callback to be called whenever a button is pressed (or connection is lost!)
-func (d *Device) ButtonPress(f func(int, *Device, error, bool)) {
-	d.buttonPressListeners = append(d.buttonPressListeners, f)
+func (d *Device) ButtonPress(f func(int, *Device, error, bool)) func() {
+	return d.addListener(&d.buttonPressListeners, f)
 }
 
 // EncoderPress registers a callback to be called whenever an encoder is pressed
-func (d *Device) EncoderPress(f func(int, *Device, bool)) {
-	d.encoderPushListeners = append(d.encoderPushListeners, f)
+func (d *Device) EncoderPress(f func(int, *Device, bool)) func() {
+	return d.addListener(&d.encoderPushListeners, f)
 }
 
 // EncoderRotate registers a callback to be called whenever an encoder is rotated
-func (d *Device) EncoderRotate(f func(int, *Device, int)) {
-	d.encoderRotationListeners = append(d.encoderRotationListeners, f)
+func (d *Device) EncoderRotate(f func(int, *Device, int)) func() {
+	return d.addListener(&d.encoderRotationListeners, f)
 }
 
 // TouchPush registers a callback to be called whenever the touch area is pushed (tap or hold)
-func (d *Device) TouchPush(f func(*Device, uint16, uint16, bool)) {
-	d.touchPushListeners = append(d.touchPushListeners, f)
+func (d *Device) TouchPush(f func(*Device, uint16, uint16, bool)) func() {
+	return d.addListener(&d.touchPushListeners, f)
 }
 
 // TouchSwipe registers a callback to be called whenever the touch area is swiped
-func (d *Device) TouchSwipe(f func(*Device, uint16, uint16, uint16, uint16)) {
-	d.touchSwipeListeners = append(d.touchSwipeListeners, f)
+func (d *Device) TouchSwipe(f func(*Device, uint16, uint16, uint16, uint16)) func() {
+	return d.addListener(&d.touchSwipeListeners, f)
 }
 
 // ResetComms will reset the comms protocol to the StreamDeck; useful if things have gotten de-synced, but it will also reboot the StreamDeck
@@ -431,33 +671,88 @@ func (d *Device) ResetComms() {
 	d.fd.SendFeatureReport(payload)
 }
 
-// WriteRawImageToButton takes an `image.Image` and writes it to the given button, after resizing and rotating the image to fit the button (for some reason the StreamDeck screens are all upside down)
-func (d *Device) WriteRawImageToButton(btnIndex int, rawImg image.Image) error {
-	btnIndex = int(d.mapButtonIn(uint(btnIndex)))
+// WriteRawImageToButton takes an `image.Image` and writes it to the given button, after resizing and rotating the image to fit the button (for some reason the StreamDeck screens are all upside down).
+// Images not the shape of the button are stretched, unless a Fit is given with WithFit.
+func (d *Device) WriteRawImageToButton(btnIndex int, rawImg image.Image, opts ...ImageOption) error {
 	if !d.HasImageCapability() {
 		return errors.New("Button doesn't have image capability")
 	}
-	img := resizeAndRotate(rawImg, d.deviceType.imageSize.X, d.deviceType.imageSize.Y, d.deviceType.name)
-	imgForButton, err := getImageForButton(img, d.deviceType.imageFormat)
+	fitted := fitImage(rawImg, d.GetButtonImageSize(btnIndex), getImageOptions(opts).fit)
+	imgForButton, err := d.encodeButtonImage(btnIndex, fitted)
 	if err != nil {
 		return err
 	}
-	return d.rawWriteToButton(btnIndex, imgForButton)
+	return d.writeToButton(int(d.mapButtonIn(uint(btnIndex))), imgForButton, fitted)
+}
+
+// encodeButtonImage draws an image over the button's background, if it has one, and turns it for the deck's
+// rotation, then resizes, rotates and encodes it as the button needs it
+func (d *Device) encodeButtonImage(btnIndex int, rawImg image.Image) ([]byte, error) {
+	mapped := d.mapButtonIn(uint(btnIndex))
+	if bg := d.shadow.getBackground(mapped); bg != nil {
+		rawImg = overBackground(bg, rawImg)
+	}
+	rawImg = d.rotateButtonImage(rawImg)
+	if area, ok := d.deviceType.buttonGeometry[mapped]; ok {
+		rawImg = placeInArea(rawImg, area, d.deviceType.imageSize)
+	}
+	img := resizeAndRotate(rawImg, d.deviceType.imageSize.X, d.deviceType.imageSize.Y, d.deviceType.quirks)
+	return getImageForButton(img, d.deviceType.imageFormat, d.deviceType.quirks)
+}
+
+// WriteEncodedImageToButton writes an image already encoded in the device's format (see GetImageFormat) to the
+// given button as it is, for frames encoded ahead of time; it must already be the right size and orientation.
+// It is kept for Redraw, so it mustn't be changed afterwards.
+func (d *Device) WriteEncodedImageToButton(btnIndex int, encoded []byte) error {
+	if !d.HasImageCapability() {
+		return errors.New("Button doesn't have image capability")
+	}
+	return d.rawWriteToButton(int(d.mapButtonIn(uint(btnIndex))), encoded)
+}
+
+// GetImageFormat returns the format button images are sent to this device in, "JPEG" or "BMP"
+func (d *Device) GetImageFormat() string {
+	return d.deviceType.imageFormat
 }
 
 func (d *Device) rawWriteToButton(btnIndex int, rawImage []byte) error {
+	return d.writeToButton(btnIndex, rawImage, nil)
+}
+
+// writeToButton writes an encoded image to a button, by the device's own button index, recording it in the shadow
+// along with the foreground it was drawn from over the button's background, if it was
+func (d *Device) writeToButton(btnIndex int, rawImage []byte, foreground image.Image) error {
 	// Based on set_key_image from https://github.com/abcminiuser/python-elgato-streamdeck/blob/master/src/StreamDeck/Devices/StreamDeckXL.py#L151
 
-	if Min(Max(btnIndex, 0), int(d.deviceType.numberOfButtons)) != btnIndex {
+	if btnIndex < 0 || btnIndex >= int(d.deviceType.numberOfButtons) {
 		return errors.New(fmt.Sprintf("Invalid key index: %d", btnIndex))
 	}
+	d.shadow.setButton(btnIndex, rawImage, foreground)
+	return d.sendToButton(btnIndex, rawImage)
+}
+
+// sendToButton sends an encoded image to a button, by the device's own button index, without recording it in the
+// shadow
+func (d *Device) sendToButton(btnIndex int, rawImage []byte) error {
+	frame := d.newFrame(btnIndex)
+
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
 
 	pageNumber := 0
 	bytesRemaining := len(rawImage)
 	halfImage := len(rawImage) / 2
 	bytesSent := 0
+	start := time.Now()
+	reportBytes := 0
+	writeErrors := 0
 
 	for bytesRemaining > 0 {
+		// Between pages is the only safe place to stop; a newer frame starts again from the first page
+		if d.isStale(btnIndex, frame) {
+			d.recordAbort(reportBytes, writeErrors)
+			return nil
+		}
 
 		header := d.deviceType.imageHeaderFunc(uint(bytesRemaining), uint(btnIndex), uint(pageNumber))
 		imageReportLength := int(d.deviceType.imagePayloadPerPage)
@@ -470,7 +765,7 @@ func (d *Device) rawWriteToButton(btnIndex int, rawImage []byte) error {
 
 		thisLength := 0
 		if imageReportPayloadLength < bytesRemaining {
-			if d.deviceType.name == "Streamdeck (original)" {
+			if d.hasQuirk(QuirkHalfImagePages) {
 				thisLength = halfImage
 			} else {
 				thisLength = imageReportPayloadLength
@@ -479,30 +774,58 @@ func (d *Device) rawWriteToButton(btnIndex int, rawImage []byte) error {
 			thisLength = bytesRemaining
 		}
 
-		payload := append(header, rawImage[bytesSent:(bytesSent+thisLength)]...)
-		padding := make([]byte, imageReportLength-len(payload))
-
-		thingToSend := append(payload, padding...)
-		d.fd.Write(thingToSend)
+		thingToSend, err := d.fillPage(header, rawImage[bytesSent:(bytesSent+thisLength)])
+		if err != nil {
+			d.recordWrite(reportBytes, writeErrors, time.Since(start))
+			return err
+		}
+		if _, err := d.fd.Write(thingToSend); err != nil {
+			writeErrors++
+		}
+		reportBytes += len(thingToSend)
 
 		bytesRemaining = bytesRemaining - thisLength
 		pageNumber = pageNumber + 1
 		bytesSent = bytesSent + thisLength
 	}
+	d.recordWrite(reportBytes, writeErrors, time.Since(start))
 	return nil
 }
 
+// fillPage puts a header and a chunk of image into the page buffer, zero-padded up to the report length, so that
+// writing images doesn't allocate for every page; it must be called with writeLock held, and the result is only
+// valid until the next call.  Pages which wouldn't fit in an output report are an error.
+func (d *Device) fillPage(header []byte, chunk []byte) ([]byte, error) {
+	length := int(d.deviceType.imagePayloadPerPage)
+	if len(header)+len(chunk) > length {
+		length = len(header) + len(chunk)
+	}
+	if max := int(d.deviceType.outputReportLength); max > 0 && length > max {
+		return nil, fmt.Errorf("Image page of %d bytes is longer than the device's %d byte reports", length, max)
+	}
+	if cap(d.pageBuffer) < length {
+		d.pageBuffer = make([]byte, length)
+	}
+	page := d.pageBuffer[:length]
+	n := copy(page, header)
+	n += copy(page[n:], chunk)
+	for i := n; i < length; i++ {
+		page[i] = 0
+	}
+	return page, nil
+}
+
 // y doesn't work, keep it zero!
 func (d *Device) WriteRawImageToAreaUnscaled(x, y int, rawImg image.Image) error {
 	img := rawImg
-	if d.GetName() == "Streamdeck Neo" { // Rotate Info Display for Streamdeck Neo
+	if d.hasQuirk(QuirkRotateAreaImage) {
 		g := gift.New(gift.Rotate180())
 		newimg := image.NewRGBA(g.Bounds(rawImg.Bounds()))
 		g.Draw(newimg, rawImg)
 		img = newimg
 	}
 
-	imgForButton, err := getImageForButton(img, d.deviceType.imageFormat)
+	imgForButton, err := getImageForButton(img, d.deviceType.imageFormat, d.deviceType.quirks)
 	if err != nil {
 		return err
 	}
@@ -512,9 +835,16 @@ func (d *Device) WriteRawImageToAreaUnscaled(x, y int, rawImg image.Image) error
 
 // y doesn't work, keep it zero!
 func (d *Device) rawWriteToArea(x, y, width, height int, rawImage []byte) error {
+	d.shadow.setArea(image.Rect(x, y, x+width, y+height), rawImage)
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+
 	pageNumber := 0
 	bytesRemaining := len(rawImage)
 	bytesSent := 0
+	start := time.Now()
+	reportBytes := 0
+	writeErrors := 0
 
 	for bytesRemaining > 0 {
 
@@ -528,16 +858,21 @@ func (d *Device) rawWriteToArea(x, y, width, height int, rawImage []byte) error
 			thisLength = bytesRemaining
 		}
 
-		payload := append(header, rawImage[bytesSent:(bytesSent+thisLength)]...)
-		padding := make([]byte, imageReportLength-len(payload))
-
-		thingToSend := append(payload, padding...)
-		d.fd.Write(thingToSend)
+		thingToSend, err := d.fillPage(header, rawImage[bytesSent:(bytesSent+thisLength)])
+		if err != nil {
+			d.recordWrite(reportBytes, writeErrors, time.Since(start))
+			return err
+		}
+		if _, err := d.fd.Write(thingToSend); err != nil {
+			writeErrors++
+		}
+		reportBytes += len(thingToSend)
 
 		bytesRemaining = bytesRemaining - thisLength
 		pageNumber = pageNumber + 1
 		bytesSent = bytesSent + thisLength
 	}
+	d.recordWrite(reportBytes, writeErrors, time.Since(start))
 	return nil
 }
 
@@ -0,0 +1,102 @@
+package streamdeck_test
+
+import (
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+// TestRemoveListener removes listeners while presses are being delivered: one removes itself from its own callback,
+// and others are added and removed from another goroutine, while a listener left in place sees every press
+func TestRemoveListener(t *testing.T) {
+	d, mock, err := streamdecktest.Open(0x6c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	kept := make(chan int, 64)
+	d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, pressed bool) {
+		if err == nil && pressed {
+			kept <- btnIndex
+		}
+	})
+	var lock sync.Mutex
+	calls := 0
+	var removeSelf func()
+	lock.Lock()
+	removeSelf = d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, pressed bool) {
+		lock.Lock()
+		calls++
+		remove := removeSelf
+		lock.Unlock()
+		remove()
+	})
+	lock.Unlock()
+
+	done := make(chan struct{})
+	churned := make(chan struct{})
+	go func() {
+		defer close(churned)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			remove := d.ButtonPress(func(int, *streamdeck.Device, error, bool) {})
+			remove()
+		}
+	}()
+
+	const presses = 10
+	for i := 0; i < presses; i++ {
+		mock.TapButton(i)
+	}
+	for i := 0; i < presses; i++ {
+		select {
+		case btnIndex := <-kept:
+			if btnIndex != i {
+				t.Errorf("Press %d was of button %d", i, btnIndex)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Only %d of %d presses arrived", i, presses)
+		}
+	}
+	close(done)
+	<-churned
+
+	lock.Lock()
+	defer lock.Unlock()
+	if calls != 1 {
+		t.Errorf("The listener removing itself was called %d times", calls)
+	}
+}
+
+// TestWriteInvalidKey writes to the key one past the last, which must be refused before anything is sent to it, or
+// kept in the shadow for Redraw to send again
+func TestWriteInvalidKey(t *testing.T) {
+	d, mock, err := streamdecktest.Open(0x6c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	n := int(d.GetNumberOfButtons())
+	img := image.NewRGBA(image.Rect(0, 0, 96, 96))
+	for _, btnIndex := range []int{-1, n} {
+		if err := d.WriteRawImageToButton(btnIndex, img); err == nil {
+			t.Errorf("Writing to key %d of %d succeeded", btnIndex, n)
+		}
+	}
+	if err := d.Redraw(); err != nil {
+		t.Fatal(err)
+	}
+	if pages := mock.WritesWithPrefix([]byte{0x02, 0x07, byte(n)}); len(pages) != 0 {
+		t.Errorf("%d image pages were sent to key %d", len(pages), n)
+	}
+}
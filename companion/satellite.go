@@ -0,0 +1,275 @@
+// Package companion presents decks to Bitfocus Companion as remote surfaces, using its Satellite API: Companion
+// draws the buttons and sets the brightness, and button presses are sent back to it
+package companion
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// DefaultPort is the port Companion listens on for Satellite connections
+const DefaultPort = 16622
+
+// Satellite is a connection to a Companion server, carrying any number of decks
+type Satellite struct {
+	conn      net.Conn
+	writeLock sync.Mutex
+
+	lock      sync.Mutex
+	devices   map[string]*streamdeck.Device
+	listeners map[string]func() // Removes the button listener of each device
+	added     map[string]chan error
+	done      chan struct{}
+}
+
+// Dial connects to Companion at the given host, or host:port if it isn't listening on DefaultPort
+func Dial(address string) (*Satellite, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, strconv.Itoa(DefaultPort))
+	}
+	conn, err := net.DialTimeout("tcp", address, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "BEGIN") {
+		conn.Close()
+		return nil, errors.New("Companion didn't begin the Satellite session")
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	s := &Satellite{
+		conn:      conn,
+		devices:   make(map[string]*streamdeck.Device),
+		listeners: make(map[string]func()),
+		added:     make(map[string]chan error),
+		done:      make(chan struct{}),
+	}
+	go s.readLoop(r)
+	return s, nil
+}
+
+// AddDevice makes a deck available to Companion as a surface, identified by its serial.  From then on Companion
+// draws its buttons and sets its brightness, and its button presses are passed to Companion.
+func (s *Satellite) AddDevice(d *streamdeck.Device) error {
+	id := d.GetSerial()
+	ch := make(chan error, 1)
+	s.lock.Lock()
+	s.devices[id] = d
+	s.added[id] = ch
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		delete(s.added, id)
+		s.lock.Unlock()
+	}()
+
+	bitmaps := 0
+	if d.HasImageCapability() {
+		bitmaps = d.GetImageSize().X
+	}
+	err := s.send("ADD-DEVICE DEVICEID=%s PRODUCT_NAME=%q KEYS_TOTAL=%d KEYS_PER_ROW=%d BITMAPS=%d COLORS=true TEXT=false",
+		id, d.GetName(), d.GetNumberOfButtons(), d.GetButtonCols(), bitmaps)
+	if err == nil {
+		select {
+		case err = <-ch:
+		case <-s.done:
+			err = errors.New("Connection to Companion lost")
+		case <-time.After(10 * time.Second):
+			err = errors.New("Companion didn't answer adding the device")
+		}
+	}
+	if err != nil {
+		s.lock.Lock()
+		delete(s.devices, id)
+		s.lock.Unlock()
+		return err
+	}
+
+	remove := d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, pressed bool) {
+		if err == nil && s.hasDevice(id) {
+			s.send("KEY-PRESS DEVICEID=%s KEY=%d PRESSED=%t", id, btnIndex, pressed)
+		}
+	})
+	s.lock.Lock()
+	if previous := s.listeners[id]; previous != nil {
+		previous()
+	}
+	s.listeners[id] = remove
+	s.lock.Unlock()
+	return nil
+}
+
+// RemoveDevice stops Companion using a deck as a surface
+func (s *Satellite) RemoveDevice(d *streamdeck.Device) error {
+	id := d.GetSerial()
+	s.lock.Lock()
+	delete(s.devices, id)
+	if remove := s.listeners[id]; remove != nil {
+		remove()
+		delete(s.listeners, id)
+	}
+	s.lock.Unlock()
+	return s.send("REMOVE-DEVICE DEVICEID=%s", id)
+}
+
+// Done is closed when the connection to Companion is lost
+func (s *Satellite) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close disconnects from Companion, which removes all of the surfaces
+func (s *Satellite) Close() error {
+	s.lock.Lock()
+	for id, remove := range s.listeners {
+		remove()
+		delete(s.listeners, id)
+	}
+	s.lock.Unlock()
+	s.send("QUIT")
+	return s.conn.Close()
+}
+
+func (s *Satellite) hasDevice(id string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.devices[id] != nil
+}
+
+func (s *Satellite) send(format string, a ...interface{}) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	_, err := fmt.Fprintf(s.conn, format+"\n", a...)
+	return err
+}
+
+func (s *Satellite) readLoop(r *bufio.Reader) {
+	defer close(s.done)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			s.conn.Close()
+			return
+		}
+		line = strings.TrimSpace(line)
+		command, rest := line, ""
+		if i := strings.Index(line, " "); i >= 0 {
+			command, rest = line[:i], line[i+1:]
+		}
+		switch command {
+		case "PING":
+			s.send("PONG %s", rest)
+		case "ADD-DEVICE":
+			s.deviceAdded(rest)
+		case "KEY-STATE":
+			s.keyState(parseParams(rest))
+		case "KEYS-CLEAR":
+			if d := s.device(parseParams(rest)); d != nil {
+				d.ClearButtons()
+			}
+		case "BRIGHTNESS":
+			params := parseParams(rest)
+			if d := s.device(params); d != nil {
+				if pct, err := strconv.Atoi(params["VALUE"]); err == nil {
+					d.SetBrightness(pct)
+				}
+			}
+		}
+	}
+}
+
+// deviceAdded handles the answer to ADD-DEVICE, which is "OK DEVICEID=..." or "ERROR DEVICEID=... MESSAGE=..."
+func (s *Satellite) deviceAdded(rest string) {
+	params := parseParams(rest)
+	s.lock.Lock()
+	ch := s.added[params["DEVICEID"]]
+	s.lock.Unlock()
+	if ch == nil {
+		return
+	}
+	if strings.HasPrefix(rest, "OK") {
+		ch <- nil
+	} else {
+		ch <- fmt.Errorf("Companion refused the device: %s", params["MESSAGE"])
+	}
+}
+
+func (s *Satellite) device(params map[string]string) *streamdeck.Device {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.devices[params["DEVICEID"]]
+}
+
+// keyState draws a button, from its bitmap if Companion sent one (raw RGB at the size given in ADD-DEVICE), or
+// else its colour
+func (s *Satellite) keyState(params map[string]string) {
+	d := s.device(params)
+	if d == nil || !d.HasImageCapability() {
+		return
+	}
+	btnIndex, err := strconv.Atoi(params["KEY"])
+	if err != nil {
+		return
+	}
+	size := d.GetImageSize().X
+	if bitmap, err := base64.StdEncoding.DecodeString(params["BITMAP"]); err == nil && len(bitmap) == size*size*3 {
+		img := image.NewRGBA(image.Rect(0, 0, size, size))
+		for i := 0; i < size*size; i++ {
+			img.Pix[i*4] = bitmap[i*3]
+			img.Pix[i*4+1] = bitmap[i*3+1]
+			img.Pix[i*4+2] = bitmap[i*3+2]
+			img.Pix[i*4+3] = 255
+		}
+		d.WriteRawImageToButton(btnIndex, img)
+		return
+	}
+	if c, err := streamdeck.ParseColour(params["COLOR"]); err == nil {
+		d.WriteColorToButton(btnIndex, c)
+	}
+}
+
+// parseParams splits the KEY=value parameters of a message; values may be quoted
+func parseParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		s = strings.TrimLeft(s, " ")
+		end := strings.Index(s, " ")
+		if end < 0 {
+			end = len(s)
+		}
+		i := strings.Index(s[:end], "=")
+		if i < 0 {
+			// A bare word, such as the OK in an answer
+			s = s[end:]
+			continue
+		}
+		key := s[:i]
+		s = s[i+1:]
+		var value string
+		if strings.HasPrefix(s, "\"") {
+			if j := strings.Index(s[1:], "\""); j >= 0 {
+				value, s = s[1:j+1], s[j+2:]
+			} else {
+				value, s = s[1:], ""
+			}
+		} else if j := strings.Index(s, " "); j >= 0 {
+			value, s = s[:j], s[j:]
+		} else {
+			value, s = s, ""
+		}
+		params[key] = value
+	}
+	return params
+}
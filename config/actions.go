@@ -0,0 +1,166 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+
+	log "github.com/s00500/env_logger"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/actionhandlers"
+)
+
+// ActionFactory builds an action from its configuration, which is the whole JSON object of the action including
+// its "type"
+type ActionFactory func(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error)
+
+var actionsLock sync.Mutex
+var actions = map[string]ActionFactory{
+	"exec":        execAction,
+	"keys":        keysAction,
+	"page":        pageAction,
+	"folder":      folderAction,
+	"back":        backAction,
+	"print":       printAction,
+	"brightness":  brightnessAction,
+	"screensaver": screensaverAction,
+	"script":      scriptAction,
+}
+
+// RegisterAction makes a new type of action available to configuration files, replacing any of the same name
+func RegisterAction(name string, f ActionFactory) {
+	actionsLock.Lock()
+	defer actionsLock.Unlock()
+	actions[name] = f
+}
+
+// BuildAction builds an action from its configuration, using the factory registered for its "type"
+func BuildAction(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(params, &header); err != nil {
+		return nil, err
+	}
+	actionsLock.Lock()
+	f, ok := actions[header.Type]
+	actionsLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("Unknown action type %q", header.Type)
+	}
+	return f(sd, params)
+}
+
+// execAction runs a command: {"type": "exec", "command": "obs", "args": ["--startrecording"]}
+func execAction(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+	var p struct {
+		Command string   `json:"command"`
+		Args    []string `json:"args"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if p.Command == "" {
+		return nil, errors.New("Exec action has no command")
+	}
+	return actionhandlers.NewCustomAction(func(streamdeck.Button) {
+		// A Cmd can only be run once, so there is a new one for every press; it is waited for so that it doesn't
+		// linger as a zombie once it exits, and so that a failure is logged like one to start it
+		cmd := exec.Command(p.Command, p.Args...)
+		if err := cmd.Start(); err != nil {
+			log.Errorf("Exec action %q: %s", p.Command, err)
+			return
+		}
+		go func() {
+			if err := cmd.Wait(); err != nil {
+				log.Errorf("Exec action %q: %s", p.Command, err)
+			}
+		}()
+	}), nil
+}
+
+// keysAction presses a key combination, see actionhandlers.NewKeyboardAction: {"type": "keys", "combo": "ctrl+s"}
+func keysAction(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+	var p struct {
+		Combo string `json:"combo"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	action, err := actionhandlers.NewKeyboardAction(p.Combo)
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// pageAction switches page: {"type": "page", "page": "lights"}
+func pageAction(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+	page, err := pageParam(params)
+	if err != nil {
+		return nil, err
+	}
+	return actionhandlers.NewPageAction(sd, page), nil
+}
+
+// folderAction opens a page as a folder: {"type": "folder", "page": "lights"}
+func folderAction(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+	page, err := pageParam(params)
+	if err != nil {
+		return nil, err
+	}
+	return actionhandlers.NewFolderAction(sd, page), nil
+}
+
+// backAction leaves the current folder: {"type": "back"}
+func backAction(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+	return actionhandlers.NewBackAction(sd), nil
+}
+
+// brightnessAction steps the brightness up or down: {"type": "brightness", "step": -10}
+func brightnessAction(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+	var p struct {
+		Step int `json:"step"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if p.Step == 0 {
+		return nil, errors.New("Brightness action has no step")
+	}
+	return actionhandlers.NewBrightnessStepAction(sd, p.Step), nil
+}
+
+// screensaverAction toggles the screensaver: {"type": "screensaver"}
+func screensaverAction(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+	return actionhandlers.NewScreensaverAction(sd), nil
+}
+
+// printAction prints some text, for trying out a configuration: {"type": "print", "text": "Hello"}
+func printAction(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+	var p struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	return actionhandlers.NewCustomAction(func(streamdeck.Button) {
+		fmt.Println(p.Text)
+	}), nil
+}
+
+func pageParam(params json.RawMessage) (string, error) {
+	var p struct {
+		Page string `json:"page"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return "", err
+	}
+	if p.Page == "" {
+		return "", errors.New("Action has no page")
+	}
+	return p.Page, nil
+}
@@ -0,0 +1,230 @@
+// Package config builds pages of buttons, encoder bindings and actions from a JSON file, so that simple panels
+// can be set up without writing Go code for every key
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/buttons"
+)
+
+// Config is the whole of a configuration file
+type Config struct {
+	Brightness int          `json:"brightness,omitempty"`
+	StartPage  string       `json:"startPage,omitempty"`
+	Pages      []PageConfig `json:"pages"`
+
+	dir string // Relative image paths are relative to the file they were loaded from
+}
+
+// PageConfig is one page of buttons and encoder bindings
+type PageConfig struct {
+	Name     string          `json:"name"`
+	Buttons  []ButtonConfig  `json:"buttons,omitempty"`
+	Encoders []EncoderConfig `json:"encoders,omitempty"`
+}
+
+// ButtonConfig is a single button; it shows an image if one is given, otherwise text, otherwise a solid colour
+type ButtonConfig struct {
+	Key              int             `json:"key"`
+	Text             string          `json:"text,omitempty"`
+	Image            string          `json:"image,omitempty"`
+	ImageData        string          `json:"imageData,omitempty"` // An image embedded as base64, see SaveLayout
+	TextColour       string          `json:"textColour,omitempty"`
+	BackgroundColour string          `json:"backgroundColour,omitempty"`
+	Action           json.RawMessage `json:"action,omitempty"`
+}
+
+// EncoderConfig binds actions to an encoder being pressed or turned; actions run from an encoder are passed a nil
+// Button
+type EncoderConfig struct {
+	Encoder     int             `json:"encoder"`
+	Press       json.RawMessage `json:"press,omitempty"`
+	RotateLeft  json.RawMessage `json:"rotateLeft,omitempty"`
+	RotateRight json.RawMessage `json:"rotateRight,omitempty"`
+}
+
+// Load reads a configuration file
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	c.dir = filepath.Dir(path)
+	return c, nil
+}
+
+// Parse reads a configuration from a reader; relative image paths are taken as relative to the working directory
+func Parse(r io.Reader) (*Config, error) {
+	var c Config
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	if len(c.Pages) == 0 {
+		return nil, errors.New("Configuration has no pages")
+	}
+	return &c, nil
+}
+
+// appliedPages are the names of the pages each StreamDeck was last given by Apply, so that those no longer
+// configured can be removed
+var appliedLock sync.Mutex
+var appliedPages = make(map[*streamdeck.StreamDeck][]string)
+
+// Apply builds the configured pages and adds them to the StreamDeck, replacing any pages of the same names.  If
+// the active page isn't one of them, it switches to the start page (or the first page, if there isn't one).
+// Pages added by an earlier Apply which aren't in this configuration are removed.
+func (c *Config) Apply(sd *streamdeck.StreamDeck) error {
+	var pages []*streamdeck.Page
+	for _, pc := range c.Pages {
+		p, err := c.buildPage(sd, pc)
+		if err != nil {
+			return fmt.Errorf("Page %q: %s", pc.Name, err)
+		}
+		pages = append(pages, p)
+	}
+
+	appliedLock.Lock()
+	defer appliedLock.Unlock()
+	active := sd.GetPage().GetName()
+	found := false
+	names := make(map[string]bool)
+	for _, p := range pages {
+		sd.AddPage(p)
+		found = found || p.GetName() == active
+		names[p.GetName()] = true
+	}
+	if c.Brightness > 0 {
+		sd.SetBrightness(c.Brightness)
+	}
+	if !found {
+		start := c.StartPage
+		if start == "" {
+			start = c.Pages[0].Name
+		}
+		if err := sd.SetPage(start); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range appliedPages[sd] {
+		if !names[name] {
+			sd.RemovePage(name) // Unless the application has removed it already
+		}
+	}
+	var applied []string
+	for name := range names {
+		applied = append(applied, name)
+	}
+	appliedPages[sd] = applied
+	return nil
+}
+
+func (c *Config) buildPage(sd *streamdeck.StreamDeck, pc PageConfig) (*streamdeck.Page, error) {
+	if pc.Name == "" {
+		return nil, errors.New("Page has no name")
+	}
+	p := streamdeck.NewPage(pc.Name)
+	for _, bc := range pc.Buttons {
+		btn, err := c.buildButton(bc)
+		if err != nil {
+			return nil, fmt.Errorf("Button %d: %s", bc.Key, err)
+		}
+		if len(bc.Action) > 0 {
+			action, err := BuildAction(sd, bc.Action)
+			if err != nil {
+				return nil, fmt.Errorf("Button %d: %s", bc.Key, err)
+			}
+			btn.SetActionHandler(action)
+		}
+		p.AddButton(bc.Key, btn)
+	}
+
+	for _, ec := range pc.Encoders {
+		encIndex := ec.Encoder
+		if len(ec.Press) > 0 {
+			action, err := BuildAction(sd, ec.Press)
+			if err != nil {
+				return nil, fmt.Errorf("Encoder %d: %s", encIndex, err)
+			}
+			p.SetEncoderPressHandler(encIndex, func(pressed bool) {
+				if pressed {
+					action.Pressed(nil)
+				}
+			})
+		}
+		var left, right streamdeck.ButtonActionHandler
+		var err error
+		if len(ec.RotateLeft) > 0 {
+			if left, err = BuildAction(sd, ec.RotateLeft); err != nil {
+				return nil, fmt.Errorf("Encoder %d: %s", encIndex, err)
+			}
+		}
+		if len(ec.RotateRight) > 0 {
+			if right, err = BuildAction(sd, ec.RotateRight); err != nil {
+				return nil, fmt.Errorf("Encoder %d: %s", encIndex, err)
+			}
+		}
+		if left != nil || right != nil {
+			// Turning quickly gives several pulses in one event, and the action is run for each
+			p.SetEncoderRotateHandler(encIndex, func(pulses int) {
+				for ; pulses < 0 && left != nil; pulses++ {
+					left.Pressed(nil)
+				}
+				for ; pulses > 0 && right != nil; pulses-- {
+					right.Pressed(nil)
+				}
+			})
+		}
+	}
+	return p, nil
+}
+
+// actionButton is a Button which can have an action set, as all of those in the buttons package can
+type actionButton interface {
+	streamdeck.Button
+	SetActionHandler(streamdeck.ButtonActionHandler)
+}
+
+func (c *Config) buildButton(bc ButtonConfig) (actionButton, error) {
+	textColour, err := parseColour(bc.TextColour, color.White)
+	if err != nil {
+		return nil, err
+	}
+	backgroundColour, err := parseColour(bc.BackgroundColour, color.Black)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bc.ImageData != "":
+		return imageDataButton(bc.ImageData)
+	case bc.Image != "":
+		return buttons.NewImageFileButton(c.imagePath(bc.Image))
+	case bc.Text != "":
+		return buttons.NewTextButtonWithColours(bc.Text, textColour, backgroundColour), nil
+	default:
+		return buttons.NewColourButton(backgroundColour), nil
+	}
+}
+
+// parseColour reads a colour given as "#rrggbb" (see streamdeck.ParseColour), or returns def if s is empty
+func parseColour(s string, def color.Color) (color.Color, error) {
+	if s == "" {
+		return def, nil
+	}
+	return streamdeck.ParseColour(s)
+}
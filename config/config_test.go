@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/actionhandlers"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+func TestApplyRemovesPages(t *testing.T) {
+	sd, _ := openDeck(t)
+	sd.AddPage(streamdeck.NewPage("own")) // Added by the application rather than a configuration
+
+	for _, tc := range []struct {
+		config  string
+		active  string
+		present []string
+		absent  []string
+	}{
+		{`{"pages": [{"name": "a"}, {"name": "b"}, {"name": "c"}]}`, "a", []string{"a", "b", "c", "own"}, nil},
+		{`{"pages": [{"name": "a"}, {"name": "c"}]}`, "a", []string{"a", "c", "own"}, []string{"b"}},
+		// The active page is switched away from before it is removed
+		{`{"startPage": "d", "pages": [{"name": "c"}, {"name": "d"}]}`, "d", []string{"c", "d", "own"}, []string{"a", "b"}},
+	} {
+		c, err := Parse(strings.NewReader(tc.config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Apply(sd); err != nil {
+			t.Fatalf("%s: %s", tc.config, err)
+		}
+		if page := sd.GetPage().GetName(); page != tc.active {
+			t.Errorf("%s left the deck on page %q, not %q", tc.config, page, tc.active)
+		}
+		for _, name := range tc.present {
+			if err := sd.SetPage(name); err != nil {
+				t.Errorf("After %s, page %q is missing", tc.config, name)
+			}
+		}
+		for _, name := range tc.absent {
+			if err := sd.SetPage(name); err == nil {
+				t.Errorf("After %s, page %q is still there", tc.config, name)
+			}
+		}
+		if err := sd.SetPage(tc.active); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEncoderPulses(t *testing.T) {
+	d, mock, err := streamdecktest.Open(0x84)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := streamdeck.NewWithDevice(d) // Left open, see openDeck
+	turns := make(map[string]int)
+	RegisterAction("turn", func(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+		var p struct {
+			Direction string `json:"direction"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return actionhandlers.NewCustomAction(func(streamdeck.Button) {
+			turns[p.Direction]++
+		}), nil
+	})
+
+	c, err := Parse(strings.NewReader(`{"pages": [{"name": "dial", "encoders": [{"encoder": 1,
+		"rotateLeft": {"type": "turn", "direction": "left"}, "rotateRight": {"type": "turn", "direction": "right"}}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Apply(sd); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		pulses      int
+		left, right int
+	}{
+		{1, 0, 1},
+		{3, 0, 4},
+		{-2, 2, 4},
+		{-1, 3, 4},
+	} {
+		mock.RotateEncoder(1, tc.pulses)
+		if turns["left"] != tc.left || turns["right"] != tc.right {
+			t.Errorf("After turning by %d, the actions had run %d times left and %d right, not %d and %d",
+				tc.pulses, turns["left"], turns["right"], tc.left, tc.right)
+		}
+	}
+}
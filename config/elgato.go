@@ -0,0 +1,335 @@
+package config
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// elgatoManifest is the manifest.json of a profile (or a folder within one) exported from Elgato's app.  Older
+// versions put the keys straight into Actions, newer ones split them up by controller.
+type elgatoManifest struct {
+	Name        string
+	Actions     map[string]elgatoAction
+	Controllers []struct {
+		Type    string
+		Actions map[string]elgatoAction
+	}
+}
+
+// keyActions gives the actions of the keys, by "column,row"
+func (m elgatoManifest) keyActions() map[string]elgatoAction {
+	for _, controller := range m.Controllers {
+		if controller.Type == "Keypad" {
+			return controller.Actions
+		}
+	}
+	return m.Actions
+}
+
+type elgatoAction struct {
+	Name     string
+	UUID     string
+	State    int
+	States   []elgatoState
+	Settings json.RawMessage
+}
+
+type elgatoState struct {
+	Title      string
+	TitleColor string
+	Image      string
+}
+
+// elgatoProfile is one .sdProfile directory within the exported file, which becomes a page
+type elgatoProfile struct {
+	dir      string
+	uuid     string
+	page     string
+	manifest elgatoManifest
+}
+
+// ImportElgatoProfile converts a profile exported from Elgato's Stream Deck app (a .streamDeckProfile file, which
+// is a zip of manifests and images) into a Config, for migrating existing layouts.  The profile becomes the start
+// page, and each of its folders another page; cols is the number of columns of keys on the deck it was made for.
+// Custom images are extracted into imageDir, which is created if need be (an empty imageDir leaves them out).
+//
+// Opening files and websites, hotkeys, folders and going back out of them become the equivalent actions; every
+// other action is left off its button, and described in the list returned, so it can be set up again by hand.
+func ImportElgatoProfile(filename string, cols int, imageDir string) (*Config, []string, error) {
+	if cols <= 0 {
+		return nil, nil, errors.New("Number of columns must be positive")
+	}
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	// Names within the zip always use forward slashes, hence path rather than filepath
+	files := make(map[string]*zip.File)
+	var profiles []*elgatoProfile
+	for _, f := range r.File {
+		name := strings.TrimPrefix(f.Name, "/")
+		files[name] = f
+		if dir := path.Dir(name); strings.HasSuffix(dir, ".sdProfile") && path.Base(name) == "manifest.json" {
+			profiles = append(profiles, &elgatoProfile{dir: dir, uuid: strings.TrimSuffix(path.Base(dir), ".sdProfile")})
+		}
+	}
+	if len(profiles) == 0 {
+		return nil, nil, errors.New("No profile found; is this a .streamDeckProfile file?")
+	}
+	// The profile itself is the outermost, and its folders are nested within it
+	sort.Slice(profiles, func(i, j int) bool {
+		di, dj := strings.Count(profiles[i].dir, "/"), strings.Count(profiles[j].dir, "/")
+		if di != dj {
+			return di < dj
+		}
+		return profiles[i].dir < profiles[j].dir
+	})
+
+	// Folders generally have no name of their own, so they are named after the button which opens them
+	folderTitles := make(map[string]string)
+	for _, p := range profiles {
+		if err := readJSON(files[p.dir+"/manifest.json"], &p.manifest); err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", p.dir, err)
+		}
+		for _, a := range p.manifest.keyActions() {
+			var settings struct{ ProfileUUID string }
+			json.Unmarshal(a.Settings, &settings)
+			if a.UUID == "com.elgato.streamdeck.profile.openchild" && a.State >= 0 && a.State < len(a.States) {
+				folderTitles[strings.ToLower(settings.ProfileUUID)] = strings.Replace(a.States[a.State].Title, "\n", " ", -1)
+			}
+		}
+	}
+
+	used := make(map[string]bool)
+	byUUID := make(map[string]*elgatoProfile)
+	for i, p := range profiles {
+		name := p.manifest.Name
+		if name == "" {
+			name = folderTitles[strings.ToLower(p.uuid)]
+		}
+		if name == "" {
+			name = p.uuid
+			if i == 0 {
+				name = "default"
+			}
+		}
+		base := name
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s %d", base, n)
+		}
+		used[name] = true
+		p.page = name
+		byUUID[strings.ToLower(p.uuid)] = p
+	}
+
+	c := &Config{StartPage: profiles[0].page}
+	var skipped []string
+	for _, p := range profiles {
+		pc := PageConfig{Name: p.page}
+		actions := p.manifest.keyActions()
+		for _, controller := range p.manifest.Controllers {
+			if controller.Type != "Keypad" && len(controller.Actions) > 0 {
+				skipped = append(skipped, fmt.Sprintf("Page %q: %s actions", p.page, controller.Type))
+			}
+		}
+
+		positions := make([]string, 0, len(actions))
+		for pos := range actions {
+			positions = append(positions, pos)
+		}
+		sort.Strings(positions)
+		for _, pos := range positions {
+			var col, row int
+			if _, err := fmt.Sscanf(pos, "%d,%d", &col, &row); err != nil {
+				continue
+			}
+			key := row*cols + col
+			a := actions[pos]
+			bc := ButtonConfig{Key: key}
+
+			var state elgatoState
+			if a.State >= 0 && a.State < len(a.States) {
+				state = a.States[a.State]
+			}
+			bc.Text = state.Title
+			if _, err := parseColour(state.TitleColor, nil); err == nil {
+				bc.TextColour = state.TitleColor
+			}
+			if imageDir != "" {
+				candidates := []string{p.dir + "/" + pos + fmt.Sprintf("/CustomImages/state%d.png", a.State)}
+				if state.Image != "" {
+					candidates = append([]string{p.dir + "/" + state.Image}, candidates...)
+				}
+				for _, candidate := range candidates {
+					if f := files[candidate]; f != nil {
+						name := fmt.Sprintf("%s-%d%s", safeFileName(p.page), key, path.Ext(candidate))
+						if err := extractFile(f, imageDir, name); err != nil {
+							return nil, nil, err
+						}
+						bc.Image = filepath.Join(imageDir, name)
+						break
+					}
+				}
+			}
+
+			action, err := elgatoToAction(a, byUUID)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("Page %q, key %d: %s", p.page, key, err))
+			} else if action != nil {
+				if bc.Action, err = json.Marshal(action); err != nil {
+					return nil, nil, err
+				}
+			}
+			pc.Buttons = append(pc.Buttons, bc)
+		}
+		c.Pages = append(c.Pages, pc)
+	}
+	return c, skipped, nil
+}
+
+// elgatoToAction gives the configuration of the action equivalent to one of Elgato's built-in actions, or an
+// error describing it if there isn't one
+func elgatoToAction(a elgatoAction, profiles map[string]*elgatoProfile) (map[string]interface{}, error) {
+	var settings struct {
+		Path        string
+		ProfileUUID string
+		Hotkeys     []elgatoHotkey
+	}
+	if len(a.Settings) > 0 {
+		json.Unmarshal(a.Settings, &settings)
+	}
+
+	switch a.UUID {
+	case "com.elgato.streamdeck.system.open", "com.elgato.streamdeck.system.website":
+		if settings.Path == "" {
+			return nil, fmt.Errorf("%q has nothing to open", a.Name)
+		}
+		command, args := openCommand(settings.Path)
+		return map[string]interface{}{"type": "exec", "command": command, "args": args}, nil
+	case "com.elgato.streamdeck.system.hotkey":
+		for _, hotkey := range settings.Hotkeys {
+			if combo, ok := hotkey.combo(); ok {
+				return map[string]interface{}{"type": "keys", "combo": combo}, nil
+			}
+		}
+		return nil, fmt.Errorf("%q has a hotkey with no equivalent", a.Name)
+	case "com.elgato.streamdeck.profile.openchild":
+		if p := profiles[strings.ToLower(settings.ProfileUUID)]; p != nil {
+			return map[string]interface{}{"type": "folder", "page": p.page}, nil
+		}
+		return nil, fmt.Errorf("%q opens a folder which isn't in the file", a.Name)
+	case "com.elgato.streamdeck.profile.backtoparent":
+		return map[string]interface{}{"type": "back"}, nil
+	}
+	name := a.Name
+	if name == "" {
+		name = a.UUID
+	}
+	return nil, fmt.Errorf("%q (%s) has no equivalent", name, a.UUID)
+}
+
+// elgatoHotkey is a key combination as Elgato saves it, with a Windows virtual key code
+type elgatoHotkey struct {
+	KeyCmd    bool
+	KeyCtrl   bool
+	KeyOption bool
+	KeyShift  bool
+	VKeyCode  int
+}
+
+var virtualKeys = map[int]string{
+	0x08: "backspace", 0x09: "tab", 0x0d: "enter", 0x1b: "esc", 0x20: "space", 0x21: "pageup", 0x22: "pagedown",
+	0x23: "end", 0x24: "home", 0x25: "left", 0x26: "up", 0x27: "right", 0x28: "down", 0x2e: "delete",
+}
+
+// combo gives the hotkey as a combination for actionhandlers.NewKeyboardAction, if it is one that can be sent
+func (h elgatoHotkey) combo() (string, bool) {
+	var key string
+	switch code := h.VKeyCode; {
+	case code >= '0' && code <= '9', code >= 'A' && code <= 'Z':
+		key = strings.ToLower(string(rune(code)))
+	case code >= 0x70 && code <= 0x7b:
+		key = fmt.Sprintf("f%d", code-0x6f)
+	default:
+		var ok bool
+		if key, ok = virtualKeys[code]; !ok {
+			return "", false
+		}
+	}
+	var parts []string
+	if h.KeyCtrl {
+		parts = append(parts, "ctrl")
+	}
+	if h.KeyShift {
+		parts = append(parts, "shift")
+	}
+	if h.KeyOption {
+		parts = append(parts, "alt")
+	}
+	if h.KeyCmd {
+		parts = append(parts, "super")
+	}
+	return strings.Join(append(parts, key), "+"), true
+}
+
+// openCommand gives the command which opens a file or website with whatever the desktop uses for it
+func openCommand(target string) (string, []string) {
+	switch runtime.GOOS {
+	case "windows":
+		return "cmd", []string{"/c", "start", "", target}
+	case "darwin":
+		return "open", []string{target}
+	default:
+		return "xdg-open", []string{target}
+	}
+}
+
+func readJSON(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+func extractFile(f *zip.File, dir, name string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	out, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// safeFileName reduces a page name to something usable in a file name
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/SKAARHOJ/go-streamdeck/buttons"
+)
+
+// A layout is a configuration saved for backing up or sharing a deck's setup, and is in the same format as a
+// configuration file.  Images are either referenced by path, relative to the layout, or embedded in "imageData"
+// as base64, so that the layout stands alone.
+
+// LoadLayout reads a layout saved by SaveLayout, or any other configuration file
+func LoadLayout(path string) (*Config, error) {
+	return Load(path)
+}
+
+// SaveLayout writes the configuration to a file.  If embedImages is set, the images are read and embedded in it;
+// otherwise image paths are rewritten to be relative to the new file where they can be.
+func (c *Config) SaveLayout(path string, embedImages bool) error {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	layout := *c
+	layout.Pages = make([]PageConfig, len(c.Pages))
+	for i, pc := range c.Pages {
+		pc.Buttons = append([]ButtonConfig(nil), pc.Buttons...)
+		for j := range pc.Buttons {
+			bc := &pc.Buttons[j]
+			if bc.Image == "" {
+				continue
+			}
+			imagePath, err := filepath.Abs(c.imagePath(bc.Image))
+			if err != nil {
+				return err
+			}
+			if embedImages {
+				data, err := ioutil.ReadFile(imagePath)
+				if err != nil {
+					return err
+				}
+				bc.Image = ""
+				bc.ImageData = base64.StdEncoding.EncodeToString(data)
+			} else if rel, err := filepath.Rel(dir, imagePath); err == nil {
+				bc.Image = filepath.ToSlash(rel)
+			} else {
+				bc.Image = imagePath
+			}
+		}
+		layout.Pages[i] = pc
+	}
+
+	data, err := json.MarshalIndent(&layout, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// imagePath resolves an image path given in the configuration, which may be relative to the file it came from
+func (c *Config) imagePath(path string) string {
+	if !filepath.IsAbs(path) && c.dir != "" {
+		return filepath.Join(c.dir, path)
+	}
+	return path
+}
+
+// imageDataButton builds a button from an image embedded as base64
+func imageDataButton(imageData string) (actionButton, error) {
+	data, err := base64.StdEncoding.DecodeString(imageData)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return buttons.NewImageButton(img), nil
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"reflect"
+	"sync"
+	"time"
+
+	log "github.com/s00500/env_logger"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/internal/lua"
+)
+
+// ScriptEngine compiles scripts in some language for "script" actions.  The "lua" engine built in is a small
+// subset of Lua, without tables or functions of its own, so as not to pull in a dependency; an application
+// wanting the whole language wraps an interpreter such as gopher-lua in a ScriptEngine, exposing the ScriptHost
+// methods to scripts, and registers it with RegisterScriptEngine("lua", engine) in its place.
+type ScriptEngine interface {
+	Compile(source string) (Script, error)
+}
+
+// Script is a compiled script, run every time its button is pressed
+type Script interface {
+	Run(host *ScriptHost) error
+}
+
+// ScriptHost is what a script can do to the deck, for a ScriptEngine to expose to scripts.  Scripts keep state
+// between runs in the StreamDeck's State, where buttons can also show it.
+type ScriptHost struct {
+	SD     *streamdeck.StreamDeck
+	Button streamdeck.Button
+}
+
+// SetPage switches to the named page
+func (h *ScriptHost) SetPage(name string) error {
+	return h.SD.SetPage(name)
+}
+
+// OpenFolder opens the named page as a folder
+func (h *ScriptHost) OpenFolder(name string) error {
+	return h.SD.OpenFolder(name)
+}
+
+// Back leaves the current folder
+func (h *ScriptHost) Back() error {
+	return h.SD.Back()
+}
+
+// SetBrightness sets the brightness, as a percentage
+func (h *ScriptHost) SetBrightness(pct int) {
+	h.SD.SetBrightness(pct)
+}
+
+// Get returns a value from the StreamDeck's State, or nil if it isn't set
+func (h *ScriptHost) Get(key string) interface{} {
+	value, _ := h.SD.GetState().Get(key)
+	return value
+}
+
+// Set sets a value in the StreamDeck's State
+func (h *ScriptHost) Set(key string, value interface{}) {
+	h.SD.GetState().Set(key, value)
+}
+
+// Exec runs a command and waits for it, returning its output
+func (h *ScriptHost) Exec(command string, args ...string) (string, error) {
+	out, err := exec.Command(command, args...).Output()
+	return string(out), err
+}
+
+// Sleep waits for a number of milliseconds
+func (h *ScriptHost) Sleep(ms int) {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+}
+
+var scriptEnginesLock sync.Mutex
+var scriptEngines = map[string]ScriptEngine{
+	"lua": luaEngine{},
+}
+
+// RegisterScriptEngine makes a scripting language available to "script" actions, replacing any of the same name
+func RegisterScriptEngine(language string, engine ScriptEngine) {
+	scriptEnginesLock.Lock()
+	defer scriptEnginesLock.Unlock()
+	scriptEngines[language] = engine
+}
+
+// scriptAction runs a script, given inline or in a file:
+// {"type": "script", "language": "lua", "source": "..."} or {"type": "script", "language": "lua", "file": "x.lua"}
+// Errors from the script are printed, as there is nowhere else for them to go.
+func scriptAction(sd *streamdeck.StreamDeck, params json.RawMessage) (streamdeck.ButtonActionHandler, error) {
+	var p struct {
+		Language string `json:"language"`
+		Source   string `json:"source"`
+		File     string `json:"file"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	scriptEnginesLock.Lock()
+	engine, ok := scriptEngines[p.Language]
+	scriptEnginesLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("No script engine registered for %q", p.Language)
+	}
+	source := p.Source
+	if p.File != "" {
+		data, err := ioutil.ReadFile(p.File)
+		if err != nil {
+			return nil, err
+		}
+		source = string(data)
+	}
+	if source == "" {
+		return nil, errors.New("Script action has no source")
+	}
+	script, err := engine.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	return &scriptHandler{sd: sd, script: script}, nil
+}
+
+// scriptHandler runs a script on its own goroutine, so that slow scripts don't hold up the deck
+type scriptHandler struct {
+	sd     *streamdeck.StreamDeck
+	script Script
+}
+
+func (h *scriptHandler) Pressed(btn streamdeck.Button) {
+	go func() {
+		if err := h.script.Run(&ScriptHost{SD: h.sd, Button: btn}); err != nil {
+			log.Errorf("Script action: %s", err)
+		}
+	}()
+}
+
+// luaStepLimit is how many statements and loop iterations a Lua script may run, so that one stuck in a loop is
+// stopped rather than holding its goroutine forever
+const luaStepLimit = 1000000
+
+// luaEngine runs scripts with the interpreter in internal/lua, stopping them after luaStepLimit steps.  The
+// ScriptHost methods are global functions: setPage(name), openFolder(name), back(), setBrightness(pct), get(key),
+// set(key, value), exec(command, ...) and sleep(ms); button is the index of the button pressed.  For example:
+//
+//	local n = (get("count") or 0) + 1
+//	set("count", n)
+//	if n % 2 == 0 then setPage("even") else setPage("odd") end
+type luaEngine struct{}
+
+func (luaEngine) Compile(source string) (Script, error) {
+	chunk, err := lua.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	return luaScript{chunk}, nil
+}
+
+type luaScript struct {
+	chunk *lua.Chunk
+}
+
+func (s luaScript) Run(host *ScriptHost) error {
+	globals := map[string]lua.Value{
+		"setPage":    stringFunction(host.SetPage),
+		"openFolder": stringFunction(host.OpenFolder),
+		"back": lua.Function(func(args ...lua.Value) (lua.Value, error) {
+			return nil, host.Back()
+		}),
+		"setBrightness": lua.Function(func(args ...lua.Value) (lua.Value, error) {
+			pct, err := lua.NumberArg(args, 0)
+			if err == nil {
+				host.SetBrightness(int(pct))
+			}
+			return nil, err
+		}),
+		"get": lua.Function(func(args ...lua.Value) (lua.Value, error) {
+			key, err := lua.StringArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			return scriptValue(host.Get(key)), nil
+		}),
+		"set": lua.Function(func(args ...lua.Value) (lua.Value, error) {
+			key, err := lua.StringArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			var value lua.Value
+			if len(args) > 1 {
+				value = args[1]
+			}
+			host.Set(key, value)
+			return nil, nil
+		}),
+		"exec": lua.Function(func(args ...lua.Value) (lua.Value, error) {
+			command := make([]string, len(args))
+			for i := range args {
+				var err error
+				if command[i], err = lua.StringArg(args, i); err != nil {
+					return nil, err
+				}
+			}
+			if len(command) == 0 {
+				return nil, errors.New("exec needs a command")
+			}
+			return host.Exec(command[0], command[1:]...)
+		}),
+		"sleep": lua.Function(func(args ...lua.Value) (lua.Value, error) {
+			ms, err := lua.NumberArg(args, 0)
+			if err == nil {
+				host.Sleep(int(ms))
+			}
+			return nil, err
+		}),
+	}
+	if host.Button != nil {
+		globals["button"] = float64(host.Button.GetButtonIndex())
+	}
+	return s.chunk.Run(globals, luaStepLimit)
+}
+
+// stringFunction makes a ScriptHost method taking a string into a function for scripts
+func stringFunction(f func(string) error) lua.Function {
+	return func(args ...lua.Value) (lua.Value, error) {
+		s, err := lua.StringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return nil, f(s)
+	}
+}
+
+// scriptValue converts a value from the State for a script; numbers of every type become float64, and anything
+// else scripts can't hold becomes a string
+func scriptValue(v interface{}) lua.Value {
+	switch v := v.(type) {
+	case nil, bool, string, float64:
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/buttons"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+	"github.com/SKAARHOJ/go-streamdeck/internal/lua"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+// testEngine records what it compiles, and gives scripts passing each ScriptHost they are run with to ran
+type testEngine struct {
+	compiled []string
+	ran      chan *ScriptHost
+}
+
+func (e *testEngine) Compile(source string) (Script, error) {
+	if source == "bad" {
+		return nil, errors.New("Syntax error")
+	}
+	e.compiled = append(e.compiled, source)
+	return testScript{e.ran}, nil
+}
+
+type testScript struct {
+	ran chan *ScriptHost
+}
+
+func (s testScript) Run(host *ScriptHost) error {
+	s.ran <- host
+	return nil
+}
+
+func TestScriptAction(t *testing.T) {
+	engine := &testEngine{ran: make(chan *ScriptHost, 1)}
+	RegisterScriptEngine("test", engine)
+	dir, err := ioutil.TempDir("", "script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "press.test")
+	if err := ioutil.WriteFile(file, []byte("from file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, params := range []string{
+		`{"type": "script", "language": "test", "source": "inline"}`,
+		`{"type": "script", "language": "test", "file": "` + filepath.ToSlash(file) + `"}`,
+	} {
+		if _, err := BuildAction(nil, json.RawMessage(params)); err != nil {
+			t.Fatalf("%s: %s", params, err)
+		}
+	}
+	if len(engine.compiled) != 2 || engine.compiled[0] != "inline" || engine.compiled[1] != "from file" {
+		t.Errorf("Compiled %q", engine.compiled)
+	}
+
+	for _, params := range []string{
+		`{"type": "script", "language": "cobol", "source": "inline"}`,
+		`{"type": "script", "language": "test"}`,
+		`{"type": "script", "language": "test", "source": "bad"}`,
+		`{"type": "script", "language": "test", "file": "` + filepath.ToSlash(filepath.Join(dir, "missing")) + `"}`,
+	} {
+		if _, err := BuildAction(nil, json.RawMessage(params)); err == nil {
+			t.Errorf("%s was built", params)
+		}
+	}
+}
+
+// openDeck opens a StreamDeck on a mock XL, with a button on it.  It is left open, as a StreamDeck panics when
+// reading its device fails.
+func openDeck(t *testing.T) (*streamdeck.StreamDeck, streamdeck.Button) {
+	d, _, err := streamdecktest.Open(0x6c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := streamdeck.NewWithDevice(d)
+	btn := buttons.NewColourButton(color.White)
+	sd.AddButton(5, btn)
+	return sd, btn
+}
+
+func TestScriptHandler(t *testing.T) {
+	sd, btn := openDeck(t)
+	engine := &testEngine{ran: make(chan *ScriptHost, 1)}
+	RegisterScriptEngine("test", engine)
+	handler, err := BuildAction(sd, json.RawMessage(`{"type": "script", "language": "test", "source": "x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.Pressed(btn)
+	select {
+	case host := <-engine.ran:
+		if host.SD != sd || host.Button != btn {
+			t.Errorf("The script was run for %v and %v", host.SD, host.Button)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The script didn't run")
+	}
+}
+
+func TestLuaScript(t *testing.T) {
+	sd, btn := openDeck(t)
+	sd.AddPage(streamdeck.NewPage("odd"))
+	sd.AddPage(streamdeck.NewPage("even"))
+	counted := make(chan interface{}, 2)
+	sd.GetState().Watch("count", func(value interface{}) {
+		counted <- value
+	})
+
+	handler, err := BuildAction(sd, json.RawMessage(`{"type": "script", "language": "lua", "source":
+		"local n = (get('count') or 0) + 1\nset('count', n)\nset('button', button)\nif n % 2 == 0 then setPage('even') else setPage('odd') end"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []struct {
+		count float64
+		page  string
+	}{{1, "odd"}, {2, "even"}} {
+		handler.Pressed(btn)
+		select {
+		case got := <-counted:
+			if got != want.count {
+				t.Errorf("Count is %v, not %v", got, want.count)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("The script didn't set the count")
+		}
+		// The page is set after the count
+		for deadline := time.Now().Add(time.Second); sd.GetPage().GetName() != want.page && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if page := sd.GetPage().GetName(); page != want.page {
+			t.Errorf("After %v presses, the script left the deck on page %q", want.count, page)
+		}
+	}
+	if value, _ := sd.GetState().Get("button"); value != 5.0 {
+		t.Errorf("The script saw button %v", value)
+	}
+
+	if _, err := BuildAction(sd, json.RawMessage(`{"type": "script", "language": "lua", "source": "if then"}`)); err == nil {
+		t.Error("A script which doesn't compile was built")
+	}
+
+	forever, err := luaEngine{}.Compile("while true do end")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := forever.Run(&ScriptHost{SD: sd, Button: btn}); err != lua.ErrTooLong {
+		t.Errorf("A script looping forever gave %v", err)
+	}
+}
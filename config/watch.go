@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// Watch loads and applies a configuration file, then checks it for changes every interval, re-applying it
+// whenever it is modified.  Errors while reloading are passed to onError (if not nil) and the previous
+// configuration is kept.  Call the returned function to stop watching.
+func Watch(path string, sd *streamdeck.StreamDeck, interval time.Duration, onError func(error)) (func(), error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Apply(sd); err != nil {
+		return nil, err
+	}
+	lastMod := modTime(path)
+
+	stop := make(chan struct{})
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+			mod := modTime(path)
+			if mod.Equal(lastMod) {
+				continue
+			}
+			lastMod = mod
+			c, err := Load(path)
+			if err == nil {
+				err = c.Apply(sd)
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+	return func() { close(stop) }, nil
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
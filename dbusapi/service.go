@@ -0,0 +1,264 @@
+// Package dbusapi offers the devices in a Manager on the D-Bus session bus, for desktop tools and scripts on
+// Linux which would rather not link Go code.  The service takes the name com.skaarhoj.StreamDeck, and its object
+// /com/skaarhoj/StreamDeck has the interface com.skaarhoj.StreamDeck, where devices are given by serial number:
+//
+//	ListDevices() -> (as serials)
+//	SetImage(s serial, u key, ay image)      A PNG, JPEG or GIF
+//	SetColour(s serial, u key, s colour)     A colour such as "#ff8000"
+//	SetText(s serial, u key, s text)
+//	SetBrightness(s serial, u percentage)
+//	Clear(s serial)
+//
+//	signal ButtonPressed(s serial, u key, b pressed)
+//	signal EncoderPressed(s serial, u encoder, b pressed)
+//	signal EncoderRotated(s serial, u encoder, i pulses)
+//	signal Touched(s serial, u x, u y, b hold)
+//
+// For example:
+//
+//	busctl --user call com.skaarhoj.StreamDeck /com/skaarhoj/StreamDeck com.skaarhoj.StreamDeck SetColour sus AL12H1A00001 0 '#ff0000'
+//	dbus-monitor "type='signal',interface='com.skaarhoj.StreamDeck'"
+package dbusapi
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"  // Decoders for button images
+	_ "image/jpeg" // ...
+	_ "image/png"  // ...
+	"strings"
+	"sync"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/internal/dbus"
+)
+
+// Names the service is offered under
+const (
+	BusName    = "com.skaarhoj.StreamDeck"
+	ObjectPath = "/com/skaarhoj/StreamDeck"
+	Interface  = "com.skaarhoj.StreamDeck"
+)
+
+const introspection = `<!DOCTYPE node PUBLIC "-//freedesktop//DTD D-BUS Object Introspection 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/introspect.dtd">
+<node>
+ <interface name="com.skaarhoj.StreamDeck">
+  <method name="ListDevices"><arg name="serials" type="as" direction="out"/></method>
+  <method name="SetImage"><arg name="serial" type="s"/><arg name="key" type="u"/><arg name="image" type="ay"/></method>
+  <method name="SetColour"><arg name="serial" type="s"/><arg name="key" type="u"/><arg name="colour" type="s"/></method>
+  <method name="SetText"><arg name="serial" type="s"/><arg name="key" type="u"/><arg name="text" type="s"/></method>
+  <method name="SetBrightness"><arg name="serial" type="s"/><arg name="percentage" type="u"/></method>
+  <method name="Clear"><arg name="serial" type="s"/></method>
+  <signal name="ButtonPressed"><arg name="serial" type="s"/><arg name="key" type="u"/><arg name="pressed" type="b"/></signal>
+  <signal name="EncoderPressed"><arg name="serial" type="s"/><arg name="encoder" type="u"/><arg name="pressed" type="b"/></signal>
+  <signal name="EncoderRotated"><arg name="serial" type="s"/><arg name="encoder" type="u"/><arg name="pulses" type="i"/></signal>
+  <signal name="Touched"><arg name="serial" type="s"/><arg name="x" type="u"/><arg name="y" type="u"/><arg name="hold" type="b"/></signal>
+ </interface>
+ <interface name="org.freedesktop.DBus.Introspectable">
+  <method name="Introspect"><arg name="xml" type="s" direction="out"/></method>
+ </interface>
+ <interface name="org.freedesktop.DBus.Peer">
+  <method name="Ping"/>
+ </interface>
+</node>
+`
+
+// Service offers the devices in a Manager on the session bus
+type Service struct {
+	manager *streamdeck.Manager
+	conn    *dbus.Conn
+
+	lock      sync.Mutex
+	listening map[*streamdeck.Device][]func() // Removes each device's listeners
+}
+
+// NewService creates a Service for the devices in a Manager; it does nothing until it is connected
+func NewService(m *streamdeck.Manager) *Service {
+	return &Service{manager: m, listening: make(map[*streamdeck.Device][]func())}
+}
+
+// Connect connects to the session bus and takes the service's name, then answers calls until Close is called or
+// the connection is lost (see Done).  Signals are sent for the devices open in the Manager, and for any opened
+// later once something has called a method.
+func (s *Service) Connect() error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+	conn.SetHandler(s.handle)
+	if err := conn.RequestName(BusName); err != nil {
+		conn.Close()
+		return err
+	}
+	s.lock.Lock()
+	s.conn = conn
+	s.lock.Unlock()
+	s.listen()
+	return nil
+}
+
+// Done is closed when the connection to the bus is lost; Connect must have succeeded first
+func (s *Service) Done() <-chan struct{} {
+	return s.conn.Done()
+}
+
+// Close disconnects from the bus
+func (s *Service) Close() error {
+	s.lock.Lock()
+	for d, listeners := range s.listening {
+		for _, remove := range listeners {
+			remove()
+		}
+		delete(s.listening, d)
+	}
+	s.lock.Unlock()
+	return s.conn.Close()
+}
+
+// listen adds listeners to the devices which don't have them yet, to send their events as signals, and removes
+// those of devices no longer in the Manager
+func (s *Service) listen() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	open := make(map[*streamdeck.Device]bool)
+	for _, d := range s.manager.GetDevices() {
+		open[d] = true
+		if s.listening[d] != nil {
+			continue
+		}
+		serial := d.GetSerial()
+		s.listening[d] = []func(){
+			d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, pressed bool) {
+				if err == nil {
+					s.emit("ButtonPressed", "sub", serial, uint32(btnIndex), pressed)
+				}
+			}),
+			d.EncoderPress(func(encIndex int, d *streamdeck.Device, pressed bool) {
+				s.emit("EncoderPressed", "sub", serial, uint32(encIndex), pressed)
+			}),
+			d.EncoderRotate(func(encIndex int, d *streamdeck.Device, pulses int) {
+				s.emit("EncoderRotated", "sui", serial, uint32(encIndex), int32(pulses))
+			}),
+			d.TouchPush(func(d *streamdeck.Device, x, y uint16, hold bool) {
+				s.emit("Touched", "suub", serial, uint32(x), uint32(y), hold)
+			}),
+		}
+	}
+	for d, listeners := range s.listening {
+		if !open[d] {
+			for _, remove := range listeners {
+				remove()
+			}
+			delete(s.listening, d)
+		}
+	}
+}
+
+func (s *Service) emit(member, signature string, args ...interface{}) {
+	s.lock.Lock()
+	conn := s.conn
+	s.lock.Unlock()
+	if conn != nil {
+		conn.Emit(ObjectPath, Interface, member, signature, args...)
+	}
+}
+
+func (s *Service) handle(call *dbus.Message) {
+	switch call.Interface {
+	case "org.freedesktop.DBus.Introspectable":
+		if call.Member == "Introspect" {
+			s.conn.Reply(call, "s", introspection)
+			return
+		}
+	case "org.freedesktop.DBus.Peer":
+		if call.Member == "Ping" {
+			s.conn.Reply(call, "")
+			return
+		}
+	case Interface, "":
+		if call.Path != ObjectPath {
+			s.conn.ReplyError(call, "org.freedesktop.DBus.Error.UnknownObject", "No such object "+call.Path)
+			return
+		}
+		s.listen()
+		if err := s.call(call); err != nil {
+			s.conn.ReplyError(call, "org.freedesktop.DBus.Error.InvalidArgs", err.Error())
+		}
+		return
+	}
+	s.conn.ReplyError(call, "org.freedesktop.DBus.Error.UnknownMethod", "No such method "+call.Member)
+}
+
+// call runs one of the service's own methods, answering it unless there is an error to return
+func (s *Service) call(call *dbus.Message) error {
+	if call.Member == "ListDevices" {
+		serials := []string{}
+		for _, d := range s.manager.GetDevices() {
+			serials = append(serials, d.GetSerial())
+		}
+		return s.conn.Reply(call, "as", serials)
+	}
+
+	signatures := map[string]string{
+		"SetImage":      "suay",
+		"SetColour":     "sus",
+		"SetText":       "sus",
+		"SetBrightness": "su",
+		"Clear":         "s",
+	}
+	signature, ok := signatures[call.Member]
+	if !ok {
+		return fmt.Errorf("No such method %s", call.Member)
+	}
+	if call.Signature != signature {
+		return fmt.Errorf("%s takes (%s), not (%s)", call.Member, signature, call.Signature)
+	}
+	d := s.manager.GetDevice(call.Body[0].(string))
+	if d == nil {
+		return fmt.Errorf("No such device %q", call.Body[0])
+	}
+
+	var err error
+	switch call.Member {
+	case "SetBrightness":
+		pct := call.Body[1].(uint32)
+		if pct > 100 {
+			return fmt.Errorf("Brightness must be a percentage")
+		}
+		d.SetBrightness(int(pct))
+	case "Clear":
+		d.ClearButtons()
+	default:
+		btnIndex := int(call.Body[1].(uint32))
+		if btnIndex >= int(d.GetNumberOfButtons()) {
+			return fmt.Errorf("No such key %d", btnIndex)
+		}
+		err = writeKey(d, btnIndex, call.Member, call.Body[2])
+	}
+	if err != nil {
+		return err
+	}
+	return s.conn.Reply(call, "")
+}
+
+func writeKey(d *streamdeck.Device, btnIndex int, method string, arg interface{}) error {
+	switch method {
+	case "SetImage":
+		img, _, err := image.Decode(bytes.NewReader(arg.([]byte)))
+		if err != nil {
+			return err
+		}
+		return d.WriteRawImageToButton(btnIndex, img)
+	case "SetColour":
+		c, err := streamdeck.ParseColour(strings.TrimSpace(arg.(string)))
+		if err != nil {
+			return err
+		}
+		return d.WriteColorToButton(btnIndex, c)
+	}
+	d.WriteTextToButton(btnIndex, arg.(string), color.White, color.Black)
+	return nil
+}
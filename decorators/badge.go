@@ -0,0 +1,54 @@
+package decorators
+
+import (
+	"image"
+	"image/color"
+)
+
+// Corner is the corner of a button where a Badge is drawn
+type Corner int
+
+const (
+	TopRight Corner = iota
+	TopLeft
+	BottomRight
+	BottomLeft
+)
+
+// Badge is a small notification dot in a corner of the button
+type Badge struct {
+	colour color.Color
+	corner Corner
+}
+
+// NewBadge creates a Badge in the top right corner
+func NewBadge(colour color.Color) *Badge {
+	return NewBadgeInCorner(colour, TopRight)
+}
+
+// NewBadgeInCorner creates a Badge in the given corner
+func NewBadgeInCorner(colour color.Color, corner Corner) *Badge {
+	b := &Badge{colour: colour, corner: corner}
+	return b
+}
+
+func (b *Badge) Apply(img image.Image, size int) image.Image {
+	newimg := toRGBA(img)
+	radius := size / 10
+	margin := size / 16
+	cx, cy := size-margin-radius, margin+radius
+	if b.corner == TopLeft || b.corner == BottomLeft {
+		cx = margin + radius
+	}
+	if b.corner == BottomRight || b.corner == BottomLeft {
+		cy = size - margin - radius
+	}
+	for x := cx - radius; x <= cx+radius; x++ {
+		for y := cy - radius; y <= cy+radius; y++ {
+			if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= radius*radius {
+				newimg.Set(x, y, b.colour)
+			}
+		}
+	}
+	return newimg
+}
@@ -0,0 +1,53 @@
+package decorators_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/SKAARHOJ/go-streamdeck/decorators"
+)
+
+var red = color.RGBA{0xff, 0, 0, 0xff}
+
+// grey is a button face of one colour, which isn't an *image.RGBA so that the decorators must convert it
+func grey(size int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Gray{0x80}), image.Point{}, draw.Src)
+	return img
+}
+
+func TestBadge(t *testing.T) {
+	const size = 96 // A radius of 9, 6 in from the edges
+	for _, tc := range []struct {
+		corner decorators.Corner
+		centre image.Point
+	}{
+		{decorators.TopRight, image.Pt(81, 15)},
+		{decorators.TopLeft, image.Pt(15, 15)},
+		{decorators.BottomRight, image.Pt(81, 81)},
+		{decorators.BottomLeft, image.Pt(15, 81)},
+	} {
+		img := decorators.NewBadgeInCorner(red, tc.corner).Apply(grey(size), size)
+		for _, p := range []struct {
+			at    image.Point
+			badge bool
+		}{
+			{tc.centre, true},
+			{tc.centre.Add(image.Pt(9, 0)), true},
+			{tc.centre.Add(image.Pt(0, -9)), true},
+			{tc.centre.Add(image.Pt(7, 7)), false}, // Outside the circle, inside its square
+			{tc.centre.Add(image.Pt(10, 0)), false},
+			{image.Pt(size/2, size/2), false},
+		} {
+			if got := color.RGBAModel.Convert(img.At(p.at.X, p.at.Y)) == red; got != p.badge {
+				t.Errorf("Corner %d: the badge covers %v: %t", tc.corner, p.at, got)
+			}
+		}
+	}
+
+	if img := decorators.NewBadge(red).Apply(grey(96), 96); color.RGBAModel.Convert(img.At(81, 15)) != red {
+		t.Error("NewBadge didn't put the badge in the top right")
+	}
+}
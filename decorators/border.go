@@ -16,7 +16,7 @@ func NewBorder(width int, colour color.Color) *Border {
 }
 
 func (b *Border) Apply(img image.Image, size int) image.Image {
-	newimg := img.(*image.RGBA)
+	newimg := toRGBA(img)
 	// TODO base the 96 on the image bounds
 	for i := 0; i < b.width; i++ {
 		rect(i, i, size-i, size-i, newimg, b.colour)
@@ -0,0 +1,41 @@
+package decorators
+
+import (
+	"image"
+	"image/color"
+)
+
+// Dim darkens the whole button, for example to show that it is disabled
+type Dim struct {
+	amount float64
+}
+
+// NewDim creates a Dim decorator; amount runs from 0 (unchanged) to 1 (black)
+func NewDim(amount float64) *Dim {
+	if amount < 0 {
+		amount = 0
+	}
+	if amount > 1 {
+		amount = 1
+	}
+	d := &Dim{amount: amount}
+	return d
+}
+
+func (d *Dim) Apply(img image.Image, size int) image.Image {
+	newimg := toRGBA(img)
+	keep := 1 - d.amount
+	bounds := newimg.Bounds()
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			c := newimg.RGBAAt(x, y)
+			newimg.SetRGBA(x, y, color.RGBA{
+				uint8(float64(c.R) * keep),
+				uint8(float64(c.G) * keep),
+				uint8(float64(c.B) * keep),
+				c.A,
+			})
+		}
+	}
+	return newimg
+}
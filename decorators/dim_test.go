@@ -0,0 +1,34 @@
+package decorators_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/SKAARHOJ/go-streamdeck/decorators"
+)
+
+func TestDim(t *testing.T) {
+	for _, tc := range []struct {
+		amount float64
+		want   uint8
+	}{
+		{0, 0x80},
+		{0.25, 0x60},
+		{0.5, 0x40},
+		{1, 0},
+		{-1, 0x80}, // Limited to 0...
+		{2, 0},     // ...and 1
+	} {
+		img := decorators.NewDim(tc.amount).Apply(grey(72), 72)
+		if img.Bounds() != image.Rect(0, 0, 72, 72) {
+			t.Errorf("Dimming by %v gave a %v image", tc.amount, img.Bounds())
+		}
+		want := color.RGBA{tc.want, tc.want, tc.want, 0xff}
+		for _, p := range []image.Point{{0, 0}, {36, 36}, {71, 71}} {
+			if got := color.RGBAModel.Convert(img.At(p.X, p.Y)); got != want {
+				t.Errorf("Dimming by %v left %v at %v, not %v", tc.amount, got, p, want)
+			}
+		}
+	}
+}
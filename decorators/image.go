@@ -0,0 +1,16 @@
+package decorators
+
+import (
+	"image"
+	"image/draw"
+)
+
+// toRGBA returns the image as an *image.RGBA which can be drawn on, converting it if needed
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,25 @@
+package decorators
+
+import (
+	"image"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// Stack applies several decorators to the same button, in order, for example a Border and a Badge
+type Stack struct {
+	decorators []streamdeck.ButtonDecorator
+}
+
+// NewStack creates a Stack of the given decorators
+func NewStack(decorators ...streamdeck.ButtonDecorator) *Stack {
+	s := &Stack{decorators: decorators}
+	return s
+}
+
+func (s *Stack) Apply(img image.Image, size int) image.Image {
+	for _, d := range s.decorators {
+		img = d.Apply(img, size)
+	}
+	return img
+}
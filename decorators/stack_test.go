@@ -0,0 +1,38 @@
+package decorators_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/decorators"
+)
+
+func TestStack(t *testing.T) {
+	const size = 96
+	at := func(img image.Image, x, y int) color.Color {
+		return color.RGBAModel.Convert(img.At(x, y))
+	}
+	dimmed := color.RGBA{0x40, 0x40, 0x40, 0xff}
+	dimmedRed := color.RGBA{0x7f, 0, 0, 0xff}
+
+	for _, tc := range []struct {
+		decorators   []streamdeck.ButtonDecorator
+		badge, plain color.Color // Where the badge is, and away from it
+	}{
+		{nil, color.RGBA{0x80, 0x80, 0x80, 0xff}, color.RGBA{0x80, 0x80, 0x80, 0xff}},
+		// In order, so the badge is dimmed along with the rest of the button...
+		{[]streamdeck.ButtonDecorator{decorators.NewBadge(red), decorators.NewDim(0.5)}, dimmedRed, dimmed},
+		// ...or drawn on top of it at full brightness
+		{[]streamdeck.ButtonDecorator{decorators.NewDim(0.5), decorators.NewBadge(red)}, red, dimmed},
+	} {
+		img := decorators.NewStack(tc.decorators...).Apply(grey(size), size)
+		if got := at(img, 80, 15); got != tc.badge {
+			t.Errorf("With %d decorators, the badge is %v, not %v", len(tc.decorators), got, tc.badge)
+		}
+		if got := at(img, size/2, size/2); got != tc.plain {
+			t.Errorf("With %d decorators, the button is %v, not %v", len(tc.decorators), got, tc.plain)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package streamdeck
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// deviceFile is the declarative form of a device definition, as read by RegisterDevicetypeFromReader
+//
+// Packets are given as hex strings ("03 02"), and are zero-padded up to the matching length field if
+// one is given.  Image headers are templates of hex bytes and placeholders, for example:
+//
+//	"02 07 {btn} {last} {len:le16} {page:le16}"
+//
+// The placeholders available are {btn}, {page}, {last} and {len} for button images, plus {x}, {y},
+// {width} and {height} for area images.  A placeholder can carry an offset ("{btn+1}") and is one byte
+// wide unless suffixed with ":le16" or ":be16".
+type deviceFile struct {
+	Name                   string       `json:"name"`
+	USBProductID           uint16       `json:"usbProductID"`
+	ImageWidth             int          `json:"imageWidth"`
+	ImageHeight            int          `json:"imageHeight"`
+	NumberOfButtons        uint         `json:"numberOfButtons"`
+	ButtonRows             uint         `json:"buttonRows"`
+	ButtonCols             uint         `json:"buttonCols"`
+	ButtonReadOffset       uint         `json:"buttonReadOffset"`
+	ButtonMap              map[uint]int `json:"buttonMap"`
+	NumberOfEncoders       uint         `json:"numberOfEncoders"`
+	EncoderReadOffset      uint         `json:"encoderReadOffset"`
+	TouchscreenWidth       int          `json:"touchscreenWidth"`
+	TouchscreenHeight      int          `json:"touchscreenHeight"`
+	TouchscreenX           int          `json:"touchscreenX"`
+	TouchscreenY           int          `json:"touchscreenY"`
+	TouchscreenInput       bool         `json:"touchscreenInput"`
+	Quirks                 []string     `json:"quirks"`
+	ResetPacket            string       `json:"resetPacket"`
+	ResetPacketLength      int          `json:"resetPacketLength"`
+	BrightnessPacket       string       `json:"brightnessPacket"`
+	BrightnessPacketLength int          `json:"brightnessPacketLength"`
+	ImageFormat            string       `json:"imageFormat"`
+	ImagePayloadPerPage    uint         `json:"imagePayloadPerPage"`
+	ImageHeader            string       `json:"imageHeader"`
+	ImageAreaHeader        string       `json:"imageAreaHeader"`
+
+	TouchscreenBrightnessPacket string         `json:"touchscreenBrightnessPacket"`
+	ButtonGeometry              map[int][4]int `json:"buttonGeometry"` // x0, y0, x1, y1 per button
+	InputReportLength           uint           `json:"inputReportLength"`
+	OutputReportLength          uint           `json:"outputReportLength"`
+}
+
+// quirkNames are the names used for each Quirk in a device file
+var quirkNames = map[string]Quirk{
+	"halfImagePages":    QuirkHalfImagePages,
+	"opaqueImage":       QuirkOpaqueImage,
+	"rotateAreaImage":   QuirkRotateAreaImage,
+	"rotateImage180":    QuirkRotateImage180,
+	"rotateImage90":     QuirkRotateImage90,
+	"flipImageVertical": QuirkFlipImageVertical,
+}
+
+// RegisterDevicetypeFromFile reads a JSON device definition from the given file and registers it, so
+// that new or cloned devices can be supported without recompiling
+func RegisterDevicetypeFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return RegisterDevicetypeFromReader(f)
+}
+
+// RegisterDevicetypeFromReader reads a JSON device definition and registers it, see RegisterDevicetypeFromFile
+func RegisterDevicetypeFromReader(r io.Reader) error {
+	var def deviceFile
+	if err := json.NewDecoder(r).Decode(&def); err != nil {
+		return err
+	}
+
+	if def.Name == "" {
+		return errors.New("Device definition has no name")
+	}
+	if def.USBProductID == 0 {
+		return errors.New("Device definition has no USB product ID")
+	}
+
+	resetPacket, err := parsePacket(def.ResetPacket, def.ResetPacketLength)
+	if err != nil {
+		return fmt.Errorf("Invalid reset packet: %s", err)
+	}
+	brightnessPacket, err := parsePacket(def.BrightnessPacket, def.BrightnessPacketLength)
+	if err != nil {
+		return fmt.Errorf("Invalid brightness packet: %s", err)
+	}
+
+	var quirks Quirk
+	for _, name := range def.Quirks {
+		q, ok := quirkNames[name]
+		if !ok {
+			return fmt.Errorf("Unknown quirk %q", name)
+		}
+		quirks |= q
+	}
+
+	var touchscreenBrightnessPacket []byte
+	if def.TouchscreenBrightnessPacket != "" {
+		touchscreenBrightnessPacket, err = parsePacket(def.TouchscreenBrightnessPacket, 0)
+		if err != nil {
+			return fmt.Errorf("Invalid touchscreen brightness packet: %s", err)
+		}
+	}
+
+	imageSize := image.Point{X: def.ImageWidth, Y: def.ImageHeight}
+	var buttonGeometry map[int]image.Rectangle
+	if len(def.ButtonGeometry) > 0 {
+		buttonGeometry = make(map[int]image.Rectangle)
+		for btnIndex, r := range def.ButtonGeometry {
+			buttonGeometry[btnIndex] = image.Rect(r[0], r[1], r[2], r[3])
+		}
+	}
+	imageHeader, err := parseHeaderTemplate(def.ImageHeader)
+	if err != nil {
+		return fmt.Errorf("Invalid image header: %s", err)
+	}
+	if imageSize != (image.Point{}) && (len(imageHeader) == 0 || def.ImagePayloadPerPage == 0) {
+		return errors.New("Device definition has an image size, but no image header or payload length")
+	}
+	imageAreaHeader, err := parseHeaderTemplate(def.ImageAreaHeader)
+	if err != nil {
+		return fmt.Errorf("Invalid image area header: %s", err)
+	}
+	// Each page needs room for some of the image after its header
+	if len(imageHeader) > 0 && imageHeader.length() >= def.ImagePayloadPerPage {
+		return fmt.Errorf("Image header of %d bytes leaves no room in a %d byte page", imageHeader.length(), def.ImagePayloadPerPage)
+	}
+	if len(imageAreaHeader) > 0 && imageAreaHeader.length() >= def.ImagePayloadPerPage {
+		return fmt.Errorf("Image area header of %d bytes leaves no room in a %d byte page", imageAreaHeader.length(), def.ImagePayloadPerPage)
+	}
+
+	payloadPerPage := def.ImagePayloadPerPage
+	halfPages := quirks&QuirkHalfImagePages != 0
+	imageHeaderFunc := func(bytesRemaining uint, btnIndex uint, pageNumber uint) []byte {
+		return imageHeader.render(payloadPerPage, halfPages, bytesRemaining, map[string]uint{
+			"btn":  btnIndex,
+			"page": pageNumber,
+		})
+	}
+	var imageAreaHeaderFunc func(bytesRemaining uint, x, y, width, height uint, pageNumber uint) []byte
+	if len(imageAreaHeader) > 0 {
+		imageAreaHeaderFunc = func(bytesRemaining uint, x, y, width, height uint, pageNumber uint) []byte {
+			return imageAreaHeader.render(payloadPerPage, false, bytesRemaining, map[string]uint{
+				"x":      x,
+				"y":      y,
+				"width":  width,
+				"height": height,
+				"page":   pageNumber,
+			})
+		}
+	}
+
+	RegisterDevice(DeviceDefinition{
+		Name:                def.Name,
+		ImageSize:           imageSize,
+		USBProductID:        def.USBProductID,
+		ResetPacket:         resetPacket,
+		NumberOfButtons:     def.NumberOfButtons,
+		ButtonRows:          def.ButtonRows,
+		ButtonCols:          def.ButtonCols,
+		BrightnessPacket:    brightnessPacket,
+		ButtonReadOffset:    def.ButtonReadOffset,
+		NumberOfEncoders:    def.NumberOfEncoders,
+		EncoderReadOffset:   def.EncoderReadOffset,
+		TouchscreenSize:     image.Point{X: def.TouchscreenWidth, Y: def.TouchscreenHeight},
+		TouchscreenPosition: image.Point{X: def.TouchscreenX, Y: def.TouchscreenY},
+		TouchscreenInput:    def.TouchscreenInput,
+		Quirks:              quirks,
+		ImageFormat:         def.ImageFormat,
+		ImagePayloadPerPage: def.ImagePayloadPerPage,
+		ButtonMap:           def.ButtonMap,
+		ImageHeaderFunc:     imageHeaderFunc,
+		ImageAreaHeaderFunc: imageAreaHeaderFunc,
+
+		TouchscreenBrightnessPacket: touchscreenBrightnessPacket,
+		ButtonGeometry:              buttonGeometry,
+		InputReportLength:           def.InputReportLength,
+		OutputReportLength:          def.OutputReportLength,
+	})
+	return nil
+}
+
+// parsePacket turns a hex string such as "03 02" into bytes, zero-padded up to length
+func parsePacket(s string, length int) ([]byte, error) {
+	pkt, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return nil, err
+	}
+	if len(pkt) < length {
+		pkt = append(pkt, make([]byte, length-len(pkt))...)
+	}
+	return pkt, nil
+}
+
+// headerField is one element of a header template; either a literal byte or a placeholder
+type headerField struct {
+	literal   byte
+	name      string
+	offset    uint
+	width     int
+	bigEndian bool
+}
+
+type headerTemplate []headerField
+
+func parseHeaderTemplate(s string) (headerTemplate, error) {
+	var t headerTemplate
+	for _, token := range strings.Fields(s) {
+		if !strings.HasPrefix(token, "{") {
+			b, err := hex.DecodeString(token)
+			if err != nil || len(b) != 1 {
+				return nil, fmt.Errorf("Invalid byte %q", token)
+			}
+			t = append(t, headerField{literal: b[0], width: 1})
+			continue
+		}
+
+		if !strings.HasSuffix(token, "}") {
+			return nil, fmt.Errorf("Unterminated placeholder %q", token)
+		}
+		field := headerField{width: 1}
+		spec := token[1 : len(token)-1]
+		if i := strings.Index(spec, ":"); i >= 0 {
+			switch spec[i+1:] {
+			case "le16":
+				field.width = 2
+			case "be16":
+				field.width = 2
+				field.bigEndian = true
+			default:
+				return nil, fmt.Errorf("Unknown placeholder width %q", token)
+			}
+			spec = spec[:i]
+		}
+		if i := strings.Index(spec, "+"); i >= 0 {
+			offset, err := strconv.ParseUint(spec[i+1:], 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid placeholder offset %q", token)
+			}
+			field.offset = uint(offset)
+			spec = spec[:i]
+		}
+		switch spec {
+		case "btn", "page", "last", "len", "x", "y", "width", "height":
+			field.name = spec
+		default:
+			return nil, fmt.Errorf("Unknown placeholder %q", token)
+		}
+		t = append(t, field)
+	}
+	return t, nil
+}
+
+// length is the number of bytes the template renders to
+func (t headerTemplate) length() uint {
+	length := uint(0)
+	for _, f := range t {
+		length += uint(f.width)
+	}
+	return length
+}
+
+// render fills in the template; "len" and "last" are worked out from the space left in the page once the
+// header itself has been accounted for, the same way rawWriteToButton splits the image.  With halfPages
+// (QuirkHalfImagePages) an image too long for the first page is sent as two halves, which must each fit in a page.
+// The template must be shorter than payloadPerPage, as RegisterDevicetypeFromReader checks.
+func (t headerTemplate) render(payloadPerPage uint, halfPages bool, bytesRemaining uint, vars map[string]uint) []byte {
+	headerLength := t.length()
+	thisLength := bytesRemaining
+	if space := payloadPerPage - headerLength; space < bytesRemaining {
+		thisLength = space
+		if halfPages && vars["page"] == 0 {
+			thisLength = bytesRemaining / 2
+		}
+	}
+	vars["len"] = thisLength
+	vars["last"] = 0
+	if thisLength == bytesRemaining {
+		vars["last"] = 1
+	}
+
+	header := make([]byte, 0, headerLength)
+	for _, f := range t {
+		if f.name == "" {
+			header = append(header, f.literal)
+			continue
+		}
+		v := vars[f.name] + f.offset
+		switch {
+		case f.width == 1:
+			header = append(header, byte(v))
+		case f.bigEndian:
+			header = append(header, byte(v>>8), byte(v&0xff))
+		default:
+			header = append(header, byte(v&0xff), byte(v>>8))
+		}
+	}
+	return header
+}
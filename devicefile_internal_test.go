@@ -0,0 +1,122 @@
+package streamdeck
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHeaderTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		template string
+		want     headerTemplate
+	}{
+		{"", nil},
+		{"02 07 {btn} {last} {len:le16} {page:le16}", headerTemplate{
+			{literal: 0x02, width: 1},
+			{literal: 0x07, width: 1},
+			{name: "btn", width: 1},
+			{name: "last", width: 1},
+			{name: "len", width: 2},
+			{name: "page", width: 2},
+		}},
+		{"ff {page+1} {width:be16} {x+300:le16}", headerTemplate{
+			{literal: 0xff, width: 1},
+			{name: "page", offset: 1, width: 1},
+			{name: "width", width: 2, bigEndian: true},
+			{name: "x", offset: 300, width: 2},
+		}},
+	} {
+		got, err := parseHeaderTemplate(tc.template)
+		if err != nil {
+			t.Errorf("%q: %s", tc.template, err)
+		} else if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q parsed to %+v, not %+v", tc.template, got, tc.want)
+		}
+	}
+
+	for _, template := range []string{
+		"2",           // Half a byte
+		"0207",        // Two bytes in one
+		"0x02",        // Not hex
+		"{btn",        // Unterminated
+		"{btn:le32}",  // Unknown width
+		"{btn+x}",     // Offset not a number
+		"{btn+70000}", // Offset too large
+		"{colour}",    // Unknown placeholder
+	} {
+		if _, err := parseHeaderTemplate(template); err == nil {
+			t.Errorf("%q was parsed", template)
+		}
+	}
+}
+
+func TestHeaderTemplateRender(t *testing.T) {
+	const xl = "02 07 {btn} {last} {len:le16} {page:le16}"
+	const orig = "02 01 {page+1} 00 {last} {btn+1} {len:le16}"
+	for _, tc := range []struct {
+		template       string
+		payloadPerPage uint
+		halfPages      bool
+		bytesRemaining uint
+		page           uint
+		want           []byte
+	}{
+		// The whole image fits in the first page
+		{xl, 1024, false, 100, 0, []byte{0x02, 0x07, 3, 1, 100, 0, 0, 0}},
+		// The page is filled after its 8 byte header, 1016 bytes
+		{xl, 1024, false, 2000, 0, []byte{0x02, 0x07, 3, 0, 0xf8, 0x03, 0, 0}},
+		// The rest fits in the second page
+		{xl, 1024, false, 984, 1, []byte{0x02, 0x07, 3, 1, 0xd8, 0x03, 1, 0}},
+		// Exactly filling a page is the last
+		{xl, 1024, false, 1016, 2, []byte{0x02, 0x07, 3, 1, 0xf8, 0x03, 2, 0}},
+		// Without halfPages an image is split page by page, 8183 bytes after the header...
+		{orig, 8191, false, 15606, 0, []byte{0x02, 0x01, 1, 0, 0, 4, 0xf7, 0x1f}},
+		// ...and with it into two halves of 7803 bytes
+		{orig, 8191, true, 15606, 0, []byte{0x02, 0x01, 1, 0, 0, 4, 0x7b, 0x1e}},
+		{orig, 8191, true, 7803, 1, []byte{0x02, 0x01, 2, 0, 1, 4, 0x7b, 0x1e}},
+		// An image fitting in one page isn't halved
+		{orig, 8191, true, 5000, 0, []byte{0x02, 0x01, 1, 0, 1, 4, 0x88, 0x13}},
+		// Offsets, and big endian placeholders
+		{"{btn+1} {len:be16}", 10, false, 5, 0, []byte{4, 0, 5}},
+		{"{btn+1} {len:be16}", 10, false, 500, 0, []byte{4, 0, 7}},
+	} {
+		template, err := parseHeaderTemplate(tc.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := template.render(tc.payloadPerPage, tc.halfPages, tc.bytesRemaining, map[string]uint{"btn": 3, "page": tc.page})
+		if !bytes.Equal(got, tc.want) {
+			t.Errorf("%q with %d of %d bytes per page left, page %d (half pages %t), rendered % x, not % x",
+				tc.template, tc.bytesRemaining, tc.payloadPerPage, tc.page, tc.halfPages, got, tc.want)
+		}
+	}
+}
+
+// TestDeviceFileHeaderRoom checks that device files whose image headers leave no room for the image in a page are
+// refused when they are read, rather than failing when an image is written
+func TestDeviceFileHeaderRoom(t *testing.T) {
+	const def = `{"name": "Header room", "usbProductID": 65522, "imageWidth": 72, "imageHeight": 72,
+		"imageFormat": "JPEG", "imagePayloadPerPage": %s, "imageHeader": "02 07 {btn} {last} {len:le16} {page:le16}",
+		"imageAreaHeader": "%s"}`
+	for _, tc := range []struct {
+		payloadPerPage string
+		areaHeader     string
+		ok             bool
+	}{
+		{"9", "", true},
+		{"8", "", false},
+		{"4", "", false},
+		{"9", "02 0c {x:le16} {y:le16} {width:le16} {height:le16}", false},
+		{"1024", "02 0c {x:le16} {y:le16} {width:le16} {height:le16}", true},
+	} {
+		err := RegisterDevicetypeFromReader(strings.NewReader(fmt.Sprintf(def, tc.payloadPerPage, tc.areaHeader)))
+		if tc.ok && err != nil {
+			t.Errorf("%s byte pages with area header %q: %s", tc.payloadPerPage, tc.areaHeader, err)
+		} else if !tc.ok && err == nil {
+			t.Errorf("%s byte pages with area header %q were accepted", tc.payloadPerPage, tc.areaHeader)
+		}
+	}
+}
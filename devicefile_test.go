@@ -0,0 +1,70 @@
+package streamdeck_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"testing"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+// TestDeviceFileExample registers the example device file and writes an image through it, as the example does.
+// The example clones the XL's product ID, which the devices package has already registered, so the test moves it
+// to a product ID of its own.
+func TestDeviceFileExample(t *testing.T) {
+	data, err := ioutil.ReadFile("examples/devicefile/xl-clone.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var def map[string]interface{}
+	if err := json.Unmarshal(data, &def); err != nil {
+		t.Fatal(err)
+	}
+	def["usbProductID"] = 0xfff0
+	def["inputReportLength"] = 512
+	data, err = json.Marshal(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := streamdeck.RegisterDevicetypeFromReader(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := streamdecktest.NewMock(protocol.Layout{})
+	d, err := streamdeck.OpenWithInterface(mock, 0xfff0, "FILE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if d.GetName() != "Streamdeck XL (from file)" {
+		t.Fatalf("Opened %q", d.GetName())
+	}
+	if d.GetInputReportLength() != 512 || d.GetOutputReportLength() != 1024 {
+		t.Errorf("Report lengths are %d in and %d out, not those from the file", d.GetInputReportLength(), d.GetOutputReportLength())
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 96, 96))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{255, 255, 0, 255}), image.Point{}, draw.Src)
+	if err := d.WriteRawImageToButton(3, img); err != nil {
+		t.Fatal(err)
+	}
+	pages := mock.WritesWithPrefix([]byte{0x02, 0x07, 3})
+	if len(pages) == 0 {
+		t.Fatal("No image pages were written for button 3")
+	}
+	last := pages[len(pages)-1]
+	if last[3] != 1 {
+		t.Errorf("The last page isn't marked as the last: % x", last[:8])
+	}
+	for _, page := range pages {
+		if len(page) > 1024 {
+			t.Errorf("A page is %d bytes, more than the payload per page", len(page))
+		}
+	}
+}
@@ -58,37 +58,37 @@ func init() {
 	miniButtonWidth = 80
 	miniButtonHeight = 80
 	miniImageReportPayloadLength = 1024
-	streamdeck.RegisterDevicetype(
-		miniName, // Name
-		image.Point{X: int(miniButtonWidth), Y: int(miniButtonHeight)}, // Width/height of a button
-		0x63,                         // USB productID
-		resetPacket17(),              // Reset packet
-		6,                            // Number of buttons
-		2,                            // Number of rows
-		3,                            // Number of cols
-		brightnessPacket17(),         // Brightness packet
-		1,                            // Button read offset
-		"BMP",                        // Image format
-		miniImageReportPayloadLength, // Amount of image payload allowed per USB packet
-		nil,
-		GetImageHeaderMini, // Function to get the comms image header
-		nil,
-	)
+	streamdeck.RegisterDevice(streamdeck.DeviceDefinition{
+		Name:                miniName,
+		ImageSize:           image.Point{X: int(miniButtonWidth), Y: int(miniButtonHeight)},
+		USBProductID:        0x63,
+		ResetPacket:         resetPacket17(),
+		NumberOfButtons:     6,
+		ButtonRows:          2,
+		ButtonCols:          3,
+		BrightnessPacket:    brightnessPacket17(),
+		ButtonReadOffset:    1,
+		InputReportLength:   17,
+		Quirks:              streamdeck.QuirkRotateImage90 | streamdeck.QuirkFlipImageVertical | streamdeck.QuirkOpaqueImage,
+		ImageFormat:         "BMP",
+		ImagePayloadPerPage: miniImageReportPayloadLength,
+		ImageHeaderFunc:     GetImageHeaderMini,
+	})
 
-	streamdeck.RegisterDevicetype(
-		miniName, // Name
-		image.Point{X: int(miniButtonWidth), Y: int(miniButtonHeight)}, // Width/height of a button
-		0x90,                         // USB productID
-		resetPacket17(),              // Reset packet
-		6,                            // Number of buttons
-		2,                            // Number of rows
-		3,                            // Number of cols
-		brightnessPacket17(),         // Brightness packet
-		1,                            // Button read offset
-		"BMP",                        // Image format
-		miniImageReportPayloadLength, // Amount of image payload allowed per USB packet
-		nil,
-		GetImageHeaderMini, // Function to get the comms image header
-		nil,
-	)
+	streamdeck.RegisterDevice(streamdeck.DeviceDefinition{
+		Name:                miniName,
+		ImageSize:           image.Point{X: int(miniButtonWidth), Y: int(miniButtonHeight)},
+		USBProductID:        0x90,
+		ResetPacket:         resetPacket17(),
+		NumberOfButtons:     6,
+		ButtonRows:          2,
+		ButtonCols:          3,
+		BrightnessPacket:    brightnessPacket17(),
+		ButtonReadOffset:    1,
+		InputReportLength:   17,
+		Quirks:              streamdeck.QuirkRotateImage90 | streamdeck.QuirkFlipImageVertical | streamdeck.QuirkOpaqueImage,
+		ImageFormat:         "BMP",
+		ImagePayloadPerPage: miniImageReportPayloadLength,
+		ImageHeaderFunc:     GetImageHeaderMini,
+	})
 }
@@ -42,20 +42,20 @@ func init() {
 	mk2ButtonWidth = 72
 	mk2ButtonHeight = 72
 	mk2ImageReportPayloadLength = 1024
-	streamdeck.RegisterDevicetype(
-		mk2Name, // Name
-		image.Point{X: int(mk2ButtonWidth), Y: int(mk2ButtonHeight)}, // Width/height of a button
-		0x80,                        // USB productID
-		resetPacket32(),             // Reset packet
-		15,                          // Number of buttons
-		3,                           // Number of rows
-		5,                           // Number of columns
-		brightnessPacket32(),        // Set brightness packet preamble
-		4,                           // Button read offset
-		"JPEG",                      // Image format
-		mk2ImageReportPayloadLength, // Amount of image payload allowed per USB packet
-		nil,
-		GetImageHeaderMk2, // Function to get the comms image header
-		nil,
-	)
+	streamdeck.RegisterDevice(streamdeck.DeviceDefinition{
+		Name:                mk2Name,
+		ImageSize:           image.Point{X: int(mk2ButtonWidth), Y: int(mk2ButtonHeight)},
+		USBProductID:        0x80,
+		ResetPacket:         resetPacket32(),
+		NumberOfButtons:     15,
+		ButtonRows:          3,
+		ButtonCols:          5,
+		BrightnessPacket:    brightnessPacket32(),
+		ButtonReadOffset:    4,
+		InputReportLength:   512,
+		Quirks:              streamdeck.QuirkRotateImage180,
+		ImageFormat:         "JPEG",
+		ImagePayloadPerPage: mk2ImageReportPayloadLength,
+		ImageHeaderFunc:     GetImageHeaderMk2,
+	})
 }
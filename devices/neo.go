@@ -63,22 +63,28 @@ func GetImageAreaHeaderNeo(bytesRemaining uint, x, y, width, height uint, pageIn
 func init() {
 	neoName = "Streamdeck Neo"
 	neoButtonWidth = 96
-	neoButtonHeight = 96 // Button index 8+9 (paging buttons) are probably about 16 pixels high. At least if you send a 96x96 image to them, only the lower 16 pixels or so will effectively paint the button. It's not completely understood honestly since there is a diffuser in front of it and I have not opened the Stream Deck to check how it really works to the edges (KS). For now I will not care and just generate a solid color 96x96 image to them as a way to set their color. But this could be optimized.
+	neoButtonHeight = 96 // Button index 8+9 (paging buttons) are probably about 16 pixels high. At least if you send a 96x96 image to them, only the lower 16 pixels or so will effectively paint the button. It's not completely understood honestly since there is a diffuser in front of it and I have not opened the Stream Deck to check how it really works to the edges (KS). The button geometry below reflects that, so images sent to them are scaled into that strip.
 	neoImageReportPayloadLength = 1024
-	streamdeck.RegisterDevicetype(
-		neoName, // Name
-		image.Point{X: int(neoButtonWidth), Y: int(neoButtonHeight)}, // Width/height of a button
-		0x9a,                        // USB productID
-		resetPacket32(),             // Reset packet
-		10,                          // Number of buttons
-		2,                           // Number of rows
-		4,                           // Number of columns
-		brightnessPacket32(),        // Set brightness packet preamble
-		4,                           // Button read offset
-		"JPEG",                      // Image format
-		neoImageReportPayloadLength, // Amount of image payload allowed per USB packet
-		nil,
-		GetImageHeaderNeo, // Function to get the comms image header
-		GetImageAreaHeaderNeo,
-	)
+	streamdeck.RegisterDevice(streamdeck.DeviceDefinition{
+		Name:                neoName,
+		ImageSize:           image.Point{X: int(neoButtonWidth), Y: int(neoButtonHeight)},
+		USBProductID:        0x9a,
+		ResetPacket:         resetPacket32(),
+		NumberOfButtons:     10,
+		ButtonRows:          2,
+		ButtonCols:          4,
+		BrightnessPacket:    brightnessPacket32(),
+		ButtonReadOffset:    4,
+		InputReportLength:   512,
+		TouchscreenSize:     image.Point{X: 248, Y: 58},
+		Quirks:              streamdeck.QuirkRotateImage180 | streamdeck.QuirkRotateAreaImage,
+		ImageFormat:         "JPEG",
+		ImagePayloadPerPage: neoImageReportPayloadLength,
+		ImageHeaderFunc:     GetImageHeaderNeo,
+		ImageAreaHeaderFunc: GetImageAreaHeaderNeo,
+		ButtonGeometry: map[int]image.Rectangle{
+			8: image.Rect(0, 80, 96, 96),
+			9: image.Rect(0, 80, 96, 96),
+		},
+	})
 }
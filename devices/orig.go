@@ -48,19 +48,21 @@ func init() {
 	originalButtonWidth = 72
 	originalButtonHeight = 72
 	originalImageReportPayloadLength = 8191 //8191
-	streamdeck.RegisterDevicetype(
-		originalName, // Name
-		image.Point{X: int(originalButtonWidth), Y: int(originalButtonHeight)}, // Width/height of a button
-		0x60,                             // USB productID
-		resetPacket17(),                  // Reset packet
-		15,                               // Number of buttons
-		3,                                // Number of rows
-		5,                                // Number of cols
-		brightnessPacket17(),             // Brightness packet
-		1,                                // Button read offset
-		"BMP",                            // Image format
-		originalImageReportPayloadLength, // Amount of image payload allowed per USB packet
-		map[uint]int{
+	streamdeck.RegisterDevice(streamdeck.DeviceDefinition{
+		Name:                originalName,
+		ImageSize:           image.Point{X: int(originalButtonWidth), Y: int(originalButtonHeight)},
+		USBProductID:        0x60,
+		ResetPacket:         resetPacket17(),
+		NumberOfButtons:     15,
+		ButtonRows:          3,
+		ButtonCols:          5,
+		BrightnessPacket:    brightnessPacket17(),
+		ButtonReadOffset:    1,
+		InputReportLength:   17,
+		Quirks:              streamdeck.QuirkRotateImage180 | streamdeck.QuirkHalfImagePages | streamdeck.QuirkOpaqueImage,
+		ImageFormat:         "BMP",
+		ImagePayloadPerPage: originalImageReportPayloadLength,
+		ButtonMap: map[uint]int{
 			4:  0,
 			3:  1,
 			2:  2,
@@ -77,7 +79,6 @@ func init() {
 			11: 13,
 			10: 14,
 		},
-		GetImageHeaderOriginal, // Function to get the comms image header
-		nil,
-	)
+		ImageHeaderFunc: GetImageHeaderOriginal,
+	})
 }
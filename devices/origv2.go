@@ -42,20 +42,20 @@ func init() {
 	ov2ButtonWidth = 72
 	ov2ButtonHeight = 72
 	ov2ImageReportPayloadLength = 1024
-	streamdeck.RegisterDevicetype(
-		ov2Name, // Name
-		image.Point{X: int(ov2ButtonWidth), Y: int(ov2ButtonHeight)}, // Width/height of a button
-		0x6d,                        // USB productID
-		resetPacket32(),             // Reset packet
-		15,                          // Number of buttons
-		3,                           // Number of rows
-		5,                           // Number of columns
-		brightnessPacket32(),        // Set brightness packet preamble
-		4,                           // Button read offset
-		"JPEG",                      // Image format
-		ov2ImageReportPayloadLength, // Amount of image payload allowed per USB packet
-		nil,
-		GetImageHeaderOv2, // Function to get the comms image header
-		nil,
-	)
+	streamdeck.RegisterDevice(streamdeck.DeviceDefinition{
+		Name:                ov2Name,
+		ImageSize:           image.Point{X: int(ov2ButtonWidth), Y: int(ov2ButtonHeight)},
+		USBProductID:        0x6d,
+		ResetPacket:         resetPacket32(),
+		NumberOfButtons:     15,
+		ButtonRows:          3,
+		ButtonCols:          5,
+		BrightnessPacket:    brightnessPacket32(),
+		ButtonReadOffset:    4,
+		InputReportLength:   512,
+		Quirks:              streamdeck.QuirkRotateImage180,
+		ImageFormat:         "JPEG",
+		ImagePayloadPerPage: ov2ImageReportPayloadLength,
+		ImageHeaderFunc:     GetImageHeaderOv2,
+	})
 }
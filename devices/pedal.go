@@ -1,8 +1,6 @@
 package devices
 
 import (
-	"image"
-
 	streamdeck "github.com/SKAARHOJ/go-streamdeck"
 )
 
@@ -19,20 +17,16 @@ func GetImageHeaderPedal(bytesRemaining uint, btnIndex uint, pageNumber uint) []
 
 func init() {
 	pedalName = "Streamdeck Pedal"
-	streamdeck.RegisterDevicetype(
-		pedalName, // Name
-		image.Point{},
-		0x86,                 // USB productID
-		resetPacket32(),      // Reset packet
-		3,                    // Number of buttons
-		1,                    // Number of rows
-		3,                    // Number of columns
-		brightnessPacket32(), // Set brightness packet preamble
-		4,                    // Button read offset
-		"",                   // Image format
-		0,                    // Amount of image payload allowed per USB packet
-		nil,
-		GetImageHeaderPedal, // Function to get the comms image header
-		nil,
-	)
+	streamdeck.RegisterDevice(streamdeck.DeviceDefinition{
+		Name:              pedalName,
+		USBProductID:      0x86,
+		ResetPacket:       resetPacket32(),
+		NumberOfButtons:   3,
+		ButtonRows:        1,
+		ButtonCols:        3,
+		BrightnessPacket:  brightnessPacket32(),
+		ButtonReadOffset:  4,
+		InputReportLength: 512,
+		ImageHeaderFunc:   GetImageHeaderPedal,
+	})
 }
@@ -118,20 +118,24 @@ func init() {
 	plusButtonWidth = 120
 	plusButtonHeight = 120
 	plusImageReportPayloadLength = 1024
-	streamdeck.RegisterDevicetype(
-		plusName, // Name
-		image.Point{X: int(plusButtonWidth), Y: int(plusButtonHeight)}, // Width/height of a button
-		0x84,                         // USB productID
-		resetPacket32(),              // Reset packet
-		8,                            // Number of buttons
-		2,                            // Number of rows
-		4,                            // Number of columns
-		brightnessPacket32(),         // Set brightness packet preamble
-		4,                            // Button read offset
-		"JPEG",                       // Image format
-		plusImageReportPayloadLength, // Amount of image payload allowed per USB packet
-		nil,
-		GetImageHeaderPlus, // Function to get the comms image header
-		GetImageAreaHeaderPlus,
-	)
+	streamdeck.RegisterDevice(streamdeck.DeviceDefinition{
+		Name:                plusName,
+		ImageSize:           image.Point{X: int(plusButtonWidth), Y: int(plusButtonHeight)},
+		USBProductID:        0x84,
+		ResetPacket:         resetPacket32(),
+		NumberOfButtons:     8,
+		ButtonRows:          2,
+		ButtonCols:          4,
+		BrightnessPacket:    brightnessPacket32(),
+		ButtonReadOffset:    4,
+		InputReportLength:   512,
+		NumberOfEncoders:    4,
+		EncoderReadOffset:   5,
+		TouchscreenSize:     image.Point{X: 800, Y: 100},
+		TouchscreenInput:    true,
+		ImageFormat:         "JPEG",
+		ImagePayloadPerPage: plusImageReportPayloadLength,
+		ImageHeaderFunc:     GetImageHeaderPlus,
+		ImageAreaHeaderFunc: GetImageAreaHeaderPlus,
+	})
 }
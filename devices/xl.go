@@ -42,36 +42,36 @@ func init() {
 	xlButtonWidth = 96
 	xlButtonHeight = 96
 	xlImageReportPayloadLength = 1024
-	streamdeck.RegisterDevicetype(
-		xlName, // Name
-		image.Point{X: int(xlButtonWidth), Y: int(xlButtonHeight)}, // Width/height of a button
-		0x6c,                       // USB productID
-		resetPacket32(),            // Reset packet
-		32,                         // Number of buttons
-		4,                          // Number of rows
-		8,                          // Number of cols
-		brightnessPacket32(),       // Set brightness packet preamble
-		4,                          // Button read offset
-		"JPEG",                     // Image format
-		xlImageReportPayloadLength, // Amount of image payload allowed per USB packet
-		nil,
-		GetImageHeaderXl, // Function to get the comms image header
-		nil,
-	)
-	streamdeck.RegisterDevicetype(
-		xlName, // Name
-		image.Point{X: int(xlButtonWidth), Y: int(xlButtonHeight)}, // Width/height of a button
-		0x8f,                       // USB productID
-		resetPacket32(),            // Reset packet
-		32,                         // Number of buttons
-		4,                          // Number of rows
-		8,                          // Number of cols
-		brightnessPacket32(),       // Set brightness packet preamble
-		4,                          // Button read offset
-		"JPEG",                     // Image format
-		xlImageReportPayloadLength, // Amount of image payload allowed per USB packet
-		nil,
-		GetImageHeaderXl, // Function to get the comms image header
-		nil,
-	)
+	streamdeck.RegisterDevice(streamdeck.DeviceDefinition{
+		Name:                xlName,
+		ImageSize:           image.Point{X: int(xlButtonWidth), Y: int(xlButtonHeight)},
+		USBProductID:        0x6c,
+		ResetPacket:         resetPacket32(),
+		NumberOfButtons:     32,
+		ButtonRows:          4,
+		ButtonCols:          8,
+		BrightnessPacket:    brightnessPacket32(),
+		ButtonReadOffset:    4,
+		InputReportLength:   512,
+		Quirks:              streamdeck.QuirkRotateImage180,
+		ImageFormat:         "JPEG",
+		ImagePayloadPerPage: xlImageReportPayloadLength,
+		ImageHeaderFunc:     GetImageHeaderXl,
+	})
+	streamdeck.RegisterDevice(streamdeck.DeviceDefinition{
+		Name:                xlName,
+		ImageSize:           image.Point{X: int(xlButtonWidth), Y: int(xlButtonHeight)},
+		USBProductID:        0x8f,
+		ResetPacket:         resetPacket32(),
+		NumberOfButtons:     32,
+		ButtonRows:          4,
+		ButtonCols:          8,
+		BrightnessPacket:    brightnessPacket32(),
+		ButtonReadOffset:    4,
+		InputReportLength:   512,
+		Quirks:              streamdeck.QuirkRotateImage180,
+		ImageFormat:         "JPEG",
+		ImagePayloadPerPage: xlImageReportPayloadLength,
+		ImageHeaderFunc:     GetImageHeaderXl,
+	})
 }
@@ -0,0 +1,120 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"time"
+)
+
+// buttonEffect temporarily changes what is shown on a button, see Page.Flash and Page.Blink
+type buttonEffect struct {
+	animation *Animation
+	img       image.Image // Shown instead of the button, if not nil
+	invert    bool        // Show the button's own image inverted
+}
+
+// Flash inverts the button's image a number of times, on and off for interval each, then restores it
+func (p *Page) Flash(btnIndex int, times int, interval time.Duration) {
+	effect := &buttonEffect{}
+	effect.animation = Animate(func(elapsed time.Duration) bool {
+		phase := int(elapsed / interval)
+		if phase >= times*2 {
+			p.clearEffect(btnIndex, effect)
+			return false
+		}
+		p.updateEffect(btnIndex, effect, nil, phase%2 == 0)
+		return true
+	})
+	p.setEffect(btnIndex, effect)
+}
+
+// Blink alternates the button between two images, each shown for half of period, until StopEffect is called.
+// If off is nil, the button's own image is shown in place of it.
+func (p *Page) Blink(btnIndex int, on image.Image, off image.Image, period time.Duration) {
+	effect := &buttonEffect{img: on}
+	effect.animation = Animate(func(elapsed time.Duration) bool {
+		if int(elapsed/(period/2))%2 == 0 {
+			p.updateEffect(btnIndex, effect, on, false)
+		} else {
+			p.updateEffect(btnIndex, effect, off, false)
+		}
+		return true
+	})
+	p.setEffect(btnIndex, effect)
+}
+
+// StopEffect stops a Flash or Blink on the button, restoring its own image
+func (p *Page) StopEffect(btnIndex int) {
+	p.lock.Lock()
+	effect := p.effects[btnIndex]
+	p.lock.Unlock()
+	if effect != nil {
+		effect.animation.Stop()
+		p.clearEffect(btnIndex, effect)
+	}
+}
+
+// setEffect starts an effect on a button, replacing any that is already running
+func (p *Page) setEffect(btnIndex int, effect *buttonEffect) {
+	p.lock.Lock()
+	old := p.effects[btnIndex]
+	p.effects[btnIndex] = effect
+	p.lock.Unlock()
+	if old != nil {
+		old.animation.Stop()
+	}
+	p.redraw(btnIndex)
+}
+
+// updateEffect changes what an effect is showing, redrawing only if that has changed
+func (p *Page) updateEffect(btnIndex int, effect *buttonEffect, img image.Image, invert bool) {
+	p.lock.Lock()
+	if p.effects[btnIndex] != effect || (effect.img == img && effect.invert == invert) {
+		p.lock.Unlock()
+		return
+	}
+	effect.img = img
+	effect.invert = invert
+	p.lock.Unlock()
+	p.redraw(btnIndex)
+}
+
+// clearEffect removes an effect from a button, unless it has already been replaced by another
+func (p *Page) clearEffect(btnIndex int, effect *buttonEffect) {
+	p.lock.Lock()
+	if p.effects[btnIndex] != effect {
+		p.lock.Unlock()
+		return
+	}
+	delete(p.effects, btnIndex)
+	p.lock.Unlock()
+	p.redraw(btnIndex)
+}
+
+// Flash inverts a button of the active page a number of times, see Page.Flash
+func (sd *StreamDeck) Flash(btnIndex int, times int, interval time.Duration) {
+	sd.GetPage().Flash(btnIndex, times, interval)
+}
+
+// Blink alternates a button of the active page between two images, see Page.Blink
+func (sd *StreamDeck) Blink(btnIndex int, on image.Image, off image.Image, period time.Duration) {
+	sd.GetPage().Blink(btnIndex, on, off, period)
+}
+
+// StopEffect stops a Flash or Blink on a button of the active page
+func (sd *StreamDeck) StopEffect(btnIndex int) {
+	sd.GetPage().StopEffect(btnIndex)
+}
+
+// getInvertedImage gives a copy of the image with its colours inverted
+func getInvertedImage(img image.Image) image.Image {
+	b := img.Bounds()
+	newimg := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			newimg.Set(x, y, color.RGBA{c.A - c.R, c.A - c.G, c.A - c.B, c.A})
+		}
+	}
+	return newimg
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/config"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+)
+
+func main() {
+	// initialise the device
+	sd, err := streamdeck.New()
+	if err != nil {
+		panic(err)
+	}
+
+	// build the pages from the config file, and keep them up to date as the file is edited
+	stop, err := config.Watch("examples/config/panel.json", sd, time.Second, func(err error) {
+		fmt.Println("Config not reloaded:", err)
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer stop()
+
+	// run for a few minutes
+	time.Sleep(5 * time.Minute)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/dbusapi"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+)
+
+func main() {
+	// open every attached deck
+	m := streamdeck.NewManager()
+	if err := m.OpenAll(); err != nil {
+		panic(err)
+	}
+	defer m.Close()
+
+	s := dbusapi.NewService(m)
+	if err := s.Connect(); err != nil {
+		panic(err)
+	}
+	for _, d := range m.GetDevices() {
+		fmt.Printf("%s: %s\n", d.GetName(), d.GetSerial())
+	}
+
+	// try: busctl --user call com.skaarhoj.StreamDeck /com/skaarhoj/StreamDeck com.skaarhoj.StreamDeck SetColour sus <serial> 0 '#ff0000'
+	<-s.Done()
+}
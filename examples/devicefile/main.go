@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+func main() {
+	// register a device from its declarative definition, instead of importing the devices package
+	err := streamdeck.RegisterDevicetypeFromFile("examples/devicefile/xl-clone.json")
+	if err != nil {
+		panic(err)
+	}
+
+	// connect to the streamdeck
+	sd, err := streamdeck.Open()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Found device [%s]\n", sd.GetName())
+
+	// show that images work using the templated headers
+	sd.ClearButtons()
+	sd.WriteTextToButton(0, "File!", color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 0, 255})
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+	"github.com/SKAARHOJ/go-streamdeck/httpapi"
+)
+
+func main() {
+	// open every attached deck
+	m := streamdeck.NewManager()
+	if err := m.OpenAll(); err != nil {
+		panic(err)
+	}
+	defer m.Close()
+
+	for _, d := range m.GetDevices() {
+		fmt.Printf("%s: http://localhost:8080/devices/%s\n", d.GetName(), d.GetSerial())
+	}
+
+	// try: curl -d '#ff0000' http://localhost:8080/devices/<serial>/keys/0/colour
+	panic(http.ListenAndServe(":8080", httpapi.NewServer(m)))
+}
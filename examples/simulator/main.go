@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+	"github.com/SKAARHOJ/go-streamdeck/simulator"
+)
+
+func main() {
+	// simulate a Streamdeck Plus
+	d, sim, err := simulator.Open(0x84)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("Simulating a", d.GetName(), "at http://localhost:8090")
+
+	for i := 0; i < int(d.GetNumberOfButtons()); i++ {
+		d.WriteTextToButton(i, fmt.Sprint(i), color.White, color.Black)
+	}
+	d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, pressed bool) {
+		if pressed {
+			d.WriteColorToButton(btnIndex, color.RGBA{255, 0, 0, 255})
+		} else {
+			d.WriteTextToButton(btnIndex, fmt.Sprint(btnIndex), color.White, color.Black)
+		}
+	})
+	d.EncoderRotate(func(encIndex int, d *streamdeck.Device, pulses int) {
+		fmt.Println("Encoder", encIndex, "turned", pulses)
+	})
+
+	panic(sim.ListenAndServe(":8090"))
+}
@@ -0,0 +1,74 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/disintegration/gift"
+)
+
+// Fit is how an image is made to fit a button whose shape it doesn't match
+type Fit int
+
+const (
+	FitStretch    Fit = iota // Stretched to the button's shape, the default
+	FitInside                // Scaled to fit inside the button, with black bars either side
+	FitFill                  // Scaled to fill the button, the middle of it being shown
+	FitCenterCrop            // Not scaled, the middle of it being shown (with black around it, if it is smaller)
+)
+
+// ImageOption is an option for writing images, such as to WriteRawImageToButton
+type ImageOption func(*imageOptions)
+
+type imageOptions struct {
+	fit Fit
+}
+
+// WithFit sets how the image is made to fit the button
+func WithFit(fit Fit) ImageOption {
+	return func(opts *imageOptions) {
+		opts.fit = fit
+	}
+}
+
+func getImageOptions(opts []ImageOption) imageOptions {
+	var res imageOptions
+	for _, opt := range opts {
+		opt(&res)
+	}
+	return res
+}
+
+// fitImage makes an image the given size as the fit says, leaving it as it is to be stretched when it says so
+func fitImage(img image.Image, size image.Point, fit Fit) image.Image {
+	b := img.Bounds()
+	if fit == FitStretch || b.Empty() || size.X <= 0 || size.Y <= 0 {
+		return img
+	}
+
+	// The size the image is drawn at, centred on the result
+	scaled := b.Size()
+	switch fit {
+	case FitInside, FitFill:
+		scaleX, scaleY := float64(size.X)/float64(b.Dx()), float64(size.Y)/float64(b.Dy())
+		scale := scaleX
+		if fit == FitInside && scaleY < scale || fit == FitFill && scaleY > scale {
+			scale = scaleY
+		}
+		scaled = image.Pt(int(float64(b.Dx())*scale+0.5), int(float64(b.Dy())*scale+0.5))
+	}
+
+	res := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(res, res.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	if scaled != b.Size() {
+		g := gift.New(gift.Resize(scaled.X, scaled.Y, gift.LanczosResampling))
+		resized := image.NewRGBA(g.Bounds(b))
+		g.Draw(resized, img)
+		img = resized
+		b = resized.Bounds()
+	}
+	offset := image.Pt((size.X-scaled.X)/2, (size.Y-scaled.Y)/2)
+	draw.Draw(res, b.Sub(b.Min).Add(offset), img, b.Min, draw.Src)
+	return res
+}
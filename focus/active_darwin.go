@@ -0,0 +1,18 @@
+//go:build darwin
+// +build darwin
+
+package focus
+
+import (
+	"os/exec"
+	"strings"
+)
+
+func activeApplication() (string, error) {
+	out, err := exec.Command("osascript", "-e",
+		`tell application "System Events" to get name of first application process whose frontmost is true`).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
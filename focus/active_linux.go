@@ -0,0 +1,103 @@
+//go:build linux
+// +build linux
+
+package focus
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func activeApplication() (string, error) {
+	if os.Getenv("WAYLAND_DISPLAY") == "" {
+		return activeX11()
+	}
+	if os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "" {
+		return activeHyprland()
+	}
+	if os.Getenv("SWAYSOCK") != "" {
+		return activeSway()
+	}
+	return "", errors.New("The focused application can't be found on this Wayland compositor")
+}
+
+// activeX11 gives the class of the active window, from its WM_CLASS of instance and class
+func activeX11() (string, error) {
+	out, err := exec.Command("xprop", "-root", "_NET_ACTIVE_WINDOW").Output()
+	if err != nil {
+		return "", err
+	}
+	// _NET_ACTIVE_WINDOW(WINDOW): window id # 0x3a00007
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 || fields[len(fields)-1] == "0x0" {
+		return "", nil
+	}
+	out, err = exec.Command("xprop", "-id", fields[len(fields)-1], "WM_CLASS").Output()
+	if err != nil {
+		return "", err
+	}
+	// WM_CLASS(STRING) = "Navigator", "firefox"
+	parts := strings.Split(string(out), "\"")
+	if len(parts) < 4 {
+		return "", nil
+	}
+	return parts[3], nil
+}
+
+func activeHyprland() (string, error) {
+	out, err := exec.Command("hyprctl", "activewindow", "-j").Output()
+	if err != nil {
+		return "", err
+	}
+	var window struct {
+		Class string `json:"class"`
+	}
+	if err := json.Unmarshal(out, &window); err != nil {
+		return "", err
+	}
+	return window.Class, nil
+}
+
+// swayNode is a node of sway's tree of outputs, workspaces and windows
+type swayNode struct {
+	Focused          bool       `json:"focused"`
+	AppID            string     `json:"app_id"`
+	Nodes            []swayNode `json:"nodes"`
+	FloatingNodes    []swayNode `json:"floating_nodes"`
+	WindowProperties struct {
+		Class string `json:"class"`
+	} `json:"window_properties"`
+}
+
+func activeSway() (string, error) {
+	out, err := exec.Command("swaymsg", "-t", "get_tree").Output()
+	if err != nil {
+		return "", err
+	}
+	var root swayNode
+	if err := json.Unmarshal(out, &root); err != nil {
+		return "", err
+	}
+	return focusedSwayApp(&root), nil
+}
+
+func focusedSwayApp(n *swayNode) string {
+	if n.Focused {
+		if n.AppID != "" {
+			return n.AppID
+		}
+		// X11 applications running under XWayland have a class instead
+		return n.WindowProperties.Class
+	}
+	for _, children := range [][]swayNode{n.Nodes, n.FloatingNodes} {
+		for i := range children {
+			if app := focusedSwayApp(&children[i]); app != "" {
+				return app
+			}
+		}
+	}
+	return ""
+}
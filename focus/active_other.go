@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package focus
+
+import "errors"
+
+func activeApplication() (string, error) {
+	return "", errors.New("Finding the focused application isn't supported on this platform")
+}
@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package focus
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// foregroundScript prints the process name of the foreground window, which PowerShell can only find through
+// user32.dll
+const foregroundScript = `Add-Type -Name Window -Namespace Focus -MemberDefinition '
+[DllImport("user32.dll")] public static extern IntPtr GetForegroundWindow();
+[DllImport("user32.dll")] public static extern uint GetWindowThreadProcessId(IntPtr hWnd, out uint processId);'
+$id = 0
+[void][Focus.Window]::GetWindowThreadProcessId([Focus.Window]::GetForegroundWindow(), [ref]$id)
+(Get-Process -Id $id).ProcessName`
+
+func activeApplication() (string, error) {
+	out, err := exec.Command("powershell", "-NoProfile", "-Command", foregroundScript).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,121 @@
+// Package focus switches a deck's pages to follow whichever application has the focus, so that each application
+// gets its own set of buttons.  The focused application is found with xprop on X11, hyprctl or swaymsg on
+// Wayland (other Wayland compositors don't say), osascript on macOS and PowerShell on Windows.
+package focus
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// ActiveApplication returns the name of the focused application: its window class on Linux, and its process
+// name on macOS and Windows
+func ActiveApplication() (string, error) {
+	return activeApplication()
+}
+
+// Watcher checks which application has the focus every so often, and when that changes, switches to the page set
+// for it
+type Watcher struct {
+	sd *streamdeck.StreamDeck
+
+	lock        sync.Mutex
+	pages       map[string]string
+	defaultPage string
+	errHandler  func(error)
+	lastApp     string
+	stop        chan struct{}
+}
+
+// NewWatcher creates a Watcher for a StreamDeck; it does nothing until it is started
+func NewWatcher(sd *streamdeck.StreamDeck) *Watcher {
+	return &Watcher{sd: sd, pages: make(map[string]string)}
+}
+
+// SetPage sets the page to switch to when the named application has the focus; the name is matched without
+// regard to case
+func (w *Watcher) SetPage(app, page string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.pages[strings.ToLower(app)] = page
+}
+
+// SetDefaultPage sets the page to switch to when an application with no page of its own has the focus; by
+// default the page is left alone
+func (w *Watcher) SetDefaultPage(page string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.defaultPage = page
+}
+
+// SetErrorHandler sets a function to be told when the focused application couldn't be found, or its page
+// couldn't be switched to
+func (w *Watcher) SetErrorHandler(f func(error)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.errHandler = f
+}
+
+// Start checks the focused application every interval, until Stop is called.  Pages are only switched when the
+// focus moves to another application, so a page switched to by hand stays until then.
+func (w *Watcher) Start(interval time.Duration) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	w.stop = stop
+	w.lastApp = ""
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			w.check()
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops checking the focused application
+func (w *Watcher) Stop() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+func (w *Watcher) check() {
+	app, err := activeApplication()
+	w.lock.Lock()
+	errHandler := w.errHandler
+	if err != nil || app == w.lastApp {
+		w.lock.Unlock()
+		if err != nil && errHandler != nil {
+			errHandler(err)
+		}
+		return
+	}
+	w.lastApp = app
+	page, ok := w.pages[strings.ToLower(app)]
+	if !ok {
+		page = w.defaultPage
+	}
+	w.lock.Unlock()
+
+	if page == "" || page == w.sd.GetPage().GetName() {
+		return
+	}
+	if err := w.sd.SetPage(page); err != nil && errHandler != nil {
+		errHandler(err)
+	}
+}
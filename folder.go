@@ -0,0 +1,83 @@
+package streamdeck
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// OpenFolder switches to the named page as a sub-page of the current one, remembering the current page on a
+// navigation stack.  A "back" button is put on the folder page automatically (see SetBackButtonIndex), unless
+// there is already a button of its own at that position, and Back returns to the page the folder was opened from.
+func (sd *StreamDeck) OpenFolder(name string) error {
+	sd.lock.Lock()
+	p, ok := sd.pages[name]
+	backButtonIndex := sd.backButtonIndex
+	sd.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("No page named %q", name)
+	}
+
+	existing := p.GetButtonIndex(backButtonIndex)
+	if existing == nil {
+		p.AddButton(backButtonIndex, &backButton{sd: sd})
+	}
+
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	sd.navStack = append(sd.navStack, sd.page.name)
+	return sd.setPage(name)
+}
+
+// Back returns to the page that the current folder was opened from
+func (sd *StreamDeck) Back() error {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	if len(sd.navStack) == 0 {
+		return errors.New("Not in a folder")
+	}
+	name := sd.navStack[len(sd.navStack)-1]
+	sd.navStack = sd.navStack[:len(sd.navStack)-1]
+	return sd.setPage(name)
+}
+
+// GetFolderPath returns the names of the pages that have been navigated through to reach the current folder, outermost first
+func (sd *StreamDeck) GetFolderPath() []string {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	path := make([]string, len(sd.navStack))
+	copy(path, sd.navStack)
+	return path
+}
+
+// SetBackButtonIndex sets which button the automatic "back" button is put on in folders; the default is button 0
+func (sd *StreamDeck) SetBackButtonIndex(btnIndex int) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	sd.backButtonIndex = btnIndex
+}
+
+// backButton is the button put onto folder pages by OpenFolder
+type backButton struct {
+	sd       *StreamDeck
+	btnIndex int
+}
+
+func (btn *backButton) GetImageForButton(btnSize int) image.Image {
+	return getImageWithText("Back", color.White, color.RGBA{64, 64, 64, 255}, btnSize)
+}
+
+func (btn *backButton) SetButtonIndex(btnIndex int) {
+	btn.btnIndex = btnIndex
+}
+
+func (btn *backButton) GetButtonIndex() int {
+	return btn.btnIndex
+}
+
+func (btn *backButton) RegisterUpdateHandler(f func(Button)) {}
+
+func (btn *backButton) Pressed() {
+	btn.sd.Back()
+}
@@ -0,0 +1,109 @@
+package streamdeck_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files from the current output")
+
+// goldenDevices are the USB product IDs of the devices with button images
+var goldenDevices = []uint16{0x60, 0x63, 0x90, 0x6d, 0x80, 0x6c, 0x8f, 0x84, 0x9a}
+
+// goldenPayload is a real encoded image, a 72x72 24-bit BMP as the original Streamdeck takes, of 15606 bytes.  It
+// spans several pages on every device, ending part way through one, and on the original it is more than one page,
+// so is split in halves (see QuirkHalfImagePages).
+func goldenPayload() []byte {
+	const size = 72
+	pixels := size * size * 3
+	le32 := func(b []byte, v int) {
+		binary.LittleEndian.PutUint32(b, uint32(v))
+	}
+	payload := make([]byte, 54+pixels)
+	copy(payload, "BM")
+	le32(payload[2:], len(payload))
+	le32(payload[10:], 54) // Where the pixels start
+	le32(payload[14:], 40) // The size of the BITMAPINFOHEADER
+	le32(payload[18:], size)
+	le32(payload[22:], size)
+	binary.LittleEndian.PutUint16(payload[26:], 1)  // Planes
+	binary.LittleEndian.PutUint16(payload[28:], 24) // Bits per pixel
+	le32(payload[34:], pixels)
+	le32(payload[38:], 2835) // 72 DPI
+	le32(payload[42:], 2835)
+	// A gradient, bottom row first, in BGR order; rows are a multiple of 4 bytes, so need no padding
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			p := payload[54+(y*size+x)*3:]
+			p[0], p[1], p[2] = byte(x*255/size), byte(y*255/size), 128
+		}
+	}
+	return payload
+}
+
+// TestImageReportsGolden pins the reports each type of device is sent for a button image: the reset, then each
+// page of the image with its header in full and a hash of the rest.  Run with -update to accept a deliberate
+// change, and check the result against a capture from the hardware (see streamdecktest.Recorder).
+//
+// The golden files were generated by this test with -update, not captured from hardware, so they pin the current
+// output rather than prove it right; a capture replacing one should keep the same format.
+func TestImageReportsGolden(t *testing.T) {
+	for _, productID := range goldenDevices {
+		mock := streamdecktest.NewMock(protocol.Layout{})
+		d, err := streamdeck.OpenWithInterface(mock, productID, "GOLDEN")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.WriteEncodedImageToButton(1, goldenPayload()); err != nil {
+			t.Fatalf("%s: %s", d.GetName(), err)
+		}
+		d.Close()
+
+		var got bytes.Buffer
+		for _, report := range mock.FeatureReports() {
+			fmt.Fprintf(&got, "feature %s\n", hex.EncodeToString(report))
+		}
+		for _, report := range mock.Writes() {
+			n := 16
+			if len(report) < n {
+				n = len(report)
+			}
+			sum := sha256.Sum256(report)
+			fmt.Fprintf(&got, "out %d %s %s\n", len(report), hex.EncodeToString(report[:n]), hex.EncodeToString(sum[:8]))
+		}
+
+		name := strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return ' '
+		}, strings.ToLower(d.GetName()))), "-")
+		path := filepath.Join("testdata", "golden", fmt.Sprintf("%s-%02x.txt", name, productID))
+		if *update {
+			if err := ioutil.WriteFile(path, got.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Bytes(), want) {
+			t.Errorf("%s: reports differ from %s:\n%s", d.GetName(), path, got.String())
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package httpapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Client uses the HTTP API of a Server, such as the one run by streamdeckd, so that several processes can share
+// decks which only one of them can have open
+type Client struct {
+	base string
+	http *http.Client
+}
+
+// NewClient creates a Client for the server at an address, which is either a URL such as
+// "http://localhost:8080" or the path of a Unix socket
+func NewClient(address string) *Client {
+	if strings.HasPrefix(address, "http://") || strings.HasPrefix(address, "https://") {
+		return &Client{base: strings.TrimSuffix(address, "/"), http: &http.Client{}}
+	}
+	var dialer net.Dialer
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", address)
+		},
+	}
+	return &Client{base: "http://streamdeckd", http: &http.Client{Transport: transport}}
+}
+
+// Devices lists the devices the server has open
+func (c *Client) Devices() ([]DeviceInfo, error) {
+	resp, err := c.http.Get(c.base + "/devices")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+	var devices []DeviceInfo
+	if err := json.NewDecoder(resp.Body).Decode(&devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// SetImage sets a button's image, which is sent as a PNG
+func (c *Client) SetImage(serial string, btnIndex int, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return c.post(keyPath(serial, btnIndex, "image"), buf.Bytes())
+}
+
+// SetImageData sets a button's image from an encoded PNG, JPEG or GIF
+func (c *Client) SetImageData(serial string, btnIndex int, data []byte) error {
+	return c.post(keyPath(serial, btnIndex, "image"), data)
+}
+
+// SetColour sets a button to a solid colour
+func (c *Client) SetColour(serial string, btnIndex int, colour color.Color) error {
+	r, g, b, _ := colour.RGBA()
+	body := fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+	return c.post(keyPath(serial, btnIndex, "colour"), []byte(body))
+}
+
+// SetText sets a button to show text, white on black
+func (c *Client) SetText(serial string, btnIndex int, text string) error {
+	return c.post(keyPath(serial, btnIndex, "text"), []byte(text))
+}
+
+// SetBrightness sets a device's brightness, as a percentage
+func (c *Client) SetBrightness(serial string, pct int) error {
+	return c.post("/devices/"+serial+"/brightness", []byte(strconv.Itoa(pct)))
+}
+
+// ClearButtons blanks every button of a device
+func (c *Client) ClearButtons(serial string) error {
+	return c.post("/devices/"+serial+"/clear", nil)
+}
+
+// Events follows a device's events until the context is cancelled or the connection is lost, when the channel is
+// closed
+func (c *Client) Events(ctx context.Context, serial string) (<-chan Event, error) {
+	req, err := http.NewRequest(http.MethodGet, c.base+"/devices/"+serial+"/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var e Event
+			if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e) != nil {
+				continue
+			}
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func keyPath(serial string, btnIndex int, what string) string {
+	return fmt.Sprintf("/devices/%s/keys/%d/%s", serial, btnIndex, what)
+}
+
+func (c *Client) post(path string, body []byte) error {
+	resp, err := c.http.Post(c.base+path, "application/octet-stream", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// checkResponse turns an unsuccessful response into an error, using the message the server sent
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(message)))
+}
@@ -0,0 +1,73 @@
+package httpapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// MetricsHandler serves the metrics of every device in a Manager in the Prometheus text format, for scraping
+// without depending on the Prometheus client library.  Server also serves it at /metrics.
+func MetricsHandler(m *streamdeck.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetrics(w, m.GetDevices())
+	})
+}
+
+func writeMetrics(w io.Writer, devices []*streamdeck.Device) {
+	type sample struct {
+		labels string
+		m      streamdeck.Metrics
+	}
+	samples := make([]sample, 0, len(devices))
+	for _, d := range devices {
+		samples = append(samples, sample{
+			labels: fmt.Sprintf("serial=%q,model=%q", d.GetSerial(), d.GetName()),
+			m:      d.GetMetrics(),
+		})
+	}
+
+	counter := func(name string, help string, value func(streamdeck.Metrics) uint64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, s := range samples {
+			fmt.Fprintf(w, "%s{%s} %d\n", name, s.labels, value(s.m))
+		}
+	}
+	counter("streamdeck_frames_written_total", "Images written to buttons or areas.",
+		func(m streamdeck.Metrics) uint64 { return m.FramesWritten })
+	counter("streamdeck_frames_aborted_total", "Images abandoned part way through for a newer one.",
+		func(m streamdeck.Metrics) uint64 { return m.FramesAborted })
+	counter("streamdeck_bytes_sent_total", "Bytes of image reports sent, including headers and padding.",
+		func(m streamdeck.Metrics) uint64 { return m.BytesSent })
+	counter("streamdeck_write_errors_total", "Image reports the device didn't accept.",
+		func(m streamdeck.Metrics) uint64 { return m.WriteErrors })
+	counter("streamdeck_read_errors_total", "Failed reads, after which the device is taken as disconnected.",
+		func(m streamdeck.Metrics) uint64 { return m.ReadErrors })
+
+	fmt.Fprintf(w, "# HELP streamdeck_events_total Input events by type.\n# TYPE streamdeck_events_total counter\n")
+	for _, s := range samples {
+		types := make([]string, 0, len(s.m.Events))
+		for t := range s.m.Events {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			fmt.Fprintf(w, "streamdeck_events_total{%s,type=%q} %d\n", s.labels, t, s.m.Events[t])
+		}
+	}
+
+	name := "streamdeck_write_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Time taken to write an image.\n# TYPE %s histogram\n", name, name)
+	for _, s := range samples {
+		for i, bucket := range streamdeck.WriteLatencyBuckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%g\"} %d\n", name, s.labels, bucket.Seconds(), s.m.WriteLatencyCounts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, s.labels, s.m.FramesWritten)
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", name, s.labels, s.m.WriteLatencySum.Seconds())
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, s.labels, s.m.FramesWritten)
+	}
+}
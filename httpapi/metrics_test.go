@@ -0,0 +1,104 @@
+package httpapi
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+// sampleLine is a sample in the Prometheus text format: a name, its labels and a value
+var sampleLine = regexp.MustCompile(`^([a-z_]+)\{([^{}]*)\} ([0-9.e+-]+)$`)
+
+// TestMetricsFormat checks the text exposition of a deck's metrics: every family has its HELP and TYPE before its
+// samples, every sample is name{labels} value, and the counts are those of what the deck did
+func TestMetricsFormat(t *testing.T) {
+	d, mock, err := streamdecktest.Open(0x6c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	events := make(chan bool, 2)
+	d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, pressed bool) {
+		if err == nil {
+			events <- pressed
+		}
+	})
+	if err := d.WriteColorToButton(0, color.White); err != nil {
+		t.Fatal(err)
+	}
+	mock.TapButton(3)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-events:
+		case <-time.After(time.Second):
+			t.Fatal("The tap didn't arrive")
+		}
+	}
+
+	var b bytes.Buffer
+	writeMetrics(&b, []*streamdeck.Device{d})
+	helped := make(map[string]bool)
+	typed := make(map[string]string)
+	samples := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case strings.HasPrefix(line, "# HELP ") && len(fields) > 3:
+			helped[fields[2]] = true
+		case strings.HasPrefix(line, "# TYPE ") && len(fields) == 4:
+			if !helped[fields[2]] {
+				t.Errorf("%s has a TYPE before its HELP", fields[2])
+			}
+			typed[fields[2]] = fields[3]
+		default:
+			m := sampleLine.FindStringSubmatch(line)
+			if m == nil {
+				t.Errorf("Malformed line %q", line)
+				continue
+			}
+			family := m[1]
+			for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+				if typed[strings.TrimSuffix(family, suffix)] == "histogram" {
+					family = strings.TrimSuffix(family, suffix)
+				}
+			}
+			if typed[family] == "" {
+				t.Errorf("%s comes before its TYPE", m[1])
+			}
+			samples[m[1]+"{"+m[2]+"}"] = m[3]
+		}
+	}
+
+	labels := fmt.Sprintf("serial=%q,model=%q", d.GetSerial(), d.GetName())
+	for _, name := range []string{"streamdeck_frames_written_total", "streamdeck_events_total"} {
+		if typed[name] != "counter" {
+			t.Errorf("%s is a %q, not a counter", name, typed[name])
+		}
+	}
+	if name := "streamdeck_write_duration_seconds"; typed[name] != "histogram" {
+		t.Errorf("%s is a %q, not a histogram", name, typed[name])
+	}
+	for sample, want := range map[string]string{
+		"streamdeck_frames_written_total{" + labels + "}":                    "1",
+		"streamdeck_frames_aborted_total{" + labels + "}":                    "0",
+		"streamdeck_read_errors_total{" + labels + "}":                       "0",
+		"streamdeck_events_total{" + labels + `,type="buttonPress"}`:         "1",
+		"streamdeck_events_total{" + labels + `,type="buttonRelease"}`:       "1",
+		"streamdeck_write_duration_seconds_bucket{" + labels + `,le="+Inf"}`: "1",
+		"streamdeck_write_duration_seconds_count{" + labels + "}":            "1",
+	} {
+		if got, ok := samples[sample]; !ok {
+			t.Errorf("There is no %s", sample)
+		} else if got != want {
+			t.Errorf("%s is %s, not %s", sample, got, want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package httpapi is an embeddable HTTP server for controlling decks from scripts and other programs: setting
+// button images, colours, text and brightness, and following button and encoder events as server-sent events.
+//
+// Every device open in a Manager is available, under its serial number:
+//
+//	GET  /devices                           List the devices, as JSON
+//	POST /devices/{serial}/keys/{n}/image   Set a button image; the body is a PNG, JPEG or GIF
+//	POST /devices/{serial}/keys/{n}/colour  Set a button colour; the body is a colour such as "#ff8000"
+//	POST /devices/{serial}/keys/{n}/text    Set a button to show text; the body is the text
+//	POST /devices/{serial}/brightness       Set the brightness; the body is a percentage
+//	POST /devices/{serial}/clear            Blank every button
+//	GET  /devices/{serial}/events           Server-sent events, one JSON object per event
+//	GET  /metrics                           Metrics of every device, in the Prometheus text format
+//
+// For example:
+//
+//	curl --data-binary @logo.png http://localhost:8080/devices/AL12H1A00001/keys/0/image
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"  // Decoders for button images
+	_ "image/jpeg" // ...
+	_ "image/png"  // ...
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// maxBodySize limits request bodies, which are at most a button image
+const maxBodySize = 8 << 20
+
+// Event is a button or encoder event, as sent by /devices/{serial}/events
+type Event struct {
+	Type    string `json:"type"` // "button", "encoderPress", "encoderRotate", "touch" or "swipe"
+	Index   int    `json:"index"`
+	Pressed bool   `json:"pressed,omitempty"`
+	Pulses  int    `json:"pulses,omitempty"`
+	X       int    `json:"x,omitempty"`
+	Y       int    `json:"y,omitempty"`
+	XEnd    int    `json:"xEnd,omitempty"`
+	YEnd    int    `json:"yEnd,omitempty"`
+}
+
+// DeviceInfo describes a device, as listed by /devices
+type DeviceInfo struct {
+	Serial       string                  `json:"serial"`
+	Name         string                  `json:"name"`
+	Rows         uint                    `json:"rows"`
+	Cols         uint                    `json:"cols"`
+	ImageSize    int                     `json:"imageSize"`
+	Capabilities streamdeck.Capabilities `json:"capabilities"`
+}
+
+// Server serves the HTTP API for the devices in a Manager
+type Server struct {
+	manager *streamdeck.Manager
+
+	lock        sync.Mutex
+	listening   map[*streamdeck.Device][]func() // Removes the listeners, once the last subscriber has gone
+	subscribers map[*streamdeck.Device]map[chan Event]bool
+}
+
+// NewServer creates a Server for the devices in a Manager; it is an http.Handler, to be served with
+// http.ListenAndServe or mounted in a larger server with http.StripPrefix
+func NewServer(m *streamdeck.Manager) *Server {
+	return &Server{
+		manager:     m,
+		listening:   make(map[*streamdeck.Device][]func()),
+		subscribers: make(map[*streamdeck.Device]map[chan Event]bool),
+	}
+}
+
+// ServeHTTP routes a request to its endpoint
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 1 && parts[0] == "metrics" && r.Method == http.MethodGet {
+		MetricsHandler(s.manager).ServeHTTP(w, r)
+		return
+	}
+	if len(parts) == 0 || parts[0] != "devices" {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.listDevices(w)
+		return
+	}
+
+	d := s.manager.GetDevice(parts[1])
+	if d == nil {
+		http.Error(w, "No such device", http.StatusNotFound)
+		return
+	}
+	endpoint := strings.Join(parts[2:], "/")
+	if endpoint == "events" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.streamEvents(w, r, d)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch {
+	case endpoint == "brightness":
+		pct, err := strconv.Atoi(strings.TrimSpace(string(body)))
+		if err != nil || pct < 0 || pct > 100 {
+			http.Error(w, "Brightness must be a percentage", http.StatusBadRequest)
+			return
+		}
+		d.SetBrightness(pct)
+	case endpoint == "clear":
+		d.ClearButtons()
+	case len(parts) == 5 && parts[2] == "keys":
+		err = s.writeKey(d, parts[3], parts[4], body)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listDevices(w http.ResponseWriter) {
+	devices := []DeviceInfo{}
+	for _, d := range s.manager.GetDevices() {
+		devices = append(devices, DeviceInfo{
+			Serial:       d.GetSerial(),
+			Name:         d.GetName(),
+			Rows:         d.GetButtonRows(),
+			Cols:         d.GetButtonCols(),
+			ImageSize:    d.GetImageSize().X,
+			Capabilities: d.Capabilities(),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(devices)
+}
+
+func (s *Server) writeKey(d *streamdeck.Device, key string, what string, body []byte) error {
+	btnIndex, err := strconv.Atoi(key)
+	if err != nil || btnIndex < 0 || btnIndex >= int(d.GetNumberOfButtons()) {
+		return fmt.Errorf("No such key %q", key)
+	}
+	switch what {
+	case "image":
+		img, _, err := image.Decode(bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		return d.WriteRawImageToButton(btnIndex, img)
+	case "colour", "color":
+		c, err := streamdeck.ParseColour(strings.TrimSpace(string(body)))
+		if err != nil {
+			return err
+		}
+		return d.WriteColorToButton(btnIndex, c)
+	case "text":
+		d.WriteTextToButton(btnIndex, string(body), color.White, color.Black)
+		return nil
+	}
+	return fmt.Errorf("Unknown key endpoint %q", what)
+}
+
+// streamEvents sends a device's events as server-sent events until the client goes away
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, d *streamdeck.Device) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	ch := s.subscribe(d)
+	defer s.unsubscribe(d, ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case e := <-ch:
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// subscribe adds a subscriber to a device's events; the first subscriber adds one set of listeners, which fan
+// events out to whoever is subscribed until the last one unsubscribes
+func (s *Server) subscribe(d *streamdeck.Device) chan Event {
+	ch := make(chan Event, 64)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.subscribers[d] == nil {
+		s.subscribers[d] = make(map[chan Event]bool)
+	}
+	s.subscribers[d][ch] = true
+	if s.listening[d] == nil {
+		s.listening[d] = []func(){
+			d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, pressed bool) {
+				if err == nil {
+					s.publish(d, Event{Type: "button", Index: btnIndex, Pressed: pressed})
+				}
+			}),
+			d.EncoderPress(func(encIndex int, d *streamdeck.Device, pressed bool) {
+				s.publish(d, Event{Type: "encoderPress", Index: encIndex, Pressed: pressed})
+			}),
+			d.EncoderRotate(func(encIndex int, d *streamdeck.Device, pulses int) {
+				s.publish(d, Event{Type: "encoderRotate", Index: encIndex, Pulses: pulses})
+			}),
+			d.TouchPush(func(d *streamdeck.Device, x, y uint16, hold bool) {
+				s.publish(d, Event{Type: "touch", X: int(x), Y: int(y), Pressed: hold})
+			}),
+			d.TouchSwipe(func(d *streamdeck.Device, xstart, ystart, xstop, ystop uint16) {
+				s.publish(d, Event{Type: "swipe", X: int(xstart), Y: int(ystart), XEnd: int(xstop), YEnd: int(ystop)})
+			}),
+		}
+	}
+	return ch
+}
+
+func (s *Server) unsubscribe(d *streamdeck.Device, ch chan Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.subscribers[d], ch)
+	if len(s.subscribers[d]) == 0 {
+		for _, remove := range s.listening[d] {
+			remove()
+		}
+		delete(s.listening, d)
+		delete(s.subscribers, d)
+	}
+}
+
+// publish passes an event to every subscriber; events are dropped for subscribers too slow to keep up, rather
+// than holding up the device
+func (s *Server) publish(d *streamdeck.Device, e Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for ch := range s.subscribers[d] {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
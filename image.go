@@ -8,70 +8,59 @@ import (
 	"image/color"
 	"image/draw"
 	_ "image/gif" // Allow gifs to be loaded
-	"image/jpeg"
 	_ "image/png" // Allow pngs to be loaded
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/disintegration/gift"
 	"golang.org/x/image/bmp"
 )
 
-func resizeAndRotate(img image.Image, width, height int, devname string) image.Image {
-	g, _ := deviceSpecifics(devname, width, height)
+func resizeAndRotate(img image.Image, width, height int, quirks Quirk) image.Image {
+	g := deviceSpecifics(quirks, width, height)
 	res := image.NewRGBA(g.Bounds(img.Bounds()))
 	g.Draw(res, img)
 	return res
 }
 
-func deviceSpecifics(devName string, width, height int) (*gift.GIFT, error) {
-	switch devName {
-	case "Streamdeck XL", "Streamdeck (original v2)", "Streamdeck MK2":
-		return gift.New(
-			gift.Resize(width, height, gift.LanczosResampling),
-			gift.Rotate180(),
-		), nil
-	case "Streamdeck Plus":
-		return gift.New(
-			gift.Resize(width, height, gift.LanczosResampling),
-		), nil
-	case "Streamdeck Neo":
-		return gift.New(
-			gift.Resize(width, height, gift.LanczosResampling),
-			gift.Rotate180(),
-		), nil
-	case "Streamdeck Mini":
-		return gift.New(
-			gift.Resize(width, height, gift.LanczosResampling),
-			gift.Rotate90(),
-			gift.FlipVertical(),
-		), nil
-	case "Streamdeck (original)":
-		return gift.New(
-			gift.Resize(width, height, gift.LanczosResampling),
-			gift.Rotate180(),
-		), nil
-	default:
-		return nil, errors.New(fmt.Sprintf("Unsupported Device: %s", devName))
+// placeInArea resizes the image to fit the given area, and places it there within an otherwise black frame
+func placeInArea(img image.Image, area image.Rectangle, frameSize image.Point) image.Image {
+	g := gift.New(gift.Resize(area.Dx(), area.Dy(), gift.LanczosResampling))
+	resized := image.NewRGBA(g.Bounds(img.Bounds()))
+	g.Draw(resized, img)
+
+	frame := getSolidColourImage(color.Black, frameSize.X)
+	draw.Draw(frame, area, resized, image.Point{0, 0}, draw.Src)
+	return frame
+}
+
+func deviceSpecifics(quirks Quirk, width, height int) *gift.GIFT {
+	g := gift.New(gift.Resize(width, height, gift.LanczosResampling))
+	if quirks&QuirkRotateImage180 != 0 {
+		g.Add(gift.Rotate180())
+	}
+	if quirks&QuirkRotateImage90 != 0 {
+		g.Add(gift.Rotate90())
+	}
+	if quirks&QuirkFlipImageVertical != 0 {
+		g.Add(gift.FlipVertical())
 	}
+	return g
 }
 
-func getImageForButton(img image.Image, btnFormat string) ([]byte, error) {
+func getImageForButton(img image.Image, btnFormat string, quirks Quirk) ([]byte, error) {
+	if quirks&QuirkOpaqueImage != 0 {
+		img = getOpaqueImage(img)
+	}
+
 	var b bytes.Buffer
 	switch btnFormat {
 	case "JPEG":
-		jpeg.Encode(&b, img, &jpeg.Options{Quality: 100})
-	case "BMP":
-		// Opaque images are necessary, otherwise you won't get anything but black. So, lets remove any alpha channel without regard to pre-multiplied alpha (could be done smarter, but we assume there is no significant transparency in the image in the first place)
-		if !img.(*image.RGBA).Opaque() {
-			bounds := img.(*image.RGBA).Bounds()
-			for x := bounds.Min.X; x < bounds.Max.X; x++ {
-				for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-					r, g, b, _ := img.(*image.RGBA).At(x, y).RGBA()
-					img.(*image.RGBA).Set(x, y, color.RGBA{uint8(r), uint8(g), uint8(b), 255})
-				}
-			}
+		if err := encodeJPEG(&b, img, 100); err != nil {
+			return nil, err
 		}
-
+	case "BMP":
 		bmp.Encode(&b, img)
 	default:
 		return nil, errors.New("Unknown button image format: " + btnFormat)
@@ -79,6 +68,25 @@ func getImageForButton(img image.Image, btnFormat string) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// getOpaqueImage removes any alpha channel without regard to pre-multiplied alpha (could be done smarter, but we assume there is no significant transparency in the image in the first place)
+func getOpaqueImage(img image.Image) image.Image {
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+	if !rgba.Opaque() {
+		bounds := rgba.Bounds()
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				r, g, b, _ := rgba.At(x, y).RGBA()
+				rgba.Set(x, y, color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 255})
+			}
+		}
+	}
+	return rgba
+}
+
 func getSolidColourImage(colour color.Color, btnSize int) *image.RGBA {
 	img := image.NewRGBA(image.Rect(0, 0, btnSize, btnSize))
 	//colour := color.RGBA{red, green, blue, 0}
@@ -86,6 +94,17 @@ func getSolidColourImage(colour color.Color, btnSize int) *image.RGBA {
 	return img
 }
 
+// ParseColour parses a colour given in hex, as "#ff8000" or "ff8000", the form used by the config files and the
+// remote control packages
+func ParseColour(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return nil, fmt.Errorf("Invalid colour %q; it should be like #ff8000", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, nil
+}
+
 func getImageFile(filename string) (image.Image, error) {
 	f, err := os.Open(filename)
 	if err != nil {
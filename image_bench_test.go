@@ -0,0 +1,51 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// benchImage is a button-sized image with some detail in it, so that encoding has work to do
+func benchImage(size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{40, 40, 40, 255}), image.Point{}, draw.Src)
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y += 4 {
+			img.Set(x, y, color.RGBA{uint8(x * 2), uint8(y * 2), 128, 255})
+		}
+	}
+	return img
+}
+
+// BenchmarkEncodeJPEG runs once for each registered encoder; build with -tags turbojpeg to compare libjpeg-turbo
+func BenchmarkEncodeJPEG(b *testing.B) {
+	img := benchImage(120)
+	defer SetJPEGEncoder(StandardJPEGEncoder)
+	for _, name := range JPEGEncoders() {
+		b.Run(name, func(b *testing.B) {
+			SetJPEGEncoder(name)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				getImageForButton(img, "JPEG", 0)
+			}
+		})
+	}
+}
+
+func BenchmarkEncodeBMP(b *testing.B) {
+	img := benchImage(72)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		getImageForButton(img, "BMP", QuirkOpaqueImage)
+	}
+}
+
+func BenchmarkResizeAndRotate(b *testing.B) {
+	img := benchImage(256)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resizeAndRotate(img, 96, 96, QuirkRotateImage180)
+	}
+}
@@ -0,0 +1,38 @@
+package streamdeck
+
+import "time"
+
+// SetInactivityTimeout calls f, on its own goroutine, once nothing has been pressed, turned or touched for the
+// given time; the countdown starts again with the next press.  A timeout of zero turns it off.
+func (sd *StreamDeck) SetInactivityTimeout(timeout time.Duration, f func()) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	if sd.inactivityTimer != nil {
+		sd.inactivityTimer.Stop()
+		sd.inactivityTimer = nil
+	}
+	if timeout <= 0 || f == nil {
+		return
+	}
+	sd.inactivityTimer = time.AfterFunc(timeout, f)
+	sd.inactivityTimeout = timeout
+}
+
+// ReturnToPageAfter switches back to the named page (for example the home page) once the deck has been left
+// alone for the given time, see SetInactivityTimeout
+func (sd *StreamDeck) ReturnToPageAfter(name string, timeout time.Duration) {
+	sd.SetInactivityTimeout(timeout, func() {
+		if sd.GetPage().GetName() != name {
+			sd.SetPage(name)
+		}
+	})
+}
+
+// activity restarts the inactivity countdown
+func (sd *StreamDeck) activity() {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	if sd.inactivityTimer != nil {
+		sd.inactivityTimer.Reset(sd.inactivityTimeout)
+	}
+}
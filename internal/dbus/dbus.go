@@ -0,0 +1,777 @@
+// Package dbus is a minimal D-Bus client, enough for offering a service on the session bus (taking a name,
+// answering method calls and emitting signals) without adding a dependency.  Values are marshalled from and to
+// plain Go types: byte, bool, int16, uint16, int32, uint32, int64, uint64, float64 and string, []byte for "ay",
+// []string for "as" and []interface{} for other arrays and structs.
+package dbus
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Message types
+const (
+	MethodCall   = 1
+	MethodReturn = 2
+	Error        = 3
+	Signal       = 4
+)
+
+// FlagNoReplyExpected is set on method calls whose caller doesn't want an answer
+const FlagNoReplyExpected = 0x1
+
+// Header field codes
+const (
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSender      = 7
+	fieldSignature   = 8
+)
+
+// maxMessageSize is the largest message the specification allows
+const maxMessageSize = 1 << 27
+
+// Message is a D-Bus message; Body holds one value for each complete type in Signature
+type Message struct {
+	Type        byte
+	Flags       byte
+	Serial      uint32
+	Path        string
+	Interface   string
+	Member      string
+	ErrorName   string
+	ReplySerial uint32
+	Destination string
+	Sender      string
+	Signature   string
+	Body        []interface{}
+}
+
+// Conn is a connection to a message bus
+type Conn struct {
+	conn      net.Conn
+	r         *bufio.Reader
+	writeLock sync.Mutex
+
+	lock    sync.Mutex
+	serial  uint32
+	pending map[uint32]chan *Message
+	handler func(*Message)
+	done    chan struct{}
+}
+
+// SessionBus connects to the session bus given by DBUS_SESSION_BUS_ADDRESS (or the usual one for this user, if
+// that isn't set), authenticating as this user
+func SessionBus() (*Conn, error) {
+	address := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	if address == "" {
+		address = fmt.Sprintf("unix:path=/run/user/%d/bus", os.Getuid())
+	}
+	var err error
+	for _, a := range strings.Split(address, ";") {
+		var conn net.Conn
+		if conn, err = dialAddress(a); err == nil {
+			return newConn(conn)
+		}
+	}
+	return nil, err
+}
+
+// dialAddress connects to a bus address such as "unix:path=/run/user/1000/bus"; only Unix sockets are supported
+func dialAddress(address string) (net.Conn, error) {
+	if !strings.HasPrefix(address, "unix:") {
+		return nil, fmt.Errorf("Unsupported D-Bus address %q", address)
+	}
+	for _, kv := range strings.Split(strings.TrimPrefix(address, "unix:"), ",") {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			continue
+		}
+		value, err := url.PathUnescape(kv[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		switch kv[:i] {
+		case "path":
+			return net.Dial("unix", value)
+		case "abstract":
+			return net.Dial("unix", "@"+value)
+		}
+	}
+	return nil, fmt.Errorf("Unsupported D-Bus address %q", address)
+}
+
+func newConn(conn net.Conn) (*Conn, error) {
+	c := &Conn{
+		conn:    conn,
+		r:       bufio.NewReader(conn),
+		pending: make(map[uint32]chan *Message),
+		done:    make(chan struct{}),
+	}
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := io.WriteString(conn, "\x00AUTH EXTERNAL "+uid+"\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "OK ") {
+		conn.Close()
+		return nil, errors.New("The D-Bus daemon refused authentication")
+	}
+	if _, err := io.WriteString(conn, "BEGIN\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.readLoop()
+
+	if _, err := c.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello", ""); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// RequestName takes a well-known name on the bus, failing if something else already has it
+func (c *Conn) RequestName(name string) error {
+	const doNotQueue = 0x4
+	reply, err := c.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "RequestName", "su",
+		name, uint32(doNotQueue))
+	if err != nil {
+		return err
+	}
+	if len(reply.Body) == 0 || reply.Body[0] != uint32(1) {
+		return fmt.Errorf("The D-Bus name %s is already taken", name)
+	}
+	return nil
+}
+
+// SetHandler sets the function given incoming method calls, each on its own goroutine; it must answer them with
+// Reply or ReplyError
+func (c *Conn) SetHandler(f func(*Message)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.handler = f
+}
+
+// Call calls a method and waits for its answer; an error answer is returned as an error
+func (c *Conn) Call(destination, path, iface, member, signature string, args ...interface{}) (*Message, error) {
+	m := &Message{Type: MethodCall, Destination: destination, Path: path, Interface: iface, Member: member,
+		Signature: signature, Body: args}
+	ch := make(chan *Message, 1)
+	if err := c.send(m, ch); err != nil {
+		return nil, err
+	}
+	select {
+	case reply := <-ch:
+		if reply.Type == Error {
+			text := reply.ErrorName
+			if len(reply.Body) > 0 {
+				if s, ok := reply.Body[0].(string); ok {
+					text += ": " + s
+				}
+			}
+			return nil, errors.New(text)
+		}
+		return reply, nil
+	case <-c.done:
+		return nil, errors.New("D-Bus connection closed")
+	}
+}
+
+// Emit sends a signal
+func (c *Conn) Emit(path, iface, member, signature string, args ...interface{}) error {
+	return c.send(&Message{Type: Signal, Path: path, Interface: iface, Member: member, Signature: signature, Body: args}, nil)
+}
+
+// Reply answers a method call, unless the caller didn't want an answer
+func (c *Conn) Reply(call *Message, signature string, args ...interface{}) error {
+	if call.Flags&FlagNoReplyExpected != 0 {
+		return nil
+	}
+	return c.send(&Message{Type: MethodReturn, ReplySerial: call.Serial, Destination: call.Sender,
+		Signature: signature, Body: args}, nil)
+}
+
+// ReplyError answers a method call with an error
+func (c *Conn) ReplyError(call *Message, name, text string) error {
+	if call.Flags&FlagNoReplyExpected != 0 {
+		return nil
+	}
+	return c.send(&Message{Type: Error, ReplySerial: call.Serial, Destination: call.Sender, ErrorName: name,
+		Signature: "s", Body: []interface{}{text}}, nil)
+}
+
+// Done is closed when the connection is lost
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close disconnects from the bus
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// send gives the message a serial and sends it, first registering ch for the answer if it isn't nil
+func (c *Conn) send(m *Message, ch chan *Message) error {
+	c.lock.Lock()
+	c.serial++
+	m.Serial = c.serial
+	if ch != nil {
+		c.pending[m.Serial] = ch
+	}
+	c.lock.Unlock()
+
+	data, err := m.marshal()
+	if err == nil {
+		c.writeLock.Lock()
+		_, err = c.conn.Write(data)
+		c.writeLock.Unlock()
+	}
+	if err != nil && ch != nil {
+		c.lock.Lock()
+		delete(c.pending, m.Serial)
+		c.lock.Unlock()
+	}
+	return err
+}
+
+func (c *Conn) readLoop() {
+	defer close(c.done)
+	for {
+		m, err := readMessage(c.r)
+		if err != nil {
+			c.conn.Close()
+			return
+		}
+		switch m.Type {
+		case MethodReturn, Error:
+			c.lock.Lock()
+			ch := c.pending[m.ReplySerial]
+			delete(c.pending, m.ReplySerial)
+			c.lock.Unlock()
+			if ch != nil {
+				ch <- m
+			}
+		case MethodCall:
+			c.lock.Lock()
+			handler := c.handler
+			c.lock.Unlock()
+			if handler != nil {
+				go handler(m)
+			} else {
+				c.ReplyError(m, "org.freedesktop.DBus.Error.UnknownObject", "Nothing is served here")
+			}
+		}
+	}
+}
+
+// variant is a value along with its signature, for the header fields
+type variant struct {
+	signature string
+	value     interface{}
+}
+
+func (m *Message) marshal() ([]byte, error) {
+	body := &encoder{}
+	sig := m.Signature
+	for _, arg := range m.Body {
+		t, rest, err := nextType(sig)
+		if err != nil {
+			return nil, errors.New("Message body doesn't match its signature")
+		}
+		if err := body.value(t, arg); err != nil {
+			return nil, err
+		}
+		sig = rest
+	}
+	if sig != "" {
+		return nil, errors.New("Message body doesn't match its signature")
+	}
+
+	var fields []interface{}
+	addField := func(code byte, signature string, value interface{}) {
+		fields = append(fields, []interface{}{code, variant{signature, value}})
+	}
+	if m.Path != "" {
+		addField(fieldPath, "o", m.Path)
+	}
+	if m.Interface != "" {
+		addField(fieldInterface, "s", m.Interface)
+	}
+	if m.Member != "" {
+		addField(fieldMember, "s", m.Member)
+	}
+	if m.ErrorName != "" {
+		addField(fieldErrorName, "s", m.ErrorName)
+	}
+	if m.ReplySerial != 0 {
+		addField(fieldReplySerial, "u", m.ReplySerial)
+	}
+	if m.Destination != "" {
+		addField(fieldDestination, "s", m.Destination)
+	}
+	if m.Signature != "" {
+		addField(fieldSignature, "g", m.Signature)
+	}
+
+	header := &encoder{buf: []byte{'l', m.Type, m.Flags, 1}}
+	header.uint32(uint32(len(body.buf)))
+	header.uint32(m.Serial)
+	if err := header.value("a(yv)", fields); err != nil {
+		return nil, err
+	}
+	header.align(8)
+	return append(header.buf, body.buf...), nil
+}
+
+func readMessage(r io.Reader) (*Message, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if fixed[0] == 'B' {
+		order = binary.BigEndian
+	}
+	bodyLength := order.Uint32(fixed[4:])
+	fieldsLength := order.Uint32(fixed[12:])
+	if bodyLength > maxMessageSize || fieldsLength > maxMessageSize {
+		return nil, errors.New("D-Bus message too long")
+	}
+	headerLength := (16 + int(fieldsLength) + 7) &^ 7
+	rest := make([]byte, headerLength-16+int(bodyLength))
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, err
+	}
+
+	m := &Message{Type: fixed[1], Flags: fixed[2], Serial: order.Uint32(fixed[8:])}
+	header := &decoder{buf: append(fixed, rest[:headerLength-16]...), pos: 12, order: order}
+	fields, err := header.value("a(yv)")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields.([]interface{}) {
+		field := f.([]interface{})
+		value := field[1].(variant).value
+		switch field[0].(byte) {
+		case fieldPath:
+			m.Path, _ = value.(string)
+		case fieldInterface:
+			m.Interface, _ = value.(string)
+		case fieldMember:
+			m.Member, _ = value.(string)
+		case fieldErrorName:
+			m.ErrorName, _ = value.(string)
+		case fieldReplySerial:
+			m.ReplySerial, _ = value.(uint32)
+		case fieldDestination:
+			m.Destination, _ = value.(string)
+		case fieldSender:
+			m.Sender, _ = value.(string)
+		case fieldSignature:
+			m.Signature, _ = value.(string)
+		}
+	}
+
+	body := &decoder{buf: rest[headerLength-16:], order: order}
+	for sig := m.Signature; sig != ""; {
+		t, next, err := nextType(sig)
+		if err != nil {
+			return nil, err
+		}
+		v, err := body.value(t)
+		if err != nil {
+			return nil, err
+		}
+		m.Body = append(m.Body, v)
+		sig = next
+	}
+	return m, nil
+}
+
+// nextType splits the first complete type off a signature
+func nextType(sig string) (string, string, error) {
+	if sig == "" {
+		return "", "", errors.New("Signature ends early")
+	}
+	switch sig[0] {
+	case 'a':
+		t, rest, err := nextType(sig[1:])
+		return "a" + t, rest, err
+	case '(', '{':
+		depth := 0
+		for i := 0; i < len(sig); i++ {
+			switch sig[i] {
+			case '(', '{':
+				depth++
+			case ')', '}':
+				depth--
+				if depth == 0 {
+					return sig[:i+1], sig[i+1:], nil
+				}
+			}
+		}
+		return "", "", errors.New("Unbalanced signature")
+	}
+	return sig[:1], sig[1:], nil
+}
+
+// alignment gives the boundary a value of the given type starts on
+func alignment(t byte) int {
+	switch t {
+	case 'y', 'g', 'v':
+		return 1
+	case 'n', 'q':
+		return 2
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	}
+	return 4
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.align(4)
+	e.buf = append(e.buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func (e *encoder) uint64(v uint64) {
+	e.align(8)
+	e.uint32(uint32(v))
+	e.uint32(uint32(v >> 32))
+}
+
+func (e *encoder) value(t string, v interface{}) error {
+	bad := fmt.Errorf("Can't marshal %T as %q", v, t)
+	switch t[0] {
+	case 'y':
+		b, ok := v.(byte)
+		if !ok {
+			return bad
+		}
+		e.buf = append(e.buf, b)
+	case 'b':
+		b, ok := v.(bool)
+		if !ok {
+			return bad
+		}
+		if b {
+			e.uint32(1)
+		} else {
+			e.uint32(0)
+		}
+	case 'n', 'q':
+		var u uint16
+		switch n := v.(type) {
+		case int16:
+			u = uint16(n)
+		case uint16:
+			u = n
+		default:
+			return bad
+		}
+		e.align(2)
+		e.buf = append(e.buf, byte(u), byte(u>>8))
+	case 'i':
+		n, ok := v.(int32)
+		if !ok {
+			return bad
+		}
+		e.uint32(uint32(n))
+	case 'u':
+		n, ok := v.(uint32)
+		if !ok {
+			return bad
+		}
+		e.uint32(n)
+	case 'x':
+		n, ok := v.(int64)
+		if !ok {
+			return bad
+		}
+		e.uint64(uint64(n))
+	case 't':
+		n, ok := v.(uint64)
+		if !ok {
+			return bad
+		}
+		e.uint64(n)
+	case 'd':
+		f, ok := v.(float64)
+		if !ok {
+			return bad
+		}
+		e.uint64(math.Float64bits(f))
+	case 's', 'o':
+		s, ok := v.(string)
+		if !ok {
+			return bad
+		}
+		e.uint32(uint32(len(s)))
+		e.buf = append(append(e.buf, s...), 0)
+	case 'g':
+		s, ok := v.(string)
+		if !ok || len(s) > 255 {
+			return bad
+		}
+		e.buf = append(append(append(e.buf, byte(len(s))), s...), 0)
+	case 'v':
+		vv, ok := v.(variant)
+		if !ok {
+			return bad
+		}
+		if err := e.value("g", vv.signature); err != nil {
+			return err
+		}
+		return e.value(vv.signature, vv.value)
+	case 'a':
+		return e.array(t[1:], v)
+	case '(', '{':
+		members, ok := v.([]interface{})
+		if !ok {
+			return bad
+		}
+		e.align(8)
+		sig := t[1 : len(t)-1]
+		for _, member := range members {
+			mt, rest, err := nextType(sig)
+			if err != nil {
+				return bad
+			}
+			if err := e.value(mt, member); err != nil {
+				return err
+			}
+			sig = rest
+		}
+		if sig != "" {
+			return bad
+		}
+	default:
+		return bad
+	}
+	return nil
+}
+
+func (e *encoder) array(elem string, v interface{}) error {
+	e.uint32(0)
+	lengthAt := len(e.buf) - 4
+	e.align(alignment(elem[0]))
+	start := len(e.buf)
+	switch items := v.(type) {
+	case []byte:
+		if elem != "y" {
+			return fmt.Errorf("Can't marshal []byte as %q", "a"+elem)
+		}
+		e.buf = append(e.buf, items...)
+	case []string:
+		for _, item := range items {
+			if err := e.value(elem, item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range items {
+			if err := e.value(elem, item); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("Can't marshal %T as %q", v, "a"+elem)
+	}
+	binary.LittleEndian.PutUint32(e.buf[lengthAt:], uint32(len(e.buf)-start))
+	return nil
+}
+
+type decoder struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+var errShort = errors.New("D-Bus message ends early")
+
+func (d *decoder) align(n int) error {
+	d.pos = (d.pos + n - 1) / n * n
+	if d.pos > len(d.buf) {
+		return errShort
+	}
+	return nil
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return nil, errShort
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) fixed(size int) ([]byte, error) {
+	if err := d.align(size); err != nil {
+		return nil, err
+	}
+	return d.take(size)
+}
+
+func (d *decoder) value(t string) (interface{}, error) {
+	switch t[0] {
+	case 'y':
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'b':
+		b, err := d.fixed(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint32(b) != 0, nil
+	case 'n', 'q':
+		b, err := d.fixed(2)
+		if err != nil {
+			return nil, err
+		}
+		if t[0] == 'n' {
+			return int16(d.order.Uint16(b)), nil
+		}
+		return d.order.Uint16(b), nil
+	case 'i', 'u':
+		b, err := d.fixed(4)
+		if err != nil {
+			return nil, err
+		}
+		if t[0] == 'i' {
+			return int32(d.order.Uint32(b)), nil
+		}
+		return d.order.Uint32(b), nil
+	case 'x', 't', 'd':
+		b, err := d.fixed(8)
+		if err != nil {
+			return nil, err
+		}
+		switch t[0] {
+		case 'x':
+			return int64(d.order.Uint64(b)), nil
+		case 't':
+			return d.order.Uint64(b), nil
+		}
+		return math.Float64frombits(d.order.Uint64(b)), nil
+	case 's', 'o':
+		b, err := d.fixed(4)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(d.order.Uint32(b)) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:len(s)-1]), nil
+	case 'g':
+		n, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(n[0]) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:len(s)-1]), nil
+	case 'v':
+		sig, err := d.value("g")
+		if err != nil {
+			return nil, err
+		}
+		vt, rest, err := nextType(sig.(string))
+		if err != nil || rest != "" {
+			return nil, errors.New("Variant doesn't hold a single value")
+		}
+		v, err := d.value(vt)
+		if err != nil {
+			return nil, err
+		}
+		return variant{vt, v}, nil
+	case 'a':
+		b, err := d.fixed(4)
+		if err != nil {
+			return nil, err
+		}
+		length := int(d.order.Uint32(b))
+		elem := t[1:]
+		if err := d.align(alignment(elem[0])); err != nil {
+			return nil, err
+		}
+		if elem == "y" {
+			data, err := d.take(length)
+			if err != nil {
+				return nil, err
+			}
+			return append([]byte(nil), data...), nil
+		}
+		end := d.pos + length
+		if length > len(d.buf) || end > len(d.buf) {
+			return nil, errShort
+		}
+		var items []interface{}
+		var strs []string
+		for d.pos < end {
+			item, err := d.value(elem)
+			if err != nil {
+				return nil, err
+			}
+			if elem == "s" {
+				strs = append(strs, item.(string))
+			} else {
+				items = append(items, item)
+			}
+		}
+		if elem == "s" {
+			return strs, nil
+		}
+		return items, nil
+	case '(', '{':
+		if err := d.align(8); err != nil {
+			return nil, err
+		}
+		var members []interface{}
+		for sig := t[1 : len(t)-1]; sig != ""; {
+			mt, rest, err := nextType(sig)
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.value(mt)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, v)
+			sig = rest
+		}
+		return members, nil
+	}
+	return nil, fmt.Errorf("Unsupported D-Bus type %q", t)
+}
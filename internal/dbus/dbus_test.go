@@ -0,0 +1,191 @@
+package dbus
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signalAB is a signal with path "/a" and member "B", laid out by hand following the specification's
+// alignment rules: the header fields are an array of 8-aligned (yv) structs, and the header is padded to 8
+var signalAB = []byte{
+	'l', Signal, 0, 1, // Little endian, type, flags, version
+	0, 0, 0, 0, // Body length
+	1, 0, 0, 0, // Serial
+	26, 0, 0, 0, // Header fields length
+	fieldPath, 1, 'o', 0, 2, 0, 0, 0, '/', 'a', 0,
+	0, 0, 0, 0, 0,
+	fieldMember, 1, 's', 0, 1, 0, 0, 0, 'B', 0,
+	0, 0, 0, 0, 0, 0,
+}
+
+func TestMarshal(t *testing.T) {
+	m := &Message{Type: Signal, Serial: 1, Path: "/a", Member: "B"}
+	got, err := m.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, signalAB) {
+		t.Errorf("Signal is\n% x, not\n% x", got, signalAB)
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	m, err := readMessage(bytes.NewReader(signalAB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Message{Type: Signal, Serial: 1, Path: "/a", Member: "B"}); !reflect.DeepEqual(m, want) {
+		t.Errorf("Read %+v", m)
+	}
+
+	// The same message from a big endian peer
+	big := append([]byte(nil), signalAB...)
+	big[0] = 'B'
+	copy(big[8:], []byte{0, 0, 0, 1})
+	copy(big[12:], []byte{0, 0, 0, 26})
+	copy(big[20:], []byte{0, 0, 0, 2})
+	copy(big[36:], []byte{0, 0, 0, 1})
+	m, err = readMessage(bytes.NewReader(big))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Serial != 1 || m.Path != "/a" || m.Member != "B" {
+		t.Errorf("Read %+v from a big endian message", m)
+	}
+
+	if _, err := readMessage(bytes.NewReader(signalAB[:40])); err == nil {
+		t.Error("Read a truncated message")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	body := []interface{}{
+		byte(7), true, int16(-2), uint16(3), int32(-4), uint32(5), int64(-6), uint64(7), 1.5,
+		"text", "/an/object", "a(si)",
+		[]string{"one", "two"},
+		[]byte{1, 2, 3},
+		[]interface{}{[]interface{}{"x", int32(1)}, []interface{}{"y", int32(2)}},
+		[]interface{}{[]interface{}{"key", variant{"u", uint32(9)}}},
+	}
+	m := &Message{Type: MethodCall, Flags: FlagNoReplyExpected, Serial: 42, Path: "/deck", Interface: "com.example.Deck",
+		Member: "Everything", Destination: "com.example", Signature: "ybnqiuxtdsogasaya(si)a{sv}", Body: body}
+	data, err := m.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Read back\n%#v, not\n%#v", got, m)
+	}
+}
+
+func TestMarshalChecksSignature(t *testing.T) {
+	for _, m := range []*Message{
+		{Type: Signal, Path: "/", Member: "M", Signature: "s", Body: []interface{}{int32(1)}},
+		{Type: Signal, Path: "/", Member: "M", Signature: "ss", Body: []interface{}{"one"}},
+		{Type: Signal, Path: "/", Member: "M", Signature: "", Body: []interface{}{"extra"}},
+		{Type: Signal, Path: "/", Member: "M", Signature: "(si", Body: []interface{}{[]interface{}{"a", int32(1)}}},
+	} {
+		if _, err := m.marshal(); err == nil {
+			t.Errorf("Marshalled %v as %q", m.Body, m.Signature)
+		}
+	}
+}
+
+func TestNextType(t *testing.T) {
+	for _, tc := range []struct {
+		sig, first, rest string
+	}{
+		{"su", "s", "u"},
+		{"aasy", "aas", "y"},
+		{"(s(ii))b", "(s(ii))", "b"},
+		{"a{sv}", "a{sv}", ""},
+	} {
+		first, rest, err := nextType(tc.sig)
+		if err != nil || first != tc.first || rest != tc.rest {
+			t.Errorf("nextType(%q) = %q, %q, %v", tc.sig, first, rest, err)
+		}
+	}
+}
+
+// fakeBus answers the authentication and Hello on the other end of a pipe, then passes the messages it
+// receives on to the test
+func fakeBus(t *testing.T, conn net.Conn, received chan<- *Message) {
+	r := bufio.NewReader(conn)
+	auth, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(auth, "\x00AUTH EXTERNAL ") {
+		t.Errorf("Authenticated with %q", auth)
+		conn.Close()
+		return
+	}
+	conn.Write([]byte("OK 1234deadbeef\r\n"))
+	if begin, _ := r.ReadString('\n'); begin != "BEGIN\r\n" {
+		t.Errorf("Sent %q rather than BEGIN", begin)
+	}
+	for {
+		m, err := readMessage(r)
+		if err != nil {
+			return
+		}
+		if m.Member == "Hello" {
+			reply, _ := (&Message{Type: MethodReturn, Serial: 1, ReplySerial: m.Serial, Signature: "s",
+				Body: []interface{}{":1.1"}}).marshal()
+			conn.Write(reply)
+			continue
+		}
+		received <- m
+	}
+}
+
+func TestConn(t *testing.T) {
+	client, server := net.Pipe()
+	received := make(chan *Message, 4)
+	go fakeBus(t, server, received)
+	c, err := newConn(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Emit("/deck", "com.example.Deck", "ButtonPressed", "ib", int32(3), true); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case m := <-received:
+		if m.Type != Signal || m.Member != "ButtonPressed" || !reflect.DeepEqual(m.Body, []interface{}{int32(3), true}) {
+			t.Errorf("Bus received %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The signal didn't arrive")
+	}
+
+	// A method call is passed to the handler, whose reply goes back to the caller
+	c.SetHandler(func(m *Message) {
+		c.Reply(m, "s", "pong")
+	})
+	call, _ := (&Message{Type: MethodCall, Serial: 7, Path: "/deck", Member: "Ping"}).marshal()
+	server.Write(call)
+	select {
+	case m := <-received:
+		if m.Type != MethodReturn || m.ReplySerial != 7 || !reflect.DeepEqual(m.Body, []interface{}{"pong"}) {
+			t.Errorf("Bus received %+v as the reply", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The reply didn't arrive")
+	}
+
+	server.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done wasn't closed")
+	}
+}
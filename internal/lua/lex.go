@@ -0,0 +1,201 @@
+package lua
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// token is a lexical token; kind is "name", "number", "string", "eof", or the keyword or operator itself
+type token struct {
+	kind string
+	text string // The name, or the string's value
+	num  float64
+	line int
+}
+
+var keywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true, "false": true, "for": true,
+	"function": true, "if": true, "in": true, "local": true, "nil": true, "not": true, "or": true, "repeat": true,
+	"return": true, "then": true, "true": true, "until": true, "while": true,
+}
+
+// describe gives a token as it appears in error messages
+func (t token) describe() string {
+	switch t.kind {
+	case "name", "number":
+		return t.text
+	case "string":
+		return strconv.Quote(t.text)
+	case "eof":
+		return "the end"
+	}
+	return "'" + t.kind + "'"
+}
+
+// lex splits a script into tokens, ending with an "eof" token
+func lex(src string) ([]token, error) {
+	var tokens []token
+	line := 1
+	i := 0
+	for {
+		var err error
+		if i, line, err = skipSpace(src, i, line); err != nil {
+			return nil, err
+		}
+		if i >= len(src) {
+			return append(tokens, token{kind: "eof", line: line}), nil
+		}
+
+		c := src[i]
+		start := i
+		switch {
+		case c == '_' || isLetter(c):
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			word := src[start:i]
+			if keywords[word] {
+				tokens = append(tokens, token{kind: word, line: line})
+			} else {
+				tokens = append(tokens, token{kind: "name", text: word, line: line})
+			}
+
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			for i < len(src) && (isDigit(src[i]) || isLetter(src[i]) || src[i] == '.' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				i++
+			}
+			n, ok := parseNumber(src[start:i])
+			if !ok {
+				return nil, fmt.Errorf("line %d: malformed number %s", line, src[start:i])
+			}
+			tokens = append(tokens, token{kind: "number", text: src[start:i], num: n, line: line})
+
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(src) || src[i] == '\n' {
+					return nil, fmt.Errorf("line %d: unfinished string", line)
+				}
+				if src[i] == c {
+					i++
+					break
+				}
+				if src[i] != '\\' {
+					b.WriteByte(src[i])
+					i++
+					continue
+				}
+				i++
+				if i >= len(src) {
+					return nil, fmt.Errorf("line %d: unfinished string", line)
+				}
+				switch e := src[i]; e {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				case '\\', '"', '\'':
+					b.WriteByte(e)
+				case '\n':
+					b.WriteByte('\n')
+					line++
+				default:
+					if !isDigit(e) {
+						return nil, fmt.Errorf("line %d: invalid escape sequence \\%c", line, e)
+					}
+					j := i
+					for j < len(src) && j < i+3 && isDigit(src[j]) {
+						j++
+					}
+					n, _ := strconv.Atoi(src[i:j])
+					if n > 255 {
+						return nil, fmt.Errorf("line %d: escape sequence too large", line)
+					}
+					b.WriteByte(byte(n))
+					i = j - 1
+				}
+				i++
+			}
+			tokens = append(tokens, token{kind: "string", text: b.String(), line: line})
+
+		case strings.HasPrefix(src[i:], "[["):
+			end := strings.Index(src[i+2:], "]]")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unfinished long string", line)
+			}
+			s := src[i+2 : i+2+end]
+			tokens = append(tokens, token{kind: "string", text: strings.TrimPrefix(s, "\n"), line: line})
+			line += strings.Count(s, "\n")
+			i += 2 + end + 2
+
+		default:
+			op := ""
+			for _, o := range []string{"==", "~=", "<=", ">=", "..", "+", "-", "*", "/", "%", "^", "#", "<", ">", "=",
+				"(", ")", ",", ";"} {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("line %d: unexpected symbol %q", line, c)
+			}
+			tokens = append(tokens, token{kind: op, line: line})
+			i += len(op)
+		}
+	}
+}
+
+// skipSpace skips whitespace and comments, returning where the next token starts and its line
+func skipSpace(src string, i int, line int) (int, int, error) {
+	for i < len(src) {
+		switch {
+		case src[i] == '\n':
+			line++
+			i++
+		case src[i] == ' ' || src[i] == '\t' || src[i] == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "--[["):
+			end := strings.Index(src[i+4:], "]]")
+			if end < 0 {
+				return i, line, fmt.Errorf("line %d: unfinished long comment", line)
+			}
+			line += strings.Count(src[i:i+4+end], "\n")
+			i += 4 + end + 2
+		case strings.HasPrefix(src[i:], "--"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		default:
+			return i, line, nil
+		}
+	}
+	return i, line, nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseNumber parses a number as Lua does, in decimal or hex
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		n, err := strconv.ParseUint(s[2:], 16, 64)
+		return float64(n), err == nil
+	}
+	if s == "" || strings.ContainsAny(s, "xXnN_") { // Not hex, NaN, Inf or Go's digit separators
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil
+}
@@ -0,0 +1,539 @@
+// Package lua is an interpreter for a small subset of Lua 5.1, enough for the scripts attached to buttons:
+// local and global variables, if, while, repeat and numeric for loops, and calls of functions given by the host.
+// Values are nil, booleans, numbers (float64), strings and Functions; there are no tables, scripts can't define
+// functions, and functions return one value.  As a script can loop forever, Run can be given a limit on the
+// statements and loop iterations it goes through.
+package lua
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Value is a value in a script: nil, bool, float64, string or Function
+type Value interface{}
+
+// Function is a function scripts can call, provided by the host
+type Function func(args ...Value) (Value, error)
+
+// Chunk is a compiled script
+type Chunk struct {
+	body []stmt
+}
+
+// Compile parses a script
+func Compile(source string) (*Chunk, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != "eof" {
+		return nil, fmt.Errorf("line %d: 'eof' expected near %s", t.line, t.describe())
+	}
+	return &Chunk{body}, nil
+}
+
+// ErrTooLong is returned by Run when a script goes past its step limit
+var ErrTooLong = errors.New("script ran for too long")
+
+// Run runs a script with the given global variables, which it can change.  The functions print, tostring,
+// tonumber and type are there too, unless globals replaces them.  Unless maxSteps is 0, the script is stopped
+// with ErrTooLong once it has run that many statements and loop iterations; time spent in the host's functions
+// isn't counted.
+func (c *Chunk) Run(globals map[string]Value, maxSteps int) error {
+	if globals == nil {
+		globals = make(map[string]Value)
+	}
+	m := &machine{globals: globals, maxSteps: maxSteps}
+	_, err := m.block(c.body, nil)
+	return err
+}
+
+// ToString converts a value to a string as tostring does
+func ToString(v Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'g', 14, 64)
+	case string:
+		return v
+	}
+	return TypeName(v)
+}
+
+// ToNumber converts a value to a number as tonumber does, reporting whether it could
+func ToNumber(v Value) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		return parseNumber(v)
+	}
+	return 0, false
+}
+
+// TypeName returns the type of a value, as type does
+func TypeName(v Value) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case Function:
+		return "function"
+	}
+	return "userdata"
+}
+
+// StringArg returns a Function's argument as a string, numbers being converted, for the host's functions
+func StringArg(args []Value, i int) (string, error) {
+	if i < len(args) {
+		switch v := args[i].(type) {
+		case string:
+			return v, nil
+		case float64:
+			return ToString(v), nil
+		}
+	}
+	return "", badArgument(args, i, "string")
+}
+
+// NumberArg returns a Function's argument as a number, strings being converted, for the host's functions
+func NumberArg(args []Value, i int) (float64, error) {
+	if i < len(args) {
+		if n, ok := ToNumber(args[i]); ok {
+			return n, nil
+		}
+	}
+	return 0, badArgument(args, i, "number")
+}
+
+func badArgument(args []Value, i int, expected string) error {
+	var got Value
+	if i < len(args) {
+		got = args[i]
+	}
+	return fmt.Errorf("bad argument #%d (%s expected, got %s)", i+1, expected, TypeName(got))
+}
+
+// builtins are the functions every script has
+var builtins = map[string]Value{
+	"print": Function(func(args ...Value) (Value, error) {
+		s := make([]string, len(args))
+		for i, arg := range args {
+			s[i] = ToString(arg)
+		}
+		fmt.Println(strings.Join(s, "\t"))
+		return nil, nil
+	}),
+	"tostring": Function(func(args ...Value) (Value, error) {
+		if len(args) == 0 {
+			return nil, badArgument(args, 0, "value")
+		}
+		return ToString(args[0]), nil
+	}),
+	"tonumber": Function(func(args ...Value) (Value, error) {
+		if len(args) == 0 {
+			return nil, badArgument(args, 0, "value")
+		}
+		if n, ok := ToNumber(args[0]); ok {
+			return n, nil
+		}
+		return nil, nil
+	}),
+	"type": Function(func(args ...Value) (Value, error) {
+		if len(args) == 0 {
+			return nil, badArgument(args, 0, "value")
+		}
+		return TypeName(args[0]), nil
+	}),
+}
+
+// scope holds the local variables of a block
+type scope struct {
+	vars   map[string]Value
+	parent *scope
+}
+
+// control is how a block finished: normally, or by break or return
+type control int
+
+const (
+	normal control = iota
+	breaking
+	returning
+)
+
+type machine struct {
+	globals  map[string]Value
+	steps    int
+	maxSteps int
+}
+
+// step counts a statement or a run through a block, which every loop iteration is, against the step limit
+func (m *machine) step() error {
+	m.steps++
+	if m.maxSteps > 0 && m.steps > m.maxSteps {
+		return ErrTooLong
+	}
+	return nil
+}
+
+// block runs statements in a new scope
+func (m *machine) block(body []stmt, parent *scope) (control, error) {
+	return m.run(body, &scope{vars: make(map[string]Value), parent: parent})
+}
+
+func (m *machine) run(body []stmt, s *scope) (control, error) {
+	if err := m.step(); err != nil {
+		return normal, err
+	}
+	for _, st := range body {
+		if err := m.step(); err != nil {
+			return normal, err
+		}
+		c, err := m.statement(st, s)
+		if err != nil || c != normal {
+			return c, err
+		}
+	}
+	return normal, nil
+}
+
+func (m *machine) statement(st stmt, s *scope) (control, error) {
+	switch st := st.(type) {
+	case *localStmt:
+		values, err := m.values(st.values, len(st.names), s)
+		if err != nil {
+			return normal, err
+		}
+		for i, name := range st.names {
+			s.vars[name] = values[i]
+		}
+
+	case *assignStmt:
+		values, err := m.values(st.values, len(st.names), s)
+		if err != nil {
+			return normal, err
+		}
+		for i, name := range st.names {
+			m.set(name, values[i], s)
+		}
+
+	case *callStmt:
+		if _, err := m.call(st.call, s); err != nil {
+			return normal, err
+		}
+
+	case *ifStmt:
+		for i, cond := range st.conds {
+			v, err := m.eval(cond, s)
+			if err != nil {
+				return normal, err
+			}
+			if truthy(v) {
+				return m.block(st.blocks[i], s)
+			}
+		}
+		if st.elseBlock != nil {
+			return m.block(st.elseBlock, s)
+		}
+
+	case *whileStmt:
+		for {
+			v, err := m.eval(st.cond, s)
+			if err != nil {
+				return normal, err
+			}
+			if !truthy(v) {
+				break
+			}
+			c, err := m.block(st.body, s)
+			if err != nil || c == returning {
+				return c, err
+			}
+			if c == breaking {
+				break
+			}
+		}
+
+	case *repeatStmt:
+		for {
+			// The condition can see the body's locals
+			inner := &scope{vars: make(map[string]Value), parent: s}
+			c, err := m.run(st.body, inner)
+			if err != nil || c == returning {
+				return c, err
+			}
+			if c == breaking {
+				break
+			}
+			v, err := m.eval(st.cond, inner)
+			if err != nil {
+				return normal, err
+			}
+			if truthy(v) {
+				break
+			}
+		}
+
+	case *forStmt:
+		return m.forLoop(st, s)
+
+	case *doStmt:
+		return m.block(st.body, s)
+
+	case *breakStmt:
+		return breaking, nil
+
+	case *returnStmt:
+		if _, err := m.values(st.values, 0, s); err != nil {
+			return normal, err
+		}
+		return returning, nil
+	}
+	return normal, nil
+}
+
+func (m *machine) forLoop(st *forStmt, s *scope) (control, error) {
+	var bounds [3]float64
+	for i, e := range []expr{st.start, st.stop, st.step} {
+		if e == nil {
+			bounds[i] = 1 // The step, if it isn't given
+			continue
+		}
+		v, err := m.eval(e, s)
+		if err != nil {
+			return normal, err
+		}
+		n, ok := ToNumber(v)
+		if !ok {
+			return normal, fmt.Errorf("line %d: 'for' %s must be a number", st.line,
+				[]string{"initial value", "limit", "step"}[i])
+		}
+		bounds[i] = n
+	}
+	start, stop, step := bounds[0], bounds[1], bounds[2]
+	if step == 0 {
+		return normal, fmt.Errorf("line %d: 'for' step is zero", st.line)
+	}
+	for i := start; (step > 0 && i <= stop) || (step < 0 && i >= stop); i += step {
+		c, err := m.block(st.body, &scope{vars: map[string]Value{st.name: i}, parent: s})
+		if err != nil || c == returning {
+			return c, err
+		}
+		if c == breaking {
+			break
+		}
+	}
+	return normal, nil
+}
+
+// values evaluates a list of expressions, all of them, giving at least n values; missing ones are nil
+func (m *machine) values(exprs []expr, n int, s *scope) ([]Value, error) {
+	if len(exprs) > n {
+		n = len(exprs)
+	}
+	values := make([]Value, n)
+	for i, e := range exprs {
+		v, err := m.eval(e, s)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func (m *machine) get(name string, s *scope) Value {
+	for ; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+	}
+	if v, ok := m.globals[name]; ok {
+		return v
+	}
+	return builtins[name]
+}
+
+func (m *machine) set(name string, v Value, s *scope) {
+	for ; s != nil; s = s.parent {
+		if _, ok := s.vars[name]; ok {
+			s.vars[name] = v
+			return
+		}
+	}
+	if v == nil {
+		delete(m.globals, name)
+	} else {
+		m.globals[name] = v
+	}
+}
+
+func (m *machine) call(e *callExpr, s *scope) (Value, error) {
+	fn, err := m.eval(e.fn, s)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := fn.(Function)
+	if !ok {
+		if name, ok := e.fn.(*nameExpr); ok {
+			return nil, fmt.Errorf("line %d: attempt to call '%s' (a %s value)", e.line, name.name, TypeName(fn))
+		}
+		return nil, fmt.Errorf("line %d: attempt to call a %s value", e.line, TypeName(fn))
+	}
+	args, err := m.values(e.args, 0, s)
+	if err != nil {
+		return nil, err
+	}
+	v, err := f(args...)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %s", e.line, err)
+	}
+	return v, nil
+}
+
+func (m *machine) eval(e expr, s *scope) (Value, error) {
+	switch e := e.(type) {
+	case *constExpr:
+		return e.value, nil
+	case *nameExpr:
+		return m.get(e.name, s), nil
+	case *callExpr:
+		return m.call(e, s)
+	case *unaryExpr:
+		x, err := m.eval(e.x, s)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case "not":
+			return !truthy(x), nil
+		case "#":
+			str, ok := x.(string)
+			if !ok {
+				return nil, fmt.Errorf("line %d: attempt to get length of a %s value", e.line, TypeName(x))
+			}
+			return float64(len(str)), nil
+		}
+		n, ok := ToNumber(x)
+		if !ok {
+			return nil, fmt.Errorf("line %d: attempt to perform arithmetic on a %s value", e.line, TypeName(x))
+		}
+		return -n, nil
+	case *binaryExpr:
+		return m.binary(e, s)
+	}
+	return nil, errors.New("Unknown expression")
+}
+
+func (m *machine) binary(e *binaryExpr, s *scope) (Value, error) {
+	x, err := m.eval(e.x, s)
+	if err != nil {
+		return nil, err
+	}
+	// and and or only evaluate the right hand side if they need to
+	switch e.op {
+	case "and":
+		if !truthy(x) {
+			return x, nil
+		}
+		return m.eval(e.y, s)
+	case "or":
+		if truthy(x) {
+			return x, nil
+		}
+		return m.eval(e.y, s)
+	}
+	y, err := m.eval(e.y, s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.op {
+	case "==":
+		return equal(x, y), nil
+	case "~=":
+		return !equal(x, y), nil
+	case "<", ">", "<=", ">=":
+		if e.op == ">" || e.op == ">=" {
+			x, y = y, x
+		}
+		xn, xok := x.(float64)
+		yn, yok := y.(float64)
+		if xok && yok {
+			return xn < yn || (len(e.op) == 2 && xn == yn), nil
+		}
+		xs, xok := x.(string)
+		ys, yok := y.(string)
+		if xok && yok {
+			return xs < ys || (len(e.op) == 2 && xs == ys), nil
+		}
+		return nil, fmt.Errorf("line %d: attempt to compare %s with %s", e.line, TypeName(x), TypeName(y))
+	case "..":
+		for _, v := range []Value{x, y} {
+			switch v.(type) {
+			case string, float64:
+			default:
+				return nil, fmt.Errorf("line %d: attempt to concatenate a %s value", e.line, TypeName(v))
+			}
+		}
+		return ToString(x) + ToString(y), nil
+	}
+
+	xn, ok := ToNumber(x)
+	if !ok {
+		return nil, fmt.Errorf("line %d: attempt to perform arithmetic on a %s value", e.line, TypeName(x))
+	}
+	yn, ok := ToNumber(y)
+	if !ok {
+		return nil, fmt.Errorf("line %d: attempt to perform arithmetic on a %s value", e.line, TypeName(y))
+	}
+	switch e.op {
+	case "+":
+		return xn + yn, nil
+	case "-":
+		return xn - yn, nil
+	case "*":
+		return xn * yn, nil
+	case "/":
+		return xn / yn, nil
+	case "%":
+		return xn - math.Floor(xn/yn)*yn, nil
+	}
+	return math.Pow(xn, yn), nil
+}
+
+// truthy reports whether a value counts as true: everything but nil and false does
+func truthy(v Value) bool {
+	return v != nil && v != false
+}
+
+func equal(x, y Value) bool {
+	if _, ok := x.(Function); ok {
+		return false // Functions aren't comparable in Go
+	}
+	if _, ok := y.(Function); ok {
+		return false
+	}
+	return x == y
+}
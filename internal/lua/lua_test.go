@@ -0,0 +1,203 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+)
+
+// run runs a script, returning what it printed, one line per print
+func run(t *testing.T, source string, globals map[string]Value) (string, error) {
+	t.Helper()
+	chunk, err := Compile(source)
+	if err != nil {
+		return "", err
+	}
+	if globals == nil {
+		globals = make(map[string]Value)
+	}
+	var out strings.Builder
+	globals["print"] = Function(func(args ...Value) (Value, error) {
+		for i, arg := range args {
+			if i > 0 {
+				out.WriteString("\t")
+			}
+			out.WriteString(ToString(arg))
+		}
+		out.WriteString("\n")
+		return nil, nil
+	})
+	err = chunk.Run(globals, 0)
+	return out.String(), err
+}
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		source, want string
+	}{
+		{`print(1, "two", nil, true)`, "1\ttwo\tnil\ttrue\n"},
+		{`print(1 + 2 * 3, (1 + 2) * 3, 2 ^ 3 ^ 2, -2 ^ 2, 7 % 3, -7 % 3, 7 / 2)`, "7\t9\t512\t-4\t1\t2\t3.5\n"},
+		{`print("a" .. "b" .. 1 .. 2, "10" + 1, #"four", 0x10, 1e3, .5)`, "ab12\t11\t4\t16\t1000\t0.5\n"},
+		{`print(1 < 2, "a" < "b", 2 <= 2, 3 > 4, 1 == 1, "1" == 1, nil ~= false)`, "true\ttrue\ttrue\tfalse\ttrue\tfalse\ttrue\n"},
+		{`print(nil or "default", false and x, 1 and 2, not nil, not 0)`, "default\tfalse\t2\ttrue\tfalse\n"},
+		{`print(tostring(12), tonumber("0x1f"), tonumber("nope"), type(print), type(nil))`, "12\t31\tnil\tfunction\tnil\n"},
+		{"local s = 'it\\'s' print(s)", "it's\n"},
+		{`print("tab\tnew\nline \65 \"q\"", [[
+long
+string]])`, "tab\tnew\nline A \"q\"\tlong\nstring\n"},
+		{`
+			-- Locals shadow, and disappear at the end of their block
+			x = 1
+			local y = 2
+			do
+				local x = 10
+				y = x + y
+			end
+			print(x, y)
+		`, "1\t12\n"},
+		{`
+			local a, b, c = 1, 2
+			a, b = b, a
+			print(a, b, c)
+		`, "2\t1\tnil\n"},
+		{`
+			for i = 1, 3 do print(i) end
+			for i = 10, 1, -4 do print(i) end
+			for i = 1, 0 do print("never") end
+		`, "1\n2\n3\n10\n6\n2\n"},
+		{`
+			local n = 0
+			while true do
+				n = n + 1
+				if n == 3 then break end
+			end
+			print(n)
+		`, "3\n"},
+		{`
+			local n = 0
+			repeat
+				local done = n >= 2
+				n = n + 1
+			until done
+			print(n)
+		`, "3\n"},
+		{`
+			local odd = 0
+			for i = 1, 5 do
+				if i % 2 == 0 then
+					print("even", i)
+				elseif i == 5 then
+					print("last")
+				else
+					odd = odd + 1
+				end
+			end
+			print(odd)
+		`, "even\t2\neven\t4\nlast\n2\n"},
+		{`print "called without parentheses"`, "called without parentheses\n"},
+		{`
+			print("before")
+			if true then return end
+			print("after")
+		`, "before\n"},
+		{`
+			--[[ A long
+			comment ]] print("after comment"); ; print(1)
+		`, "after comment\n1\n"},
+	} {
+		got, err := run(t, tc.source, nil)
+		if err != nil {
+			t.Errorf("%s\nfailed: %s", tc.source, err)
+		} else if got != tc.want {
+			t.Errorf("%s\nprinted %q, not %q", tc.source, got, tc.want)
+		}
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	var got []Value
+	globals := map[string]Value{
+		"count": 1.0,
+		"gone":  "here",
+		"record": Function(func(args ...Value) (Value, error) {
+			got = append(got, args...)
+			return "recorded", nil
+		}),
+	}
+	if _, err := run(t, `count = count + 1; gone = nil; result = record("x", count)`, globals); err != nil {
+		t.Fatal(err)
+	}
+	if globals["count"] != 2.0 || globals["result"] != "recorded" {
+		t.Errorf("Globals are %v", globals)
+	}
+	if _, ok := globals["gone"]; ok {
+		t.Error("Setting a global to nil didn't remove it")
+	}
+	if len(got) != 2 || got[0] != "x" || got[1] != 2.0 {
+		t.Errorf("The function was called with %v", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	fail := Function(func(args ...Value) (Value, error) {
+		n, err := NumberArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	})
+	for _, tc := range []struct {
+		source, want string
+	}{
+		// Compiling
+		{`x = `, "line 1: unexpected symbol near the end"},
+		{"if x then\nprint(1)", "line 2: 'end' expected near the end"},
+		{`x = "unfinished`, "line 1: unfinished string"},
+		{`function f() end`, "line 1: scripts can't define functions"},
+		{`for k in x do end`, "line 1: only numeric for loops are supported"},
+		{`break`, "line 1: no loop to break"},
+		{`x = 1 2`, "line 1: unexpected symbol near 2"},
+		{`x`, "line 1: '=' expected near the end"},
+		{`(1)`, "line 1: syntax error near '('"},
+		{`return 1 print(2)`, "line 1: 'end' expected near print"},
+		{`x = 3 @ 4`, "line 1: unexpected symbol '@'"},
+		// Running
+		{"\n\nnothing()", "line 3: attempt to call 'nothing' (a nil value)"},
+		{`x = 1 + nil`, "line 1: attempt to perform arithmetic on a nil value"},
+		{`x = "a" < 1`, "line 1: attempt to compare string with number"},
+		{`x = "a" .. true`, "line 1: attempt to concatenate a boolean value"},
+		{`x = #5`, "line 1: attempt to get length of a number value"},
+		{`for i = 1, "x" do end`, "line 1: 'for' limit must be a number"},
+		{`for i = 1, 2, 0 do end`, "line 1: 'for' step is zero"},
+		{`fail("x")`, "line 1: bad argument #1 (number expected, got string)"},
+	} {
+		_, err := run(t, tc.source, map[string]Value{"fail": fail})
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%s\ngave %v, not %q", tc.source, err, tc.want)
+		}
+	}
+}
+
+func TestStepLimit(t *testing.T) {
+	for _, tc := range []struct {
+		source string
+		steps  int
+		ok     bool
+	}{
+		{`while true do end`, 1000, false},
+		{`repeat until false`, 1000, false},
+		{`for i = 1, 1e9 do end`, 1000, false},
+		{`x = 0 while x < 100 do x = x + 1 end`, 1000, true},
+		{`x = 0 while x < 1000 do x = x + 1 end`, 1000, false},
+	} {
+		chunk, err := Compile(tc.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = chunk.Run(nil, tc.steps)
+		if tc.ok && err != nil {
+			t.Errorf("%s with %d steps: %s", tc.source, tc.steps, err)
+		} else if !tc.ok && err != ErrTooLong {
+			t.Errorf("%s with %d steps gave %v", tc.source, tc.steps, err)
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package lua
+
+import "fmt"
+
+// The syntax tree: expressions...
+type expr interface{}
+
+type constExpr struct {
+	value Value
+}
+
+type nameExpr struct {
+	name string
+}
+
+type unaryExpr struct {
+	op   string
+	x    expr
+	line int
+}
+
+type binaryExpr struct {
+	op   string
+	x, y expr
+	line int
+}
+
+type callExpr struct {
+	fn   expr
+	args []expr
+	line int
+}
+
+// ...and statements
+type stmt interface{}
+
+type localStmt struct {
+	names  []string
+	values []expr
+}
+
+type assignStmt struct {
+	names  []string
+	values []expr
+}
+
+type callStmt struct {
+	call *callExpr
+}
+
+type ifStmt struct {
+	conds     []expr
+	blocks    [][]stmt
+	elseBlock []stmt
+}
+
+type whileStmt struct {
+	cond expr
+	body []stmt
+}
+
+type repeatStmt struct {
+	body []stmt
+	cond expr
+}
+
+type forStmt struct {
+	name              string
+	start, stop, step expr
+	body              []stmt
+	line              int
+}
+
+type doStmt struct {
+	body []stmt
+}
+
+type breakStmt struct{}
+
+type returnStmt struct {
+	values []expr
+}
+
+// Binary operator priorities, left and right, as in Lua: a right priority lower than the left makes an operator
+// right associative
+var binaryPriority = map[string][2]int{
+	"or":  {1, 1},
+	"and": {2, 2},
+	"<":   {3, 3},
+	">":   {3, 3},
+	"<=":  {3, 3},
+	">=":  {3, 3},
+	"~=":  {3, 3},
+	"==":  {3, 3},
+	"..":  {5, 4},
+	"+":   {6, 6},
+	"-":   {6, 6},
+	"*":   {7, 7},
+	"/":   {7, 7},
+	"%":   {7, 7},
+	"^":   {10, 9},
+}
+
+const unaryPriority = 8
+
+type parser struct {
+	tokens []token
+	pos    int
+	loops  int // How many loops the parser is in, for break
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != "eof" {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(kind string) bool {
+	if p.peek().kind == kind {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind string, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, fmt.Errorf("line %d: %s expected near %s", t.line, what, t.describe())
+	}
+	return t, nil
+}
+
+// blockEnds reports whether the next token ends a block
+func (p *parser) blockEnds() bool {
+	switch p.peek().kind {
+	case "eof", "end", "else", "elseif", "until":
+		return true
+	}
+	return false
+}
+
+func (p *parser) block() ([]stmt, error) {
+	var stmts []stmt
+	for !p.blockEnds() {
+		if p.accept(";") {
+			continue
+		}
+		if p.peek().kind == "return" {
+			p.next()
+			var values []expr
+			if !p.blockEnds() && p.peek().kind != ";" {
+				var err error
+				if values, err = p.exprList(); err != nil {
+					return nil, err
+				}
+			}
+			p.accept(";")
+			if !p.blockEnds() {
+				t := p.peek()
+				return nil, fmt.Errorf("line %d: 'end' expected near %s", t.line, t.describe())
+			}
+			return append(stmts, &returnStmt{values}), nil
+		}
+		s, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, s)
+	}
+	return stmts, nil
+}
+
+// blockTo parses a block and the keyword which must end it
+func (p *parser) blockTo(end string) ([]stmt, error) {
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(end, "'"+end+"'"); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// loopBody parses the body of a loop, up to the keyword ending it
+func (p *parser) loopBody(end string) ([]stmt, error) {
+	p.loops++
+	defer func() { p.loops-- }()
+	return p.blockTo(end)
+}
+
+func (p *parser) statement() (stmt, error) {
+	t := p.next()
+	switch t.kind {
+	case "local":
+		names, err := p.nameList()
+		if err != nil {
+			return nil, err
+		}
+		var values []expr
+		if p.accept("=") {
+			if values, err = p.exprList(); err != nil {
+				return nil, err
+			}
+		}
+		return &localStmt{names, values}, nil
+
+	case "if":
+		s := &ifStmt{}
+		for {
+			cond, err := p.expr(0)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect("then", "'then'"); err != nil {
+				return nil, err
+			}
+			block, err := p.block()
+			if err != nil {
+				return nil, err
+			}
+			s.conds = append(s.conds, cond)
+			s.blocks = append(s.blocks, block)
+			if !p.accept("elseif") {
+				break
+			}
+		}
+		if p.accept("else") {
+			block, err := p.block()
+			if err != nil {
+				return nil, err
+			}
+			s.elseBlock = block
+		}
+		if _, err := p.expect("end", "'end'"); err != nil {
+			return nil, err
+		}
+		return s, nil
+
+	case "while":
+		cond, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect("do", "'do'"); err != nil {
+			return nil, err
+		}
+		body, err := p.loopBody("end")
+		if err != nil {
+			return nil, err
+		}
+		return &whileStmt{cond, body}, nil
+
+	case "repeat":
+		body, err := p.loopBody("until")
+		if err != nil {
+			return nil, err
+		}
+		cond, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		return &repeatStmt{body, cond}, nil
+
+	case "for":
+		name, err := p.expect("name", "name")
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind == "in" {
+			return nil, fmt.Errorf("line %d: only numeric for loops are supported", t.line)
+		}
+		if _, err := p.expect("=", "'='"); err != nil {
+			return nil, err
+		}
+		s := &forStmt{name: name.text, line: t.line}
+		if s.start, err = p.expr(0); err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(",", "','"); err != nil {
+			return nil, err
+		}
+		if s.stop, err = p.expr(0); err != nil {
+			return nil, err
+		}
+		if p.accept(",") {
+			if s.step, err = p.expr(0); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.expect("do", "'do'"); err != nil {
+			return nil, err
+		}
+		if s.body, err = p.loopBody("end"); err != nil {
+			return nil, err
+		}
+		return s, nil
+
+	case "do":
+		body, err := p.blockTo("end")
+		if err != nil {
+			return nil, err
+		}
+		return &doStmt{body}, nil
+
+	case "break":
+		if p.loops == 0 {
+			return nil, fmt.Errorf("line %d: no loop to break", t.line)
+		}
+		return &breakStmt{}, nil
+
+	case "function":
+		return nil, fmt.Errorf("line %d: scripts can't define functions", t.line)
+	}
+
+	// An assignment or a call
+	p.pos--
+	e, err := p.primaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	if call, ok := e.(*callExpr); ok {
+		return &callStmt{call}, nil
+	}
+	name, ok := e.(*nameExpr)
+	if !ok {
+		return nil, fmt.Errorf("line %d: syntax error near %s", t.line, t.describe())
+	}
+	names := []string{name.name}
+	for p.accept(",") {
+		n, err := p.expect("name", "name")
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, n.text)
+	}
+	if _, err := p.expect("=", "'='"); err != nil {
+		return nil, err
+	}
+	values, err := p.exprList()
+	if err != nil {
+		return nil, err
+	}
+	return &assignStmt{names, values}, nil
+}
+
+func (p *parser) nameList() ([]string, error) {
+	var names []string
+	for {
+		t, err := p.expect("name", "name")
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, t.text)
+		if !p.accept(",") {
+			return names, nil
+		}
+	}
+}
+
+func (p *parser) exprList() ([]expr, error) {
+	var exprs []expr
+	for {
+		e, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+		if !p.accept(",") {
+			return exprs, nil
+		}
+	}
+}
+
+// expr parses an expression whose binary operators bind more tightly than limit
+func (p *parser) expr(limit int) (expr, error) {
+	var left expr
+	var err error
+	if t := p.peek(); t.kind == "not" || t.kind == "-" || t.kind == "#" {
+		p.next()
+		x, err := p.expr(unaryPriority)
+		if err != nil {
+			return nil, err
+		}
+		left = &unaryExpr{t.kind, x, t.line}
+	} else if left, err = p.simpleExpr(); err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		priority, ok := binaryPriority[t.kind]
+		if !ok || priority[0] <= limit {
+			return left, nil
+		}
+		p.next()
+		right, err := p.expr(priority[1])
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{t.kind, left, right, t.line}
+	}
+}
+
+func (p *parser) simpleExpr() (expr, error) {
+	switch t := p.peek(); t.kind {
+	case "number":
+		p.next()
+		return &constExpr{t.num}, nil
+	case "string":
+		p.next()
+		return &constExpr{t.text}, nil
+	case "nil":
+		p.next()
+		return &constExpr{nil}, nil
+	case "true", "false":
+		p.next()
+		return &constExpr{t.kind == "true"}, nil
+	case "function":
+		return nil, fmt.Errorf("line %d: scripts can't define functions", t.line)
+	}
+	return p.primaryExpr()
+}
+
+// primaryExpr parses a name or parenthesised expression, and any calls of it
+func (p *parser) primaryExpr() (expr, error) {
+	var e expr
+	switch t := p.next(); t.kind {
+	case "name":
+		e = &nameExpr{t.text}
+	case "(":
+		inner, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(")", "')'"); err != nil {
+			return nil, err
+		}
+		e = inner
+	default:
+		return nil, fmt.Errorf("line %d: unexpected symbol near %s", t.line, t.describe())
+	}
+
+	for {
+		switch t := p.peek(); t.kind {
+		case "(":
+			p.next()
+			var args []expr
+			if !p.accept(")") {
+				var err error
+				if args, err = p.exprList(); err != nil {
+					return nil, err
+				}
+				if _, err := p.expect(")", "')'"); err != nil {
+					return nil, err
+				}
+			}
+			e = &callExpr{e, args, t.line}
+		case "string": // f"text"
+			p.next()
+			e = &callExpr{e, []expr{&constExpr{t.text}}, t.line}
+		default:
+			return e, nil
+		}
+	}
+}
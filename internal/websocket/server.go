@@ -0,0 +1,29 @@
+package websocket
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Accept answers a client's handshake on a connection, for tests standing in for the applications the action
+// packs talk to.  What is sent is masked as a client's messages are; Dial's connections accept that.
+func Accept(conn net.Conn) (*Conn, error) {
+	r := bufio.NewReader(conn)
+	req, err := http.ReadRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") || req.Header.Get("Sec-WebSocket-Key") == "" {
+		conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+		return nil, errors.New("Not a WebSocket handshake")
+	}
+	_, err = conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(req.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n"))
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{conn: conn, r: r}, nil
+}
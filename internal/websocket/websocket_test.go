@@ -0,0 +1,239 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// The examples are those of RFC 6455: the handshake in section 1.3 and the frames in section 5.7
+
+func TestAcceptKey(t *testing.T) {
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Accept key is %q", got)
+	}
+}
+
+var rfcMask = [4]byte{0x37, 0xfa, 0x21, 0x3d}
+
+func TestAppendFrame(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		opcode  byte
+		payload []byte
+		want    []byte
+	}{
+		{"masked text", opText, []byte("Hello"), []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58}},
+		{"masked pong", opPong, []byte("Hello"), []byte{0x8a, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58}},
+		{"empty close", opClose, nil, []byte{0x88, 0x80, 0x37, 0xfa, 0x21, 0x3d}},
+	} {
+		if got := appendFrame(tc.opcode, tc.payload, rfcMask); !bytes.Equal(got, tc.want) {
+			t.Errorf("%s frame is % x, not % x", tc.name, got, tc.want)
+		}
+	}
+
+	// The extended lengths, from the 256 byte and 64KiB examples
+	got := appendFrame(opBinary, make([]byte, 256), rfcMask)
+	if want := []byte{0x82, 0xfe, 0x01, 0x00}; !bytes.Equal(got[:4], want) || len(got) != 4+4+256 {
+		t.Errorf("256 byte frame starts % x and is %d bytes", got[:4], len(got))
+	}
+	got = appendFrame(opBinary, make([]byte, 65536), rfcMask)
+	if want := []byte{0x82, 0xff, 0, 0, 0, 0, 0, 0x01, 0x00, 0x00}; !bytes.Equal(got[:10], want) || len(got) != 10+4+65536 {
+		t.Errorf("64KiB frame starts % x and is %d bytes", got[:10], len(got))
+	}
+}
+
+// pipe returns a Conn reading what is written to server, and writing to it
+func pipe() (*Conn, net.Conn) {
+	client, server := net.Pipe()
+	return &Conn{conn: client, r: bufio.NewReader(client)}, server
+}
+
+func TestReadMessage(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"unmasked text", []byte{0x81, 0x05, 0x48, 0x65, 0x6c, 0x6c, 0x6f}, "Hello"},
+		{"masked text", []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58}, "Hello"},
+		{"fragmented", []byte{0x01, 0x03, 0x48, 0x65, 0x6c, 0x80, 0x02, 0x6c, 0x6f}, "Hello"},
+		{"256 bytes", append([]byte{0x82, 0x7e, 0x01, 0x00}, bytes.Repeat([]byte{'x'}, 256)...), string(bytes.Repeat([]byte{'x'}, 256))},
+		{"64KiB", append([]byte{0x82, 0x7f, 0, 0, 0, 0, 0, 0x01, 0x00, 0x00}, bytes.Repeat([]byte{'y'}, 65536)...), string(bytes.Repeat([]byte{'y'}, 65536))},
+	} {
+		c := &Conn{r: bufio.NewReader(bytes.NewReader(tc.input))}
+		got, err := c.ReadMessage()
+		if err != nil || string(got) != tc.want {
+			t.Errorf("%s: read %d bytes (%v)", tc.name, len(got), err)
+		}
+	}
+}
+
+func TestReadMessageAnswersPing(t *testing.T) {
+	c, server := pipe()
+	defer server.Close()
+	go server.Write([]byte{0x89, 0x05, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x81, 0x02, 'h', 'i'}) // Ping, then text
+
+	messages := make(chan string, 1)
+	go func() {
+		message, _ := c.ReadMessage()
+		messages <- string(message)
+	}()
+
+	// The pong is masked with a random key, so is unmasked to check it
+	pong := make([]byte, 11)
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(server, pong); err != nil {
+		t.Fatal(err)
+	}
+	if pong[0] != 0x8a || pong[1] != 0x85 {
+		t.Fatalf("Pong starts % x", pong[:2])
+	}
+	for i := range pong[6:] {
+		pong[6+i] ^= pong[2+i%4]
+	}
+	if string(pong[6:]) != "Hello" {
+		t.Errorf("Pong carries %q, not the ping's payload", pong[6:])
+	}
+	if got := <-messages; got != "hi" {
+		t.Errorf("Read %q after the ping", got)
+	}
+}
+
+func TestReadMessageClose(t *testing.T) {
+	c, server := pipe()
+	defer server.Close()
+	go server.Write([]byte{0x88, 0x00})
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.ReadMessage()
+		errs <- err
+	}()
+	reply := make([]byte, 6)
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(server, reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply[0] != 0x88 || reply[1] != 0x80 {
+		t.Errorf("Close was answered with % x", reply)
+	}
+	if err := <-errs; err != io.EOF {
+		t.Errorf("ReadMessage returned %v after a close", err)
+	}
+}
+
+func TestDial(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		req, err := http.ReadRequest(r)
+		if err != nil || req.URL.Path != "/api" || req.Header.Get("Upgrade") != "websocket" ||
+			req.Header.Get("Sec-WebSocket-Version") != "13" {
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + acceptKey(req.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n"))
+		conn.Write([]byte{0x81, 0x02, 'o', 'k'})
+		frame := make([]byte, 2+4+5)
+		if _, err := io.ReadFull(r, frame); err == nil {
+			received <- frame
+		}
+	}()
+
+	c, err := Dial("ws://" + l.Addr().String() + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	message, err := c.ReadMessage()
+	if err != nil || string(message) != "ok" {
+		t.Fatalf("Read %q (%v)", message, err)
+	}
+	if err := c.WriteMessage([]byte("Hello")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case frame := <-received:
+		if frame[0] != 0x81 || frame[1] != 0x85 {
+			t.Errorf("Text frame starts % x; it should be final and masked", frame[:2])
+		}
+		for i := range frame[6:] {
+			frame[6+i] ^= frame[2+i%4]
+		}
+		if string(frame[6:]) != "Hello" {
+			t.Errorf("Server received %q", frame[6:])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server received nothing")
+	}
+}
+
+func TestDialRejectsBadAccept(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		http.ReadRequest(bufio.NewReader(conn))
+		conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: s3pPLMBiTxaQ9kYGzzhZRbK+xOo=\r\n\r\n"))
+	}()
+	if _, err := Dial("ws://" + l.Addr().String() + "/"); err == nil {
+		t.Error("Dial accepted a handshake answering another key")
+	}
+}
+
+func TestAccept(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		c, err := Accept(conn)
+		if err != nil {
+			conn.Close()
+			return
+		}
+		defer c.Close()
+		if message, err := c.ReadMessage(); err == nil {
+			c.WriteMessage(append([]byte("re: "), message...))
+		}
+	}()
+
+	c, err := Dial("ws://" + l.Addr().String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.WriteMessage([]byte("Hello")); err != nil {
+		t.Fatal(err)
+	}
+	if message, err := c.ReadMessage(); err != nil || string(message) != "re: Hello" {
+		t.Errorf("Read %q (%v)", message, err)
+	}
+}
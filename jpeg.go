@@ -0,0 +1,69 @@
+package streamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// JPEGEncoder encodes an image as a JPEG of the given quality (1-100).  It may be called from several goroutines
+// at once, see WriteRawImagesToButtons.
+type JPEGEncoder func(w io.Writer, img image.Image, quality int) error
+
+// StandardJPEGEncoder is the name of the default encoder, which uses image/jpeg
+const StandardJPEGEncoder = "image/jpeg"
+
+var (
+	jpegEncodersLock sync.Mutex
+	jpegEncoders     = map[string]JPEGEncoder{StandardJPEGEncoder: encodeStandardJPEG}
+	jpegEncoder      atomic.Value // JPEGEncoder
+)
+
+func init() {
+	jpegEncoder.Store(JPEGEncoder(encodeStandardJPEG))
+}
+
+func encodeStandardJPEG(w io.Writer, img image.Image, quality int) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+}
+
+// RegisterJPEGEncoder makes a JPEG encoder available to SetJPEGEncoder under the given name, replacing any
+// encoder of the same name.  Building with the turbojpeg tag registers "turbojpeg", which uses libjpeg-turbo.
+func RegisterJPEGEncoder(name string, enc JPEGEncoder) {
+	jpegEncodersLock.Lock()
+	defer jpegEncodersLock.Unlock()
+	jpegEncoders[name] = enc
+}
+
+// SetJPEGEncoder chooses the encoder used for all devices which take JPEG images, by the name it was registered
+// under
+func SetJPEGEncoder(name string) error {
+	jpegEncodersLock.Lock()
+	defer jpegEncodersLock.Unlock()
+	enc, ok := jpegEncoders[name]
+	if !ok {
+		return fmt.Errorf("No JPEG encoder named %q", name)
+	}
+	jpegEncoder.Store(enc)
+	return nil
+}
+
+// JPEGEncoders lists the names of the registered JPEG encoders
+func JPEGEncoders() []string {
+	jpegEncodersLock.Lock()
+	defer jpegEncodersLock.Unlock()
+	names := make([]string, 0, len(jpegEncoders))
+	for name := range jpegEncoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func encodeJPEG(w io.Writer, img image.Image, quality int) error {
+	return jpegEncoder.Load().(JPEGEncoder)(w, img, quality)
+}
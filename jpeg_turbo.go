@@ -0,0 +1,51 @@
+//go:build turbojpeg && cgo
+// +build turbojpeg,cgo
+
+package streamdeck
+
+// #cgo LDFLAGS: -lturbojpeg
+// #include <turbojpeg.h>
+import "C"
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"unsafe"
+)
+
+func init() {
+	RegisterJPEGEncoder("turbojpeg", encodeTurboJPEG)
+}
+
+// encodeTurboJPEG encodes with libjpeg-turbo, using the same 4:2:0 chroma subsampling as image/jpeg.  Handles
+// can't be shared between threads, so each call has its own.
+func encodeTurboJPEG(w io.Writer, img image.Image, quality int) error {
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+	bounds := rgba.Bounds()
+	if bounds.Empty() {
+		return errors.New("Can't encode an empty image")
+	}
+
+	handle := C.tjInitCompress()
+	if handle == nil {
+		return errors.New("Couldn't start libjpeg-turbo: " + C.GoString(C.tjGetErrorStr()))
+	}
+	defer C.tjDestroy(handle)
+
+	var buf *C.uchar
+	var size C.ulong
+	pix := (*C.uchar)(unsafe.Pointer(&rgba.Pix[rgba.PixOffset(bounds.Min.X, bounds.Min.Y)]))
+	if C.tjCompress2(handle, pix, C.int(bounds.Dx()), C.int(rgba.Stride), C.int(bounds.Dy()), C.TJPF_RGBA,
+		&buf, &size, C.TJSAMP_420, C.int(quality), 0) != 0 {
+		return errors.New("libjpeg-turbo couldn't encode the image: " + C.GoString(C.tjGetErrorStr2(handle)))
+	}
+	defer C.tjFree(buf)
+	_, err := w.Write(C.GoBytes(unsafe.Pointer(buf), C.int(size)))
+	return err
+}
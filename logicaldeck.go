@@ -0,0 +1,240 @@
+package streamdeck
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// LogicalDeck joins several devices placed side by side into one grid of buttons, numbered left to right and
+// top to bottom across all of them, with their events merged.  Encoders are numbered the same way, left to right.
+type LogicalDeck struct {
+	devices        []*Device
+	colOffsets     []int
+	encoderOffsets []int
+	rows           int
+	cols           int
+	encoders       int
+
+	lock                 sync.Mutex
+	buttonPressListeners []func(int, *LogicalDeck, error, bool)
+	encoderPushListeners []func(int, *LogicalDeck, bool)
+	encoderRotListeners  []func(int, *LogicalDeck, int)
+}
+
+// NewLogicalDeck joins the given devices, from left to right, into a single LogicalDeck
+func NewLogicalDeck(devices ...*Device) (*LogicalDeck, error) {
+	if len(devices) == 0 {
+		return nil, errors.New("A logical deck needs at least one device")
+	}
+	ld := &LogicalDeck{devices: devices}
+	for i, d := range devices {
+		if d.GetButtonCols() == 0 {
+			return nil, fmt.Errorf("Device %s has no button grid", d.GetName())
+		}
+		ld.colOffsets = append(ld.colOffsets, ld.cols)
+		ld.encoderOffsets = append(ld.encoderOffsets, ld.encoders)
+		ld.cols += int(d.GetButtonCols())
+		ld.encoders += int(d.deviceType.numberOfEncoders)
+		if rows := int(d.GetButtonRows()); rows > ld.rows {
+			ld.rows = rows
+		}
+
+		i := i
+		d.ButtonPress(func(btnIndex int, d *Device, err error, pressed bool) {
+			row, col := d.ButtonPosition(btnIndex)
+			if err == nil && row < 0 {
+				return
+			}
+			ld.sendButtonPress(ld.ButtonAt(row, ld.colOffsets[i]+col), err, pressed)
+		})
+		d.EncoderPress(func(encIndex int, d *Device, pressed bool) {
+			ld.sendEncoderPress(ld.encoderOffsets[i]+encIndex, pressed)
+		})
+		d.EncoderRotate(func(encIndex int, d *Device, pulses int) {
+			ld.sendEncoderRotate(ld.encoderOffsets[i]+encIndex, pulses)
+		})
+	}
+	return ld, nil
+}
+
+// GetDevices returns the devices making up the deck, from left to right
+func (ld *LogicalDeck) GetDevices() []*Device {
+	return ld.devices
+}
+
+// GetButtonRows returns the number of rows of the tallest device
+func (ld *LogicalDeck) GetButtonRows() int {
+	return ld.rows
+}
+
+// GetButtonCols returns the number of columns across all of the devices
+func (ld *LogicalDeck) GetButtonCols() int {
+	return ld.cols
+}
+
+// GetNumberOfButtons returns the size of the grid; where devices have different numbers of rows, some of the
+// grid positions have no button
+func (ld *LogicalDeck) GetNumberOfButtons() int {
+	return ld.rows * ld.cols
+}
+
+// GetNumberOfEncoders returns the number of encoders across all of the devices
+func (ld *LogicalDeck) GetNumberOfEncoders() int {
+	return ld.encoders
+}
+
+// ButtonAt returns the index of the button at the given row and column of the whole grid, or -1
+func (ld *LogicalDeck) ButtonAt(row, col int) int {
+	if row < 0 || col < 0 || row >= ld.rows || col >= ld.cols {
+		return -1
+	}
+	return row*ld.cols + col
+}
+
+// ButtonPosition returns the row and column of a button in the whole grid, or -1, -1
+func (ld *LogicalDeck) ButtonPosition(btnIndex int) (int, int) {
+	if btnIndex < 0 || btnIndex >= ld.rows*ld.cols {
+		return -1, -1
+	}
+	return btnIndex / ld.cols, btnIndex % ld.cols
+}
+
+// Locate returns the device and its own button index for a button of the whole grid, or nil if there is no
+// button there
+func (ld *LogicalDeck) Locate(btnIndex int) (*Device, int) {
+	row, col := ld.ButtonPosition(btnIndex)
+	if row < 0 {
+		return nil, -1
+	}
+	for i := len(ld.devices) - 1; i >= 0; i-- {
+		if col >= ld.colOffsets[i] {
+			d := ld.devices[i]
+			local := d.ButtonAt(row, col-ld.colOffsets[i])
+			if local < 0 {
+				return nil, -1
+			}
+			return d, local
+		}
+	}
+	return nil, -1
+}
+
+// WriteRawImageToButton writes an image to a button of the whole grid, see Device.WriteRawImageToButton
+func (ld *LogicalDeck) WriteRawImageToButton(btnIndex int, img image.Image, opts ...ImageOption) error {
+	d, local := ld.Locate(btnIndex)
+	if d == nil {
+		return fmt.Errorf("Invalid key index: %d", btnIndex)
+	}
+	return d.WriteRawImageToButton(local, img, opts...)
+}
+
+// WriteColorToButton writes a colour to a button of the whole grid
+func (ld *LogicalDeck) WriteColorToButton(btnIndex int, colour color.Color) error {
+	d, local := ld.Locate(btnIndex)
+	if d == nil {
+		return fmt.Errorf("Invalid key index: %d", btnIndex)
+	}
+	return d.WriteColorToButton(local, colour)
+}
+
+// WriteImageAcross spreads one image over every button of the whole grid, each button showing its own tile of
+// it; the gaps between buttons are not allowed for.  A Fit given with WithFit applies to the grid as a whole,
+// taking the buttons of the first device as the size of each.
+func (ld *LogicalDeck) WriteImageAcross(img image.Image, opts ...ImageOption) error {
+	if fit := getImageOptions(opts).fit; fit != FitStretch {
+		for _, d := range ld.devices {
+			if d.HasImageCapability() {
+				size := d.GetImageSize()
+				img = fitImage(img, image.Pt(size.X*ld.cols, size.Y*ld.rows), fit)
+				break
+			}
+		}
+	}
+	b := img.Bounds()
+	var err error
+	for btnIndex := 0; btnIndex < ld.GetNumberOfButtons(); btnIndex++ {
+		d, local := ld.Locate(btnIndex)
+		if d == nil || !d.HasImageCapability() {
+			continue
+		}
+		row, col := ld.ButtonPosition(btnIndex)
+		tile := image.Rect(
+			b.Min.X+b.Dx()*col/ld.cols, b.Min.Y+b.Dy()*row/ld.rows,
+			b.Min.X+b.Dx()*(col+1)/ld.cols, b.Min.Y+b.Dy()*(row+1)/ld.rows,
+		)
+		tileImg := image.NewRGBA(image.Rectangle{Max: tile.Size()})
+		draw.Draw(tileImg, tileImg.Bounds(), img, tile.Min, draw.Src)
+		if e := d.WriteRawImageToButton(local, tileImg); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// ClearButtons writes black to every button of every device
+func (ld *LogicalDeck) ClearButtons() {
+	for _, d := range ld.devices {
+		if d.HasImageCapability() {
+			d.ClearButtons()
+		}
+	}
+}
+
+// SetBrightness sets the brightness of every device
+func (ld *LogicalDeck) SetBrightness(pct int) {
+	for _, d := range ld.devices {
+		d.SetBrightness(pct)
+	}
+}
+
+// ButtonPress registers a callback to be called whenever a button on any of the devices is pressed (or a connection is lost!)
+func (ld *LogicalDeck) ButtonPress(f func(int, *LogicalDeck, error, bool)) {
+	ld.lock.Lock()
+	defer ld.lock.Unlock()
+	ld.buttonPressListeners = append(ld.buttonPressListeners, f)
+}
+
+// EncoderPress registers a callback to be called whenever an encoder on any of the devices is pressed
+func (ld *LogicalDeck) EncoderPress(f func(int, *LogicalDeck, bool)) {
+	ld.lock.Lock()
+	defer ld.lock.Unlock()
+	ld.encoderPushListeners = append(ld.encoderPushListeners, f)
+}
+
+// EncoderRotate registers a callback to be called whenever an encoder on any of the devices is rotated
+func (ld *LogicalDeck) EncoderRotate(f func(int, *LogicalDeck, int)) {
+	ld.lock.Lock()
+	defer ld.lock.Unlock()
+	ld.encoderRotListeners = append(ld.encoderRotListeners, f)
+}
+
+func (ld *LogicalDeck) sendButtonPress(btnIndex int, err error, pressed bool) {
+	ld.lock.Lock()
+	listeners := ld.buttonPressListeners
+	ld.lock.Unlock()
+	for _, f := range listeners {
+		f(btnIndex, ld, err, pressed)
+	}
+}
+
+func (ld *LogicalDeck) sendEncoderPress(encIndex int, pressed bool) {
+	ld.lock.Lock()
+	listeners := ld.encoderPushListeners
+	ld.lock.Unlock()
+	for _, f := range listeners {
+		f(encIndex, ld, pressed)
+	}
+}
+
+func (ld *LogicalDeck) sendEncoderRotate(encIndex int, pulses int) {
+	ld.lock.Lock()
+	listeners := ld.encoderRotListeners
+	ld.lock.Unlock()
+	for _, f := range listeners {
+		f(encIndex, ld, pulses)
+	}
+}
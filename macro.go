@@ -0,0 +1,92 @@
+package streamdeck
+
+import (
+	"sync"
+	"time"
+
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+)
+
+// MacroStep is an event in a Macro, with the time to wait before it
+type MacroStep struct {
+	Delay time.Duration
+	Event protocol.Event
+}
+
+// Macro is a sequence of events with their timing, recorded by an EventRecorder or put together by hand, which can
+// be saved as JSON
+type Macro struct {
+	Steps []MacroStep
+}
+
+// Duration returns how long the macro takes to replay
+func (m *Macro) Duration() time.Duration {
+	var total time.Duration
+	for _, step := range m.Steps {
+		total += step.Delay
+	}
+	return total
+}
+
+// EventRecorder records events as a Macro; it is added to a Device (or a Manager) with Use, and records while it
+// is started
+type EventRecorder struct {
+	lock      sync.Mutex
+	recording bool
+	last      time.Time
+	macro     Macro
+}
+
+// NewEventRecorder creates an EventRecorder, which isn't recording yet
+func NewEventRecorder() *EventRecorder {
+	return &EventRecorder{}
+}
+
+// Middleware returns the Middleware to add to a Device with Use, which records events and passes them on
+func (r *EventRecorder) Middleware() Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(d *Device, e protocol.Event) {
+			r.record(e)
+			next(d, e)
+		}
+	}
+}
+
+func (r *EventRecorder) record(e protocol.Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.recording {
+		return
+	}
+	now := time.Now()
+	r.macro.Steps = append(r.macro.Steps, MacroStep{Delay: now.Sub(r.last), Event: e})
+	r.last = now
+}
+
+// Start starts recording a new macro, timed from now
+func (r *EventRecorder) Start() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.recording = true
+	r.last = time.Now()
+	r.macro = Macro{}
+}
+
+// Stop stops recording, returning the macro recorded
+func (r *EventRecorder) Stop() *Macro {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.recording = false
+	steps := append([]MacroStep(nil), r.macro.Steps...)
+	return &Macro{Steps: steps}
+}
+
+// Replay passes a macro's events through the device's middleware to its listeners with their original timing, as
+// if they had come from the device, returning once the last one has been passed on.  A macro replayed from a
+// button shouldn't press that button, or it will go on replaying.
+func (d *Device) Replay(m *Macro) {
+	for _, step := range m.Steps {
+		time.Sleep(step.Delay)
+		d.dispatch(step.Event)
+	}
+}
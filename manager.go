@@ -0,0 +1,106 @@
+package streamdeck
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Manager keeps track of several Stream Decks open at once, identified by their serial numbers
+type Manager struct {
+	lock       sync.Mutex
+	devices    map[string]*Device
+	order      []string
+	middleware []Middleware
+	pairings   map[string]pedalPairing // By pedal serial, see PairPedal
+}
+
+// NewManager creates a new Manager with no devices open
+func NewManager() *Manager {
+	return &Manager{devices: make(map[string]*Device)}
+}
+
+// OpenAll opens every attached Stream Deck which isn't already open, returning an error if there were none at all
+func (m *Manager) OpenAll() error {
+	for _, found := range Search() {
+		if m.GetDevice(found.Serial) != nil {
+			continue
+		}
+		// Devices without a definition are skipped, rather than failing the rest
+		m.Open(found.Serial)
+	}
+	if len(m.GetDevices()) == 0 {
+		return errors.New("No Stream Decks could be opened")
+	}
+	return nil
+}
+
+// Open opens the Stream Deck with the given serial number, or returns it if it is already open
+func (m *Manager) Open(serial string) (*Device, error) {
+	if d := m.GetDevice(serial); d != nil {
+		return d, nil
+	}
+	d, err := OpenBySerial(serial)
+	if err != nil {
+		return nil, err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	d.Use(m.middleware...)
+	d.ButtonPress(m.acknowledge)
+	m.devices[serial] = d
+	m.order = append(m.order, serial)
+	return d, nil
+}
+
+// Use adds middleware to every open device and to devices opened later, see Device.Use
+func (m *Manager) Use(mw ...Middleware) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.middleware = append(m.middleware, mw...)
+	for _, d := range m.devices {
+		d.Use(mw...)
+	}
+}
+
+// GetDevice returns the open device with the given serial number, or nil
+func (m *Manager) GetDevice(serial string) *Device {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.devices[serial]
+}
+
+// GetDevices returns all of the open devices, in the order they were opened
+func (m *Manager) GetDevices() []*Device {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	devices := make([]*Device, 0, len(m.order))
+	for _, serial := range m.order {
+		devices = append(devices, m.devices[serial])
+	}
+	return devices
+}
+
+// Close closes all of the open devices
+func (m *Manager) Close() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, d := range m.devices {
+		d.Close()
+	}
+	m.devices = make(map[string]*Device)
+	m.order = nil
+}
+
+// NewLogicalDeck joins open devices, given by serial number from left to right, into a single LogicalDeck
+func (m *Manager) NewLogicalDeck(serials ...string) (*LogicalDeck, error) {
+	var devices []*Device
+	for _, serial := range serials {
+		d := m.GetDevice(serial)
+		if d == nil {
+			return nil, fmt.Errorf("No open device with serial %q", serial)
+		}
+		devices = append(devices, d)
+	}
+	return NewLogicalDeck(devices...)
+}
@@ -0,0 +1,144 @@
+package streamdeck
+
+import (
+	"sync"
+	"time"
+)
+
+// WriteLatencyBuckets are the upper bounds of the write latency histogram in Metrics
+var WriteLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+}
+
+// Metrics count what a Device has done since it was opened, for monitoring, see GetMetrics
+type Metrics struct {
+	FramesWritten uint64            // Images written to buttons or areas
+	BytesSent     uint64            // Bytes of image reports sent, including headers and padding
+	WriteErrors   uint64            // Image reports the device didn't accept
+	FramesAborted uint64            // Images abandoned part way through for a newer one, see SetAbortStaleWrites
+	ReadErrors    uint64            // Failed reads; reading stops at the first, the device being taken as disconnected
+	Events        map[string]uint64 // Events by type: "buttonPress", "buttonRelease", "encoderPress", "encoderRotate", "touch" and "swipe"
+
+	WriteLatencyCounts []uint64      // Images written within each of WriteLatencyBuckets, cumulatively
+	WriteLatencySum    time.Duration // Total time spent writing images
+}
+
+type deviceMetrics struct {
+	lock    sync.Mutex
+	metrics Metrics
+	recent  []frameRecord // Frames written within the last statsWindow, oldest first
+}
+
+type frameRecord struct {
+	at    time.Time
+	bytes int
+}
+
+// statsWindow is the time over which Stats works out rates
+const statsWindow = time.Second
+
+// Stats are the rates a Device is writing at, for checking an application is within the device's bandwidth
+type Stats struct {
+	FramesWritten       uint64        // Images written since the device was opened
+	FramesPerSecond     float64       // Images written over the last second
+	BytesPerSecond      float64       // Bytes of image reports sent over the last second
+	AverageWriteLatency time.Duration // Average time taken to write an image, since the device was opened
+}
+
+// Stats returns the rates the device is writing at
+func (d *Device) Stats() Stats {
+	d.metrics.lock.Lock()
+	defer d.metrics.lock.Unlock()
+	d.metrics.trim(time.Now())
+	m := &d.metrics.metrics
+	stats := Stats{
+		FramesWritten:   m.FramesWritten,
+		FramesPerSecond: float64(len(d.metrics.recent)) / statsWindow.Seconds(),
+	}
+	for _, f := range d.metrics.recent {
+		stats.BytesPerSecond += float64(f.bytes) / statsWindow.Seconds()
+	}
+	if m.FramesWritten > 0 {
+		stats.AverageWriteLatency = m.WriteLatencySum / time.Duration(m.FramesWritten)
+	}
+	return stats
+}
+
+// trim forgets frames older than statsWindow, and must be called with the lock held
+func (dm *deviceMetrics) trim(now time.Time) {
+	i := 0
+	for i < len(dm.recent) && now.Sub(dm.recent[i].at) > statsWindow {
+		i++
+	}
+	dm.recent = append(dm.recent[:0], dm.recent[i:]...)
+}
+
+// GetMetrics returns a snapshot of the device's metrics
+func (d *Device) GetMetrics() Metrics {
+	d.metrics.lock.Lock()
+	defer d.metrics.lock.Unlock()
+	m := d.metrics.metrics
+	m.Events = make(map[string]uint64, len(d.metrics.metrics.Events))
+	for k, v := range d.metrics.metrics.Events {
+		m.Events[k] = v
+	}
+	m.WriteLatencyCounts = make([]uint64, len(WriteLatencyBuckets))
+	copy(m.WriteLatencyCounts, d.metrics.metrics.WriteLatencyCounts)
+	return m
+}
+
+// recordWrite counts an image written, in however many reports, and how long it took
+func (d *Device) recordWrite(bytesSent int, errors int, took time.Duration) {
+	d.metrics.lock.Lock()
+	defer d.metrics.lock.Unlock()
+	m := &d.metrics.metrics
+	m.FramesWritten++
+	m.BytesSent += uint64(bytesSent)
+	m.WriteErrors += uint64(errors)
+	m.WriteLatencySum += took
+	now := time.Now()
+	d.metrics.recent = append(d.metrics.recent, frameRecord{at: now, bytes: bytesSent})
+	d.metrics.trim(now)
+	if m.WriteLatencyCounts == nil {
+		m.WriteLatencyCounts = make([]uint64, len(WriteLatencyBuckets))
+	}
+	for i, bucket := range WriteLatencyBuckets {
+		if took <= bucket {
+			m.WriteLatencyCounts[i]++
+		}
+	}
+}
+
+// recordAbort counts an image abandoned for a newer one, after sending some of it
+func (d *Device) recordAbort(bytesSent int, errors int) {
+	d.metrics.lock.Lock()
+	defer d.metrics.lock.Unlock()
+	m := &d.metrics.metrics
+	m.FramesAborted++
+	m.BytesSent += uint64(bytesSent)
+	m.WriteErrors += uint64(errors)
+}
+
+// recordReadError counts a failed read
+func (d *Device) recordReadError() {
+	d.metrics.lock.Lock()
+	defer d.metrics.lock.Unlock()
+	d.metrics.metrics.ReadErrors++
+}
+
+// recordEvent counts an event of the given type
+func (d *Device) recordEvent(eventType string) {
+	d.metrics.lock.Lock()
+	defer d.metrics.lock.Unlock()
+	if d.metrics.metrics.Events == nil {
+		d.metrics.metrics.Events = make(map[string]uint64)
+	}
+	d.metrics.metrics.Events[eventType]++
+}
@@ -0,0 +1,37 @@
+package streamdeck_test
+
+import (
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+// TestReadErrorMetrics checks that unplugging counts a read error, and not a button press, though the listeners
+// are told of it as a press with an error
+func TestReadErrorMetrics(t *testing.T) {
+	d, mock, err := streamdecktest.Open(0x6c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	failed := make(chan error, 1)
+	d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, isPressed bool) {
+		if err != nil {
+			failed <- err
+		}
+	})
+	mock.TapButton(2)
+	mock.Close()
+	select {
+	case <-failed:
+	case <-time.After(time.Second):
+		t.Fatal("The listener wasn't told of the failed read")
+	}
+
+	m := d.GetMetrics()
+	if m.ReadErrors != 1 || m.Events["buttonPress"] != 1 || m.Events["buttonRelease"] != 1 {
+		t.Errorf("Counted %d read errors and events %v", m.ReadErrors, m.Events)
+	}
+}
@@ -0,0 +1,130 @@
+package streamdeck
+
+import (
+	"sync"
+	"time"
+
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+)
+
+// EventHandler handles an event from a device; buttons are numbered as by the button map, if there is one
+type EventHandler func(d *Device, e protocol.Event)
+
+// Middleware wraps an EventHandler, to log, filter, limit or change events before the listeners see them.  It
+// passes events on by calling next, any number of times, or drops them by not calling it.
+type Middleware func(next EventHandler) EventHandler
+
+// Use adds middleware between the device and its listeners; middleware added first sees events first
+func (d *Device) Use(mw ...Middleware) {
+	d.middlewareLock.Lock()
+	defer d.middlewareLock.Unlock()
+	d.middleware = append(d.middleware, mw...)
+	handler := EventHandler(func(d *Device, e protocol.Event) { d.deliver(e) })
+	for i := len(d.middleware) - 1; i >= 0; i-- {
+		handler = d.middleware[i](handler)
+	}
+	d.eventHandler = handler
+}
+
+// dispatch passes an event through the middleware to the listeners
+func (d *Device) dispatch(e protocol.Event) {
+	d.middlewareLock.RLock()
+	handler := d.eventHandler
+	d.middlewareLock.RUnlock()
+	if handler == nil {
+		d.deliver(e)
+		return
+	}
+	handler(d, e)
+}
+
+// deliver passes an event to the listeners
+func (d *Device) deliver(e protocol.Event) {
+	switch e.Type {
+	case protocol.ButtonPress:
+		d.sendButtonPressEvent(e.Index, nil)
+	case protocol.ButtonRelease:
+		d.sendButtonReleaseEvent(e.Index, nil)
+	case protocol.EncoderPress:
+		d.sendEncoderPushEvent(e.Index, true)
+	case protocol.EncoderRelease:
+		d.sendEncoderPushEvent(e.Index, false)
+	case protocol.EncoderRotate:
+		d.sendEncoderRotateEvent(e.Index, e.Pulses)
+	case protocol.TouchTap:
+		d.sendTouchPushEvent(e.X, e.Y, false)
+	case protocol.TouchHold:
+		d.sendTouchPushEvent(e.X, e.Y, true)
+	case protocol.TouchSwipe:
+		d.sendTouchSwipeEvent(e.X, e.Y, e.XEnd, e.YEnd)
+	}
+}
+
+// LogEvents is Middleware passing every event to logf, such as log.Printf, before passing it on
+func LogEvents(logf func(format string, a ...interface{})) Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(d *Device, e protocol.Event) {
+			logf("%s: %+v", d.GetSerial(), e)
+			next(d, e)
+		}
+	}
+}
+
+// FilterEvents is Middleware passing on only the events keep returns true for
+func FilterEvents(keep func(d *Device, e protocol.Event) bool) Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(d *Device, e protocol.Event) {
+			if keep(d, e) {
+				next(d, e)
+			}
+		}
+	}
+}
+
+// ThrottleEvents is Middleware dropping events of the given types which come within interval of the last one
+// passed on for the same button or encoder, such as to stop a quickly turned encoder flooding a slow listener;
+// the pulses of dropped rotations are lost
+func ThrottleEvents(interval time.Duration, types ...protocol.EventType) Middleware {
+	type key struct {
+		t     protocol.EventType
+		index int
+	}
+	return func(next EventHandler) EventHandler {
+		var lock sync.Mutex // Handlers aren't only called from the device's own goroutine
+		last := make(map[key]time.Time)
+		return func(d *Device, e protocol.Event) {
+			for _, t := range types {
+				if e.Type != t {
+					continue
+				}
+				k := key{e.Type, e.Index}
+				now := time.Now()
+				lock.Lock()
+				throttled := now.Sub(last[k]) < interval
+				if !throttled {
+					last[k] = now
+				}
+				lock.Unlock()
+				if throttled {
+					return
+				}
+			}
+			next(d, e)
+		}
+	}
+}
+
+// RemapButtons is Middleware changing button numbers, for buttons in the map; unlike SetButtonMap it only
+// affects events, not which buttons images are written to
+func RemapButtons(buttonMap map[int]int) Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(d *Device, e protocol.Event) {
+			if e.Type == protocol.ButtonPress || e.Type == protocol.ButtonRelease {
+				if to, ok := buttonMap[e.Index]; ok {
+					e.Index = to
+				}
+			}
+			next(d, e)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package mqtt
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/buttons"
+)
+
+// PublishAction publishes a message to a topic when the button is pressed
+type PublishAction struct {
+	client   Client
+	topic    string
+	payload  []byte
+	retained bool
+}
+
+// Pressed publishes the message
+func (action *PublishAction) Pressed(btn streamdeck.Button) {
+	action.client.Publish(action.topic, action.retained, action.payload)
+}
+
+// NewPublishAction creates a PublishAction
+func NewPublishAction(client Client, topic string, payload string, retained bool) *PublishAction {
+	return &PublishAction{client: client, topic: topic, payload: []byte(payload), retained: retained}
+}
+
+// BindText sets the text of a button to the value of a topic; retained values are shown as soon as the broker
+// sends them after subscribing
+func BindText(client Client, topic string, btn *buttons.TextButton) error {
+	return client.Subscribe(topic, func(topic string, payload []byte) {
+		btn.SetText(string(payload))
+	})
+}
+
+// BindColour sets the colour of a button from the value of a topic, looking the value up in colours; values not
+// in the map leave the colour as it is
+func BindColour(client Client, topic string, btn *buttons.ColourButton, colours map[string]color.Color) error {
+	return client.Subscribe(topic, func(topic string, payload []byte) {
+		if c, ok := colours[strings.TrimSpace(string(payload))]; ok {
+			btn.SetColour(c)
+		}
+	})
+}
+
+// BindState sets a key in a StreamDeck's State from the value of a topic, so that buttons bound to the key with
+// BindState follow it.  Values which look like numbers or booleans are stored as float64 or bool, and anything
+// else as a string.
+func BindState(client Client, topic string, state *streamdeck.State, key string) error {
+	return client.Subscribe(topic, func(topic string, payload []byte) {
+		s := strings.TrimSpace(string(payload))
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			state.Set(key, f)
+		} else if b, err := strconv.ParseBool(s); err == nil {
+			state.Set(key, b)
+		} else {
+			state.Set(key, s)
+		}
+	})
+}
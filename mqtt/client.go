@@ -0,0 +1,350 @@
+// Package mqtt publishes to an MQTT broker when buttons are pressed, and drives buttons from the values of
+// topics, so that a deck can be plugged into home-automation and IoT setups
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client is what the actions and bindings in this package need from an MQTT client.  Conn is a minimal client
+// built in; to use another client library (for example for TLS or QoS 1 and 2), wrap it to satisfy this.
+type Client interface {
+	Publish(topic string, retained bool, payload []byte) error
+	Subscribe(topic string, handler func(topic string, payload []byte)) error
+}
+
+// Conn is a minimal MQTT 3.1.1 client over TCP.  Everything is sent and subscribed to at QoS 0.
+type Conn struct {
+	address   string
+	opts      Options
+	writeLock sync.Mutex // Also guards conn, which is replaced on reconnecting
+	conn      net.Conn
+	lock      sync.Mutex
+	handlers  []subscription
+	packetID  uint16
+	closed    bool
+	done      chan struct{}
+	err       error
+}
+
+type subscription struct {
+	filter  string
+	handler func(string, []byte)
+}
+
+// Options are the connection options for Dial
+type Options struct {
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration // 30 seconds if zero
+
+	// Reconnect makes a Conn connect again when the connection is lost, backing off up to a minute between tries,
+	// and subscribe again to everything it was subscribed to.  Messages published while it is disconnected are
+	// lost, with an error.  Done is then only closed by Close.
+	Reconnect bool
+}
+
+// Dial connects to a broker, such as "localhost:1883"
+func Dial(address string, opts Options) (*Conn, error) {
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	if opts.ClientID == "" {
+		opts.ClientID = fmt.Sprintf("go-streamdeck-%d", time.Now().UnixNano()%1000000)
+	}
+	conn, r, err := connect(address, opts)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{address: address, opts: opts, conn: conn, done: make(chan struct{})}
+	go c.readLoop(r)
+	go c.keepAlive(opts.KeepAlive / 2)
+	return c, nil
+}
+
+// connect opens a connection to a broker and sends CONNECT, waiting for the CONNACK
+func connect(address string, opts Options) (net.Conn, *bufio.Reader, error) {
+	conn, err := net.DialTimeout("tcp", address, 10*time.Second)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := conn.Write(connectPacket(opts)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	header, ack, err := readPacket(r)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if header>>4 != 2 || len(ack) < 2 {
+		conn.Close()
+		return nil, nil, errors.New("Unexpected reply to MQTT connect")
+	}
+	if ack[1] != 0 {
+		conn.Close()
+		return nil, nil, fmt.Errorf("MQTT connection refused (code %d)", ack[1])
+	}
+	return conn, r, nil
+}
+
+func connectPacket(opts Options) []byte {
+	var flags byte = 0x02 // Clean session
+	payload := appendString(nil, opts.ClientID)
+	if opts.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, opts.Username)
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+		payload = appendString(payload, opts.Password)
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags, 0, 0)
+	binary.BigEndian.PutUint16(body[len(body)-2:], uint16(opts.KeepAlive/time.Second))
+	return appendPacket(0x10, append(body, payload...))
+}
+
+// Publish sends a message to a topic
+func (c *Conn) Publish(topic string, retained bool, payload []byte) error {
+	var header byte = 0x30
+	if retained {
+		header |= 0x01
+	}
+	return c.writePacket(header, append(appendString(nil, topic), payload...))
+}
+
+// Subscribe calls handler for every message on topics matching the filter, which may contain the + and #
+// wildcards
+func (c *Conn) Subscribe(filter string, handler func(topic string, payload []byte)) error {
+	c.lock.Lock()
+	c.handlers = append(c.handlers, subscription{filter: filter, handler: handler})
+	c.lock.Unlock()
+	return c.subscribe(filter)
+}
+
+func (c *Conn) subscribe(filter string) error {
+	c.lock.Lock()
+	c.packetID++
+	if c.packetID == 0 {
+		c.packetID = 1
+	}
+	id := c.packetID
+	c.lock.Unlock()
+
+	body := []byte{byte(id >> 8), byte(id)}
+	body = appendString(body, filter)
+	body = append(body, 0) // QoS 0
+	return c.writePacket(0x82, body)
+}
+
+// Done is closed when the connection is lost, after which Err says why
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the connection was lost
+func (c *Conn) Err() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.err
+}
+
+// Close disconnects from the broker
+func (c *Conn) Close() error {
+	c.lock.Lock()
+	c.closed = true
+	c.lock.Unlock()
+	c.writePacket(0xe0, nil)
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.conn.Close()
+}
+
+func (c *Conn) keepAlive(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-t.C:
+			c.writePacket(0xc0, nil)
+		}
+	}
+}
+
+func (c *Conn) readLoop(r *bufio.Reader) {
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			c.lock.Lock()
+			c.err = err
+			closed := c.closed
+			c.lock.Unlock()
+			c.writeLock.Lock()
+			c.conn.Close()
+			c.writeLock.Unlock()
+			if closed || !c.opts.Reconnect {
+				close(c.done)
+				return
+			}
+			if r = c.reconnect(); r == nil {
+				close(c.done)
+				return
+			}
+			continue
+		}
+		if header>>4 != 3 { // Only PUBLISH matters; acks and ping responses are ignored
+			continue
+		}
+		if len(body) < 2 {
+			continue
+		}
+		n := int(binary.BigEndian.Uint16(body))
+		if len(body) < 2+n {
+			continue
+		}
+		topic := string(body[2 : 2+n])
+		payload := body[2+n:]
+		if qos := (header >> 1) & 3; qos > 0 && len(payload) >= 2 {
+			if qos == 1 {
+				c.writePacket(0x40, payload[:2]) // PUBACK
+			}
+			payload = payload[2:]
+		}
+
+		c.lock.Lock()
+		handlers := c.handlers
+		c.lock.Unlock()
+		for _, s := range handlers {
+			if matchTopic(s.filter, topic) {
+				s.handler(topic, payload)
+			}
+		}
+	}
+}
+
+// reconnect connects again until it succeeds, subscribing again to every filter, or returns nil if the Conn is
+// closed meanwhile
+func (c *Conn) reconnect() *bufio.Reader {
+	wait := time.Second
+	for {
+		time.Sleep(wait)
+		c.lock.Lock()
+		closed := c.closed
+		c.lock.Unlock()
+		if closed {
+			return nil
+		}
+		conn, r, err := connect(c.address, c.opts)
+		if err != nil {
+			if wait *= 2; wait > time.Minute {
+				wait = time.Minute
+			}
+			continue
+		}
+
+		c.writeLock.Lock()
+		c.conn = conn
+		c.writeLock.Unlock()
+		c.lock.Lock()
+		c.err = nil
+		var filters []string
+		for _, s := range c.handlers {
+			filters = append(filters, s.filter)
+		}
+		c.lock.Unlock()
+		for _, filter := range filters {
+			c.subscribe(filter)
+		}
+		return r
+	}
+}
+
+func (c *Conn) writePacket(header byte, body []byte) error {
+	packet := appendPacket(header, body)
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+// appendPacket makes a packet of a fixed header byte and a body, with the remaining length in between
+func appendPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length := 0
+	for shift := uint(0); ; shift += 7 {
+		if shift > 21 {
+			return 0, nil, errors.New("Invalid MQTT packet length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+// matchTopic reports whether a topic matches a subscription filter with + and # wildcards.  Topics starting with $,
+// such as $SYS, are the broker's own and aren't matched by a wildcard at the start of a filter.
+func matchTopic(filter, topic string) bool {
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+	f := strings.Split(filter, "/")
+	t := strings.Split(topic, "/")
+	for i, level := range f {
+		if level == "#" {
+			return true
+		}
+		if i >= len(t) || (level != "+" && level != t[i]) {
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
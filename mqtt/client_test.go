@@ -0,0 +1,244 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRemainingLength(t *testing.T) {
+	// The examples of the remaining length encoding in section 2.2.3 of the MQTT 3.1.1 specification
+	for _, tc := range []struct {
+		length  int
+		encoded []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{16383, []byte{0xff, 0x7f}},
+		{16384, []byte{0x80, 0x80, 0x01}},
+		{2097151, []byte{0xff, 0xff, 0x7f}},
+		{2097152, []byte{0x80, 0x80, 0x80, 0x01}},
+	} {
+		packet := appendPacket(0x30, make([]byte, tc.length))
+		if got := packet[1 : 1+len(tc.encoded)]; !bytes.Equal(got, tc.encoded) {
+			t.Errorf("Length %d encoded as % x, not % x", tc.length, got, tc.encoded)
+		}
+		header, body, err := readPacket(bufio.NewReader(bytes.NewReader(packet)))
+		if err != nil || header != 0x30 || len(body) != tc.length {
+			t.Errorf("Length %d read back as %d (%v)", tc.length, len(body), err)
+		}
+	}
+}
+
+func TestConnectPacket(t *testing.T) {
+	got := connectPacket(Options{ClientID: "abc", KeepAlive: 30 * time.Second})
+	want := []byte{
+		0x10, 0x0f, // CONNECT, remaining length
+		0x00, 0x04, 'M', 'Q', 'T', 'T', // Protocol name
+		0x04,       // Protocol level 3.1.1
+		0x02,       // Clean session
+		0x00, 0x1e, // Keep alive, 30 seconds
+		0x00, 0x03, 'a', 'b', 'c', // Client ID
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("CONNECT is\n% x, not\n% x", got, want)
+	}
+
+	got = connectPacket(Options{ClientID: "c", Username: "u", Password: "p", KeepAlive: time.Minute})
+	want = []byte{
+		0x10, 0x13,
+		0x00, 0x04, 'M', 'Q', 'T', 'T',
+		0x04,
+		0xc2, // User name, password and clean session
+		0x00, 0x3c,
+		0x00, 0x01, 'c',
+		0x00, 0x01, 'u',
+		0x00, 0x01, 'p',
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("CONNECT with a login is\n% x, not\n% x", got, want)
+	}
+}
+
+func TestMatchTopic(t *testing.T) {
+	for _, tc := range []struct {
+		filter, topic string
+		match         bool
+	}{
+		{"sport/tennis/player1", "sport/tennis/player1", true},
+		{"sport/tennis/player1/#", "sport/tennis/player1", true},
+		{"sport/tennis/player1/#", "sport/tennis/player1/ranking", true},
+		{"sport/tennis/player1/#", "sport/tennis/player1/score/wimbledon", true},
+		{"sport/#", "sport", true},
+		{"#", "sport/tennis", true},
+		{"sport/tennis/+", "sport/tennis/player1", true},
+		{"sport/tennis/+", "sport/tennis/player1/ranking", false},
+		{"sport/+", "sport", false},
+		{"sport/+", "sport/", true},
+		{"+/+", "/finance", true},
+		{"/+", "/finance", true},
+		{"+", "/finance", false},
+		{"sport/tennis", "sport/football", false},
+		// Section 4.7.2: wildcards at the start don't match the broker's own $ topics
+		{"#", "$SYS/broker/uptime", false},
+		{"+/monitor/Clients", "$SYS/monitor/Clients", false},
+		{"$SYS/#", "$SYS/broker/uptime", true},
+		{"$SYS/monitor/+", "$SYS/monitor/Clients", true},
+	} {
+		if got := matchTopic(tc.filter, tc.topic); got != tc.match {
+			t.Errorf("matchTopic(%q, %q) = %t", tc.filter, tc.topic, got)
+		}
+	}
+}
+
+// broker is a fake MQTT broker, accepting connections and passing the packets received on to the test
+type broker struct {
+	t        *testing.T
+	listener net.Listener
+	conns    chan net.Conn
+	packets  chan []byte
+}
+
+func newBroker(t *testing.T) *broker {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &broker{t: t, listener: l, conns: make(chan net.Conn, 4), packets: make(chan []byte, 16)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *broker) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		if header == 0x10 {
+			conn.Write([]byte{0x20, 0x02, 0x00, 0x00}) // CONNACK, accepted
+			b.conns <- conn
+		}
+		if header != 0xc0 { // Keep alive pings would make the tests depend on timing
+			b.packets <- appendPacket(header, body)
+		}
+	}
+}
+
+func (b *broker) expect(want []byte) {
+	b.t.Helper()
+	select {
+	case got := <-b.packets:
+		if !bytes.Equal(got, want) {
+			b.t.Fatalf("Broker received\n% x, not\n% x", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		b.t.Fatalf("Broker didn't receive % x", want)
+	}
+}
+
+func (b *broker) conn() net.Conn {
+	b.t.Helper()
+	select {
+	case conn := <-b.conns:
+		return conn
+	case <-time.After(5 * time.Second):
+		b.t.Fatal("Nothing connected to the broker")
+	}
+	return nil
+}
+
+func TestConn(t *testing.T) {
+	b := newBroker(t)
+	defer b.listener.Close()
+	c, err := Dial(b.listener.Addr().String(), Options{ClientID: "abc", KeepAlive: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	conn := b.conn()
+	b.expect(connectPacket(Options{ClientID: "abc", KeepAlive: time.Hour}))
+
+	if err := c.Publish("a/b", false, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	b.expect([]byte{0x30, 0x07, 0x00, 0x03, 'a', '/', 'b', 'h', 'i'})
+	c.Publish("a/b", true, nil)
+	b.expect([]byte{0x31, 0x05, 0x00, 0x03, 'a', '/', 'b'})
+
+	received := make(chan string, 4)
+	if err := c.Subscribe("a/#", func(topic string, payload []byte) {
+		received <- topic + "=" + string(payload)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	b.expect([]byte{0x82, 0x08, 0x00, 0x01, 0x00, 0x03, 'a', '/', '#', 0x00}) // Packet ID 1, QoS 0
+
+	conn.Write([]byte{0x30, 0x07, 0x00, 0x03, 'a', '/', 'c', 'o', 'n'})                     // QoS 0
+	conn.Write([]byte{0x30, 0x0b, 0x00, 0x07, 'o', 't', 'h', 'e', 'r', '/', 'x', 'n', 'o'}) // Not subscribed to
+	conn.Write([]byte{0x32, 0x0a, 0x00, 0x03, 'a', '/', 'd', 0x12, 0x34, 'o', 'f', 'f'})    // QoS 1, packet ID 0x1234
+	for _, want := range []string{"a/c=on", "a/d=off"} {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Errorf("Received %q, not %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Didn't receive %q", want)
+		}
+	}
+	b.expect([]byte{0x40, 0x02, 0x12, 0x34}) // PUBACK
+	select {
+	case got := <-received:
+		t.Errorf("Received %q, which wasn't subscribed to", got)
+	default:
+	}
+
+	c.Close()
+	b.expect([]byte{0xe0, 0x00}) // DISCONNECT
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done wasn't closed")
+	}
+}
+
+func TestConnReconnects(t *testing.T) {
+	b := newBroker(t)
+	defer b.listener.Close()
+	c, err := Dial(b.listener.Addr().String(), Options{ClientID: "abc", KeepAlive: time.Hour, Reconnect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	conn := b.conn()
+	b.expect(connectPacket(Options{ClientID: "abc", KeepAlive: time.Hour}))
+	c.Subscribe("a/b", func(string, []byte) {})
+	b.expect([]byte{0x82, 0x08, 0x00, 0x01, 0x00, 0x03, 'a', '/', 'b', 0x00})
+
+	conn.Close()
+	b.conn()
+	b.expect(connectPacket(Options{ClientID: "abc", KeepAlive: time.Hour}))
+	b.expect([]byte{0x82, 0x08, 0x00, 0x02, 0x00, 0x03, 'a', '/', 'b', 0x00}) // Subscribed again
+	select {
+	case <-c.Done():
+		t.Fatal("Done was closed, though the Conn reconnected")
+	default:
+	}
+	if err := c.Publish("a/b", false, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	b.expect([]byte{0x30, 0x07, 0x00, 0x03, 'a', '/', 'b', 'h', 'i'})
+}
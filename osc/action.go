@@ -0,0 +1,19 @@
+package osc
+
+import streamdeck "github.com/SKAARHOJ/go-streamdeck"
+
+// Action sends an OSC message when the button is pressed
+type Action struct {
+	client  *Client
+	message Message
+}
+
+// Pressed sends the message
+func (action *Action) Pressed(btn streamdeck.Button) {
+	action.client.Send(action.message)
+}
+
+// NewAction creates an Action which sends a message to the given address, with the given arguments
+func NewAction(client *Client, address string, args ...interface{}) *Action {
+	return &Action{client: client, message: NewMessage(address, args...)}
+}
@@ -0,0 +1,38 @@
+package osc
+
+import (
+	"net"
+	"sync"
+)
+
+// Client sends OSC messages to one destination
+type Client struct {
+	lock sync.Mutex
+	conn net.Conn
+}
+
+// Dial creates a Client sending to the given address, such as "192.168.10.20:10023"
+func Dial(address string) (*Client, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Send sends a message
+func (c *Client) Send(m Message) error {
+	packet, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, err = c.conn.Write(packet)
+	return err
+}
+
+// Close closes the client
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
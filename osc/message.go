@@ -0,0 +1,185 @@
+// Package osc sends and receives Open Sound Control messages over UDP, so that buttons can drive, and be driven
+// by, audio consoles, media servers and the like
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Message is an OSC message; arguments may be int32, float32, string, []byte or bool (and int and float64, which
+// are sent as int32 and float32)
+type Message struct {
+	Address   string
+	Arguments []interface{}
+}
+
+// NewMessage creates a Message
+func NewMessage(address string, args ...interface{}) Message {
+	return Message{Address: address, Arguments: args}
+}
+
+// MarshalBinary encodes the message as an OSC packet
+func (m Message) MarshalBinary() ([]byte, error) {
+	if len(m.Address) == 0 || m.Address[0] != '/' {
+		return nil, fmt.Errorf("Invalid OSC address %q", m.Address)
+	}
+	var args bytes.Buffer
+	tags := ","
+	for _, arg := range m.Arguments {
+		switch v := arg.(type) {
+		case int32:
+			tags += "i"
+			binary.Write(&args, binary.BigEndian, v)
+		case int:
+			tags += "i"
+			binary.Write(&args, binary.BigEndian, int32(v))
+		case float32:
+			tags += "f"
+			binary.Write(&args, binary.BigEndian, math.Float32bits(v))
+		case float64:
+			tags += "f"
+			binary.Write(&args, binary.BigEndian, math.Float32bits(float32(v)))
+		case string:
+			tags += "s"
+			writeString(&args, v)
+		case []byte:
+			tags += "b"
+			binary.Write(&args, binary.BigEndian, int32(len(v)))
+			args.Write(v)
+			args.Write(make([]byte, padding(len(v))))
+		case bool:
+			if v {
+				tags += "T"
+			} else {
+				tags += "F"
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported OSC argument type %T", arg)
+		}
+	}
+
+	var packet bytes.Buffer
+	writeString(&packet, m.Address)
+	writeString(&packet, tags)
+	packet.Write(args.Bytes())
+	return packet.Bytes(), nil
+}
+
+// UnmarshalBinary decodes an OSC packet; bundles are not supported
+func (m *Message) UnmarshalBinary(data []byte) error {
+	address, rest, err := readString(data)
+	if err != nil {
+		return err
+	}
+	if len(address) == 0 || address[0] != '/' {
+		return errors.New("Not an OSC message")
+	}
+	m.Address = address
+	m.Arguments = nil
+	if len(rest) == 0 {
+		return nil // Very old implementations leave out the type tags
+	}
+
+	tags, rest, err := readString(rest)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 || tags[0] != ',' {
+		return errors.New("Invalid OSC type tags")
+	}
+	for _, tag := range tags[1:] {
+		switch tag {
+		case 'i', 'f':
+			if len(rest) < 4 {
+				return errors.New("Truncated OSC message")
+			}
+			v := binary.BigEndian.Uint32(rest)
+			rest = rest[4:]
+			if tag == 'i' {
+				m.Arguments = append(m.Arguments, int32(v))
+			} else {
+				m.Arguments = append(m.Arguments, math.Float32frombits(v))
+			}
+		case 's':
+			var s string
+			s, rest, err = readString(rest)
+			if err != nil {
+				return err
+			}
+			m.Arguments = append(m.Arguments, s)
+		case 'b':
+			if len(rest) < 4 {
+				return errors.New("Truncated OSC message")
+			}
+			n := int(binary.BigEndian.Uint32(rest))
+			rest = rest[4:]
+			if n < 0 || len(rest) < n+padding(n) {
+				return errors.New("Truncated OSC message")
+			}
+			m.Arguments = append(m.Arguments, append([]byte(nil), rest[:n]...))
+			rest = rest[n+padding(n):]
+		case 'T':
+			m.Arguments = append(m.Arguments, true)
+		case 'F':
+			m.Arguments = append(m.Arguments, false)
+		default:
+			return fmt.Errorf("Unsupported OSC type tag %q", tag)
+		}
+	}
+	return nil
+}
+
+// Float returns the argument at index i as a float64, converting integers and booleans, and whether it could
+func (m Message) Float(i int) (float64, bool) {
+	if i >= len(m.Arguments) {
+		return 0, false
+	}
+	switch v := m.Arguments[i].(type) {
+	case int32:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// String returns the argument at index i as a string, and whether it is one
+func (m Message) String(i int) (string, bool) {
+	if i >= len(m.Arguments) {
+		return "", false
+	}
+	s, ok := m.Arguments[i].(string)
+	return s, ok
+}
+
+// writeString writes an OSC string; null terminated, and padded to a multiple of four bytes
+func writeString(b *bytes.Buffer, s string) {
+	b.WriteString(s)
+	b.Write(make([]byte, 4-len(s)%4))
+}
+
+func readString(data []byte) (string, []byte, error) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 {
+		return "", nil, errors.New("Unterminated OSC string")
+	}
+	next := end + 4 - end%4
+	if next > len(data) {
+		next = len(data)
+	}
+	return string(data[:end]), data[next:], nil
+}
+
+// padding gives the number of bytes needed to pad n bytes to a multiple of four
+func padding(n int) int {
+	return (4 - n%4) % 4
+}
@@ -0,0 +1,128 @@
+package osc
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// The first two packets are the examples in the OSC 1.0 specification
+var packets = []struct {
+	name    string
+	message Message
+	packet  []byte
+}{
+	{"float", NewMessage("/oscillator/4/frequency", float32(440)), []byte{
+		'/', 'o', 's', 'c', 'i', 'l', 'l', 'a', 't', 'o', 'r', '/', '4', '/', 'f', 'r', 'e', 'q', 'u', 'e', 'n', 'c', 'y', 0,
+		',', 'f', 0, 0,
+		0x43, 0xdc, 0x00, 0x00}},
+	{"several", NewMessage("/foo", int32(1000), int32(-1), "hello", float32(1.234), float32(5.678)), []byte{
+		'/', 'f', 'o', 'o', 0, 0, 0, 0,
+		',', 'i', 'i', 's', 'f', 'f', 0, 0,
+		0x00, 0x00, 0x03, 0xe8,
+		0xff, 0xff, 0xff, 0xff,
+		'h', 'e', 'l', 'l', 'o', 0, 0, 0,
+		0x3f, 0x9d, 0xf3, 0xb6,
+		0x40, 0xb5, 0xb2, 0x2d}},
+	{"no arguments", NewMessage("/go"), []byte{'/', 'g', 'o', 0, ',', 0, 0, 0}},
+	{"blob and booleans", NewMessage("/b", []byte{1, 2, 3, 4, 5}, true, false), []byte{
+		'/', 'b', 0, 0,
+		',', 'b', 'T', 'F', 0, 0, 0, 0,
+		0, 0, 0, 5, 1, 2, 3, 4, 5, 0, 0, 0}},
+	{"empty string", NewMessage("/s", ""), []byte{'/', 's', 0, 0, ',', 's', 0, 0, 0, 0, 0, 0}},
+}
+
+func TestMarshal(t *testing.T) {
+	for _, tc := range packets {
+		got, err := tc.message.MarshalBinary()
+		if err != nil {
+			t.Errorf("%s: %s", tc.name, err)
+		} else if !bytes.Equal(got, tc.packet) {
+			t.Errorf("%s marshalled to\n% x, not\n% x", tc.name, got, tc.packet)
+		}
+	}
+
+	// int and float64 are sent as int32 and float32
+	got, err := NewMessage("/foo", 1000, -1, "hello", 1.234, 5.678).MarshalBinary()
+	if err != nil || !bytes.Equal(got, packets[1].packet) {
+		t.Errorf("int and float64 arguments marshalled to % x (%v)", got, err)
+	}
+
+	for _, m := range []Message{
+		NewMessage(""),
+		NewMessage("foo"),
+		NewMessage("/foo", int64(1)),
+		NewMessage("/foo", nil),
+	} {
+		if _, err := m.MarshalBinary(); err == nil {
+			t.Errorf("%+v was marshalled", m)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	for _, tc := range packets {
+		var m Message
+		if err := m.UnmarshalBinary(tc.packet); err != nil {
+			t.Errorf("%s: %s", tc.name, err)
+		} else if !reflect.DeepEqual(m, tc.message) {
+			t.Errorf("%s unmarshalled to %#v, not %#v", tc.name, m, tc.message)
+		}
+	}
+
+	// Very old implementations leave out the type tags
+	var m Message
+	if err := m.UnmarshalBinary([]byte{'/', 'g', 'o', 0}); err != nil || m.Address != "/go" || len(m.Arguments) != 0 {
+		t.Errorf("A message without type tags unmarshalled to %+v (%v)", m, err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		packet []byte
+	}{
+		{"empty", nil},
+		{"unterminated address", []byte{'/', 'f', 'o', 'o'}},
+		{"bundle", []byte{'#', 'b', 'u', 'n', 'd', 'l', 'e', 0, 0, 0, 0, 0, 0, 0, 0, 1}},
+		{"no comma", []byte{'/', 'x', 0, 0, 'i', 0, 0, 0, 0, 0, 0, 1}},
+		{"unknown tag", []byte{'/', 'x', 0, 0, ',', 'h', 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}},
+		{"truncated int", []byte{'/', 'x', 0, 0, ',', 'i', 0, 0, 0, 0, 1}},
+		{"truncated float", []byte{'/', 'x', 0, 0, ',', 'f', 0, 0}},
+		{"unterminated string", []byte{'/', 'x', 0, 0, ',', 's', 0, 0, 'a', 'b'}},
+		{"truncated blob", []byte{'/', 'x', 0, 0, ',', 'b', 0, 0, 0, 0, 0, 5, 1, 2, 3, 4}},
+		{"blob without its length", []byte{'/', 'x', 0, 0, ',', 'b', 0, 0, 0, 0}},
+		{"blob longer than the packet", []byte{'/', 'x', 0, 0, ',', 'b', 0, 0, 0xff, 0xff, 0xff, 0xff}},
+	} {
+		var m Message
+		if err := m.UnmarshalBinary(tc.packet); err == nil {
+			t.Errorf("A packet with %s unmarshalled to %+v", tc.name, m)
+		}
+	}
+}
+
+func TestArguments(t *testing.T) {
+	m := NewMessage("/x", int32(-3), float32(0.5), true, false, "text")
+	for _, tc := range []struct {
+		i     int
+		float float64
+		ok    bool
+	}{
+		{0, -3, true},
+		{1, 0.5, true},
+		{2, 1, true},
+		{3, 0, true},
+		{4, 0, false},
+		{5, 0, false},
+	} {
+		if f, ok := m.Float(tc.i); f != tc.float || ok != tc.ok {
+			t.Errorf("Argument %d as a float is %v, %t", tc.i, f, ok)
+		}
+	}
+	if s, ok := m.String(4); s != "text" || !ok {
+		t.Errorf("Argument 4 as a string is %q, %t", s, ok)
+	}
+	for _, i := range []int{0, 5} {
+		if s, ok := m.String(i); ok {
+			t.Errorf("Argument %d is the string %q", i, s)
+		}
+	}
+}
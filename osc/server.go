@@ -0,0 +1,82 @@
+package osc
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// Server receives OSC messages and passes them to the handlers registered for their addresses
+type Server struct {
+	conn     net.PacketConn
+	lock     sync.Mutex
+	handlers map[string][]func(Message)
+}
+
+// Listen creates a Server receiving on the given address, such as ":9000", and starts it
+func Listen(address string) (*Server, error) {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{conn: conn, handlers: make(map[string][]func(Message))}
+	go s.serve()
+	return s, nil
+}
+
+// Handle registers a function to be called for every message to the given address.  An address ending in "/*"
+// matches every address below it, and "*" on its own matches everything.
+func (s *Server) Handle(address string, f func(Message)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handlers[address] = append(s.handlers[address], f)
+}
+
+// BindState sets a key in a StreamDeck's State from the first argument of every message to the given address,
+// so that buttons bound to the key with BindState follow it.  Numbers are stored as float64.
+func (s *Server) BindState(address string, state *streamdeck.State, key string) {
+	s.Handle(address, func(m Message) {
+		if v, ok := m.Float(0); ok {
+			state.Set(key, v)
+		} else if len(m.Arguments) > 0 {
+			state.Set(key, m.Arguments[0])
+		}
+	})
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Server) serve() {
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var m Message
+		if m.UnmarshalBinary(buf[:n]) != nil {
+			continue
+		}
+		for _, f := range s.handlersFor(m.Address) {
+			f(m)
+		}
+	}
+}
+
+func (s *Server) handlersFor(address string) []func(Message) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var handlers []func(Message)
+	for pattern, fs := range s.handlers {
+		if pattern == address || pattern == "*" ||
+			(strings.HasSuffix(pattern, "/*") && strings.HasPrefix(address, pattern[:len(pattern)-1])) {
+			handlers = append(handlers, fs...)
+		}
+	}
+	return handlers
+}
@@ -0,0 +1,102 @@
+package osc
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+func TestServer(t *testing.T) {
+	s, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	c, err := Dial(s.conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	received := make(chan string, 10)
+	for _, pattern := range []string{"/mix/fader", "/mix/*", "*", "/other"} {
+		pattern := pattern
+		s.Handle(pattern, func(m Message) { received <- pattern + " " + m.Address })
+	}
+	state := streamdeck.NewState()
+	s.BindState("/mix/fader", state, "fader")
+	s.BindState("/mix/name", state, "name")
+
+	for _, tc := range []struct {
+		message Message
+		want    []string // The patterns handling it, sorted
+	}{
+		{NewMessage("/mix/fader", float32(0.75)), []string{"* /mix/fader", "/mix/* /mix/fader", "/mix/fader /mix/fader"}},
+		{NewMessage("/mix/name", "Vox"), []string{"* /mix/name", "/mix/* /mix/name"}},
+		{NewMessage("/mixer"), []string{"* /mixer"}},
+		{NewMessage("/other", true), []string{"* /other", "/other /other"}},
+	} {
+		if err := c.Send(tc.message); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for range tc.want {
+			select {
+			case r := <-received:
+				got = append(got, r)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s was handled by %q, not %q", tc.message.Address, got, tc.want)
+			}
+		}
+		sort.Strings(got)
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s was handled by %q, not %q", tc.message.Address, got, tc.want)
+				break
+			}
+		}
+	}
+	select {
+	case r := <-received:
+		t.Errorf("An extra handler was called: %s", r)
+	default:
+	}
+
+	// Handlers run in turn, so BindState has run by the time the last message's handlers have
+	if v, _ := state.Get("fader"); v != 0.75 {
+		t.Errorf("The fader is %v", v)
+	}
+	if v, _ := state.Get("name"); v != "Vox" {
+		t.Errorf("The name is %v", v)
+	}
+}
+
+func TestAction(t *testing.T) {
+	s, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	c, err := Dial(s.conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	received := make(chan Message, 1)
+	s.Handle("/cue/go", func(m Message) { received <- m })
+
+	NewAction(c, "/cue/go", 3, "now").Pressed(nil)
+	select {
+	case m := <-received:
+		if n, _ := m.Float(0); n != 3 {
+			t.Errorf("The cue is %v", m.Arguments[0])
+		}
+		if s, _ := m.String(1); s != "now" {
+			t.Errorf("The second argument is %v", m.Arguments[1])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Pressing the button sent nothing")
+	}
+}
@@ -0,0 +1,140 @@
+package streamdeck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+
+	"github.com/disintegration/gift"
+	"golang.org/x/image/bmp"
+)
+
+// Corner is the corner of a button where an overlay is drawn
+type Corner int
+
+const (
+	CornerTopRight Corner = iota
+	CornerTopLeft
+	CornerBottomRight
+	CornerBottomLeft
+)
+
+// OverlayTextOnButton draws a small badge with the given text, such as a count, in a corner of the image the
+// button is showing, so the caller needn't supply the image again.  A later overlay replaces this one rather than
+// going on top of it, empty text removes it, and writing a new image to the button drops it.
+func (d *Device) OverlayTextOnButton(btnIndex int, text string, corner Corner) error {
+	if !d.HasImageCapability() {
+		return errors.New("Button doesn't have image capability")
+	}
+	mapped := int(d.mapButtonIn(uint(btnIndex)))
+	base, ok := d.shadow.underOverlay(mapped)
+	if !ok {
+		return fmt.Errorf("Nothing has been written to button %d", btnIndex)
+	}
+	if text == "" {
+		return d.rawWriteToButton(mapped, base)
+	}
+
+	img, err := d.decodeButtonImage(base)
+	if err != nil {
+		return err
+	}
+	area, ok := d.deviceType.buttonGeometry[mapped]
+	if !ok {
+		area = image.Rectangle{Max: d.deviceType.imageSize}
+	}
+	drawBadge(img, area, text, corner)
+
+	rotated := resizeAndRotate(d.rotateButtonImage(img), d.deviceType.imageSize.X, d.deviceType.imageSize.Y, d.deviceType.quirks)
+	encoded, err := getImageForButton(rotated, d.deviceType.imageFormat, d.deviceType.quirks)
+	if err != nil {
+		return err
+	}
+	d.shadow.setOverlay(mapped, base, encoded)
+	return d.sendToButton(mapped, encoded)
+}
+
+// decodeButtonImage decodes an image as it was sent to a button, undoing the device's rotation (and the deck's,
+// see SetRotation) so that it is the right way up
+func (d *Device) decodeButtonImage(encoded []byte) (*image.RGBA, error) {
+	var img image.Image
+	var err error
+	switch d.deviceType.imageFormat {
+	case "JPEG":
+		img, err = jpeg.Decode(bytes.NewReader(encoded))
+	case "BMP":
+		img, err = bmp.Decode(bytes.NewReader(encoded))
+	default:
+		err = errors.New("Unknown button image format: " + d.deviceType.imageFormat)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The reverse of deviceSpecifics
+	g := gift.New()
+	if d.hasQuirk(QuirkFlipImageVertical) {
+		g.Add(gift.FlipVertical())
+	}
+	if d.hasQuirk(QuirkRotateImage90) {
+		g.Add(gift.Rotate270())
+	}
+	if d.hasQuirk(QuirkRotateImage180) {
+		g.Add(gift.Rotate180())
+	}
+	res := image.NewRGBA(g.Bounds(img.Bounds()))
+	g.Draw(res, img)
+	if unrotated, ok := d.unrotateButtonImage(res).(*image.RGBA); ok {
+		res = unrotated
+	}
+	return res, nil
+}
+
+// drawBadge draws white text on a red rounded badge in a corner of the given area of the image
+func drawBadge(img *image.RGBA, area image.Rectangle, text string, corner Corner) {
+	size := area.Dx()
+	if area.Dy() < size {
+		size = area.Dy()
+	}
+	fontSize := float64(size) / 5
+	height := int(fontSize * 1.4)
+	width := getTextWidth(text, fontSize) + height/2
+	if width < height {
+		width = height
+	}
+	if width > area.Dx() {
+		width = area.Dx()
+	}
+	margin := size / 16
+
+	badge := image.Rect(area.Max.X-margin-width, area.Min.Y+margin, area.Max.X-margin, area.Min.Y+margin+height)
+	if corner == CornerTopLeft || corner == CornerBottomLeft {
+		badge = badge.Add(image.Pt(area.Min.X+margin-badge.Min.X, 0))
+	}
+	if corner == CornerBottomRight || corner == CornerBottomLeft {
+		badge = badge.Add(image.Pt(0, area.Max.Y-margin-badge.Max.Y))
+	}
+
+	// A rectangle with a half circle at each end
+	red := image.NewUniform(color.RGBA{220, 30, 30, 255})
+	radius := height / 2
+	draw.Draw(img, image.Rect(badge.Min.X+radius, badge.Min.Y, badge.Max.X-radius, badge.Max.Y), red, image.Point{}, draw.Src)
+	for _, cx := range []int{badge.Min.X + radius, badge.Max.X - radius} {
+		cy := badge.Min.Y + radius
+		for x := cx - radius; x <= cx+radius; x++ {
+			for y := cy - radius; y <= cy+radius; y++ {
+				if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= radius*radius {
+					img.Set(x, y, red.C)
+				}
+			}
+		}
+	}
+
+	x := badge.Min.X + (badge.Dx()-getTextWidth(text, fontSize))/2
+	y := badge.Min.Y + height/2 + int(fontSize*0.35) // Centre on the cap height, roughly 70% of the font size
+	drawText(img, badge, x, y, fontSize, text, color.White)
+}
@@ -0,0 +1,130 @@
+package streamdeck
+
+import "sync"
+
+// Page holds a full set of button, decorator, encoder and touch assignments.  Pages are added to a StreamDeck
+// with AddPage, and swapped onto the device with SetPage; only the active page is drawn and receives events.
+type Page struct {
+	name string
+	sd   *StreamDeck
+
+	lock                  sync.Mutex
+	buttons               map[int]Button
+	decorators            map[int]ButtonDecorator
+	encoderPressHandlers  map[int]func(bool)
+	encoderRotateHandlers map[int]func(int)
+	touchPushHandler      func(uint16, uint16, bool)
+	touchSwipeHandler     func(uint16, uint16, uint16, uint16)
+	effects               map[int]*buttonEffect
+	bindings              map[int][]StateBinding
+	touchButtons          []*touchButton
+}
+
+// NewPage creates a new, empty, Page
+func NewPage(name string) *Page {
+	p := &Page{
+		name:                  name,
+		buttons:               make(map[int]Button),
+		decorators:            make(map[int]ButtonDecorator),
+		encoderPressHandlers:  make(map[int]func(bool)),
+		encoderRotateHandlers: make(map[int]func(int)),
+		effects:               make(map[int]*buttonEffect),
+		bindings:              make(map[int][]StateBinding),
+	}
+	return p
+}
+
+// GetName returns the name of the page
+func (p *Page) GetName() string {
+	return p.name
+}
+
+// AddButton adds a `Button` object to the page at the specified index
+func (p *Page) AddButton(btnIndex int, b Button) {
+	b.RegisterUpdateHandler(p.buttonUpdateHandler)
+	b.SetButtonIndex(btnIndex)
+	p.lock.Lock()
+	p.buttons[btnIndex] = b
+	p.lock.Unlock()
+	p.redraw(btnIndex)
+}
+
+// RemoveButton removes the button at the specified index, leaving it blank
+func (p *Page) RemoveButton(btnIndex int) {
+	p.lock.Lock()
+	delete(p.buttons, btnIndex)
+	p.lock.Unlock()
+	p.redraw(btnIndex)
+}
+
+// GetButtonIndex returns the button at the given index, or nil if there isn't one
+func (p *Page) GetButtonIndex(btnIndex int) Button {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.buttons[btnIndex]
+}
+
+// SetDecorator imposes a ButtonDecorator onto a given button
+func (p *Page) SetDecorator(btnIndex int, d ButtonDecorator) {
+	p.lock.Lock()
+	p.decorators[btnIndex] = d
+	p.lock.Unlock()
+	p.redraw(btnIndex)
+}
+
+// UnsetDecorator removes a ButtonDecorator from a given button
+func (p *Page) UnsetDecorator(btnIndex int) {
+	p.lock.Lock()
+	delete(p.decorators, btnIndex)
+	p.lock.Unlock()
+	p.redraw(btnIndex)
+}
+
+// SetEncoderPressHandler sets the function called when the given encoder is pressed or released while the page is active
+func (p *Page) SetEncoderPressHandler(encIndex int, f func(pressed bool)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.encoderPressHandlers[encIndex] = f
+}
+
+// SetEncoderRotateHandler sets the function called when the given encoder is rotated while the page is active
+func (p *Page) SetEncoderRotateHandler(encIndex int, f func(pulses int)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.encoderRotateHandlers[encIndex] = f
+}
+
+// SetTouchPushHandler sets the function called when the touchscreen is tapped or held while the page is active
+func (p *Page) SetTouchPushHandler(f func(x, y uint16, hold bool)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.touchPushHandler = f
+}
+
+// SetTouchSwipeHandler sets the function called when the touchscreen is swiped while the page is active
+func (p *Page) SetTouchSwipeHandler(f func(xstart, ystart, xstop, ystop uint16)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.touchSwipeHandler = f
+}
+
+// buttonUpdateHandler is registered with each button, so that it is redrawn when it changes
+func (p *Page) buttonUpdateHandler(b Button) {
+	btnIndex := b.GetButtonIndex()
+	p.lock.Lock()
+	current := p.buttons[btnIndex]
+	p.lock.Unlock()
+	if current == b {
+		p.redraw(btnIndex)
+	}
+}
+
+// redraw draws the given button, if the page is the active one on a StreamDeck
+func (p *Page) redraw(btnIndex int) {
+	p.lock.Lock()
+	sd := p.sd
+	p.lock.Unlock()
+	if sd != nil {
+		sd.redraw(p, btnIndex)
+	}
+}
@@ -0,0 +1,55 @@
+package streamdeck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PermissionError is returned when a Stream Deck is found but can't be opened because this user isn't allowed to,
+// with what to do about it
+type PermissionError struct {
+	ProductID         uint16
+	Path              string // The device node that couldn't be opened, if there is one
+	SuggestedUdevRule string // On Linux, the udev rule which gives logged in users access to this type of device
+	Guidance          string // What to do about it, in a sentence or two for showing to the user
+	Err               error  // The error from opening the device
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("No permission to open the Stream Deck (%s): %s", e.Err, e.Guidance)
+}
+
+// Unwrap returns the error from opening the device
+func (e *PermissionError) Unwrap() error {
+	return e.Err
+}
+
+// udevRule gives the udev rules for one product ID, covering both libusb (which Open uses) and hidraw access
+func udevRule(productID uint16) string {
+	return fmt.Sprintf("SUBSYSTEM==\"usb\", ATTRS{idVendor}==\"%04x\", ATTRS{idProduct}==\"%04x\", MODE=\"0660\", TAG+=\"uaccess\"\n"+
+		"KERNEL==\"hidraw*\", ATTRS{idVendor}==\"%04x\", ATTRS{idProduct}==\"%04x\", MODE=\"0660\", TAG+=\"uaccess\"\n",
+		vendorID, productID, vendorID, productID)
+}
+
+// UdevRules gives udev rules which let logged in users open every registered type of device (see the devices
+// package), for saving as /etc/udev/rules.d/50-streamdeck.rules
+func UdevRules() string {
+	var productIDs []int
+	seen := make(map[uint16]bool)
+	for _, devType := range deviceTypes {
+		if !seen[devType.usbProductID] {
+			seen[devType.usbProductID] = true
+			productIDs = append(productIDs, int(devType.usbProductID))
+		}
+	}
+	sort.Ints(productIDs)
+
+	var b strings.Builder
+	b.WriteString("# Elgato Stream Deck; save as /etc/udev/rules.d/50-streamdeck.rules, then run\n")
+	b.WriteString("# \"sudo udevadm control --reload-rules && sudo udevadm trigger\" and plug the devices in again\n")
+	for _, id := range productIDs {
+		b.WriteString(udevRule(uint16(id)))
+	}
+	return b.String()
+}
@@ -0,0 +1,20 @@
+//go:build darwin
+// +build darwin
+
+package streamdeck
+
+import (
+	"github.com/karalabe/hid"
+)
+
+// permissionProblem works out whether a device failed to open because of its permissions.  macOS doesn't say
+// why, but it is nearly always down to Input Monitoring not having been allowed, or another app having the
+// device open.
+func permissionProblem(device hid.DeviceInfo, err error) error {
+	return &PermissionError{
+		ProductID: device.ProductID,
+		Path:      device.Path,
+		Guidance:  "allow this program under System Settings > Privacy & Security > Input Monitoring, and quit the Elgato Stream Deck app if it is running",
+		Err:       err,
+	}
+}
@@ -0,0 +1,37 @@
+//go:build linux
+// +build linux
+
+package streamdeck
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/karalabe/hid"
+)
+
+// permissionProblem works out whether a device failed to open because of its permissions, giving a
+// PermissionError if so and otherwise the original error.  On Linux devices are opened through libusb, whose
+// paths are bus:address:interface, so the device node can be checked directly.
+func permissionProblem(device hid.DeviceInfo, err error) error {
+	var bus, address, iface int
+	if _, scanErr := fmt.Sscanf(device.Path, "%x:%x:%x", &bus, &address, &iface); scanErr != nil {
+		return err
+	}
+	path := fmt.Sprintf("/dev/bus/usb/%03d/%03d", bus, address)
+	f, openErr := os.OpenFile(path, os.O_RDWR, 0)
+	if openErr == nil {
+		f.Close()
+		return err
+	}
+	if !os.IsPermission(openErr) {
+		return err
+	}
+	return &PermissionError{
+		ProductID:         device.ProductID,
+		Path:              path,
+		SuggestedUdevRule: udevRule(device.ProductID),
+		Guidance:          "add a udev rule giving logged in users access (see UdevRules), or run as a user who can write to " + path,
+		Err:               err,
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package streamdeck
+
+import (
+	"github.com/karalabe/hid"
+)
+
+// permissionProblem gives the original error, as permission problems aren't detected on this platform
+func permissionProblem(device hid.DeviceInfo, err error) error {
+	return err
+}
@@ -0,0 +1,101 @@
+package streamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// ProgressStyle is the shape drawn by WriteProgressToButton
+type ProgressStyle int
+
+const (
+	// ProgressBar is a horizontal bar along the bottom of the button
+	ProgressBar ProgressStyle = iota
+	// ProgressArc is a ring, filling clockwise from the top
+	ProgressArc
+)
+
+// ProgressOptions are the options for WriteProgressToButton; the zero value is a green bar with no text
+type ProgressOptions struct {
+	Style            ProgressStyle
+	Colour           color.Color // Filled part, green if nil
+	TrackColour      color.Color // Unfilled part, dark grey if nil
+	BackgroundColour color.Color // Black if nil
+	ShowText         bool        // Write the percentage in the middle of the button
+	TextColour       color.Color // White if nil
+}
+
+// WriteProgressToButton draws a progress bar or arc onto a button, for pct between 0 and 100
+func (d *Device) WriteProgressToButton(btnIndex int, pct float64, opts ProgressOptions) error {
+	img := getProgressImage(pct, opts, d.GetButtonImageSize(btnIndex))
+	return d.WriteRawImageToButton(btnIndex, img)
+}
+
+func getProgressImage(pct float64, opts ProgressOptions, size image.Point) image.Image {
+	pct = math.Max(0, math.Min(100, pct))
+	colour := colourOrDefault(opts.Colour, color.RGBA{0, 200, 0, 255})
+	trackColour := colourOrDefault(opts.TrackColour, color.RGBA{48, 48, 48, 255})
+	backgroundColour := colourOrDefault(opts.BackgroundColour, color.Black)
+
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColour), image.Point{0, 0}, draw.Src)
+
+	switch opts.Style {
+	case ProgressArc:
+		cx, cy := float64(size.X)/2, float64(size.Y)/2
+		outer := math.Min(cx, cy) * 0.9
+		inner := outer * 0.75
+		limit := pct / 100 * 2 * math.Pi
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+				r := math.Hypot(dx, dy)
+				if r < inner || r > outer {
+					continue
+				}
+				// Angle clockwise from the top
+				angle := math.Atan2(dx, -dy)
+				if angle < 0 {
+					angle += 2 * math.Pi
+				}
+				if angle <= limit && pct > 0 {
+					img.Set(x, y, colour)
+				} else {
+					img.Set(x, y, trackColour)
+				}
+			}
+		}
+	default:
+		margin := size.X / 10
+		track := image.Rect(margin, size.Y*3/4, size.X-margin, size.Y*3/4+size.Y/8)
+		draw.Draw(img, track, image.NewUniform(trackColour), image.Point{0, 0}, draw.Src)
+		filled := track
+		filled.Max.X = track.Min.X + int(float64(track.Dx())*pct/100)
+		draw.Draw(img, filled, image.NewUniform(colour), image.Point{0, 0}, draw.Src)
+	}
+
+	if opts.ShowText {
+		textColour := colourOrDefault(opts.TextColour, color.White)
+		text := fmt.Sprintf("%.0f%%", pct)
+		fontSize := float64(size.Y) / 4
+		width := getTextWidth(text, fontSize)
+
+		y := size.Y/2 + int(fontSize*0.35) // Centre on the cap height, roughly 70% of the font size
+		if opts.Style == ProgressBar {
+			y = size.Y*3/8 + int(fontSize*0.35)
+		}
+		drawText(img, img.Bounds(), (size.X-width)/2, y, fontSize, text, textColour)
+	}
+	return img
+}
+
+// colourOrDefault returns c, or def if c is nil
+func colourOrDefault(c color.Color, def color.Color) color.Color {
+	if c == nil {
+		return def
+	}
+	return c
+}
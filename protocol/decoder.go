@@ -0,0 +1,204 @@
+// Package protocol decodes the input reports sent by Stream Decks into typed events, separately from the
+// transport they arrive over, so that the decoding can be tested (and fuzzed) without a device
+package protocol
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// EventType is the kind of an Event
+type EventType int
+
+const (
+	ButtonPress EventType = iota
+	ButtonRelease
+	EncoderPress
+	EncoderRelease
+	EncoderRotate
+	TouchTap
+	TouchHold
+	TouchSwipe
+)
+
+// touchReportLength is the length of a touch report, which is longest for a swipe
+const touchReportLength = 14
+
+// Debounce is how long after a press another press of the same button or encoder is ignored
+const Debounce = 100 * time.Millisecond
+
+// Event is one thing decoded from an input report
+type Event struct {
+	Type   EventType
+	Index  int    // Button or encoder; buttons are as numbered by the hardware, before any button map
+	Pulses int    // For EncoderRotate, positive clockwise
+	X, Y   uint16 // For touches, and the start of a swipe
+	XEnd   uint16 // For the end of a swipe
+	YEnd   uint16
+}
+
+// Layout is what a Decoder needs to know about a device to find things in its reports
+type Layout struct {
+	NumberOfButtons   int
+	ButtonReadOffset  int
+	NumberOfEncoders  int
+	EncoderReadOffset int
+	TouchscreenInput  bool
+}
+
+// Decoder turns a device's input reports into events.  Reports only carry which buttons are held, so it keeps
+// track of that in order to report presses and releases, debouncing presses.
+type Decoder struct {
+	layout Layout
+	now    func() time.Time
+
+	buttonMask  []bool
+	buttonTime  []time.Time
+	encoderMask []bool
+	encoderTime []time.Time
+	events      []Event // Reused by every call to Decode
+}
+
+// NewDecoder creates a Decoder for a device with the given layout.  As with the device itself, presses in the
+// first moment after this are ignored.
+func NewDecoder(layout Layout) *Decoder {
+	dec := &Decoder{
+		layout:      layout,
+		now:         time.Now,
+		buttonMask:  make([]bool, layout.NumberOfButtons),
+		buttonTime:  make([]time.Time, layout.NumberOfButtons),
+		encoderMask: make([]bool, layout.NumberOfEncoders),
+		encoderTime: make([]time.Time, layout.NumberOfEncoders),
+	}
+	start := dec.now()
+	for i := range dec.buttonTime {
+		dec.buttonTime[i] = start
+	}
+	for i := range dec.encoderTime {
+		dec.encoderTime[i] = start
+	}
+	return dec
+}
+
+// ReportLength is the length of input report needed to hold everything a device with this layout reports
+func (l Layout) ReportLength() int {
+	length := l.ButtonReadOffset + l.NumberOfButtons
+	if l.NumberOfEncoders > 0 && l.EncoderReadOffset+l.NumberOfEncoders > length {
+		length = l.EncoderReadOffset + l.NumberOfEncoders
+	}
+	if l.TouchscreenInput && length < touchReportLength {
+		length = touchReportLength
+	}
+	return length
+}
+
+// Decode decodes an input report, starting with its report ID.  Reports which aren't input events, or are too
+// short for what they claim to be, give no events.  So that decoding doesn't allocate, the events returned are
+// only valid until the next call.
+func (dec *Decoder) Decode(report []byte) []Event {
+	dec.events = dec.decode(report)
+	return dec.events
+}
+
+func (dec *Decoder) decode(report []byte) []Event {
+	dec.events = dec.events[:0]
+	if len(report) < 2 || report[0] != 1 { // Seems like the first byte is always one for events...
+		return dec.events
+	}
+	if (dec.layout.NumberOfEncoders > 0 || dec.layout.TouchscreenInput) && report[1] > 0 {
+		switch report[1] {
+		case 2:
+			return dec.decodeTouch(report)
+		case 3:
+			return dec.decodeEncoders(report)
+		}
+		return dec.events
+	}
+	return dec.decodeButtons(report)
+}
+
+func (dec *Decoder) decodeTouch(report []byte) []Event {
+	if len(report) < 10 {
+		return dec.events
+	}
+	x := binary.LittleEndian.Uint16(report[6:])
+	y := binary.LittleEndian.Uint16(report[8:])
+	switch report[4] {
+	case 1:
+		return append(dec.events, Event{Type: TouchTap, X: x, Y: y})
+	case 2:
+		return append(dec.events, Event{Type: TouchHold, X: x, Y: y})
+	case 3:
+		if len(report) < touchReportLength {
+			return dec.events
+		}
+		return append(dec.events, Event{
+			Type: TouchSwipe,
+			X:    x,
+			Y:    y,
+			XEnd: binary.LittleEndian.Uint16(report[10:]),
+			YEnd: binary.LittleEndian.Uint16(report[12:]),
+		})
+	}
+	return dec.events
+}
+
+func (dec *Decoder) decodeEncoders(report []byte) []Event {
+	offset := dec.layout.EncoderReadOffset
+	if len(report) < 5 || len(report) < offset+dec.layout.NumberOfEncoders {
+		return dec.events
+	}
+	events := dec.events
+	switch report[4] {
+	case 1: // Rotate
+		for i := 0; i < dec.layout.NumberOfEncoders; i++ {
+			if report[offset+i] > 0 {
+				events = append(events, Event{Type: EncoderRotate, Index: i, Pulses: int(int8(report[offset+i]))})
+			}
+		}
+	case 0: // Press
+		for i := 0; i < dec.layout.NumberOfEncoders; i++ {
+			if e, ok := dec.update(dec.encoderMask, dec.encoderTime, i, report[offset+i] == 1, EncoderPress, EncoderRelease); ok {
+				events = append(events, e)
+			}
+		}
+	}
+	return events
+}
+
+func (dec *Decoder) decodeButtons(report []byte) []Event {
+	offset := dec.layout.ButtonReadOffset
+	if len(report) < offset+dec.layout.NumberOfButtons {
+		return dec.events
+	}
+	events := dec.events
+	for i := 0; i < dec.layout.NumberOfButtons; i++ {
+		if e, ok := dec.update(dec.buttonMask, dec.buttonTime, i, report[offset+i] == 1, ButtonPress, ButtonRelease); ok {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// update tracks whether a button or encoder is held.  A release is only reported if there has been a press
+// first, as debouncing can lead to presses being ignored.
+func (dec *Decoder) update(mask []bool, times []time.Time, i int, held bool, press EventType, release EventType) (Event, bool) {
+	if held {
+		now := dec.now()
+		if !now.After(times[i].Add(Debounce)) {
+			return Event{}, false
+		}
+		wasHeld := mask[i]
+		mask[i] = true
+		if !wasHeld {
+			times[i] = now
+			return Event{Type: press, Index: i}, true
+		}
+		return Event{}, false
+	}
+	if mask[i] {
+		mask[i] = false
+		return Event{Type: release, Index: i}, true
+	}
+	return Event{}, false
+}
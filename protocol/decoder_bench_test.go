@@ -0,0 +1,32 @@
+package protocol
+
+import (
+	"testing"
+	"time"
+)
+
+func BenchmarkDecodeButtons(b *testing.B) {
+	dec := NewDecoder(Layout{NumberOfButtons: 32, ButtonReadOffset: 4})
+	dec.now = func() time.Time { return time.Unix(1<<40, 0) }
+	pressed := make([]byte, 255)
+	pressed[0], pressed[4+7] = 1, 1
+	released := make([]byte, 255)
+	released[0] = 1
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dec.Decode(pressed)
+		dec.Decode(released)
+	}
+}
+
+func BenchmarkDecodeEncoders(b *testing.B) {
+	dec := NewDecoder(Layout{NumberOfButtons: 8, ButtonReadOffset: 4, NumberOfEncoders: 4, EncoderReadOffset: 5, TouchscreenInput: true})
+	report := make([]byte, 255)
+	report[0], report[1], report[4], report[5], report[7] = 1, 3, 1, 1, 0xff
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dec.Decode(report)
+	}
+}
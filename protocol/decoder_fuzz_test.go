@@ -0,0 +1,50 @@
+//go:build go1.18
+// +build go1.18
+
+package protocol
+
+import (
+	"testing"
+	"time"
+)
+
+// layouts covers the shapes of report the devices send: buttons only (original and v2 offsets), and buttons
+// with encoders and a touchscreen (Plus)
+var layouts = []Layout{
+	{NumberOfButtons: 15, ButtonReadOffset: 1},
+	{NumberOfButtons: 32, ButtonReadOffset: 4},
+	{NumberOfButtons: 8, ButtonReadOffset: 4, NumberOfEncoders: 4, EncoderReadOffset: 5, TouchscreenInput: true},
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte{1, 0, 0, 0, 1, 0, 0, 0})                   // Button press
+	f.Add([]byte{1, 3, 0, 0, 1, 0xff, 0x02, 0, 0})          // Encoders turned both ways
+	f.Add([]byte{1, 3, 0, 0, 0, 1, 0, 0, 1})                // Encoders pressed
+	f.Add([]byte{1, 2, 0, 0, 1, 0, 0x10, 0, 0x20, 0})       // Touch
+	f.Add([]byte{1, 2, 0, 0, 3, 0, 1, 0, 2, 0, 3, 0, 4, 0}) // Swipe
+	f.Add([]byte{1, 2, 0, 0, 3, 0, 1, 0, 2, 0})             // Swipe cut short
+	f.Add([]byte{1})                                        // Nothing after the report ID
+	f.Add([]byte{})                                         // Empty read
+
+	f.Fuzz(func(t *testing.T, report []byte) {
+		for _, layout := range layouts {
+			dec := NewDecoder(layout)
+			dec.now = func() time.Time { return time.Unix(1<<40, 0) } // Well past debouncing
+			for _, e := range dec.Decode(report) {
+				switch e.Type {
+				case ButtonPress, ButtonRelease:
+					if e.Index < 0 || e.Index >= layout.NumberOfButtons {
+						t.Errorf("Button %d out of range for %+v", e.Index, layout)
+					}
+				case EncoderPress, EncoderRelease, EncoderRotate:
+					if e.Index < 0 || e.Index >= layout.NumberOfEncoders {
+						t.Errorf("Encoder %d out of range for %+v", e.Index, layout)
+					}
+					if e.Type == EncoderRotate && e.Pulses == 0 {
+						t.Errorf("Encoder %d rotated by nothing", e.Index)
+					}
+				}
+			}
+		}
+	})
+}
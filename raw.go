@@ -0,0 +1,49 @@
+package streamdeck
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SendRaw sends an output report straight to the device, for experimenting with commands this package doesn't
+// know about.  The first byte is the report ID.  Reports shorter than the device's output report length (see
+// GetOutputReportLength) are zero-padded; longer ones are refused.
+func (d *Device) SendRaw(report []byte) error {
+	report, err := padReport(report, d.GetOutputReportLength())
+	if err != nil {
+		return err
+	}
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+	_, err = d.fd.Write(report)
+	return err
+}
+
+// SendFeature sends a feature report straight to the device, such as "03 05 01", for experimenting with
+// commands this package doesn't know about.  The first byte is the report ID.  Reports are zero-padded to the
+// device's feature report length (17 or 32 bytes, the length of its reset packet); longer ones are refused.
+func (d *Device) SendFeature(report []byte) error {
+	report, err := padReport(report, len(d.deviceType.resetPacket))
+	if err != nil {
+		return err
+	}
+	_, err = d.fd.SendFeatureReport(report)
+	return err
+}
+
+// padReport zero-pads a report up to length; a length of zero means the length isn't known, and the report is
+// sent as it is
+func padReport(report []byte, length int) ([]byte, error) {
+	if len(report) == 0 {
+		return nil, errors.New("Report is empty; it needs at least a report ID")
+	}
+	if length == 0 {
+		return report, nil
+	}
+	if len(report) > length {
+		return nil, fmt.Errorf("Report is %d bytes, but this device takes at most %d", len(report), length)
+	}
+	padded := make([]byte, length)
+	copy(padded, report)
+	return padded, nil
+}
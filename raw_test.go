@@ -0,0 +1,44 @@
+package streamdeck_test
+
+import (
+	"strings"
+	"testing"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+// TestSendRawReportLength sends raw reports to a deck whose output reports are longer than its image pages, which
+// are padded to, and limited by, the output report length
+func TestSendRawReportLength(t *testing.T) {
+	err := streamdeck.RegisterDevicetypeFromReader(strings.NewReader(`{"name": "Long reports", "usbProductID": 65523,
+		"imageWidth": 72, "imageHeight": 72, "numberOfButtons": 6, "buttonRows": 2, "buttonCols": 3,
+		"imageFormat": "JPEG", "imagePayloadPerPage": 512, "outputReportLength": 1024,
+		"imageHeader": "02 07 {btn} {last} {len:le16} {page:le16}"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := streamdecktest.NewMock(protocol.Layout{})
+	d, err := streamdeck.OpenWithInterface(mock, 65523, "RAW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	report := make([]byte, 700)
+	report[0], report[1] = 0x02, 0xaa
+	if err := d.SendRaw(report); err != nil {
+		t.Fatal(err)
+	}
+	sent := mock.WritesWithPrefix([]byte{0x02, 0xaa})
+	if len(sent) != 1 {
+		t.Fatalf("Sent %d reports for one", len(sent))
+	}
+	if len(sent[0]) != 1024 {
+		t.Errorf("A 700 byte report was padded to %d bytes, not 1024", len(sent[0]))
+	}
+	if err := d.SendRaw(make([]byte, 1025)); err == nil {
+		t.Error("A 1025 byte report was sent")
+	}
+}
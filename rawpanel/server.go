@@ -0,0 +1,392 @@
+// Package rawpanel serves a deck as a SKAARHOJ Raw Panel, so that it can take the place of a hardware panel for
+// anything that drives Raw Panels, such as Blue Pill.  Only the ASCII protocol is spoken; the binary protocol is
+// built on protocol buffers, which this module doesn't depend on.
+//
+// Each key is a hardware component (HWC) numbered from 1, and the encoders follow on after the keys.  Key presses
+// are sent as "HWC#n=Down" and "HWC#n=Up", and turning an encoder as "HWC#n=Enc:pulses".  Clients set keys up
+// with these commands, where ids are one or more HWC numbers separated by commas:
+//
+//	HWC#ids=state            LED state: the lower four bits are 0 for off, 4 for dimmed, 2 for green, 3 for red and anything else for on
+//	HWCc#ids=colour          LED colour: with bit 6 set, the lower six bits are 2-bit red, green and blue; otherwise a colour index
+//	HWCt#ids=text            Display text, as value|format|fine|title|..., of which the title and value are shown
+//	HWCgRGB#ids=i/last,WxH:data   Display image, in parts i to last of base64 16-bit RGB565 pixels (big-endian)
+//	PanelBrightness=n[,m]    Brightness, from 0 to 8; with two values, the second (for displays) is used
+//	Clear                    Clear every key
+//
+// "ping" is answered with "ack", and "list" with the panel's model, serial, version and map of HWCs.
+package rawpanel
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// DefaultPort is the port Raw Panel clients connect to
+const DefaultPort = 9923
+
+// maxImageSize limits the width and height of the images clients send, which are the size of a key
+const maxImageSize = 512
+
+// colourIndexes are SKAARHOJ's colour indexes, starting with the default
+var colourIndexes = []color.RGBA{
+	{255, 255, 255, 255}, // Default
+	{0, 0, 0, 255},       // Off
+	{255, 255, 255, 255}, // White
+	{255, 160, 80, 255},  // Warm
+	{255, 0, 0, 255},     // Red
+	{255, 60, 100, 255},  // Rose
+	{255, 0, 170, 255},   // Pink
+	{160, 0, 255, 255},   // Purple
+	{255, 140, 0, 255},   // Amber
+	{255, 230, 0, 255},   // Yellow
+	{0, 0, 170, 255},     // Dark blue
+	{0, 80, 255, 255},    // Blue
+	{160, 200, 255, 255}, // Ice
+	{0, 220, 255, 255},   // Cyan
+	{120, 255, 60, 255},  // Spring
+	{0, 255, 0, 255},     // Green
+	{100, 255, 170, 255}, // Mint
+}
+
+// keyState is what a client has set a key to show
+type keyState struct {
+	mode   int
+	colour color.RGBA
+	text   string
+	img    image.Image
+	parts  []string // Parts of an image still being received
+}
+
+// Server serves one deck to any number of Raw Panel clients, which all see the same events
+type Server struct {
+	d *streamdeck.Device
+
+	lock      sync.Mutex
+	keys      []keyState
+	clients   map[*client]bool
+	listener  net.Listener
+	listeners []func() // Remove the deck's listeners, see Close
+}
+
+type client struct {
+	conn      net.Conn
+	writeLock sync.Mutex
+}
+
+func (c *client) send(line string) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_, err := fmt.Fprintf(c.conn, "%s\n", line)
+	return err
+}
+
+// NewServer creates a Server for a deck, passing on its events from now on, until it is closed
+func NewServer(d *streamdeck.Device) *Server {
+	s := &Server{
+		d:       d,
+		keys:    make([]keyState, d.GetNumberOfButtons()),
+		clients: make(map[*client]bool),
+	}
+	for i := range s.keys {
+		s.keys[i].colour = colourIndexes[0]
+	}
+	numButtons := int(d.GetNumberOfButtons())
+	s.listeners = []func(){
+		d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, pressed bool) {
+			if err == nil {
+				s.broadcast(fmt.Sprintf("HWC#%d=%s", btnIndex+1, upDown(pressed)))
+			}
+		}),
+		d.EncoderPress(func(encIndex int, d *streamdeck.Device, pressed bool) {
+			s.broadcast(fmt.Sprintf("HWC#%d=%s", numButtons+encIndex+1, upDown(pressed)))
+		}),
+		d.EncoderRotate(func(encIndex int, d *streamdeck.Device, pulses int) {
+			s.broadcast(fmt.Sprintf("HWC#%d=Enc:%d", numButtons+encIndex+1, pulses))
+		}),
+	}
+	return s
+}
+
+func upDown(pressed bool) string {
+	if pressed {
+		return "Down"
+	}
+	return "Up"
+}
+
+// ListenAndServe listens on the given address (":9923" for DefaultPort on every interface) and serves clients
+// until Close is called
+func (s *Server) ListenAndServe(address string) error {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve serves clients connecting to the listener until Close is called
+func (s *Server) Serve(l net.Listener) error {
+	s.lock.Lock()
+	s.listener = l
+	s.lock.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		c := &client{conn: conn}
+		s.lock.Lock()
+		s.clients[c] = true
+		s.lock.Unlock()
+		go s.serveClient(c)
+	}
+}
+
+// Close stops listening and disconnects every client
+func (s *Server) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, remove := range s.listeners {
+		remove()
+	}
+	s.listeners = nil
+	for c := range s.clients {
+		c.conn.Close()
+	}
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+func (s *Server) broadcast(line string) {
+	s.lock.Lock()
+	clients := make([]*client, 0, len(s.clients))
+	for c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.lock.Unlock()
+	for _, c := range clients {
+		c.send(line)
+	}
+}
+
+func (s *Server) serveClient(c *client) {
+	defer func() {
+		s.lock.Lock()
+		delete(s.clients, c)
+		s.lock.Unlock()
+		c.conn.Close()
+	}()
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(nil, 1<<20) // Images come in long lines
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "ping":
+			c.send("ack")
+		case line == "list":
+			s.list(c)
+		case line == "Clear":
+			s.clear()
+		case strings.HasPrefix(line, "PanelBrightness="):
+			s.brightness(strings.TrimPrefix(line, "PanelBrightness="))
+		case strings.HasPrefix(line, "HWC"):
+			s.command(line)
+		}
+	}
+}
+
+func (s *Server) list(c *client) {
+	c.send("_model=" + strings.Replace(s.d.GetName(), " ", "", -1))
+	c.send("_serial=" + s.d.GetSerial())
+	c.send("_version=1.0")
+	total := int(s.d.GetNumberOfButtons()) + int(s.d.Capabilities().NumberOfEncoders)
+	for hwc := 1; hwc <= total; hwc++ {
+		c.send(fmt.Sprintf("map=%d:%d", hwc, hwc))
+	}
+	c.send("list")
+}
+
+func (s *Server) brightness(value string) {
+	if i := strings.Index(value, ","); i >= 0 {
+		value = value[i+1:]
+	}
+	level, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+	s.d.SetBrightness(level * 100 / 8)
+}
+
+func (s *Server) clear() {
+	s.lock.Lock()
+	for i := range s.keys {
+		s.keys[i] = keyState{colour: colourIndexes[0]}
+	}
+	s.lock.Unlock()
+	s.d.ClearButtons()
+}
+
+// command handles the HWC commands, which are of the form HWCx#ids=value
+func (s *Server) command(line string) {
+	hash := strings.Index(line, "#")
+	equals := strings.Index(line, "=")
+	if hash < 0 || equals < hash {
+		return
+	}
+	kind, value := line[3:hash], line[equals+1:]
+	for _, id := range strings.Split(line[hash+1:equals], ",") {
+		hwc, err := strconv.Atoi(id)
+		if err != nil || hwc < 1 || hwc > len(s.keys) {
+			continue
+		}
+		s.lock.Lock()
+		key := &s.keys[hwc-1]
+		redraw := true
+		switch kind {
+		case "":
+			if mode, err := strconv.Atoi(value); err == nil {
+				key.mode = mode & 0xf
+			}
+		case "c":
+			if v, err := strconv.Atoi(value); err == nil {
+				key.colour = parseColour(v)
+			}
+		case "t":
+			key.text = displayText(value)
+			key.img = nil
+		case "gRGB":
+			redraw = key.addImagePart(value)
+		default:
+			redraw = false
+		}
+		state := *key
+		s.lock.Unlock()
+		if redraw {
+			s.draw(hwc-1, state)
+		}
+	}
+}
+
+// parseColour reads an HWCc colour: with bit 6 set it is 2-bit red, green and blue, otherwise a colour index
+func parseColour(v int) color.RGBA {
+	if v&0x40 != 0 {
+		return color.RGBA{uint8((v >> 4 & 3) * 85), uint8((v >> 2 & 3) * 85), uint8((v & 3) * 85), 255}
+	}
+	v &= 0x3f
+	if v < len(colourIndexes) {
+		return colourIndexes[v]
+	}
+	return colourIndexes[0]
+}
+
+// displayText gives what is shown of an HWCt value|format|fine|title|... text: the title and the value
+func displayText(value string) string {
+	fields := strings.Split(value, "|")
+	text := fields[0]
+	if len(fields) > 3 && fields[3] != "" {
+		text = strings.TrimSpace(fields[3] + " " + text)
+	}
+	return text
+}
+
+// addImagePart adds a part of an HWCgRGB image, "i/last,WxH:data" (the size only being given with the first
+// part), returning whether the image is complete
+func (k *keyState) addImagePart(value string) bool {
+	colon := strings.Index(value, ":")
+	if colon < 0 {
+		return false
+	}
+	header, data := value[:colon], value[colon+1:]
+	var part, last int
+	if _, err := fmt.Sscanf(header, "%d/%d", &part, &last); err != nil || part < 0 || part > last {
+		return false
+	}
+	if part == 0 {
+		k.parts = []string{header}
+	}
+	if len(k.parts) != part+1 {
+		k.parts = nil // A part went missing, so wait for the next image
+		return false
+	}
+	k.parts = append(k.parts, data)
+	if part < last {
+		return false
+	}
+
+	var width, height int
+	if i := strings.Index(k.parts[0], ","); i < 0 {
+		return false
+	} else if _, err := fmt.Sscanf(k.parts[0][i+1:], "%dx%d", &width, &height); err != nil ||
+		width <= 0 || height <= 0 || width > maxImageSize || height > maxImageSize {
+		k.parts = nil
+		return false
+	}
+	// Each part is encoded separately
+	var pixels []byte
+	for _, part := range k.parts[1:] {
+		data, err := base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			k.parts = nil
+			return false
+		}
+		pixels = append(pixels, data...)
+	}
+	k.parts = nil
+	if len(pixels) < width*height*2 {
+		return false
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < width*height; i++ {
+		p := int(pixels[i*2])<<8 | int(pixels[i*2+1])
+		r, g, b := p>>11&0x1f, p>>5&0x3f, p&0x1f
+		img.Pix[i*4] = uint8(r<<3 | r>>2)
+		img.Pix[i*4+1] = uint8(g<<2 | g>>4)
+		img.Pix[i*4+2] = uint8(b<<3 | b>>2)
+		img.Pix[i*4+3] = 255
+	}
+	k.img = img
+	return true
+}
+
+// draw shows a key: its image if it has one, otherwise its text on its LED colour, which is darker when the
+// LED is dimmed and black when it is off
+func (s *Server) draw(btnIndex int, k keyState) {
+	if k.img != nil {
+		s.d.WriteRawImageToButton(btnIndex, k.img)
+		return
+	}
+	var background color.RGBA
+	switch k.mode {
+	case 0:
+		background = colourIndexes[1]
+	case 4:
+		background = color.RGBA{k.colour.R / 4, k.colour.G / 4, k.colour.B / 4, 255}
+	case 2:
+		background = colourIndexes[15]
+	case 3:
+		background = colourIndexes[4]
+	default:
+		background = k.colour
+	}
+	if k.text == "" {
+		s.d.WriteColorToButton(btnIndex, background)
+		return
+	}
+	// Text is black on bright colours, so that it can still be read
+	var textColour color.Color = color.White
+	if 299*int(background.R)+587*int(background.G)+114*int(background.B) > 150000 {
+		textColour = color.Black
+	}
+	s.d.WriteTextToButton(btnIndex, k.text, textColour, background)
+}
@@ -0,0 +1,31 @@
+package rawpanel
+
+import (
+	"encoding/base64"
+	"image/color"
+	"testing"
+)
+
+func TestAddImagePart(t *testing.T) {
+	var k keyState
+	// Two RGB565 pixels, red and blue, sent in two parts
+	if k.addImagePart("0/1,2x1:" + base64.StdEncoding.EncodeToString([]byte{0xf8, 0x00})) {
+		t.Fatal("The image was complete after its first part")
+	}
+	if !k.addImagePart("1/1:" + base64.StdEncoding.EncodeToString([]byte{0x00, 0x1f})) {
+		t.Fatal("The image wasn't complete after its last part")
+	}
+	if got := k.img.At(0, 0); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("The first pixel is %v", got)
+	}
+	if got := k.img.At(1, 0); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("The second pixel is %v", got)
+	}
+
+	for _, header := range []string{"0/0,0x1", "0/0,-1x1", "0/0,3037000500x3037000500", "0/0,2x100000"} {
+		k = keyState{}
+		if k.addImagePart(header + ":" + base64.StdEncoding.EncodeToString(make([]byte, 8))) {
+			t.Errorf("An image of %s was accepted", header)
+		}
+	}
+}
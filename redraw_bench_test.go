@@ -0,0 +1,56 @@
+package streamdeck_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+// BenchmarkFullDeckRedraw writes an image to every button of an XL, as a page switch does
+func BenchmarkFullDeckRedraw(b *testing.B) {
+	mock := streamdecktest.NewMock(protocol.Layout{})
+	d, err := streamdeck.OpenWithInterface(mock, 0x6c, "BENCH")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer d.Close()
+	img := image.NewRGBA(image.Rect(0, 0, 96, 96))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0, 128, 255, 255}), image.Point{}, draw.Src)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for btnIndex := 0; btnIndex < int(d.GetNumberOfButtons()); btnIndex++ {
+			d.WriteRawImageToButton(btnIndex, img)
+		}
+		mock.ClearRecorded()
+	}
+}
+
+// BenchmarkFullDeckRedrawBatch writes the same images as BenchmarkFullDeckRedraw, encoding them in parallel
+func BenchmarkFullDeckRedrawBatch(b *testing.B) {
+	mock := streamdecktest.NewMock(protocol.Layout{})
+	d, err := streamdeck.OpenWithInterface(mock, 0x6c, "BENCH")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer d.Close()
+	img := image.NewRGBA(image.Rect(0, 0, 96, 96))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0, 128, 255, 255}), image.Point{}, draw.Src)
+	images := make(map[int]image.Image)
+	for btnIndex := 0; btnIndex < int(d.GetNumberOfButtons()); btnIndex++ {
+		images[btnIndex] = img
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.WriteRawImagesToButtons(images)
+		mock.ClearRecorded()
+	}
+}
@@ -0,0 +1,107 @@
+package streamdeck
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/disintegration/gift"
+)
+
+// SetRotation allows for a deck mounted turned round, such as on its side in a panel, by how far it is turned
+// clockwise: 0, 90, 180 or 270 degrees.  The button grid is numbered (see ButtonAt, GetButtonRows and
+// GetButtonCols) and images are written the right way up as the deck is seen, buttons being numbered from the top
+// left as usual.  The rotation is applied on top of the device's own button map (the original Streamdeck numbers
+// its buttons right to left), replacing any set with SetButtonMap, and 0 degrees goes back to the device's own map.
+// Images already on the buttons are left as they are, as is the touchscreen.
+func (d *Device) SetRotation(degrees int) error {
+	switch degrees {
+	case 0, 90, 180, 270:
+	default:
+		return fmt.Errorf("Rotation must be 0, 90, 180 or 270 degrees, not %d", degrees)
+	}
+
+	// Nil, for no rotation, goes back to the definition's map
+	var buttonMap map[uint]int
+	if degrees != 0 {
+		rows, cols := int(d.deviceType.buttonRows), int(d.deviceType.buttonCols)
+		buttonMap = make(map[uint]int, d.deviceType.numberOfButtons)
+		for hw := uint(0); hw < d.deviceType.numberOfButtons; hw++ {
+			// Where the definition puts the button, counting from the top left of the deck the right way up
+			index := int(hw)
+			if mapped, ok := d.deviceType.buttonMap[hw]; ok {
+				index = mapped
+			}
+			if index >= rows*cols {
+				buttonMap[hw] = index // Not part of the grid, such as the Neo's paging buttons
+				continue
+			}
+			row, col := index/cols, index%cols
+			var seenRow, seenCol, seenCols int
+			switch degrees {
+			case 90:
+				seenRow, seenCol, seenCols = col, rows-1-row, rows
+			case 180:
+				seenRow, seenCol, seenCols = rows-1-row, cols-1-col, cols
+			case 270:
+				seenRow, seenCol, seenCols = cols-1-col, row, rows
+			}
+			buttonMap[hw] = seenRow*seenCols + seenCol
+		}
+	}
+
+	d.buttonMapLock.Lock()
+	defer d.buttonMapLock.Unlock()
+	d.buttonMap = buttonMap
+	d.rotation = degrees
+	return nil
+}
+
+// GetRotation returns how far the deck is turned clockwise, see SetRotation
+func (d *Device) GetRotation() int {
+	d.buttonMapLock.RLock()
+	defer d.buttonMapLock.RUnlock()
+	return d.rotation
+}
+
+// gridSize returns the number of rows and columns of buttons, as the deck is seen
+func (d *Device) gridSize() (int, int) {
+	rows, cols := int(d.deviceType.buttonRows), int(d.deviceType.buttonCols)
+	if rotation := d.GetRotation(); rotation == 90 || rotation == 270 {
+		return cols, rows
+	}
+	return rows, cols
+}
+
+// rotateButtonImage turns an image the opposite way to the deck, so that it is seen the right way up
+func (d *Device) rotateButtonImage(img image.Image) image.Image {
+	// The gift rotations are anticlockwise
+	switch d.GetRotation() {
+	case 90:
+		return applyFilter(img, gift.Rotate90())
+	case 180:
+		return applyFilter(img, gift.Rotate180())
+	case 270:
+		return applyFilter(img, gift.Rotate270())
+	}
+	return img
+}
+
+// unrotateButtonImage undoes rotateButtonImage
+func (d *Device) unrotateButtonImage(img image.Image) image.Image {
+	switch d.GetRotation() {
+	case 90:
+		return applyFilter(img, gift.Rotate270())
+	case 180:
+		return applyFilter(img, gift.Rotate180())
+	case 270:
+		return applyFilter(img, gift.Rotate90())
+	}
+	return img
+}
+
+func applyFilter(img image.Image, filter gift.Filter) image.Image {
+	g := gift.New(filter)
+	res := image.NewRGBA(g.Bounds(img.Bounds()))
+	g.Draw(res, img)
+	return res
+}
@@ -0,0 +1,147 @@
+package streamdeck_test
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"strings"
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+// TestRotationOriginal checks the numbering at each rotation of the original Streamdeck, whose hardware numbers its
+// 5x3 buttons from the top right, right to left; its button map has to be kept under the rotation
+func TestRotationOriginal(t *testing.T) {
+	d, mock, err := streamdecktest.Open(0x60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	pressed := make(chan int, 4)
+	d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, isPressed bool) {
+		if err == nil && isPressed {
+			pressed <- btnIndex
+		}
+	})
+
+	// The hardware numbers of the buttons at the deck's top left and top right, and where they are seen
+	const topLeft, topRight = 4, 0
+	for _, tc := range []struct {
+		degrees           int
+		topLeft, topRight int
+	}{
+		{0, 0, 4},
+		{90, 2, 14},
+		{180, 14, 10},
+		{270, 12, 0},
+		{0, 0, 4},
+	} {
+		if err := d.SetRotation(tc.degrees); err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range []struct{ hw, seen int }{{topLeft, tc.topLeft}, {topRight, tc.topRight}} {
+			mock.TapButton(b.hw)
+			select {
+			case got := <-pressed:
+				if got != b.seen {
+					t.Errorf("At %d degrees, hardware button %d is numbered %d, not %d", tc.degrees, b.hw, got, b.seen)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("At %d degrees, pressing hardware button %d was missed", tc.degrees, b.hw)
+			}
+
+			mock.ClearRecorded()
+			if err := d.WriteEncodedImageToButton(b.seen, []byte{0}); err != nil {
+				t.Fatal(err)
+			}
+			pages := mock.WritesWithPrefix([]byte{0x02, 0x01})
+			if len(pages) == 0 || int(pages[0][5]) != b.hw+1 {
+				t.Errorf("At %d degrees, writing button %d didn't go to hardware button %d", tc.degrees, b.seen, b.hw)
+			}
+		}
+		time.Sleep(protocol.Debounce) // So the next presses of the same buttons aren't ignored
+	}
+}
+
+// TestRotationGeometry turns a deck with one short key, whose geometry is given by its hardware number, and checks
+// the key is sized and drawn as short whichever number it's seen under, and its neighbours aren't
+func TestRotationGeometry(t *testing.T) {
+	const def = `{"name": "Short key", "usbProductID": 65524, "imageWidth": 72, "imageHeight": 72,
+		"numberOfButtons": 4, "buttonRows": 2, "buttonCols": 2, "buttonReadOffset": 4, "imageFormat": "JPEG",
+		"imagePayloadPerPage": 1024, "imageHeader": "02 07 {btn} {last} {len:le16} {page:le16}",
+		"buttonGeometry": {"0": [0, 0, 72, 36]}}`
+	if err := streamdeck.RegisterDevicetypeFromReader(strings.NewReader(def)); err != nil {
+		t.Fatal(err)
+	}
+	mock := streamdecktest.NewMock(protocol.Layout{NumberOfButtons: 4, ButtonReadOffset: 4})
+	d, err := streamdeck.OpenWithInterface(mock, 65524, "SHORT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	pressed := make(chan int, 4)
+	d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, isPressed bool) {
+		if err == nil && isPressed {
+			pressed <- btnIndex
+		}
+	})
+	time.Sleep(protocol.Debounce) // As streamdecktest.Open does
+
+	const short, full = 0, 3 // Hardware numbers
+	for _, degrees := range []int{0, 90, 180, 270} {
+		if err := d.SetRotation(degrees); err != nil {
+			t.Fatal(err)
+		}
+		for _, hw := range []int{short, full} {
+			mock.TapButton(hw)
+			var seen int
+			select {
+			case seen = <-pressed:
+			case <-time.After(time.Second):
+				t.Fatalf("At %d degrees, pressing hardware button %d was missed", degrees, hw)
+			}
+
+			want := image.Pt(72, 72)
+			if hw == short {
+				want = image.Pt(72, 36)
+				if degrees == 90 || degrees == 270 {
+					want = image.Pt(36, 72)
+				}
+			}
+			size := d.GetButtonImageSize(seen)
+			if size != want {
+				t.Errorf("At %d degrees, button %d (hardware %d) is %v, not %v", degrees, seen, hw, size, want)
+			}
+
+			white := image.NewRGBA(image.Rectangle{Max: size})
+			draw.Draw(white, white.Bounds(), image.White, image.Point{}, draw.Src)
+			mock.ClearRecorded()
+			if err := d.WriteRawImageToButton(seen, white); err != nil {
+				t.Fatal(err)
+			}
+			var frame []byte
+			for _, page := range mock.WritesWithPrefix([]byte{0x02, 0x07, byte(hw)}) {
+				n := int(page[4]) | int(page[5])<<8
+				frame = append(frame, page[8:8+n]...)
+			}
+			img, err := jpeg.Decode(bytes.NewReader(frame))
+			if err != nil {
+				t.Fatalf("At %d degrees, button %d (hardware %d) wasn't sent a JPEG: %s", degrees, seen, hw, err)
+			}
+			// Only the top half of the short key is visible, and it should be left black below that
+			top, _, _, _ := img.At(36, 18).RGBA()
+			bottom, _, _, _ := img.At(36, 54).RGBA()
+			if top < 0xc000 || (hw == short) != (bottom < 0x4000) {
+				t.Errorf("At %d degrees, button %d (hardware %d) was drawn with %#x at the top and %#x at the bottom",
+					degrees, seen, hw, top, bottom)
+			}
+		}
+		time.Sleep(protocol.Debounce)
+	}
+}
@@ -0,0 +1,165 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// brightnessRule sets the brightness from a time of day, in minutes after midnight, until the next rule
+type brightnessRule struct {
+	minute int
+	pct    int
+}
+
+// Brightness sets the brightness of every device in a Manager through the day, such as dimming them at night.
+// Rules give the brightness from a time of day until the next rule, carrying over midnight; alternatively the
+// application can feed in an ambient light level, which takes over from the rules until it is cleared.
+// Devices opened later are brought into line within a minute.
+type Brightness struct {
+	lock       sync.Mutex
+	manager    *streamdeck.Manager
+	rules      []brightnessRule
+	ambient    float64
+	hasAmbient bool
+	min, max   int
+	applied    map[*streamdeck.Device]int
+	stop       chan struct{}
+}
+
+// NewBrightness creates a Brightness schedule for the devices in a Manager, with no rules (so 100%) and an
+// ambient range of 10% to 100%; it does nothing until it is started
+func NewBrightness(m *streamdeck.Manager) *Brightness {
+	return &Brightness{manager: m, min: 10, max: 100, applied: make(map[*streamdeck.Device]int)}
+}
+
+// AddRule sets the brightness, as a percentage, from a time of day given as "HH:MM" in local time
+func (b *Brightness) AddRule(at string, pct int) error {
+	var hour, minute int
+	if _, err := fmt.Sscanf(at, "%d:%d", &hour, &minute); err != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return fmt.Errorf("Invalid time of day %q; it should be like 07:30", at)
+	}
+	if pct < 0 || pct > 100 {
+		return fmt.Errorf("Brightness must be a percentage")
+	}
+	b.lock.Lock()
+	b.rules = append(b.rules, brightnessRule{minute: hour*60 + minute, pct: pct})
+	sort.Slice(b.rules, func(i, j int) bool { return b.rules[i].minute < b.rules[j].minute })
+	b.lock.Unlock()
+	b.apply()
+	return nil
+}
+
+// SetAmbientRange sets the brightness at the darkest (0) and brightest (1) ambient light levels
+func (b *Brightness) SetAmbientRange(min, max int) {
+	b.lock.Lock()
+	b.min, b.max = min, max
+	b.lock.Unlock()
+	b.apply()
+}
+
+// SetAmbient feeds in an ambient light level between 0 and 1, such as from a light sensor, which sets the
+// brightness instead of the rules
+func (b *Brightness) SetAmbient(level float64) {
+	if level < 0 {
+		level = 0
+	} else if level > 1 {
+		level = 1
+	}
+	b.lock.Lock()
+	b.ambient = level
+	b.hasAmbient = true
+	b.lock.Unlock()
+	b.apply()
+}
+
+// ClearAmbient goes back to following the rules
+func (b *Brightness) ClearAmbient() {
+	b.lock.Lock()
+	b.hasAmbient = false
+	b.lock.Unlock()
+	b.apply()
+}
+
+// Start sets the brightness now, and then whenever it changes
+func (b *Brightness) Start() {
+	b.lock.Lock()
+	if b.stop != nil {
+		b.lock.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	b.stop = stop
+	b.lock.Unlock()
+
+	b.apply()
+	go func() {
+		tick := time.NewTicker(time.Minute)
+		defer tick.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-tick.C:
+				b.apply()
+			}
+		}
+	}()
+}
+
+// Stop stops setting the brightness, leaving it as it is
+func (b *Brightness) Stop() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+}
+
+// At returns the brightness the schedule gives at a time
+func (b *Brightness) At(t time.Time) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.at(t)
+}
+
+func (b *Brightness) at(t time.Time) int {
+	if b.hasAmbient {
+		return b.min + int(b.ambient*float64(b.max-b.min)+0.5)
+	}
+	if len(b.rules) == 0 {
+		return 100
+	}
+	// Before the first rule of the day, the last rule of the day before still holds
+	minute := t.Hour()*60 + t.Minute()
+	pct := b.rules[len(b.rules)-1].pct
+	for _, r := range b.rules {
+		if r.minute > minute {
+			break
+		}
+		pct = r.pct
+	}
+	return pct
+}
+
+// apply sets the brightness of any device not already at the scheduled brightness, while running
+func (b *Brightness) apply() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.stop == nil {
+		return
+	}
+	pct := b.at(time.Now())
+	applied := make(map[*streamdeck.Device]int) // Forgetting devices which have been closed
+	for _, d := range b.manager.GetDevices() {
+		if was, ok := b.applied[d]; !ok || was != pct {
+			d.SetBrightness(pct)
+		}
+		applied[d] = pct
+	}
+	b.applied = applied
+}
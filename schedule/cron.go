@@ -0,0 +1,144 @@
+// Package schedule runs things at set times: on cron-like schedules, such as refreshing a button every five
+// minutes, or once after a delay.  Brightness sets the brightness of devices by the time of day.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression, see Parse
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // Bitmasks of the allowed values of each field
+	domAny, dowAny                bool
+}
+
+var fieldRanges = [5]struct{ min, max int }{
+	{0, 59}, // Minute
+	{0, 23}, // Hour
+	{1, 31}, // Day of the month
+	{1, 12}, // Month
+	{0, 7},  // Day of the week, where both 0 and 7 are Sunday
+}
+
+var shorthands = map[string]string{
+	"@yearly":  "0 0 1 1 *",
+	"@monthly": "0 0 1 * *",
+	"@weekly":  "0 0 * * 0",
+	"@daily":   "0 0 * * *",
+	"@hourly":  "0 * * * *",
+}
+
+// Parse parses a standard five field cron expression: minute, hour, day of the month, month and day of the
+// week, in local time.  Each field is "*", a number, a range ("1-5") or a list of those ("1,15,30"), and may
+// have a step ("*/15").  The shorthands @yearly, @monthly, @weekly, @daily and @hourly are also understood.
+// As with cron, if both the day of the month and day of the week are given, either matching is enough.
+func Parse(spec string) (*Schedule, error) {
+	if s, ok := shorthands[strings.TrimSpace(spec)]; ok {
+		spec = s
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Cron expression %q doesn't have five fields", spec)
+	}
+	var masks [5]uint64
+	for i, field := range fields {
+		mask, err := parseField(field, fieldRanges[i].min, fieldRanges[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cron expression %q: %s", spec, err)
+		}
+		masks[i] = mask
+	}
+	if masks[4]&(1<<7) != 0 {
+		masks[4] |= 1
+	}
+	return &Schedule{
+		minute: masks[0],
+		hour:   masks[1],
+		dom:    masks[2],
+		month:  masks[3],
+		dow:    masks[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func parseField(field string, min int, max int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+		from, to := min, max
+		if part != "*" {
+			if i := strings.Index(part, "-"); i >= 0 {
+				var err1, err2 error
+				from, err1 = strconv.Atoi(part[:i])
+				to, err2 = strconv.Atoi(part[i+1:])
+				if err1 != nil || err2 != nil {
+					return 0, fmt.Errorf("bad range %q", part)
+				}
+			} else {
+				n, err := strconv.Atoi(part)
+				if err != nil {
+					return 0, fmt.Errorf("bad value %q", part)
+				}
+				from, to = n, n
+				if step > 1 {
+					to = max
+				}
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := from; v <= to; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+// Next returns the first time after t matching the schedule, or the zero time if there isn't one in the next
+// five years (for example for the 31st of February)
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
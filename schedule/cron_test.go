@@ -0,0 +1,95 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	at := func(s string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	// The 1st of January 2024 is a Monday
+	for _, tc := range []struct {
+		spec, from, want string
+	}{
+		{"*/15 * * * *", "2024-01-01 10:07:30", "2024-01-01 10:15:00"},
+		{"*/15 * * * *", "2024-01-01 10:15:00", "2024-01-01 10:30:00"}, // Strictly after
+		{"*/15 * * * *", "2024-01-01 23:50:00", "2024-01-02 00:00:00"},
+		{"0 9 * * 1-5", "2024-01-05 10:00:00", "2024-01-08 09:00:00"}, // Friday to Monday
+		{"30 8 1 * *", "2024-01-01 09:00:00", "2024-02-01 08:30:00"},
+		{"0 0 * 12 *", "2024-06-15 12:00:00", "2024-12-01 00:00:00"},
+		{"59 23 31 12 *", "2024-12-31 23:59:00", "2025-12-31 23:59:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"0 0 31 2 *", "2024-01-01 00:00:00", ""}, // Never
+		// Lists, ranges and steps
+		{"5,10-12/2 * * * *", "2024-01-01 10:00:00", "2024-01-01 10:05:00"},
+		{"5,10-12/2 * * * *", "2024-01-01 10:05:00", "2024-01-01 10:10:00"},
+		{"5,10-12/2 * * * *", "2024-01-01 10:10:00", "2024-01-01 10:12:00"},
+		{"5,10-12/2 * * * *", "2024-01-01 10:12:00", "2024-01-01 11:05:00"},
+		{"5/20 * * * *", "2024-01-01 10:26:00", "2024-01-01 10:45:00"}, // A start with a step runs to the end
+		// Sunday is both 0 and 7
+		{"0 12 * * 0", "2024-01-01 00:00:00", "2024-01-07 12:00:00"},
+		{"0 12 * * 7", "2024-01-01 00:00:00", "2024-01-07 12:00:00"},
+		// With both days given, either will do: Fridays and the 13th
+		{"0 0 13 * 5", "2024-01-01 00:00:00", "2024-01-05 00:00:00"},
+		{"0 0 13 * 5", "2024-01-10 00:00:00", "2024-01-12 00:00:00"},
+		{"0 0 13 * 5", "2024-01-12 01:00:00", "2024-01-13 00:00:00"},
+		// With one of them "*", the other decides
+		{"0 0 * * 5", "2024-01-12 01:00:00", "2024-01-19 00:00:00"},
+		{"0 0 13 * *", "2024-01-13 01:00:00", "2024-02-13 00:00:00"},
+		// Shorthands
+		{"@hourly", "2024-01-01 10:59:59", "2024-01-01 11:00:00"},
+		{"@daily", "2024-01-01 10:00:00", "2024-01-02 00:00:00"},
+		{"@weekly", "2024-01-01 00:00:00", "2024-01-07 00:00:00"},
+		{"@monthly", "2024-01-15 00:00:00", "2024-02-01 00:00:00"},
+		{"@yearly", "2024-01-01 00:00:00", "2025-01-01 00:00:00"},
+		{" @daily ", "2024-01-01 10:00:00", "2024-01-02 00:00:00"},
+	} {
+		s, err := Parse(tc.spec)
+		if err != nil {
+			t.Errorf("%q: %s", tc.spec, err)
+			continue
+		}
+		got := s.Next(at(tc.from))
+		if tc.want == "" {
+			if !got.IsZero() {
+				t.Errorf("%q after %s is %s, not never", tc.spec, tc.from, got)
+			}
+		} else if !got.Equal(at(tc.want)) {
+			t.Errorf("%q after %s is %s, not %s", tc.spec, tc.from, got, tc.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@reboot",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"-1 * * * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"1,,2 * * * *",
+		"* * * JAN *", // Names aren't supported
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("%q was parsed", spec)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// Job is something scheduled to run, see Cron and After
+type Job struct {
+	lock    sync.Mutex
+	timer   *time.Timer
+	stopped bool
+}
+
+// Cron calls f, on its own goroutine, every time the cron expression matches (see Parse), until the Job is
+// stopped
+func Cron(spec string, f func()) (*Job, error) {
+	s, err := Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	j := &Job{}
+	j.scheduleNext(s, f)
+	return j, nil
+}
+
+func (j *Job) scheduleNext(s *Schedule, f func()) {
+	next := s.Next(time.Now())
+	if next.IsZero() {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.stopped {
+		return
+	}
+	j.timer = time.AfterFunc(time.Until(next), func() {
+		j.scheduleNext(s, f)
+		f()
+	})
+}
+
+// After calls f, on its own goroutine, once after a delay, unless the Job is stopped first
+func After(d time.Duration, f func()) *Job {
+	j := &Job{}
+	j.timer = time.AfterFunc(d, f)
+	return j
+}
+
+// Stop stops the Job; it doesn't wait for f if it is already running
+func (j *Job) Stop() {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.stopped = true
+	if j.timer != nil {
+		j.timer.Stop()
+	}
+}
+
+// CronAction runs a ButtonActionHandler for a button every time the cron expression matches, as if the button
+// had been pressed
+func CronAction(spec string, btn streamdeck.Button, action streamdeck.ButtonActionHandler) (*Job, error) {
+	return Cron(spec, func() { action.Pressed(btn) })
+}
+
+// CronRedraw redraws a button every time the cron expression matches, for buttons whose image depends on the
+// time or on something outside that doesn't say when it changes
+func CronRedraw(spec string, sd *streamdeck.StreamDeck, btn streamdeck.Button) (*Job, error) {
+	return Cron(spec, func() { sd.ButtonUpdateHandler(btn) })
+}
@@ -0,0 +1,98 @@
+package streamdeck
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultFrameRate is how many times a second animations are driven, unless changed with SetFrameRate
+const DefaultFrameRate = 30
+
+// Animation is a function being called on every frame by the frame scheduler, see Animate
+type Animation struct {
+	f     func(time.Duration) bool
+	start time.Time
+}
+
+// scheduler calls every running Animation once per frame from a single goroutine, which only runs while there
+// are animations, so that animated buttons don't each need a ticker of their own
+type scheduler struct {
+	lock       sync.Mutex
+	interval   time.Duration
+	animations map[*Animation]bool
+	running    bool
+}
+
+var frameScheduler = &scheduler{
+	interval:   time.Second / DefaultFrameRate,
+	animations: make(map[*Animation]bool),
+}
+
+// Animate calls f on every frame with the time since the animation started, until f returns false or the
+// animation is stopped.  f is called from the scheduler's goroutine, and a slow f delays every animation.
+func Animate(f func(elapsed time.Duration) bool) *Animation {
+	a := &Animation{f: f, start: time.Now()}
+	frameScheduler.add(a)
+	return a
+}
+
+// Stop stops the animation; f isn't called again after any frame already in progress
+func (a *Animation) Stop() {
+	frameScheduler.remove(a)
+}
+
+// SetFrameRate sets how many times a second animations are driven
+func SetFrameRate(fps int) {
+	if fps <= 0 {
+		return
+	}
+	frameScheduler.lock.Lock()
+	defer frameScheduler.lock.Unlock()
+	frameScheduler.interval = time.Second / time.Duration(fps)
+}
+
+func (s *scheduler) add(a *Animation) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.animations[a] = true
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+}
+
+func (s *scheduler) remove(a *Animation) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.animations, a)
+}
+
+func (s *scheduler) run() {
+	for {
+		s.lock.Lock()
+		interval := s.interval
+		s.lock.Unlock()
+		time.Sleep(interval)
+
+		s.lock.Lock()
+		if len(s.animations) == 0 {
+			s.running = false
+			s.lock.Unlock()
+			return
+		}
+		animations := make([]*Animation, 0, len(s.animations))
+		for a := range s.animations {
+			animations = append(animations, a)
+		}
+		s.lock.Unlock()
+
+		for _, a := range animations {
+			s.lock.Lock()
+			running := s.animations[a]
+			s.lock.Unlock()
+			if running && !a.f(time.Since(a.start)) {
+				s.remove(a)
+			}
+		}
+	}
+}
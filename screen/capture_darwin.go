@@ -0,0 +1,29 @@
+//go:build darwin
+// +build darwin
+
+package screen
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"os/exec"
+)
+
+// capture has screencapture write to a temporary file, as it can't write to standard output
+func capture(region image.Rectangle) ([]byte, error) {
+	f, err := ioutil.TempFile("", "streamdeck-*.png")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	size := region.Size()
+	rect := fmt.Sprintf("%d,%d,%d,%d", region.Min.X, region.Min.Y, size.X, size.Y)
+	if err := exec.Command("screencapture", "-x", "-t", "png", "-R", rect, f.Name()).Run(); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(f.Name())
+}
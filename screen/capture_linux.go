@@ -0,0 +1,21 @@
+//go:build linux
+// +build linux
+
+package screen
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+)
+
+func capture(region image.Rectangle) ([]byte, error) {
+	size := region.Size()
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		geometry := fmt.Sprintf("%d,%d %dx%d", region.Min.X, region.Min.Y, size.X, size.Y)
+		return exec.Command("grim", "-g", geometry, "-").Output()
+	}
+	geometry := fmt.Sprintf("%dx%d+%d+%d", size.X, size.Y, region.Min.X, region.Min.Y)
+	return exec.Command("import", "-silent", "-window", "root", "-crop", geometry, "png:-").Output()
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package screen
+
+import (
+	"errors"
+	"image"
+)
+
+func capture(region image.Rectangle) ([]byte, error) {
+	return nil, errors.New("Capturing the screen isn't supported on this platform")
+}
@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package screen
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+	"os/exec"
+	"strings"
+)
+
+// captureScript copies a region of the screen into a bitmap and prints it as a base64 PNG, as PowerShell's
+// standard output isn't safe for binary data
+const captureScript = `Add-Type -AssemblyName System.Drawing
+$bitmap = New-Object System.Drawing.Bitmap %d, %d
+$graphics = [System.Drawing.Graphics]::FromImage($bitmap)
+$graphics.CopyFromScreen(%d, %d, 0, 0, $bitmap.Size)
+$stream = New-Object System.IO.MemoryStream
+$bitmap.Save($stream, [System.Drawing.Imaging.ImageFormat]::Png)
+[Convert]::ToBase64String($stream.ToArray())`
+
+func capture(region image.Rectangle) ([]byte, error) {
+	size := region.Size()
+	script := fmt.Sprintf(captureScript, size.X, size.Y, region.Min.X, region.Min.Y)
+	out, err := exec.Command("powershell", "-NoProfile", "-Command", script).Output()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+}
@@ -0,0 +1,22 @@
+// Package screen captures regions of the desktop, by running the platform's screenshot tool: ImageMagick's import
+// on X11, grim on Wayland, screencapture on macOS and PowerShell on Windows.
+package screen
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+)
+
+// Capture takes a picture of a region of the desktop, in screen coordinates
+func Capture(region image.Rectangle) (image.Image, error) {
+	if region.Empty() {
+		return nil, errors.New("The region to capture is empty")
+	}
+	data, err := capture(region)
+	if err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(data))
+}
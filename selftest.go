@@ -0,0 +1,186 @@
+package streamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SelfTestResult holds everything the device reported while RunSelfTest was running
+type SelfTestResult struct {
+	ButtonsPressed  []int
+	EncodersPressed []int
+	EncodersRotated []int
+	Touches         int
+	Errors          []error
+}
+
+var selfTestColours = []color.Color{
+	color.RGBA{255, 0, 0, 255},
+	color.RGBA{0, 255, 0, 255},
+	color.RGBA{0, 0, 255, 255},
+	color.White,
+	color.Black,
+}
+
+var selfTestBrightness = []int{100, 75, 50, 25, 10, 100}
+
+// RunSelfTest is for hardware QA and installation commissioning.  It cycles all buttons (and the touchscreen, if
+// there is one) through solid colours, then striped calibration patterns, then numbers each button so the
+// mapping can be checked, then steps through brightness levels; each step is shown for stepDuration.  Meanwhile
+// every button press, encoder and touch event is passed as a line of text to report (if not nil), and collected
+// in the result.  Brightness is left at 100% afterwards.
+func (d *Device) RunSelfTest(stepDuration time.Duration, report func(string)) SelfTestResult {
+	var result SelfTestResult
+	var lock sync.Mutex
+	running := true
+
+	log := func(format string, a ...interface{}) {
+		if report != nil {
+			report(fmt.Sprintf(format, a...))
+		}
+	}
+
+	// The listeners are removed once the test is over; running keeps any event already on its way from counting
+	removeButton := d.ButtonPress(func(btnIndex int, d *Device, err error, pressed bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		if !running {
+			return
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+			log("Error: %s", err)
+			return
+		}
+		if pressed {
+			result.ButtonsPressed = append(result.ButtonsPressed, btnIndex)
+			log("Button %d pressed", btnIndex)
+		} else {
+			log("Button %d released", btnIndex)
+		}
+	})
+	removeEncoderPress := d.EncoderPress(func(encIndex int, d *Device, pressed bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		if running && pressed {
+			result.EncodersPressed = append(result.EncodersPressed, encIndex)
+			log("Encoder %d pressed", encIndex)
+		}
+	})
+	removeEncoderRotate := d.EncoderRotate(func(encIndex int, d *Device, pulses int) {
+		lock.Lock()
+		defer lock.Unlock()
+		if running {
+			result.EncodersRotated = append(result.EncodersRotated, encIndex)
+			log("Encoder %d rotated %d", encIndex, pulses)
+		}
+	})
+	removeTouch := d.TouchPush(func(d *Device, x, y uint16, hold bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		if running {
+			result.Touches++
+			log("Touch at %d,%d (hold: %t)", x, y, hold)
+		}
+	})
+	removeSwipe := d.TouchSwipe(func(d *Device, xstart, ystart, xstop, ystop uint16) {
+		lock.Lock()
+		defer lock.Unlock()
+		if running {
+			result.Touches++
+			log("Swipe from %d,%d to %d,%d", xstart, ystart, xstop, ystop)
+		}
+	})
+
+	addError := func(err error) {
+		if err != nil {
+			lock.Lock()
+			result.Errors = append(result.Errors, err)
+			lock.Unlock()
+			log("Error: %s", err)
+		}
+	}
+
+	numButtons := int(d.deviceType.numberOfButtons)
+	if d.HasImageCapability() {
+		for _, c := range selfTestColours {
+			for i := 0; i < numButtons; i++ {
+				addError(d.WriteColorToButton(i, c))
+			}
+			addError(d.fillTouchscreen(c))
+			time.Sleep(stepDuration)
+		}
+
+		for _, vertical := range []bool{false, true} {
+			stripes := getStripedImage(d.deviceType.imageSize, vertical)
+			for i := 0; i < numButtons; i++ {
+				addError(d.WriteRawImageToButton(i, stripes))
+			}
+			time.Sleep(stepDuration)
+		}
+
+		for i := 0; i < numButtons; i++ {
+			d.WriteTextToButton(i, strconv.Itoa(i), color.White, color.Black)
+		}
+		time.Sleep(stepDuration)
+	}
+
+	for _, pct := range selfTestBrightness {
+		log("Brightness %d%%", pct)
+		d.SetBrightness(pct)
+		time.Sleep(stepDuration)
+	}
+
+	for _, remove := range []func(){removeButton, removeEncoderPress, removeEncoderRotate, removeTouch, removeSwipe} {
+		remove()
+	}
+	lock.Lock()
+	running = false
+	lock.Unlock()
+	return result
+}
+
+// fillTouchscreen fills the touchscreen (or info display) with a solid colour, if the device has one
+func (d *Device) fillTouchscreen(c color.Color) error {
+	size := d.deviceType.touchscreenSize
+	if size == (image.Point{}) || d.deviceType.imageAreaHeaderFunc == nil {
+		return nil
+	}
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{0, 0}, draw.Src)
+	pos := d.deviceType.touchscreenPosition
+	return d.WriteRawImageToAreaUnscaled(pos.X, pos.Y, img)
+}
+
+var selfTestStripes = []color.Color{
+	color.RGBA{255, 0, 0, 255},
+	color.RGBA{255, 128, 0, 255},
+	color.RGBA{255, 255, 0, 255},
+	color.RGBA{0, 255, 0, 255},
+	color.RGBA{0, 255, 255, 255},
+	color.RGBA{0, 0, 255, 255},
+	color.RGBA{255, 0, 255, 255},
+	color.White,
+}
+
+// getStripedImage gives a calibration pattern of stripes, running from red at the top (or left) through to white,
+// so that the orientation and the edges of each button can be checked
+func getStripedImage(size image.Point, vertical bool) image.Image {
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	n := len(selfTestStripes)
+	for i, c := range selfTestStripes {
+		var stripe image.Rectangle
+		if vertical {
+			stripe = image.Rect(size.X*i/n, 0, size.X*(i+1)/n, size.Y)
+		} else {
+			stripe = image.Rect(0, size.Y*i/n, size.X, size.Y*(i+1)/n)
+		}
+		draw.Draw(img, stripe, image.NewUniform(c), image.Point{0, 0}, draw.Src)
+	}
+	return img
+}
@@ -0,0 +1,122 @@
+package streamdeck
+
+import (
+	"image"
+	"sync"
+)
+
+// shadow keeps a copy of everything last sent to a device's displays, as it was encoded for the device, so that
+// Redraw can send it all again once the device has lost it; it also keeps what buttons are drawn from, for
+// overlays and backgrounds
+type shadow struct {
+	lock     sync.Mutex
+	buttons  map[int][]byte // By the device's own button index, after mapping
+	overlaid map[int][]byte // What is underneath an overlay, for buttons showing one, see OverlayTextOnButton
+
+	backgrounds map[int]Background  // See SetButtonBackground
+	foregrounds map[int]image.Image // What was drawn over the background, for buttons written with an image
+	areas       []shadowArea        // Oldest first; areas covered by a later write are dropped
+	brightness  map[string]int      // By brightness packet, as buttons and touchscreen can be set apart
+}
+
+type shadowArea struct {
+	rect    image.Rectangle
+	encoded []byte
+}
+
+func (s *shadow) setButton(btnIndex int, encoded []byte, foreground image.Image) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.buttons == nil {
+		s.buttons = make(map[int][]byte)
+	}
+	s.buttons[btnIndex] = encoded
+	delete(s.overlaid, btnIndex)
+	if foreground == nil {
+		delete(s.foregrounds, btnIndex)
+		return
+	}
+	if s.foregrounds == nil {
+		s.foregrounds = make(map[int]image.Image)
+	}
+	s.foregrounds[btnIndex] = foreground
+}
+
+// setOverlay records an image with an overlay, keeping what is underneath so that the overlay can be changed
+func (s *shadow) setOverlay(btnIndex int, base, encoded []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.buttons == nil {
+		s.buttons = make(map[int][]byte)
+	}
+	if s.overlaid == nil {
+		s.overlaid = make(map[int][]byte)
+	}
+	s.buttons[btnIndex] = encoded
+	s.overlaid[btnIndex] = base
+}
+
+// underOverlay returns the image last written to a button, leaving out any overlay
+func (s *shadow) underOverlay(btnIndex int) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if base, ok := s.overlaid[btnIndex]; ok {
+		return base, true
+	}
+	encoded, ok := s.buttons[btnIndex]
+	return encoded, ok
+}
+
+func (s *shadow) setArea(rect image.Rectangle, encoded []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	areas := s.areas[:0]
+	for _, a := range s.areas {
+		if !a.rect.In(rect) {
+			areas = append(areas, a)
+		}
+	}
+	s.areas = append(areas, shadowArea{rect: rect, encoded: encoded})
+}
+
+func (s *shadow) setBrightness(preamble []byte, pct int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.brightness == nil {
+		s.brightness = make(map[string]int)
+	}
+	s.brightness[string(preamble)] = pct
+}
+
+// Redraw sends everything last written to the buttons and the touchscreen (or info display) again, along with
+// the brightness, such as after ResetComms or once a device that lost power is back.  Buttons and areas never
+// written are left as they are.
+func (d *Device) Redraw() error {
+	d.shadow.lock.Lock()
+	brightness := make(map[string]int, len(d.shadow.brightness))
+	for preamble, pct := range d.shadow.brightness {
+		brightness[preamble] = pct
+	}
+	buttons := make(map[int][]byte, len(d.shadow.buttons))
+	for btnIndex, encoded := range d.shadow.buttons {
+		buttons[btnIndex] = encoded
+	}
+	areas := append([]shadowArea(nil), d.shadow.areas...)
+	d.shadow.lock.Unlock()
+
+	for preamble, pct := range brightness {
+		d.sendBrightness([]byte(preamble), pct)
+	}
+	var firstErr error
+	for btnIndex, encoded := range buttons {
+		if err := d.sendToButton(btnIndex, encoded); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, a := range areas {
+		if err := d.rawWriteToArea(a.rect.Min.X, a.rect.Min.Y, a.rect.Dx(), a.rect.Dy(), a.encoded); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,222 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ListenAndServe serves the simulator's web page on the given address, such as ":8090"
+func (s *Simulator) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s)
+}
+
+// ServeHTTP serves the web page, the images on it, and the input from it
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	q := r.URL.Query()
+	intParam := func(name string) int {
+		v, _ := strconv.Atoi(q.Get(name))
+		return v
+	}
+	switch {
+	case path == "":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	case path == "state":
+		s.serveState(w)
+	case path == "events":
+		s.serveEvents(w, r)
+	case strings.HasPrefix(path, "key/"):
+		btnIndex, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path, "key/"), ".png"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		s.serveImage(w, r, s.buttonImage(btnIndex))
+	case path == "touchscreen.png":
+		s.lock.Lock()
+		var img image.Image
+		if s.touchscreen != nil {
+			// A copy, as the touchscreen may be drawn on while this is being encoded
+			snapshot := image.NewRGBA(s.touchscreen.Bounds())
+			draw.Draw(snapshot, snapshot.Bounds(), s.touchscreen, image.Point{}, draw.Src)
+			img = snapshot
+		}
+		s.lock.Unlock()
+		s.serveImage(w, r, img)
+	case r.Method != http.MethodPost:
+		http.NotFound(w, r)
+	case path == "button":
+		s.SetButton(intParam("index"), q.Get("pressed") == "true")
+	case path == "encoder":
+		s.SetEncoder(intParam("index"), q.Get("pressed") == "true")
+	case path == "rotate":
+		s.RotateEncoder(intParam("index"), intParam("pulses"))
+	case path == "touch":
+		s.Touch(intParam("x"), intParam("y"), q.Get("hold") == "true")
+	case path == "swipe":
+		s.Swipe(intParam("x"), intParam("y"), intParam("x2"), intParam("y2"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Simulator) serveState(w http.ResponseWriter) {
+	s.lock.Lock()
+	state := map[string]interface{}{
+		"name":       s.d.GetName(),
+		"rows":       s.d.GetButtonRows(),
+		"cols":       s.d.GetButtonCols(),
+		"buttons":    len(s.buttons),
+		"encoders":   s.layout.NumberOfEncoders,
+		"imageSize":  s.d.GetImageSize().X,
+		"brightness": s.brightness,
+		"version":    s.version,
+		"touchInput": s.layout.TouchscreenInput,
+	}
+	if s.touchscreen != nil {
+		state["touchscreen"] = s.touchscreen.Bounds().Max
+	}
+	s.lock.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// serveEvents sends a server-sent event whenever the display changes, until the page goes away
+func (s *Simulator) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	for {
+		s.lock.Lock()
+		changed := s.changed
+		version, brightness := s.version, s.brightness
+		s.lock.Unlock()
+		fmt.Fprintf(w, "data: {\"version\": %d, \"brightness\": %d}\n\n", version, brightness)
+		flusher.Flush()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+func (s *Simulator) buttonImage(btnIndex int) image.Image {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if btnIndex < 0 || btnIndex >= len(s.buttons) {
+		return nil
+	}
+	return s.buttons[btnIndex]
+}
+
+// serveImage serves an image as a PNG, or a black square if nothing has been drawn yet
+func (s *Simulator) serveImage(w http.ResponseWriter, r *http.Request, img image.Image) {
+	if img == nil {
+		size := s.d.GetImageSize().X
+		if size == 0 {
+			size = 1
+		}
+		black := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.Draw(black, black.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+		img = black
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	png.Encode(w, img)
+}
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<title>Stream Deck simulator</title>
+<style>
+body { background: #222; color: #ccc; font-family: sans-serif; }
+#deck { display: inline-block; background: #000; padding: 16px; border-radius: 16px; }
+#keys { display: grid; gap: 12px; }
+#keys img { border-radius: 8px; cursor: pointer; user-select: none; }
+#touchscreen { display: block; margin-top: 12px; cursor: crosshair; user-select: none; }
+#encoders { display: flex; justify-content: space-around; margin-top: 12px; }
+.encoder { width: 48px; height: 48px; border-radius: 50%; background: #444; cursor: pointer; }
+.encoder.pressed, #keys img.pressed { outline: 2px solid #09f; }
+</style>
+</head>
+<body>
+<h3 id="name"></h3>
+<div id="deck"><div id="keys"></div><img id="touchscreen" draggable="false"><div id="encoders"></div></div>
+<p>Click buttons and encoders; scroll over encoders to turn them; click or drag on the touchscreen.</p>
+<script>
+function post(path) { fetch(path, {method: "POST"}); }
+
+fetch("state").then(r => r.json()).then(state => {
+  document.getElementById("name").textContent = state.name;
+  const keys = document.getElementById("keys");
+  keys.style.gridTemplateColumns = "repeat(" + state.cols + ", " + state.imageSize + "px)";
+  for (let i = 0; i < state.buttons; i++) {
+    const img = document.createElement("img");
+    img.src = "key/" + i + ".png";
+    img.width = img.height = state.imageSize || 72;
+    img.draggable = false;
+    img.onmousedown = () => { img.classList.add("pressed"); post("button?index=" + i + "&pressed=true"); };
+    img.onmouseup = img.onmouseleave = () => {
+      if (img.classList.contains("pressed")) { img.classList.remove("pressed"); post("button?index=" + i + "&pressed=false"); }
+    };
+    keys.appendChild(img);
+  }
+
+  const ts = document.getElementById("touchscreen");
+  if (state.touchscreen) {
+    ts.src = "touchscreen.png";
+    let start = null;
+    ts.onmousedown = e => { start = {x: e.offsetX, y: e.offsetY, t: Date.now()}; };
+    ts.onmouseup = e => {
+      if (!start) return;
+      const dx = e.offsetX - start.x, dy = e.offsetY - start.y;
+      if (Math.abs(dx) > 10 || Math.abs(dy) > 10) {
+        post("swipe?x=" + start.x + "&y=" + start.y + "&x2=" + e.offsetX + "&y2=" + e.offsetY);
+      } else if (state.touchInput) {
+        post("touch?x=" + start.x + "&y=" + start.y + "&hold=" + (Date.now() - start.t > 500));
+      }
+      start = null;
+    };
+  } else {
+    ts.style.display = "none";
+  }
+
+  const encoders = document.getElementById("encoders");
+  for (let i = 0; i < state.encoders; i++) {
+    const enc = document.createElement("div");
+    enc.className = "encoder";
+    enc.onmousedown = () => { enc.classList.add("pressed"); post("encoder?index=" + i + "&pressed=true"); };
+    enc.onmouseup = enc.onmouseleave = () => {
+      if (enc.classList.contains("pressed")) { enc.classList.remove("pressed"); post("encoder?index=" + i + "&pressed=false"); }
+    };
+    enc.onwheel = e => { e.preventDefault(); post("rotate?index=" + i + "&pulses=" + (e.deltaY > 0 ? 1 : -1)); };
+    encoders.appendChild(enc);
+  }
+
+  new EventSource("events").onmessage = e => {
+    const update = JSON.parse(e.data);
+    document.getElementById("deck").style.filter = "brightness(" + update.brightness + "%)";
+    keys.querySelectorAll("img").forEach((img, i) => { img.src = "key/" + i + ".png?v=" + update.version; });
+    if (state.touchscreen) ts.src = "touchscreen.png?v=" + update.version;
+  };
+});
+</script>
+</body>
+</html>
+`
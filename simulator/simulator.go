@@ -0,0 +1,334 @@
+// Package simulator is an on-screen Stream Deck for developing without the hardware.  It stands in for the USB
+// device, decoding the button images from the same reports a real deck would be sent, and serves a web page
+// showing the deck, where clicking buttons and scrolling over encoders sends input back as a deck would.
+//
+//	d, sim, err := simulator.Open(0x84) // Streamdeck Plus, with the devices package imported
+//	go sim.ListenAndServe(":8090")      // then browse to http://localhost:8090
+package simulator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // Decoders for button images
+	"io"
+	"sync"
+
+	"github.com/disintegration/gift"
+	_ "golang.org/x/image/bmp" // ...
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+)
+
+// Simulator is a streamdeck.DeviceInterface showing what is written to it on a web page, see ListenAndServe
+type Simulator struct {
+	lock        sync.Mutex
+	d           *streamdeck.Device
+	layout      protocol.Layout
+	quirks      streamdeck.Quirk
+	buttons     []image.Image
+	pending     map[int][]byte // Image data received so far for each button, or for the touchscreen (-1)
+	touchscreen *image.RGBA
+	brightness  int
+	version     int
+	changed     chan struct{}
+
+	buttonState  []byte
+	encoderState []byte
+
+	reports   chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// touchscreenKey is the key in Simulator.pending of data for the touchscreen
+const touchscreenKey = -1
+
+// Open creates a Device, of the type registered for the given USB product ID, shown by a new Simulator
+func Open(productID uint16) (*streamdeck.Device, *Simulator, error) {
+	s := &Simulator{
+		pending:    make(map[int][]byte),
+		brightness: 100,
+		changed:    make(chan struct{}),
+		reports:    make(chan []byte, 16),
+		closed:     make(chan struct{}),
+	}
+	d, err := streamdeck.OpenWithInterface(s, productID, "SIMULATOR")
+	if err != nil {
+		return nil, nil, err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.d = d
+	s.layout = d.GetLayout()
+	s.quirks = d.GetQuirks()
+	s.buttons = make([]image.Image, d.GetNumberOfButtons())
+	s.buttonState = make([]byte, s.layout.NumberOfButtons)
+	s.encoderState = make([]byte, s.layout.NumberOfEncoders)
+	if size := d.GetTouchscreenSize(); size != (image.Point{}) {
+		s.touchscreen = image.NewRGBA(image.Rectangle{Max: size})
+	}
+	return d, s, nil
+}
+
+// Write takes an output report; button and touchscreen images are reassembled from their pages and decoded
+func (s *Simulator) Write(report []byte) (int, error) {
+	if len(report) < 8 || report[0] != 2 {
+		return len(report), nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch report[1] {
+	case 0x07: // 02 07 btn last len(le16) page(le16), as on the MK.2, XL, Neo and Plus
+		length := int(binary.LittleEndian.Uint16(report[4:]))
+		page := int(binary.LittleEndian.Uint16(report[6:]))
+		s.addPage(int(report[2]), page == 0, report[3] == 1, report[8:], length)
+	case 0x01: // 02 01 page 00 last btn+1, as on the original and the Mini, whose pages don't carry a length
+		if len(report) < 16 || report[5] == 0 {
+			return len(report), nil
+		}
+		first := report[2] == 0 || (report[2] == 1 && s.quirks&streamdeck.QuirkHalfImagePages != 0)
+		s.addPage(int(report[5])-1, first, report[4] == 1, report[16:], 0)
+	case 0x0c: // Touchscreen area on the Plus: 02 0c x(le16) y(le16) width(le16) height(le16) last page(le16) len(le16)
+		if len(report) < 16 {
+			return len(report), nil
+		}
+		x := int(binary.LittleEndian.Uint16(report[2:]))
+		page := int(binary.LittleEndian.Uint16(report[11:]))
+		length := int(binary.LittleEndian.Uint16(report[13:]))
+		if s.addAreaPage(page == 0, report[10] == 1, report[16:], length) {
+			s.drawArea(x)
+		}
+	case 0x0b: // Info display on the Neo: 02 0b 00 last len(le16) page(le16)
+		length := int(binary.LittleEndian.Uint16(report[4:]))
+		page := int(binary.LittleEndian.Uint16(report[6:]))
+		if s.addAreaPage(page == 0, report[3] == 1, report[8:], length) {
+			s.drawArea(0)
+		}
+	}
+	return len(report), nil
+}
+
+// addPage adds a page of a button image, decoding it once the last page is in.  As the length in a page header
+// can't always be trusted, a page marked last may still be followed by another, in which case the image is
+// decoded again.
+func (s *Simulator) addPage(btnIndex int, first bool, last bool, payload []byte, length int) {
+	if btnIndex < 0 || btnIndex >= len(s.buttons) {
+		return
+	}
+	if first {
+		s.pending[btnIndex] = nil
+	}
+	if length > 0 && length < len(payload) {
+		payload = payload[:length]
+	}
+	if s.quirks&streamdeck.QuirkHalfImagePages != 0 {
+		payload = trimHalfPage(s.pending[btnIndex], payload)
+	}
+	s.pending[btnIndex] = append(s.pending[btnIndex], payload...)
+	if !last {
+		return
+	}
+	img, _, err := image.Decode(bytes.NewReader(s.pending[btnIndex]))
+	if err != nil {
+		return
+	}
+	s.buttons[btnIndex] = s.undoQuirks(img)
+	s.notify()
+}
+
+// trimHalfPage cuts a page down to half of the image, as the original Streamdeck sends it (see
+// QuirkHalfImagePages), working out the image size from its BMP header
+func trimHalfPage(sofar []byte, payload []byte) []byte {
+	header := sofar
+	if len(header) == 0 {
+		header = payload
+	}
+	if len(header) < 6 || header[0] != 'B' || header[1] != 'M' {
+		return payload
+	}
+	size := int(binary.LittleEndian.Uint32(header[2:]))
+	half := size / 2
+	if len(sofar) > 0 {
+		half = size - len(sofar)
+	}
+	if half > 0 && half < len(payload) {
+		return payload[:half]
+	}
+	return payload
+}
+
+// addAreaPage adds a page of a touchscreen image, returning whether it is complete
+func (s *Simulator) addAreaPage(first bool, last bool, payload []byte, length int) bool {
+	if s.touchscreen == nil {
+		return false
+	}
+	if first {
+		s.pending[touchscreenKey] = nil
+	}
+	if length > 0 && length < len(payload) {
+		payload = payload[:length]
+	}
+	s.pending[touchscreenKey] = append(s.pending[touchscreenKey], payload...)
+	return last
+}
+
+// drawArea decodes a complete touchscreen image and draws it at x
+func (s *Simulator) drawArea(x int) {
+	img, _, err := image.Decode(bytes.NewReader(s.pending[touchscreenKey]))
+	if err != nil {
+		return
+	}
+	if s.quirks&streamdeck.QuirkRotateAreaImage != 0 {
+		img = applyFilters(img, gift.Rotate180())
+	}
+	pos := s.d.GetTouchscreenPosition()
+	at := image.Pt(x-pos.X, -pos.Y)
+	draw.Draw(s.touchscreen, img.Bounds().Add(at), img, img.Bounds().Min, draw.Src)
+	s.notify()
+}
+
+// undoQuirks turns a button image the right way up again, reversing what the Device did for the hardware
+func (s *Simulator) undoQuirks(img image.Image) image.Image {
+	var filters []gift.Filter
+	if s.quirks&streamdeck.QuirkFlipImageVertical != 0 {
+		filters = append(filters, gift.FlipVertical())
+	}
+	if s.quirks&streamdeck.QuirkRotateImage90 != 0 {
+		filters = append(filters, gift.Rotate270())
+	}
+	if s.quirks&streamdeck.QuirkRotateImage180 != 0 {
+		filters = append(filters, gift.Rotate180())
+	}
+	return applyFilters(img, filters...)
+}
+
+func applyFilters(img image.Image, filters ...gift.Filter) image.Image {
+	if len(filters) == 0 {
+		return img
+	}
+	g := gift.New(filters...)
+	res := image.NewRGBA(g.Bounds(img.Bounds()))
+	g.Draw(res, img)
+	return res
+}
+
+// SendFeatureReport takes a feature report, following the brightness
+func (s *Simulator) SendFeatureReport(report []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch {
+	case len(report) >= 3 && report[0] == 0x03 && report[1] == 0x08:
+		s.brightness = int(report[2])
+		s.notify()
+	case len(report) >= 6 && report[0] == 0x05 && report[1] == 0x55:
+		s.brightness = int(report[5])
+		s.notify()
+	}
+	return len(report), nil
+}
+
+// Read blocks until there is input from the web page, or the Simulator is closed
+func (s *Simulator) Read(report []byte) (int, error) {
+	select {
+	case r := <-s.reports:
+		return copy(report, r), nil
+	case <-s.closed:
+		return 0, io.EOF
+	}
+}
+
+// Close makes any Read return an error, as happens when a device is unplugged
+func (s *Simulator) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+// notify wakes up anything waiting for the display to change, and must be called with the lock held
+func (s *Simulator) notify() {
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// inject queues an input report; reports are padded as the Device expects them
+func (s *Simulator) inject(report []byte) {
+	r := make([]byte, 255)
+	copy(r, report)
+	select {
+	case s.reports <- r:
+	case <-s.closed:
+	}
+}
+
+// SetButton presses or releases a button, numbered as the hardware numbers them
+func (s *Simulator) SetButton(btnIndex int, pressed bool) {
+	s.lock.Lock()
+	if btnIndex < 0 || btnIndex >= len(s.buttonState) {
+		s.lock.Unlock()
+		return
+	}
+	s.buttonState[btnIndex] = 0
+	if pressed {
+		s.buttonState[btnIndex] = 1
+	}
+	report := make([]byte, s.layout.ButtonReadOffset+len(s.buttonState))
+	report[0] = 1
+	copy(report[s.layout.ButtonReadOffset:], s.buttonState)
+	s.lock.Unlock()
+	s.inject(report)
+}
+
+// SetEncoder pushes in or lets go of an encoder
+func (s *Simulator) SetEncoder(encIndex int, pressed bool) {
+	s.lock.Lock()
+	if encIndex < 0 || encIndex >= len(s.encoderState) {
+		s.lock.Unlock()
+		return
+	}
+	s.encoderState[encIndex] = 0
+	if pressed {
+		s.encoderState[encIndex] = 1
+	}
+	report := make([]byte, 255)
+	report[0], report[1], report[4] = 1, 3, 0
+	copy(report[s.layout.EncoderReadOffset:], s.encoderState)
+	s.lock.Unlock()
+	s.inject(report)
+}
+
+// RotateEncoder turns an encoder by a number of pulses, positive for clockwise
+func (s *Simulator) RotateEncoder(encIndex int, pulses int) {
+	if encIndex < 0 || encIndex >= s.layout.NumberOfEncoders || pulses == 0 {
+		return
+	}
+	report := make([]byte, 255)
+	report[0], report[1], report[4] = 1, 3, 1
+	report[s.layout.EncoderReadOffset+encIndex] = byte(int8(pulses))
+	s.inject(report)
+}
+
+// Touch taps (or holds) the touchscreen
+func (s *Simulator) Touch(x, y int, hold bool) {
+	report := make([]byte, 14)
+	report[0], report[1], report[4] = 1, 2, 1
+	if hold {
+		report[4] = 2
+	}
+	binary.LittleEndian.PutUint16(report[6:], uint16(x))
+	binary.LittleEndian.PutUint16(report[8:], uint16(y))
+	s.inject(report)
+}
+
+// Swipe swipes across the touchscreen
+func (s *Simulator) Swipe(xstart, ystart, xstop, ystop int) {
+	report := make([]byte, 14)
+	report[0], report[1], report[4] = 1, 2, 3
+	binary.LittleEndian.PutUint16(report[6:], uint16(xstart))
+	binary.LittleEndian.PutUint16(report[8:], uint16(ystart))
+	binary.LittleEndian.PutUint16(report[10:], uint16(xstop))
+	binary.LittleEndian.PutUint16(report[12:], uint16(ystop))
+	s.inject(report)
+}
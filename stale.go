@@ -0,0 +1,36 @@
+package streamdeck
+
+// staleFrames numbers the frames written to each button, so that a write can tell that a newer frame for the
+// same button is waiting and give up, see SetAbortStaleWrites
+type staleFrames struct {
+	enabled bool
+	latest  map[int]uint64 // Newest frame for each button, by the device's own button index
+}
+
+// SetAbortStaleWrites sets whether an image part way through being written to a button is abandoned when a newer
+// image for the same button comes along, so that rapid changes show the latest state sooner.  The write stops
+// between pages, which the device is built to cope with as every image starts again from its first page; what
+// the button shows is unchanged until the newer image is complete.
+func (d *Device) SetAbortStaleWrites(on bool) {
+	d.frameLock.Lock()
+	defer d.frameLock.Unlock()
+	d.frames.enabled = on
+}
+
+// newFrame numbers a frame about to be written to a button
+func (d *Device) newFrame(btnIndex int) uint64 {
+	d.frameLock.Lock()
+	defer d.frameLock.Unlock()
+	if d.frames.latest == nil {
+		d.frames.latest = make(map[int]uint64)
+	}
+	d.frames.latest[btnIndex]++
+	return d.frames.latest[btnIndex]
+}
+
+// isStale returns whether a newer frame than this one is waiting to be written to the button
+func (d *Device) isStale(btnIndex int, frame uint64) bool {
+	d.frameLock.Lock()
+	defer d.frameLock.Unlock()
+	return d.frames.enabled && d.frames.latest[btnIndex] != frame
+}
@@ -0,0 +1,131 @@
+package streamdeck
+
+import (
+	"reflect"
+	"sync"
+)
+
+// State is a key/value store shared by everything on a StreamDeck.  Buttons can be bound to keys with BindState,
+// so that they are redrawn whenever those keys change, and anything else can Watch keys for changes.
+type State struct {
+	lock     sync.Mutex
+	values   map[string]interface{}
+	watchers []stateWatcher
+}
+
+type stateWatcher struct {
+	key string // Empty to watch every key
+	f   func(string, interface{})
+}
+
+// NewState creates a new, empty, State
+func NewState() *State {
+	return &State{values: make(map[string]interface{})}
+}
+
+// Set sets the value of a key, notifying anything watching it if the value has changed
+func (s *State) Set(key string, value interface{}) {
+	s.lock.Lock()
+	old, ok := s.values[key]
+	if ok && reflect.DeepEqual(old, value) {
+		s.lock.Unlock()
+		return
+	}
+	s.values[key] = value
+	watchers := s.watchers
+	s.lock.Unlock()
+
+	for _, w := range watchers {
+		if w.key == "" || w.key == key {
+			w.f(key, value)
+		}
+	}
+}
+
+// Get returns the value of a key, and whether it has been set
+func (s *State) Get(key string) (interface{}, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Watch registers a callback to be called with the new value whenever the key changes
+func (s *State) Watch(key string, f func(value interface{})) {
+	s.watch(key, func(key string, value interface{}) {
+		f(value)
+	})
+}
+
+func (s *State) watch(key string, f func(string, interface{})) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.watchers = append(s.watchers, stateWatcher{key: key, f: f})
+}
+
+// StateBinding decorates a button according to the value of a key in the State; Decorator is given the value (nil
+// if the key isn't set) and returns the ButtonDecorator to apply, or nil for none
+type StateBinding struct {
+	Key       string
+	Decorator func(value interface{}) ButtonDecorator
+}
+
+// WhenEquals gives a StateBinding which applies the decorator while the key has the given value, for example
+// to show a red border while "recording" is true
+func WhenEquals(key string, value interface{}, d ButtonDecorator) StateBinding {
+	return StateBinding{
+		Key: key,
+		Decorator: func(v interface{}) ButtonDecorator {
+			if reflect.DeepEqual(v, value) {
+				return d
+			}
+			return nil
+		},
+	}
+}
+
+// BindState adds a StateBinding to a button; its decorator is applied on top of any set with SetDecorator, and
+// the button is redrawn whenever the key changes
+func (p *Page) BindState(btnIndex int, b StateBinding) {
+	p.lock.Lock()
+	p.bindings[btnIndex] = append(p.bindings[btnIndex], b)
+	p.lock.Unlock()
+	p.redraw(btnIndex)
+}
+
+// UnbindState removes all of the StateBindings from a button
+func (p *Page) UnbindState(btnIndex int) {
+	p.lock.Lock()
+	delete(p.bindings, btnIndex)
+	p.lock.Unlock()
+	p.redraw(btnIndex)
+}
+
+// GetState returns the State shared by everything on the StreamDeck
+func (sd *StreamDeck) GetState() *State {
+	return sd.state
+}
+
+// BindState adds a StateBinding to a button of the active page, see Page.BindState
+func (sd *StreamDeck) BindState(btnIndex int, b StateBinding) {
+	sd.GetPage().BindState(btnIndex, b)
+}
+
+// stateChanged redraws the buttons of the active page which are bound to a key which has changed
+func (sd *StreamDeck) stateChanged(key string, value interface{}) {
+	p := sd.GetPage()
+	var affected []int
+	p.lock.Lock()
+	for btnIndex, bindings := range p.bindings {
+		for _, b := range bindings {
+			if b.Key == key {
+				affected = append(affected, btnIndex)
+				break
+			}
+		}
+	}
+	p.lock.Unlock()
+	for _, btnIndex := range affected {
+		sd.redraw(p, btnIndex)
+	}
+}
@@ -1,6 +1,12 @@
 package streamdeck
 
-import "image"
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+	"time"
+)
 
 // ButtonDisplay is the interface to satisfy for displaying on a button
 type ButtonDisplay interface {
@@ -8,6 +14,8 @@ type ButtonDisplay interface {
 	GetButtonIndex() int
 	SetButtonIndex(int)
 	RegisterUpdateHandler(func(Button))
+	// Pressed is called when the button goes down, but not when it is released; it used to be called for both,
+	// which ran actions twice for every press
 	Pressed()
 }
 
@@ -26,25 +34,58 @@ type ButtonDecorator interface {
 	Apply(image.Image, int) image.Image
 }
 
+// DefaultPageName is the name of the page a new StreamDeck starts on
+const DefaultPageName = "default"
+
 // StreamDeck is the main struct to represent a StreamDeck device, and internally contains the reference to a `Device`
 type StreamDeck struct {
-	dev        *Device
-	buttons    map[int]Button
-	decorators map[int]ButtonDecorator
+	dev *Device
+
+	lock  sync.Mutex // Guards the pages, and serialises drawing so that page switches are atomic
+	pages map[string]*Page
+	page  *Page
+
+	navStack        []string
+	backButtonIndex int
+	state           *State
+
+	brightness  int
+	screensaver bool
+
+	inactivityTimer   *time.Timer
+	inactivityTimeout time.Duration
+
+	pageTransition         Transition
+	pageTransitionDuration time.Duration
+	transition             *Animation // The page transition running, if any
 }
 
 // New will return a new instance of a `StreamDeck`, and is the main entry point for the higher-level interface.  It will return an error if there is no StreamDeck plugged in.
 func New() (*StreamDeck, error) {
-	sd := &StreamDeck{}
 	d, err := Open()
 	if err != nil {
 		return nil, err
 	}
+	return NewWithDevice(d), nil
+}
+
+// NewWithDevice returns a new `StreamDeck` for a Device already open, such as one from a Manager or a
+// streamdecktest Mock
+func NewWithDevice(d *Device) *StreamDeck {
+	sd := &StreamDeck{}
 	sd.dev = d
-	sd.buttons = make(map[int]Button)
-	sd.decorators = make(map[int]ButtonDecorator)
+	sd.brightness = 100
+	sd.state = NewState()
+	sd.state.watch("", sd.stateChanged)
+	sd.pages = make(map[string]*Page)
+	sd.AddPage(NewPage(DefaultPageName))
+	sd.page = sd.pages[DefaultPageName]
 	sd.dev.ButtonPress(sd.pressHandler)
-	return sd, nil
+	sd.dev.EncoderPress(sd.encoderPressHandler)
+	sd.dev.EncoderRotate(sd.encoderRotateHandler)
+	sd.dev.TouchPush(sd.touchPushHandler)
+	sd.dev.TouchSwipe(sd.touchSwipeHandler)
+	return sd
 }
 
 // GetName returns the name of the type of Streamdeck
@@ -52,69 +93,247 @@ func (sd *StreamDeck) GetName() string {
 	return sd.dev.deviceType.name
 }
 
-// AddButton adds a `Button` object to the StreamDeck at the specified index
+// GetDevice returns the underlying Device, for lower-level access such as writing to the touchscreen
+func (sd *StreamDeck) GetDevice() *Device {
+	return sd.dev
+}
+
+// AddButton adds a `Button` object to the active page at the specified index
 func (sd *StreamDeck) AddButton(btnIndex int, b Button) {
-	b.RegisterUpdateHandler(sd.ButtonUpdateHandler)
-	b.SetButtonIndex(btnIndex)
-	sd.buttons[btnIndex] = b
-	sd.updateButton(b)
+	sd.GetPage().AddButton(btnIndex, b)
 }
 
-// SetDecorator imposes a ButtonDecorator onto a given button
+// SetDecorator imposes a ButtonDecorator onto a given button of the active page
 func (sd *StreamDeck) SetDecorator(btnIndex int, d ButtonDecorator) {
-	sd.decorators[btnIndex] = d
-	// If there's a button there, update it
-	btn, ok := sd.buttons[btnIndex]
-	if ok {
-		sd.updateButton(btn)
-	}
+	sd.GetPage().SetDecorator(btnIndex, d)
 }
 
-// UnsetDecorator removes a ButtonDecorator from a given button
+// UnsetDecorator removes a ButtonDecorator from a given button of the active page
 func (sd *StreamDeck) UnsetDecorator(btnIndex int) {
-	delete(sd.decorators, btnIndex)
-	// If there's a button there, update it
-	btn, ok := sd.buttons[btnIndex]
-	if ok {
-		sd.updateButton(btn)
-	}
+	sd.GetPage().UnsetDecorator(btnIndex)
 }
 
 // ButtonUpdateHandler allows a user of this library to signal when something external has changed, such that this button should be update
 func (sd *StreamDeck) ButtonUpdateHandler(b Button) {
-	sd.buttons[b.GetButtonIndex()] = b
-	sd.updateButton(b)
+	p := sd.GetPage()
+	p.lock.Lock()
+	p.buttons[b.GetButtonIndex()] = b
+	p.lock.Unlock()
+	sd.redraw(p, b.GetButtonIndex())
 }
 
-// GetButtonByIndex returns a button for the given index
+// GetButtonByIndex returns a button of the active page for the given index
 func (sd *StreamDeck) GetButtonIndex(btnIndex int) Button {
-	b, ok := sd.buttons[btnIndex]
+	return sd.GetPage().GetButtonIndex(btnIndex)
+}
+
+// AddPage makes a Page available to SetPage, replacing any existing page of the same name
+func (sd *StreamDeck) AddPage(p *Page) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	p.lock.Lock()
+	p.sd = sd
+	p.lock.Unlock()
+	sd.pages[p.name] = p
+	if sd.page != nil && sd.page.name == p.name {
+		sd.page = p
+		sd.redrawAll()
+	}
+}
+
+// RemovePage removes the named page; the active page can't be removed, so switch away from it first
+func (sd *StreamDeck) RemovePage(name string) error {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	p, ok := sd.pages[name]
 	if !ok {
-		return nil
+		return fmt.Errorf("No page named %q", name)
 	}
-	return b
+	if p == sd.page {
+		return fmt.Errorf("Page %q is active", name)
+	}
+	delete(sd.pages, name)
+	return nil
+}
+
+// GetPage returns the active page
+func (sd *StreamDeck) GetPage() *Page {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	return sd.page
+}
+
+// SetPage switches to the named page, redrawing every button in one go; from then on, events are only routed to that
+// page.  This also clears the folder navigation stack, see OpenFolder.
+func (sd *StreamDeck) SetPage(name string) error {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	sd.navStack = nil
+	return sd.setPage(name)
+}
+
+// setPage switches to the named page, and must be called with the lock held
+func (sd *StreamDeck) setPage(name string) error {
+	p, ok := sd.pages[name]
+	if !ok {
+		return fmt.Errorf("No page named %q", name)
+	}
+	old := sd.page
+	var from map[int]image.Image
+	if sd.pageTransition != TransitionNone && sd.pageTransitionDuration > 0 && old != nil && old != p && sd.dev.HasImageCapability() {
+		from = make(map[int]image.Image)
+		for i := 0; i < int(sd.dev.deviceType.numberOfButtons); i++ {
+			from[i] = sd.buttonImage(i)
+		}
+	}
+	if sd.transition != nil {
+		sd.transition.Stop()
+		sd.transition = nil
+	}
+	sd.page = p
+	var err error
+	if from != nil {
+		sd.transitionPage(from)
+	} else {
+		err = sd.redrawAll()
+	}
+	if p.hasTouchButtons() || (old != nil && old.hasTouchButtons()) {
+		if e := sd.redrawTouchscreen(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
 
 func (sd *StreamDeck) pressHandler(btnIndex int, d *Device, err error, pressed bool) {
 	if err != nil {
 		panic(err)
 	}
-	b := sd.buttons[btnIndex]
+	if !pressed {
+		// Releases aren't passed on to buttons, see ButtonDisplay.Pressed
+		return
+	}
+	sd.activity()
+	if sd.wake() {
+		return
+	}
+	b := sd.GetPage().GetButtonIndex(btnIndex)
 	if b != nil {
-		sd.buttons[btnIndex].Pressed()
+		b.Pressed()
 	}
 }
 
-func (sd *StreamDeck) updateButton(b Button) error {
-	img := b.GetImageForButton(sd.dev.deviceType.imageSize.X)
-	decorator, ok := sd.decorators[b.GetButtonIndex()]
-	if ok {
-		img = decorator.Apply(img, sd.dev.deviceType.imageSize.X)
+func (sd *StreamDeck) encoderPressHandler(encIndex int, d *Device, pressed bool) {
+	sd.activity()
+	p := sd.GetPage()
+	p.lock.Lock()
+	f := p.encoderPressHandlers[encIndex]
+	p.lock.Unlock()
+	if f != nil {
+		f(pressed)
 	}
-	e := sd.dev.WriteRawImageToButton(b.GetButtonIndex(), img)
-	return e
 }
 
-func (sd *StreamDeck) SetBrightness(brightness int) {
-	sd.dev.SetBrightness(brightness)
+func (sd *StreamDeck) encoderRotateHandler(encIndex int, d *Device, pulses int) {
+	sd.activity()
+	p := sd.GetPage()
+	p.lock.Lock()
+	f := p.encoderRotateHandlers[encIndex]
+	p.lock.Unlock()
+	if f != nil {
+		f(pulses)
+	}
+}
+
+func (sd *StreamDeck) touchPushHandler(d *Device, x, y uint16, hold bool) {
+	sd.activity()
+	p := sd.GetPage()
+	if tb := p.touchButtonAt(int(x), int(y)); tb != nil {
+		p.tapTouchButton(tb)
+		return
+	}
+	p.lock.Lock()
+	f := p.touchPushHandler
+	p.lock.Unlock()
+	if f != nil {
+		f(x, y, hold)
+	}
+}
+
+func (sd *StreamDeck) touchSwipeHandler(d *Device, xstart, ystart, xstop, ystop uint16) {
+	sd.activity()
+	p := sd.GetPage()
+	p.lock.Lock()
+	f := p.touchSwipeHandler
+	p.lock.Unlock()
+	if f != nil {
+		f(xstart, ystart, xstop, ystop)
+	}
+}
+
+// redraw draws the given button of a page, if it is the active page
+func (sd *StreamDeck) redraw(p *Page, btnIndex int) error {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	if sd.page != p {
+		return nil
+	}
+	return sd.updateButton(btnIndex)
+}
+
+// redrawAll draws every button of the active page, and must be called with the lock held.  The images are
+// encoded in parallel, see WriteRawImagesToButtons.
+func (sd *StreamDeck) redrawAll() error {
+	if !sd.dev.HasImageCapability() {
+		return nil
+	}
+	images := make(map[int]image.Image)
+	for i := 0; i < int(sd.dev.deviceType.numberOfButtons); i++ {
+		images[i] = sd.buttonImage(i)
+	}
+	return sd.dev.WriteRawImagesToButtons(images)
+}
+
+// updateButton draws a button of the active page, and must be called with the lock held
+func (sd *StreamDeck) updateButton(btnIndex int) error {
+	return sd.dev.WriteRawImageToButton(btnIndex, sd.buttonImage(btnIndex))
+}
+
+// buttonImage gives the image for a button of the active page (or a blank, if there is no button there), and
+// must be called with the lock held
+func (sd *StreamDeck) buttonImage(btnIndex int) image.Image {
+	sd.page.lock.Lock()
+	b := sd.page.buttons[btnIndex]
+	decorator, ok := sd.page.decorators[btnIndex]
+	bindings := sd.page.bindings[btnIndex]
+	var effect buttonEffect
+	if e := sd.page.effects[btnIndex]; e != nil {
+		effect = *e
+	}
+	sd.page.lock.Unlock()
+
+	size := sd.dev.deviceType.imageSize.X
+	if effect.img != nil {
+		return effect.img
+	}
+	if b == nil {
+		if effect.invert {
+			return getSolidColourImage(color.White, size)
+		}
+		return getSolidColourImage(color.Black, size)
+	}
+	img := b.GetImageForButton(size)
+	if ok {
+		img = decorator.Apply(img, size)
+	}
+	for _, binding := range bindings {
+		value, _ := sd.state.Get(binding.Key)
+		if d := binding.Decorator(value); d != nil {
+			img = d.Apply(img, size)
+		}
+	}
+	if effect.invert {
+		img = getInvertedImage(img)
+	}
+	return img
 }
@@ -0,0 +1,120 @@
+package streamdecktest
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// Directions of the reports in a capture
+const (
+	In      = "in"      // Input reports read from the device
+	Out     = "out"     // Output reports written to the device
+	Feature = "feature" // Feature reports sent to the device
+)
+
+// CaptureEntry is one report in a capture, see Recorder
+type CaptureEntry struct {
+	At        time.Duration // Since recording started
+	Direction string        // In, Out or Feature
+	Report    []byte
+}
+
+// Recorder wraps a streamdeck.DeviceInterface, such as a USB HID device, writing every report that passes through
+// it to a capture, one per line: the milliseconds since recording started, the direction and the report in hex.
+// Captures from real devices can then be replayed with Mock.Replay.
+type Recorder struct {
+	fd    streamdeck.DeviceInterface
+	lock  sync.Mutex
+	w     io.Writer
+	start time.Time
+}
+
+// NewRecorder creates a Recorder writing the reports passing through fd to w; to record a deck, open its HID
+// device and pass the Recorder to streamdeck.OpenWithInterface
+func NewRecorder(fd streamdeck.DeviceInterface, w io.Writer) *Recorder {
+	return &Recorder{fd: fd, w: w, start: time.Now()}
+}
+
+func (r *Recorder) record(direction string, report []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	fmt.Fprintf(r.w, "%d %s %s\n", time.Since(r.start)/time.Millisecond, direction, hex.EncodeToString(report))
+}
+
+// Write writes an output report, recording it
+func (r *Recorder) Write(report []byte) (int, error) {
+	r.record(Out, report)
+	return r.fd.Write(report)
+}
+
+// Read reads an input report, recording it
+func (r *Recorder) Read(report []byte) (int, error) {
+	n, err := r.fd.Read(report)
+	if n > 0 {
+		r.record(In, report[:n])
+	}
+	return n, err
+}
+
+// SendFeatureReport sends a feature report, recording it
+func (r *Recorder) SendFeatureReport(report []byte) (int, error) {
+	r.record(Feature, report)
+	return r.fd.SendFeatureReport(report)
+}
+
+// Close closes the wrapped device
+func (r *Recorder) Close() error {
+	return r.fd.Close()
+}
+
+// ReadCapture reads a capture written by a Recorder
+func ReadCapture(r io.Reader) ([]CaptureEntry, error) {
+	var entries []CaptureEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("Line %d of the capture should have three fields", line)
+		}
+		ms, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d of the capture has an invalid time: %s", line, err)
+		}
+		report, err := hex.DecodeString(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("Line %d of the capture has an invalid report: %s", line, err)
+		}
+		entries = append(entries, CaptureEntry{At: time.Duration(ms) * time.Millisecond, Direction: fields[1], Report: report})
+	}
+	return entries, scanner.Err()
+}
+
+// Replay injects the input reports of a capture in order, waiting for each to be handled.  The time between
+// them is kept as it was recorded, as the Device debounces presses that come too close together.
+func (m *Mock) Replay(entries []CaptureEntry) {
+	var last time.Duration
+	first := true
+	for _, e := range entries {
+		if e.Direction != In {
+			continue
+		}
+		if !first && e.At > last {
+			time.Sleep(e.At - last)
+		}
+		first = false
+		last = e.At
+		m.InjectReport(e.Report)
+	}
+}
@@ -0,0 +1,245 @@
+// Package streamdecktest helps test applications built on go-streamdeck without a deck plugged in.  Mock stands
+// in for the USB device: it records every report written, and injects input reports such as button presses and
+// encoder turns, waiting until the Device's listeners have handled them.  Recorder captures the traffic of a
+// real device, for replaying through a Mock.
+//
+//	d, mock, err := streamdecktest.Open(0x80) // Stream Deck MK.2, with the devices package imported
+//	...
+//	mock.PressButton(3)
+//	// assert on what the application did, for example on mock.Writes()
+package streamdecktest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+)
+
+// reportLength is the length of the input reports a Device reads
+const reportLength = 255
+
+// Mock is a scripted streamdeck.DeviceInterface
+type Mock struct {
+	lock     sync.Mutex
+	writes   [][]byte
+	features [][]byte
+	layout   protocol.Layout
+	buttons  []byte
+	encoders []byte
+
+	reports   chan []byte
+	processed chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMock creates a Mock for a device with the given layout (see Device.GetLayout); to create a Device using it,
+// see Open
+func NewMock(layout protocol.Layout) *Mock {
+	return &Mock{
+		layout:    layout,
+		buttons:   make([]byte, layout.NumberOfButtons),
+		encoders:  make([]byte, layout.NumberOfEncoders),
+		reports:   make(chan []byte),
+		processed: make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+}
+
+// Open creates a Device, of the type registered for the given USB product ID, talking to a new Mock.  As a real
+// device ignores presses in the first moment after opening, this waits for that to pass.
+func Open(productID uint16) (*streamdeck.Device, *Mock, error) {
+	m := NewMock(protocol.Layout{})
+	d, err := streamdeck.OpenWithInterface(m, productID, "MOCK")
+	if err != nil {
+		return nil, nil, err
+	}
+	layout := d.GetLayout()
+	m.lock.Lock()
+	m.layout = layout
+	m.buttons = make([]byte, layout.NumberOfButtons)
+	m.encoders = make([]byte, layout.NumberOfEncoders)
+	m.lock.Unlock()
+	time.Sleep(protocol.Debounce + 10*time.Millisecond)
+	return d, m, nil
+}
+
+// Write records an output report, such as a page of a button image
+func (m *Mock) Write(report []byte) (int, error) {
+	select {
+	case <-m.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.writes = append(m.writes, append([]byte(nil), report...))
+	return len(report), nil
+}
+
+// SendFeatureReport records a feature report, such as a reset or brightness command
+func (m *Mock) SendFeatureReport(report []byte) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.features = append(m.features, append([]byte(nil), report...))
+	return len(report), nil
+}
+
+// Read blocks until a report is injected, or the Mock is closed.  A Device only reads again once it has passed
+// the previous report to its listeners, which is how InjectReport knows they have run.
+func (m *Mock) Read(report []byte) (int, error) {
+	select {
+	case m.processed <- struct{}{}:
+	default:
+	}
+	select {
+	case r := <-m.reports:
+		return copy(report, r), nil
+	case <-m.closed:
+		return 0, io.EOF
+	}
+}
+
+// Close makes any Read return an error, as happens when a device is unplugged
+func (m *Mock) Close() error {
+	m.closeOnce.Do(func() { close(m.closed) })
+	return nil
+}
+
+// Writes returns every output report written so far
+func (m *Mock) Writes() [][]byte {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([][]byte(nil), m.writes...)
+}
+
+// WritesWithPrefix returns the output reports starting with the given bytes, such as the image header of one
+// button
+func (m *Mock) WritesWithPrefix(prefix []byte) [][]byte {
+	var found [][]byte
+	for _, w := range m.Writes() {
+		if bytes.HasPrefix(w, prefix) {
+			found = append(found, w)
+		}
+	}
+	return found
+}
+
+// FeatureReports returns every feature report sent so far
+func (m *Mock) FeatureReports() [][]byte {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([][]byte(nil), m.features...)
+}
+
+// ClearRecorded forgets the reports recorded so far, to make assertions on what happens next easier
+func (m *Mock) ClearRecorded() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.writes = nil
+	m.features = nil
+}
+
+// InjectReport passes an input report to the Device, and waits until its listeners have handled it
+func (m *Mock) InjectReport(report []byte) {
+	r := make([]byte, reportLength)
+	copy(r, report)
+	select {
+	case m.reports <- r:
+	case <-m.closed:
+		return
+	}
+	select {
+	case <-m.processed:
+	case <-m.closed:
+	}
+}
+
+// PressButton presses a button, numbered as the hardware numbers them (before any button map).  As with a real
+// device, a press less than protocol.Debounce after the last press of the same button is ignored.
+func (m *Mock) PressButton(btnIndex int) {
+	m.setButton(btnIndex, 1)
+}
+
+// ReleaseButton releases a button pressed with PressButton
+func (m *Mock) ReleaseButton(btnIndex int) {
+	m.setButton(btnIndex, 0)
+}
+
+// TapButton presses and releases a button
+func (m *Mock) TapButton(btnIndex int) {
+	m.PressButton(btnIndex)
+	m.ReleaseButton(btnIndex)
+}
+
+func (m *Mock) setButton(btnIndex int, value byte) {
+	m.lock.Lock()
+	m.buttons[btnIndex] = value
+	report := make([]byte, reportLength)
+	report[0] = 1
+	copy(report[m.layout.ButtonReadOffset:], m.buttons)
+	m.lock.Unlock()
+	m.InjectReport(report)
+}
+
+// PressEncoder pushes an encoder in
+func (m *Mock) PressEncoder(encIndex int) {
+	m.setEncoder(encIndex, 1)
+}
+
+// ReleaseEncoder lets go of an encoder pushed with PressEncoder
+func (m *Mock) ReleaseEncoder(encIndex int) {
+	m.setEncoder(encIndex, 0)
+}
+
+func (m *Mock) setEncoder(encIndex int, value byte) {
+	m.lock.Lock()
+	m.encoders[encIndex] = value
+	report := m.encoderReport(0)
+	copy(report[m.layout.EncoderReadOffset:], m.encoders)
+	m.lock.Unlock()
+	m.InjectReport(report)
+}
+
+// RotateEncoder turns an encoder by a number of pulses, positive for clockwise
+func (m *Mock) RotateEncoder(encIndex int, pulses int) {
+	m.lock.Lock()
+	report := m.encoderReport(1)
+	report[m.layout.EncoderReadOffset+encIndex] = byte(int8(pulses))
+	m.lock.Unlock()
+	m.InjectReport(report)
+}
+
+func (m *Mock) encoderReport(kind byte) []byte {
+	report := make([]byte, reportLength)
+	report[0], report[1], report[4] = 1, 3, kind
+	return report
+}
+
+// Tap taps the touchscreen, or holds it if hold is set
+func (m *Mock) Tap(x, y uint16, hold bool) {
+	report := make([]byte, 14)
+	report[0], report[1], report[4] = 1, 2, 1
+	if hold {
+		report[4] = 2
+	}
+	binary.LittleEndian.PutUint16(report[6:], x)
+	binary.LittleEndian.PutUint16(report[8:], y)
+	m.InjectReport(report)
+}
+
+// Swipe swipes across the touchscreen
+func (m *Mock) Swipe(xstart, ystart, xstop, ystop uint16) {
+	report := make([]byte, 14)
+	report[0], report[1], report[4] = 1, 2, 3
+	binary.LittleEndian.PutUint16(report[6:], xstart)
+	binary.LittleEndian.PutUint16(report[8:], ystart)
+	binary.LittleEndian.PutUint16(report[10:], xstop)
+	binary.LittleEndian.PutUint16(report[12:], ystop)
+	m.InjectReport(report)
+}
@@ -0,0 +1,170 @@
+package streamdecktest_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+	_ "github.com/SKAARHOJ/go-streamdeck/devices"
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+	"github.com/SKAARHOJ/go-streamdeck/streamdecktest"
+)
+
+// eventLog records a Device's events as text, in the order they arrive
+type eventLog struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func listen(d *streamdeck.Device) *eventLog {
+	l := &eventLog{}
+	add := func(format string, args ...interface{}) {
+		l.lock.Lock()
+		l.events = append(l.events, fmt.Sprintf(format, args...))
+		l.lock.Unlock()
+	}
+	d.ButtonPress(func(btnIndex int, d *streamdeck.Device, err error, pressed bool) {
+		if err == nil {
+			add("button %d %t", btnIndex, pressed)
+		}
+	})
+	d.EncoderPress(func(encIndex int, d *streamdeck.Device, pressed bool) {
+		add("encoderPress %d %t", encIndex, pressed)
+	})
+	d.EncoderRotate(func(encIndex int, d *streamdeck.Device, pulses int) {
+		add("encoderRotate %d %d", encIndex, pulses)
+	})
+	d.TouchPush(func(d *streamdeck.Device, x, y uint16, hold bool) {
+		add("touch %d,%d %t", x, y, hold)
+	})
+	d.TouchSwipe(func(d *streamdeck.Device, xstart, ystart, xstop, ystop uint16) {
+		add("swipe %d,%d %d,%d", xstart, ystart, xstop, ystop)
+	})
+	return l
+}
+
+// expect checks the events since the last call; injecting a report waits for it to be handled, so they have all
+// arrived
+func (l *eventLog) expect(t *testing.T, want ...string) {
+	t.Helper()
+	l.lock.Lock()
+	got := l.events
+	l.events = nil
+	l.lock.Unlock()
+	if !reflect.DeepEqual(got, want) && (len(got) != 0 || len(want) != 0) {
+		t.Errorf("Events were\n%s\nnot\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMockButtons(t *testing.T) {
+	d, mock, err := streamdecktest.Open(0x80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	events := listen(d)
+
+	mock.PressButton(3)
+	mock.PressButton(5)
+	events.expect(t, "button 3 true", "button 5 true")
+	mock.ReleaseButton(3)
+	mock.ReleaseButton(5)
+	events.expect(t, "button 3 false", "button 5 false")
+
+	// Pressed again too soon after the last press, so ignored along with its release
+	mock.TapButton(3)
+	events.expect(t)
+	time.Sleep(protocol.Debounce)
+	mock.TapButton(3)
+	events.expect(t, "button 3 true", "button 3 false")
+}
+
+func TestMockEncodersAndTouch(t *testing.T) {
+	d, mock, err := streamdecktest.Open(0x84)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	events := listen(d)
+
+	mock.RotateEncoder(2, 3)
+	mock.RotateEncoder(0, -2)
+	events.expect(t, "encoderRotate 2 3", "encoderRotate 0 -2")
+	mock.PressEncoder(1)
+	mock.ReleaseEncoder(1)
+	events.expect(t, "encoderPress 1 true", "encoderPress 1 false")
+	mock.Tap(400, 50, false)
+	mock.Tap(12, 34, true)
+	mock.Swipe(100, 50, 700, 60)
+	events.expect(t, "touch 400,50 false", "touch 12,34 true", "swipe 100,50 700,60")
+}
+
+func TestReplay(t *testing.T) {
+	f, err := os.Open("testdata/plus-session.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	entries, err := streamdecktest.ReadCapture(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 11 || entries[0].Direction != streamdecktest.Feature || entries[1].At != 20*time.Millisecond {
+		t.Fatalf("Read %d entries, starting %+v", len(entries), entries[0])
+	}
+
+	d, mock, err := streamdecktest.Open(0x84)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	events := listen(d)
+	mock.ClearRecorded()
+	mock.Replay(entries)
+	events.expect(t,
+		"button 1 true",
+		"button 1 false",
+		"encoderRotate 0 3",
+		"encoderRotate 1 -2",
+		"encoderRotate 2 -3",
+		"encoderPress 1 true",
+		"encoderPress 1 false",
+		"touch 400,50 false",
+		"touch 200,40 true",
+		"swipe 100,50 700,50",
+	)
+	if len(mock.Writes()) != 0 || len(mock.FeatureReports()) != 0 {
+		t.Error("Replaying sent the capture's output reports")
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	mock := streamdecktest.NewMock(protocol.Layout{})
+	var capture bytes.Buffer
+	r := streamdecktest.NewRecorder(mock, &capture)
+	r.SendFeatureReport([]byte{0x03, 0x02})
+	r.Write([]byte{0x02, 0x07, 0x01})
+	go mock.InjectReport([]byte{0x01, 0x00, 0x00, 0x00, 0x01})
+	report := make([]byte, 5)
+	if _, err := r.Read(report); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := streamdecktest.ReadCapture(&capture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, fmt.Sprintf("%s %x", e.Direction, e.Report))
+	}
+	if want := []string{"feature 0302", "out 020701", "in 0100000001"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Captured %q", got)
+	}
+}
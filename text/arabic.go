@@ -0,0 +1,137 @@
+package text
+
+// Arabic letters are written joined up, each taking one of up to four forms depending on whether it joins the
+// letters either side.  Fonts have these forms as presentation form characters, which is what is drawn.
+
+// arabicForms are the isolated, final, initial and medial forms of each letter; letters without initial and
+// medial forms only join the letter before them
+var arabicForms = map[rune][4]rune{
+	0x0621: {0xfe80, 0, 0, 0}, // Hamza joins neither side
+	0x0622: {0xfe81, 0xfe82, 0, 0},
+	0x0623: {0xfe83, 0xfe84, 0, 0},
+	0x0624: {0xfe85, 0xfe86, 0, 0},
+	0x0625: {0xfe87, 0xfe88, 0, 0},
+	0x0626: {0xfe89, 0xfe8a, 0xfe8b, 0xfe8c},
+	0x0627: {0xfe8d, 0xfe8e, 0, 0},
+	0x0628: {0xfe8f, 0xfe90, 0xfe91, 0xfe92},
+	0x0629: {0xfe93, 0xfe94, 0, 0},
+	0x062a: {0xfe95, 0xfe96, 0xfe97, 0xfe98},
+	0x062b: {0xfe99, 0xfe9a, 0xfe9b, 0xfe9c},
+	0x062c: {0xfe9d, 0xfe9e, 0xfe9f, 0xfea0},
+	0x062d: {0xfea1, 0xfea2, 0xfea3, 0xfea4},
+	0x062e: {0xfea5, 0xfea6, 0xfea7, 0xfea8},
+	0x062f: {0xfea9, 0xfeaa, 0, 0},
+	0x0630: {0xfeab, 0xfeac, 0, 0},
+	0x0631: {0xfead, 0xfeae, 0, 0},
+	0x0632: {0xfeaf, 0xfeb0, 0, 0},
+	0x0633: {0xfeb1, 0xfeb2, 0xfeb3, 0xfeb4},
+	0x0634: {0xfeb5, 0xfeb6, 0xfeb7, 0xfeb8},
+	0x0635: {0xfeb9, 0xfeba, 0xfebb, 0xfebc},
+	0x0636: {0xfebd, 0xfebe, 0xfebf, 0xfec0},
+	0x0637: {0xfec1, 0xfec2, 0xfec3, 0xfec4},
+	0x0638: {0xfec5, 0xfec6, 0xfec7, 0xfec8},
+	0x0639: {0xfec9, 0xfeca, 0xfecb, 0xfecc},
+	0x063a: {0xfecd, 0xfece, 0xfecf, 0xfed0},
+	0x0641: {0xfed1, 0xfed2, 0xfed3, 0xfed4},
+	0x0642: {0xfed5, 0xfed6, 0xfed7, 0xfed8},
+	0x0643: {0xfed9, 0xfeda, 0xfedb, 0xfedc},
+	0x0644: {0xfedd, 0xfede, 0xfedf, 0xfee0},
+	0x0645: {0xfee1, 0xfee2, 0xfee3, 0xfee4},
+	0x0646: {0xfee5, 0xfee6, 0xfee7, 0xfee8},
+	0x0647: {0xfee9, 0xfeea, 0xfeeb, 0xfeec},
+	0x0648: {0xfeed, 0xfeee, 0, 0},
+	0x0649: {0xfeef, 0xfef0, 0, 0},
+	0x064a: {0xfef1, 0xfef2, 0xfef3, 0xfef4},
+	// Persian
+	0x067e: {0xfb56, 0xfb57, 0xfb58, 0xfb59},
+	0x0686: {0xfb7a, 0xfb7b, 0xfb7c, 0xfb7d},
+	0x0698: {0xfb8a, 0xfb8b, 0, 0},
+	0x06a9: {0xfb8e, 0xfb8f, 0xfb90, 0xfb91},
+	0x06af: {0xfb92, 0xfb93, 0xfb94, 0xfb95},
+	0x06cc: {0xfbfc, 0xfbfd, 0xfbfe, 0xfbff},
+}
+
+// lamAlef are the isolated and final forms of lam followed by each kind of alef, which are always drawn as one
+var lamAlef = map[rune][2]rune{
+	0x0622: {0xfef5, 0xfef6},
+	0x0623: {0xfef7, 0xfef8},
+	0x0625: {0xfef9, 0xfefa},
+	0x0627: {0xfefb, 0xfefc},
+}
+
+const (
+	lam     = 0x0644
+	tatweel = 0x0640 // Joins either side, to stretch a word out
+	zwj     = 0x200d // Zero width joiner, joins either side
+)
+
+// joinsBefore returns whether a character joins the one before it
+func joinsBefore(r rune) bool {
+	if r == tatweel || r == zwj {
+		return true
+	}
+	for _, forms := range lamAlef {
+		if r == forms[1] {
+			return true
+		}
+	}
+	forms, ok := arabicForms[r]
+	return ok && forms[1] != 0
+}
+
+// joinsAfter returns whether a character joins the one after it
+func joinsAfter(r rune) bool {
+	if r == tatweel || r == zwj {
+		return true
+	}
+	forms, ok := arabicForms[r]
+	return ok && forms[2] != 0
+}
+
+// joinArabic replaces Arabic letters with the forms that join them up, as the text is read
+func joinArabic(clusters []cluster) []cluster {
+	// Lam alef first, as the ligature doesn't join the letter after it
+	var joined []cluster
+	for i := 0; i < len(clusters); i++ {
+		c := clusters[i]
+		if c.runes[0] == lam && i+1 < len(clusters) {
+			if forms, ok := lamAlef[clusters[i+1].runes[0]]; ok {
+				final := len(joined) > 0 && joinsAfter(joined[len(joined)-1].runes[0])
+				r := forms[0]
+				if final {
+					r = forms[1]
+				}
+				runes := append([]rune{r}, c.runes[1:]...)
+				joined = append(joined, cluster{runes: append(runes, clusters[i+1].runes[1:]...)})
+				i++
+				continue
+			}
+		}
+		joined = append(joined, cluster{runes: append([]rune(nil), c.runes...)})
+	}
+
+	// The decisions are made on the letters as they were, so work from a copy
+	bases := make([]rune, len(joined))
+	for i, c := range joined {
+		bases[i] = c.runes[0]
+	}
+	for i, r := range bases {
+		forms, ok := arabicForms[r]
+		if !ok {
+			continue
+		}
+		before := i > 0 && joinsAfter(bases[i-1]) && forms[1] != 0
+		after := i+1 < len(bases) && joinsBefore(bases[i+1]) && forms[2] != 0
+		switch {
+		case before && after:
+			joined[i].runes[0] = forms[3]
+		case before:
+			joined[i].runes[0] = forms[1]
+		case after:
+			joined[i].runes[0] = forms[2]
+		default:
+			joined[i].runes[0] = forms[0]
+		}
+	}
+	return joined
+}
@@ -0,0 +1,35 @@
+package text
+
+import (
+	"testing"
+)
+
+func TestJoinArabic(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		in, want string
+	}{
+		{"isolated", "ب", "ﺏ"},
+		{"initial, medial and final", "بيت", "ﺑﻴﺖ"},
+		{"non-joining letters", "دار", "ﺩﺍﺭ"},
+		{"hamza", "بءب", "ﺏﺀﺏ"},
+		{"words apart", "بب بب", "ﺑﺐ ﺑﺐ"},
+		{"isolated lam alef", "لا", "ﻻ"},
+		{"final lam alef", "سلام", "ﺳﻼﻡ"},
+		{"lam alef with hamza", "لأ", "ﻷ"},
+		{"lam alone", "ل", "ﻝ"},
+		{"tatweel", "بـب", "ﺑـﺐ"},
+		{"zero width joiner", "ب‍", "ﺑ‍"},
+		{"Persian", "پک", "ﭘﮏ"},
+		{"marks", "بَت", "ﺑَﺖ"},
+		{"not Arabic", "abc", "abc"},
+	} {
+		var got []rune
+		for _, c := range joinArabic(splitClusters(tc.in)) {
+			got = append(got, c.runes...)
+		}
+		if string(got) != tc.want {
+			t.Errorf("%s: %q is joined as %q, not %q", tc.name, tc.in, string(got), tc.want)
+		}
+	}
+}
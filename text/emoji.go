@@ -0,0 +1,232 @@
+package text
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png" // Emoji images are usually PNGs
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/gift"
+)
+
+// Colour emoji are drawn from images, as TrueType fonts can only have them in black and white.  The coloured
+// circles and squares often used for status, such as "🔴 REC", are built in; others are added with AddEmoji or
+// LoadEmojiDirectory.  Emoji without an image are drawn from the fonts like any other character, so a black and
+// white emoji font can be added to the chain to cover the rest.
+
+const maxEmojiLength = 10 // Characters in the longest sequence looked for, such as a family joined with ZWJs
+
+var (
+	emoji       = make(map[string]image.Image) // By sequence, without variation selectors
+	emojiStarts = make(map[rune]bool)          // First characters of the sequences, to look up only when worthwhile
+	emojiScaled = make(map[emojiSize]image.Image)
+)
+
+type emojiSize struct {
+	sequence string
+	px       int
+}
+
+func init() {
+	circles := map[rune]color.RGBA{
+		0x1f534: {0xdd, 0x2e, 0x44, 0xff}, // Red
+		0x1f7e0: {0xf4, 0x90, 0x0c, 0xff}, // Orange
+		0x1f7e1: {0xfd, 0xcb, 0x58, 0xff}, // Yellow
+		0x1f7e2: {0x78, 0xb1, 0x59, 0xff}, // Green
+		0x1f535: {0x55, 0xac, 0xee, 0xff}, // Blue
+		0x1f7e3: {0xaa, 0x8e, 0xd6, 0xff}, // Purple
+		0x1f7e4: {0xc1, 0x69, 0x4f, 0xff}, // Brown
+		0x26ab:  {0x31, 0x37, 0x3d, 0xff}, // Black
+		0x26aa:  {0xe6, 0xe7, 0xe8, 0xff}, // White
+	}
+	for r, c := range circles {
+		addEmoji(string(r), emojiShape(c, true))
+	}
+	squares := map[rune]color.RGBA{
+		0x1f7e5: {0xdd, 0x2e, 0x44, 0xff},
+		0x1f7e7: {0xf4, 0x90, 0x0c, 0xff},
+		0x1f7e8: {0xfd, 0xcb, 0x58, 0xff},
+		0x1f7e9: {0x78, 0xb1, 0x59, 0xff},
+		0x1f7e6: {0x55, 0xac, 0xee, 0xff},
+		0x1f7ea: {0xaa, 0x8e, 0xd6, 0xff},
+		0x1f7eb: {0xc1, 0x69, 0x4f, 0xff},
+		0x2b1b:  {0x31, 0x37, 0x3d, 0xff},
+		0x2b1c:  {0xe6, 0xe7, 0xe8, 0xff},
+	}
+	for r, c := range squares {
+		addEmoji(string(r), emojiShape(c, false))
+	}
+}
+
+// emojiShape draws a built in emoji, a circle or a square, with smoothed edges
+func emojiShape(c color.RGBA, circle bool) image.Image {
+	const size, samples = 72, 4
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	margin, centre := 4.0, float64(size)/2
+	radius := centre - margin
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			covered := 0
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					px := float64(x) + (float64(sx)+0.5)/samples - centre
+					py := float64(y) + (float64(sy)+0.5)/samples - centre
+					if circle && px*px+py*py <= radius*radius ||
+						!circle && px >= -radius && px <= radius && py >= -radius && py <= radius {
+						covered++
+					}
+				}
+			}
+			a := uint32(covered) * 0xffff / (samples * samples)
+			img.Set(x, y, color.RGBA64{uint16(uint32(c.R) * 0x101 * a / 0xffff), uint16(uint32(c.G) * 0x101 * a / 0xffff), uint16(uint32(c.B) * 0x101 * a / 0xffff), uint16(a)})
+		}
+	}
+	return img
+}
+
+// AddEmoji sets the image drawn for an emoji, given as the emoji itself, such as "🔴" or "👨‍💻"; variation
+// selectors make no difference.  The image should be square, and is scaled to the size of the text.
+func AddEmoji(sequence string, img image.Image) {
+	lock.Lock()
+	defer lock.Unlock()
+	addEmoji(sequence, img)
+}
+
+// addEmoji must be called with the lock held
+func addEmoji(sequence string, img image.Image) {
+	key := emojiKey([]rune(sequence))
+	if key == "" {
+		return
+	}
+	emoji[key] = img
+	emojiStarts[[]rune(key)[0]] = true
+	for k := range emojiScaled {
+		if k.sequence == key {
+			delete(emojiScaled, k)
+		}
+	}
+}
+
+// LoadEmojiDirectory adds the images of a directory of emoji, named by their characters in hexadecimal separated
+// by dashes or underscores, as are Twemoji ("1f534.png", "1f468-200d-1f4bb.png") and Noto Emoji
+// ("emoji_u1f534.png"); files named otherwise are skipped.
+func LoadEmojiDirectory(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, info := range files {
+		if info.IsDir() {
+			continue
+		}
+		sequence, ok := parseEmojiFilename(info.Name())
+		if !ok {
+			continue
+		}
+		img, err := loadEmojiImage(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return fmt.Errorf("%s: %v", info.Name(), err)
+		}
+		AddEmoji(sequence, img)
+	}
+	return nil
+}
+
+func parseEmojiFilename(name string) (string, bool) {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = strings.TrimPrefix(name, "emoji_u")
+	var runes []rune
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' }) {
+		r, err := strconv.ParseUint(part, 16, 32)
+		if err != nil {
+			return "", false
+		}
+		runes = append(runes, rune(r))
+	}
+	return string(runes), len(runes) > 0
+}
+
+func loadEmojiImage(filename string) (image.Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// emojiKey is the key of an emoji sequence, leaving out variation selectors
+func emojiKey(runes []rune) string {
+	var b strings.Builder
+	for _, r := range runes {
+		if r != 0xfe0e && r != 0xfe0f {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// matchEmoji returns the emoji with an image at the start of the characters, the longest if several match, and
+// how many characters it takes up; it must be called with the lock held
+func matchEmoji(runes []rune) (string, int) {
+	if !emojiStarts[runes[0]] {
+		return "", 0
+	}
+	n := len(runes)
+	if n > maxEmojiLength {
+		n = maxEmojiLength
+	}
+	for ; n > 0; n-- {
+		key := emojiKey(runes[:n])
+		if _, ok := emoji[key]; ok {
+			// Take any variation selector that follows along with it
+			if n < len(runes) && (runes[n] == 0xfe0e || runes[n] == 0xfe0f) {
+				n++
+			}
+			return key, n
+		}
+	}
+	return "", 0
+}
+
+// emojiImage returns an emoji's image scaled to the given size; it must be called with the lock held
+func emojiImage(key string, px int) image.Image {
+	k := emojiSize{key, px}
+	if img, ok := emojiScaled[k]; ok {
+		return img
+	}
+	g := gift.New(gift.Resize(px, px, gift.LanczosResampling))
+	img := image.NewRGBA(g.Bounds(emoji[key].Bounds()))
+	g.Draw(img, emoji[key])
+	emojiScaled[k] = img
+	return img
+}
+
+// isEmoji returns whether a character is in one of the blocks emoji are in
+func isEmoji(r rune) bool {
+	return r >= 0x1f000 && r <= 0x1faff || r >= 0x2600 && r <= 0x27bf || r >= 0x2300 && r <= 0x23ff ||
+		r >= 0x2b00 && r <= 0x2bff
+}
+
+// joinsEmoji returns whether a character continues an emoji sequence, rather than starting something new
+func joinsEmoji(sequence []rune, r rune) bool {
+	switch {
+	case sequence[len(sequence)-1] == zwj:
+		return true
+	case r == zwj, r >= 0x1f3fb && r <= 0x1f3ff: // Joiner, or skin tone
+		return true
+	case len(sequence) == 1 && isRegionalIndicator(sequence[0]) && isRegionalIndicator(r): // Flags are pairs of letters
+		return true
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
@@ -0,0 +1,239 @@
+package text
+
+import (
+	"unicode"
+)
+
+// Shaper turns a line of text into the characters to draw, in order from left to right, each combining mark
+// following the character it belongs to.  A shaper can substitute characters, such as the joined forms of Arabic
+// letters, as fonts are drawn from character by character.
+type Shaper interface {
+	Shape(s string) []rune
+}
+
+// Direction is the direction of a line of text as a whole, which decides where text in the other direction goes
+type Direction int
+
+const (
+	DirectionAuto        Direction = iota // From the first letter with a direction, or left to right if none has
+	DirectionLeftToRight                  // Such as English
+	DirectionRightToLeft                  // Such as Arabic and Hebrew
+)
+
+// BasicShaper is the default Shaper.  It puts right to left text (Arabic and Hebrew) in the right order, numbers
+// and all, mirrors brackets within it, and joins Arabic (and Persian) letters using their presentation forms,
+// which fonts with Arabic generally have.  It doesn't handle explicit direction marks and embeddings, nor scripts
+// needing glyphs that have no character of their own, such as Indic conjuncts; a Shaper built on a full shaping
+// engine can be set for those, see SetShaper.
+type BasicShaper struct {
+	Direction Direction
+}
+
+// bidiClass is a much simplified version of the Unicode bidirectional character types
+type bidiClass int
+
+const (
+	classNeutral bidiClass = iota
+	classL
+	classR
+	classNumber    // European or Arabic digits
+	classSeparator // Between digits, such as a decimal point
+)
+
+// cluster is a character along with the combining marks following it
+type cluster struct {
+	runes []rune
+	class bidiClass
+	level int
+}
+
+func (s BasicShaper) Shape(str string) []rune {
+	clusters := splitClusters(str)
+	clusters = joinArabic(clusters)
+	for i := range clusters {
+		clusters[i].class = classOf(clusters[i].runes[0])
+	}
+
+	paragraph := 0
+	switch s.Direction {
+	case DirectionRightToLeft:
+		paragraph = 1
+	case DirectionAuto:
+		for _, c := range clusters {
+			if c.class == classL {
+				break
+			}
+			if c.class == classR {
+				paragraph = 1
+				break
+			}
+		}
+	}
+	resolveLevels(clusters, paragraph)
+	clusters = reorder(clusters)
+
+	var res []rune
+	for _, c := range clusters {
+		if c.level%2 == 1 {
+			if m, ok := mirrored[c.runes[0]]; ok {
+				c.runes = append([]rune{m}, c.runes[1:]...)
+			}
+		}
+		res = append(res, c.runes...)
+	}
+	return res
+}
+
+// splitClusters splits text into characters and their combining marks, keeping emoji sequences together; marks
+// at the start go on their own
+func splitClusters(str string) []cluster {
+	var clusters []cluster
+	for _, r := range str {
+		if len(clusters) > 0 {
+			last := &clusters[len(clusters)-1]
+			if isMark(r) || isEmoji(last.runes[0]) && joinsEmoji(last.runes, r) {
+				last.runes = append(last.runes, r)
+				continue
+			}
+		}
+		clusters = append(clusters, cluster{runes: []rune{r}})
+	}
+	return clusters
+}
+
+// isMark returns whether a character is a combining mark, drawn over (or under) the one before
+func isMark(r rune) bool {
+	return unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r)
+}
+
+func classOf(r rune) bidiClass {
+	switch {
+	case r >= '0' && r <= '9', r >= 0x0660 && r <= 0x0669, r >= 0x06f0 && r <= 0x06f9:
+		return classNumber
+	case r == '.' || r == ',' || r == ':' || r == '/':
+		return classSeparator
+	case r >= 0x0590 && r <= 0x08ff, r >= 0xfb1d && r <= 0xfdff, r >= 0xfe70 && r <= 0xfefe:
+		return classR
+	case unicode.IsLetter(r):
+		return classL
+	}
+	return classNeutral
+}
+
+// resolveLevels gives each cluster its embedding level, rules W and N of the Unicode bidirectional algorithm
+// pared down to what is left without explicit embeddings
+func resolveLevels(clusters []cluster, paragraph int) {
+	sos := classL
+	if paragraph == 1 {
+		sos = classR
+	}
+
+	// A separator between two digits is part of the number
+	for i := 1; i+1 < len(clusters); i++ {
+		if clusters[i].class == classSeparator && clusters[i-1].class == classNumber && clusters[i+1].class == classNumber {
+			clusters[i].class = classNumber
+		}
+	}
+	// Numbers after left to right text (or at the start of a left to right line) are left to right
+	last := sos
+	for i := range clusters {
+		switch clusters[i].class {
+		case classL, classR:
+			last = clusters[i].class
+		case classNumber:
+			if last == classL {
+				clusters[i].class = classL
+			}
+		case classSeparator:
+			clusters[i].class = classNeutral
+		}
+	}
+	// Neutrals between text of the same direction take that direction (numbers counting as right to left), and
+	// otherwise that of the line
+	for i := 0; i < len(clusters); {
+		if clusters[i].class != classNeutral {
+			i++
+			continue
+		}
+		end := i
+		for end < len(clusters) && clusters[end].class == classNeutral {
+			end++
+		}
+		before, after := sos, sos
+		if i > 0 {
+			before = strongClass(clusters[i-1].class)
+		}
+		if end < len(clusters) {
+			after = strongClass(clusters[end].class)
+		}
+		class := sos
+		if before == after {
+			class = before
+		}
+		for j := i; j < end; j++ {
+			clusters[j].class = class
+		}
+		i = end
+	}
+
+	for i := range clusters {
+		switch clusters[i].class {
+		case classL:
+			clusters[i].level = paragraph + paragraph%2 // 0, or 2 within a right to left line
+		case classR:
+			clusters[i].level = paragraph | 1
+		case classNumber:
+			clusters[i].level = 2
+		}
+	}
+	// Spaces at the end of the line go at the line's own end
+	for i := len(clusters) - 1; i >= 0 && unicode.IsSpace(clusters[i].runes[0]); i-- {
+		clusters[i].level = paragraph
+	}
+}
+
+func strongClass(c bidiClass) bidiClass {
+	if c == classNumber {
+		return classR
+	}
+	return c
+}
+
+// reorder puts clusters in order from left to right, reversing runs at each level from the highest down to the
+// lowest odd one
+func reorder(clusters []cluster) []cluster {
+	highest, lowestOdd := 0, 3
+	for _, c := range clusters {
+		if c.level > highest {
+			highest = c.level
+		}
+		if c.level%2 == 1 && c.level < lowestOdd {
+			lowestOdd = c.level
+		}
+	}
+	for level := highest; level >= lowestOdd; level-- {
+		for i := 0; i < len(clusters); {
+			if clusters[i].level < level {
+				i++
+				continue
+			}
+			end := i
+			for end < len(clusters) && clusters[end].level >= level {
+				end++
+			}
+			for a, b := i, end-1; a < b; a, b = a+1, b-1 {
+				clusters[a], clusters[b] = clusters[b], clusters[a]
+			}
+			i = end
+		}
+	}
+	return clusters
+}
+
+var mirrored = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'<': '>', '>': '<',
+	'«': '»', '»': '«',
+}
@@ -0,0 +1,39 @@
+package text_test
+
+import (
+	"testing"
+
+	"github.com/SKAARHOJ/go-streamdeck/text"
+)
+
+func TestBasicShaper(t *testing.T) {
+	for _, tc := range []struct {
+		direction text.Direction
+		in, want  string
+	}{
+		{text.DirectionAuto, "", ""},
+		{text.DirectionAuto, "Hello", "Hello"},
+		{text.DirectionAuto, "שלום", "םולש"},
+		{text.DirectionAuto, "abc אבג def", "abc גבא def"},
+		// Numbers keep their order within right to left text, decimal points and all
+		{text.DirectionAuto, "אב 123", "123 בא"},
+		{text.DirectionAuto, "א 1.5", "1.5 א"},
+		{text.DirectionAuto, "abc 12 אב", "abc 12 בא"},
+		// Brackets are mirrored in right to left text, so still open before what they enclose
+		{text.DirectionAuto, "(א)", "(א)"},
+		{text.DirectionAuto, "a (b) ג", "a (b) ג"},
+		// Marks stay after the letter they belong to
+		{text.DirectionAuto, "בּא", "אבּ"},
+		// The direction can be forced, which moves neutrals at the ends
+		{text.DirectionLeftToRight, "!שלום", "!םולש"},
+		{text.DirectionRightToLeft, "abc!", "!abc"},
+		{text.DirectionRightToLeft, "abc def", "abc def"},
+		// Arabic is joined up as well as reversed
+		{text.DirectionAuto, "بيت", "ﺖﻴﺑ"},
+		{text.DirectionAuto, "ب ١٢", "١٢ ﺏ"},
+	} {
+		if got := string(text.BasicShaper{Direction: tc.direction}.Shape(tc.in)); got != tc.want {
+			t.Errorf("%q (direction %d) is shaped as %q, not %q", tc.in, tc.direction, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Package text draws single lines of text for button images and the touchscreen, shaping them first (see Shaper)
+// so that right to left and joined up scripts come out right, and falling back through a chain of fonts for
+// characters the first font doesn't have, such as Chinese, Japanese and Korean.  Colour emoji are drawn from
+// images, see AddEmoji.
+//
+// The built in font is Go Medium, which covers Latin, Greek and Cyrillic; fonts for other scripts are added with
+// AddFallbackFont or LoadFallbackFont, such as from the Noto family:
+//
+//	text.LoadFallbackFont("/usr/share/fonts/truetype/noto/NotoSansArabic-Regular.ttf")
+//	text.LoadFallbackFont("/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc")
+//
+// Fonts must be TrueType, as that is what is drawn with.
+package text
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomedium"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	lock   sync.Mutex // Also serialises drawing, as faces can't be used from two goroutines at once
+	fonts  []*truetype.Font
+	shaper Shaper = BasicShaper{}
+	faces         = make(map[faceKey]font.Face) // Faces are costly to make, and text is drawn often
+)
+
+type faceKey struct {
+	font *truetype.Font
+	size float64
+}
+
+func init() {
+	f, err := truetype.Parse(gomedium.TTF)
+	if err != nil {
+		panic(err)
+	}
+	fonts = []*truetype.Font{f}
+}
+
+// AddFallbackFont adds a font to the end of the chain, to be used for characters none of the fonts before it have
+func AddFallbackFont(f *truetype.Font) {
+	lock.Lock()
+	defer lock.Unlock()
+	fonts = append(fonts, f)
+}
+
+// LoadFallbackFont loads a TrueType font file and adds it to the end of the chain, see AddFallbackFont
+func LoadFallbackFont(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	f, err := truetype.Parse(data)
+	if err != nil {
+		return err
+	}
+	AddFallbackFont(f)
+	return nil
+}
+
+// SetShaper sets the Shaper all text is shaped with, in place of BasicShaper
+func SetShaper(s Shaper) {
+	lock.Lock()
+	defer lock.Unlock()
+	shaper = s
+}
+
+// glyph is a character to draw, in the font to draw it with, or an emoji drawn from its image
+type glyph struct {
+	r     rune
+	face  font.Face
+	mark  bool
+	emoji string
+}
+
+// layout shapes text and picks the font for each character; it must be called with the lock held
+func layout(s string, size float64) []glyph {
+	runes := shaper.Shape(s)
+	glyphs := make([]glyph, 0, len(runes))
+	for i := 0; i < len(runes); {
+		if key, n := matchEmoji(runes[i:]); n > 0 {
+			glyphs = append(glyphs, glyph{emoji: key})
+			i += n
+			continue
+		}
+		r := runes[i]
+		i++
+		if isIgnorable(r) {
+			continue
+		}
+		glyphs = append(glyphs, glyph{r: r, face: faceFor(r, size), mark: isMark(r)})
+	}
+	return glyphs
+}
+
+// advance returns how far a glyph moves the text along; emoji are square, as wide as the font size
+func (g glyph) advance(size float64) fixed.Int26_6 {
+	if g.emoji != "" {
+		return fixed.I(emojiPixels(size))
+	}
+	advance, _ := g.face.GlyphAdvance(g.r)
+	return advance
+}
+
+func emojiPixels(size float64) int {
+	return int(size + 0.5)
+}
+
+// isIgnorable returns whether a character only affects how those around it are drawn, such as a joiner, so isn't
+// drawn itself
+func isIgnorable(r rune) bool {
+	switch r {
+	case 0x200c, 0x200d, 0x200e, 0x200f, 0xfe0e, 0xfe0f:
+		return true
+	}
+	return false
+}
+
+// faceFor returns the face of the first font in the chain with the character, or of the first font if none has
+// it; it must be called with the lock held
+func faceFor(r rune, size float64) font.Face {
+	f := fonts[0]
+	for _, candidate := range fonts {
+		if candidate.Index(r) != 0 {
+			f = candidate
+			break
+		}
+	}
+	key := faceKey{f, size}
+	face, ok := faces[key]
+	if !ok {
+		face = truetype.NewFace(f, &truetype.Options{Size: size})
+		faces[key] = face
+	}
+	return face
+}
+
+// Width returns the width of a line of text drawn at the given size, in pixels
+func Width(s string, size float64) int {
+	lock.Lock()
+	defer lock.Unlock()
+	var width fixed.Int26_6
+	for _, g := range layout(s, size) {
+		if !g.mark {
+			width += g.advance(size)
+		}
+	}
+	return width.Round()
+}
+
+// Draw draws a line of text with its left end at x and its baseline at y, clipped to the given rectangle
+func Draw(dst draw.Image, clip image.Rectangle, x, y int, size float64, s string, colour color.Color) {
+	lock.Lock()
+	defer lock.Unlock()
+	src := image.NewUniform(colour)
+	dot := fixed.P(x, y)
+	var base fixed.Point26_6
+	var baseAdvance fixed.Int26_6
+	for _, g := range layout(s, size) {
+		if g.emoji != "" {
+			// Emoji sit on the baseline, a little below it as letters with descenders do
+			px := emojiPixels(size)
+			pt := image.Pt(dot.X.Round(), y+px/5-px)
+			img := emojiImage(g.emoji, px)
+			r := image.Rectangle{Min: pt, Max: pt.Add(img.Bounds().Size())}.Intersect(clip)
+			draw.Draw(dst, r, img, img.Bounds().Min.Add(r.Min.Sub(pt)), draw.Over)
+			base, baseAdvance = dot, g.advance(size)
+			dot.X += baseAdvance
+			continue
+		}
+		at := dot
+		if g.mark {
+			// Marks with a width of their own are centred over the character they belong to, rather than after it
+			if advance := g.advance(size); advance > 0 {
+				at = fixed.Point26_6{X: base.X + (baseAdvance-advance)/2, Y: base.Y}
+			}
+		}
+		dr, mask, maskp, advance, ok := g.face.Glyph(at, g.r)
+		if ok {
+			r := dr.Intersect(clip)
+			draw.DrawMask(dst, r, src, image.Point{}, mask, maskp.Add(r.Min.Sub(dr.Min)), draw.Over)
+		}
+		if !g.mark {
+			base, baseAdvance = dot, advance
+			dot.X += advance
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+)
+
+// touchPressFeedback is how long a touch button is shown inverted after being tapped
+const touchPressFeedback = 150 * time.Millisecond
+
+// touchButton is a Button drawn on, and tapped on, a region of the touchscreen
+type touchButton struct {
+	area   image.Rectangle
+	button Button
+}
+
+// AddTouchButton puts a Button on a region of the touchscreen, in touchscreen coordinates, so that it can be
+// tapped like a key; it flashes inverted briefly when tapped or held.  The button's image is drawn as a square, centred
+// in the region.  Taps outside of every touch button still go to the page's touch push handler.
+func (p *Page) AddTouchButton(area image.Rectangle, b Button) {
+	tb := &touchButton{area: area, button: b}
+	b.RegisterUpdateHandler(func(Button) {
+		p.redrawTouchButton(tb, false)
+	})
+	p.lock.Lock()
+	p.touchButtons = append(p.touchButtons, tb)
+	p.lock.Unlock()
+	p.redrawTouchButton(tb, false)
+}
+
+// RemoveTouchButtons removes all of the touch buttons from the page
+func (p *Page) RemoveTouchButtons() {
+	p.lock.Lock()
+	p.touchButtons = nil
+	p.lock.Unlock()
+}
+
+// AddTouchButton puts a Button on a region of the touchscreen of the active page, see Page.AddTouchButton
+func (sd *StreamDeck) AddTouchButton(area image.Rectangle, b Button) {
+	sd.GetPage().AddTouchButton(area, b)
+}
+
+func (p *Page) hasTouchButtons() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.touchButtons) > 0
+}
+
+// touchButtonAt returns the touch button at the given touchscreen coordinates, or nil
+func (p *Page) touchButtonAt(x, y int) *touchButton {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	pt := image.Pt(x, y)
+	for i := len(p.touchButtons) - 1; i >= 0; i-- {
+		if pt.In(p.touchButtons[i].area) {
+			return p.touchButtons[i]
+		}
+	}
+	return nil
+}
+
+// tapTouchButton gives the press feedback and presses the button
+func (p *Page) tapTouchButton(tb *touchButton) {
+	p.redrawTouchButton(tb, true)
+	time.AfterFunc(touchPressFeedback, func() {
+		p.redrawTouchButton(tb, false)
+	})
+	tb.button.Pressed()
+}
+
+// redrawTouchButton draws a touch button, if the page is the active one on a StreamDeck
+func (p *Page) redrawTouchButton(tb *touchButton, inverted bool) {
+	p.lock.Lock()
+	sd := p.sd
+	p.lock.Unlock()
+	if sd == nil {
+		return
+	}
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	if sd.page == p {
+		sd.drawTouchButton(tb, inverted)
+	}
+}
+
+// drawTouchButton draws a touch button of the active page, and must be called with the lock held
+func (sd *StreamDeck) drawTouchButton(tb *touchButton, inverted bool) error {
+	size := tb.area.Size()
+	btnSize := size.X
+	if size.Y < btnSize {
+		btnSize = size.Y
+	}
+	img := tb.button.GetImageForButton(btnSize)
+	if inverted {
+		img = getInvertedImage(img)
+	}
+
+	frame := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(color.Black), image.Point{0, 0}, draw.Src)
+	square := image.Rect((size.X-btnSize)/2, (size.Y-btnSize)/2, (size.X+btnSize)/2, (size.Y+btnSize)/2)
+	draw.Draw(frame, square, img, img.Bounds().Min, draw.Src)
+
+	pos := sd.dev.deviceType.touchscreenPosition.Add(tb.area.Min)
+	return sd.dev.WriteRawImageToAreaUnscaled(pos.X, pos.Y, frame)
+}
+
+// redrawTouchscreen clears the touchscreen and draws the touch buttons of the active page, and must be called with
+// the lock held
+func (sd *StreamDeck) redrawTouchscreen() error {
+	sd.page.lock.Lock()
+	touchButtons := sd.page.touchButtons
+	sd.page.lock.Unlock()
+
+	err := sd.dev.clearTouchscreen()
+	for _, tb := range touchButtons {
+		if e := sd.drawTouchButton(tb, false); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/disintegration/gift"
+)
+
+// Transition is a way of changing from one button image to another, see SetPageTransition and TransitionButton
+type Transition int
+
+const (
+	TransitionNone       Transition = iota
+	TransitionCrossfade             // The new image fades in over the old one
+	TransitionSlideLeft             // The new image pushes the old one out to the left
+	TransitionSlideRight            // The new image pushes the old one out to the right
+	TransitionSlideUp               // The new image pushes the old one out of the top
+	TransitionSlideDown             // The new image pushes the old one out of the bottom
+	TransitionFlip                  // The button turns over, showing the new image on the back
+)
+
+// TransitionButton changes a button from one image to another over the given time, drawing a frame of the
+// transition on every frame of the animation scheduler (see SetFrameRate); it ends on the new image.  Stopping the
+// animation leaves the button part way through.
+func (d *Device) TransitionButton(btnIndex int, from, to image.Image, t Transition, duration time.Duration) *Animation {
+	size := d.deviceType.imageSize.X
+	from, to = fitTransitionImage(from, size), fitTransitionImage(to, size)
+	return Animate(func(elapsed time.Duration) bool {
+		if elapsed >= duration || t == TransitionNone {
+			d.WriteRawImageToButton(btnIndex, to)
+			return false
+		}
+		d.WriteRawImageToButton(btnIndex, transitionFrame(from, to, t, float64(elapsed)/float64(duration)))
+		return true
+	})
+}
+
+// SetPageTransition sets how buttons change when switching page, and how long it takes; a quarter of a second or
+// so looks smooth without holding things up.  Buttons showing the same image on both pages don't change.
+func (sd *StreamDeck) SetPageTransition(t Transition, duration time.Duration) {
+	sd.lock.Lock()
+	defer sd.lock.Unlock()
+	sd.pageTransition = t
+	sd.pageTransitionDuration = duration
+}
+
+// transitionPage draws the transition from the images of the old page to the new page, which is now active; it
+// must be called with the lock held
+func (sd *StreamDeck) transitionPage(from map[int]image.Image) {
+	p := sd.page
+	t, duration := sd.pageTransition, sd.pageTransitionDuration
+	size := sd.dev.deviceType.imageSize.X
+	changed := make(map[int]image.Image)
+	for btnIndex, img := range from {
+		if to := sd.buttonImage(btnIndex); !sameImage(img, to) {
+			from[btnIndex] = fitTransitionImage(img, size)
+			changed[btnIndex] = fitTransitionImage(to, size)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	sd.transition = Animate(func(elapsed time.Duration) bool {
+		sd.lock.Lock()
+		defer sd.lock.Unlock()
+		if sd.page != p {
+			return false
+		}
+		if elapsed >= duration {
+			// The buttons may have changed while the transition was running
+			sd.redrawAll()
+			return false
+		}
+		frames := make(map[int]image.Image, len(changed))
+		for btnIndex, to := range changed {
+			frames[btnIndex] = transitionFrame(from[btnIndex], to, t, float64(elapsed)/float64(duration))
+		}
+		sd.dev.WriteRawImagesToButtons(frames)
+		return true
+	})
+}
+
+// transitionFrame draws the frame of a transition part way through, progress being from 0 to 1; both images must
+// be square and the same size
+func transitionFrame(from, to image.Image, t Transition, progress float64) image.Image {
+	size := from.Bounds().Dx()
+	frame := image.NewRGBA(image.Rect(0, 0, size, size))
+	offset := int(progress * float64(size))
+	switch t {
+	case TransitionCrossfade:
+		draw.Draw(frame, frame.Bounds(), from, from.Bounds().Min, draw.Src)
+		mask := image.NewUniform(color.Alpha{uint8(progress * 255)})
+		draw.DrawMask(frame, frame.Bounds(), to, to.Bounds().Min, mask, image.Point{}, draw.Over)
+	case TransitionSlideLeft, TransitionSlideRight, TransitionSlideUp, TransitionSlideDown:
+		var step image.Point
+		switch t {
+		case TransitionSlideLeft:
+			step = image.Pt(-1, 0)
+		case TransitionSlideRight:
+			step = image.Pt(1, 0)
+		case TransitionSlideUp:
+			step = image.Pt(0, -1)
+		default:
+			step = image.Pt(0, 1)
+		}
+		draw.Draw(frame, frame.Bounds().Add(step.Mul(offset)), from, from.Bounds().Min, draw.Src)
+		draw.Draw(frame, frame.Bounds().Add(step.Mul(offset-size)), to, to.Bounds().Min, draw.Src)
+	case TransitionFlip:
+		// The old image narrows to nothing, then the new one widens from nothing
+		img, width := from, size-2*offset
+		if progress >= 0.5 {
+			img, width = to, 2*offset-size
+		}
+		draw.Draw(frame, frame.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+		if width > 0 {
+			g := gift.New(gift.Resize(width, size, gift.LinearResampling))
+			g.DrawAt(frame, img, image.Pt((size-width)/2, 0), gift.CopyOperator)
+		}
+	default:
+		draw.Draw(frame, frame.Bounds(), to, to.Bounds().Min, draw.Src)
+	}
+	return frame
+}
+
+// fitTransitionImage scales an image to a square of the given size, if it isn't already
+func fitTransitionImage(img image.Image, size int) image.Image {
+	if img.Bounds().Dx() == size && img.Bounds().Dy() == size {
+		return img
+	}
+	g := gift.New(gift.Resize(size, size, gift.LanczosResampling))
+	fitted := image.NewRGBA(g.Bounds(img.Bounds()))
+	g.Draw(fitted, img)
+	return fitted
+}
+
+// sameImage returns whether two images have the same pixels
+func sameImage(a, b image.Image) bool {
+	if a == b {
+		return true
+	}
+	if a.Bounds().Size() != b.Bounds().Size() {
+		return false
+	}
+	ab, bb := a.Bounds(), b.Bounds()
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			r1, g1, b1, a1 := a.At(ab.Min.X+x, ab.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := b.At(bb.Min.X+x, bb.Min.Y+y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package streamdeck
+
+import (
+	"errors"
+
+	"github.com/SKAARHOJ/go-streamdeck/protocol"
+)
+
+// DeviceInterface is what a Device talks to the hardware through; normally a USB HID device, but it can be
+// anything else that sends and receives the same reports, such as streamdecktest.Mock in tests
+type DeviceInterface interface {
+	Write(report []byte) (int, error)
+	Read(report []byte) (int, error)
+	SendFeatureReport(report []byte) (int, error)
+	Close() error
+}
+
+// OpenWithInterface creates a Device talking through fd, as the type of device registered for the given USB
+// product ID; like Open, it resets the device first
+func OpenWithInterface(fd DeviceInterface, productID uint16, serial string) (*Device, error) {
+	for _, devType := range deviceTypes {
+		if devType.usbProductID == productID {
+			d := &Device{deviceType: devType, fd: fd}
+			d.deviceType.serial = serial
+			d.ResetComms()
+			go d.eventListener()
+			return d, nil
+		}
+	}
+	return nil, errors.New("No device definition for that product ID; have you imported the devices package?")
+}
+
+// GetLayout returns where this device puts things in its input reports, for decoding them (see the protocol
+// package) or building them (see streamdecktest)
+func (d *Device) GetLayout() protocol.Layout {
+	return protocol.Layout{
+		NumberOfButtons:   int(d.deviceType.numberOfButtons),
+		ButtonReadOffset:  int(d.deviceType.buttonReadOffset),
+		NumberOfEncoders:  int(d.deviceType.numberOfEncoders),
+		EncoderReadOffset: int(d.deviceType.encoderReadOffset),
+		TouchscreenInput:  d.deviceType.touchscreenInput,
+	}
+}
+
+// GetInputReportLength returns the size of buffer input reports are read into: the device's own report size, or
+// as much as its events need if that is longer
+func (d *Device) GetInputReportLength() int {
+	length := d.GetLayout().ReportLength()
+	if int(d.deviceType.inputReportLength) > length {
+		length = int(d.deviceType.inputReportLength)
+	}
+	return length
+}
+
+// GetOutputReportLength returns the size of the device's output reports, which no page of an image may be longer
+// than
+func (d *Device) GetOutputReportLength() int {
+	return int(d.deviceType.outputReportLength)
+}
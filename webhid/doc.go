@@ -0,0 +1,18 @@
+// Package webhid lets a js/wasm build drive Stream Decks attached to the browser, through WebHID, so that the
+// same panel code can run in a browser kiosk.  Browsers only give a page access to a device the user has chosen:
+// call Request from a click handler the first time, and after that Devices finds it again on its own.
+//
+//	button.Call("addEventListener", "click", js.FuncOf(func(js.Value, []js.Value) interface{} {
+//		webhid.Request(func(devices []webhid.HIDDevice, err error) {
+//			if err == nil && len(devices) > 0 {
+//				d, err := devices[0].Open()
+//				...
+//			}
+//		})
+//		return nil
+//	}))
+//
+// WebHID doesn't give serial numbers, so devices opened this way have an empty one.  Go can't wait for the
+// browser inside a js.Func, so devices mustn't be written to from event handlers set up with syscall/js, only
+// from other goroutines (the listeners of a streamdeck.Device are fine).
+package webhid
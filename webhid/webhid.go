@@ -0,0 +1,213 @@
+//go:build js && wasm
+// +build js,wasm
+
+package webhid
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"syscall/js"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// vendorID is Elgato's USB vendor ID
+const vendorID = 0x0fd9
+
+// HIDDevice is a Stream Deck the page has access to, which can be opened
+type HIDDevice struct {
+	dev js.Value
+}
+
+// ProductID returns the device's USB product ID
+func (h HIDDevice) ProductID() uint16 {
+	return uint16(h.dev.Get("productId").Int())
+}
+
+// ProductName returns the name the device gives itself
+func (h HIDDevice) ProductName() string {
+	return h.dev.Get("productName").String()
+}
+
+// Open opens the device, as the type of device registered for its product ID
+func (h HIDDevice) Open() (*streamdeck.Device, error) {
+	if !h.dev.Get("opened").Bool() {
+		if _, err := await(h.dev.Call("open")); err != nil {
+			return nil, err
+		}
+	}
+	t := newTransport(h.dev)
+	d, err := streamdeck.OpenWithInterface(t, h.ProductID(), "")
+	if err != nil {
+		t.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// Request asks the user to choose Stream Decks to give the page access to.  Browsers only allow this in answer to
+// a user gesture, so it must be called from an event handler such as a click; done is called from a new
+// goroutine with the devices chosen.
+func Request(done func([]HIDDevice, error)) {
+	hid := js.Global().Get("navigator").Get("hid")
+	if hid.Type() == js.TypeUndefined {
+		go done(nil, errors.New("This browser doesn't support WebHID"))
+		return
+	}
+	filter := map[string]interface{}{"vendorId": vendorID}
+	options := map[string]interface{}{"filters": []interface{}{filter}}
+	promise := hid.Call("requestDevice", options)
+	go func() {
+		done(devices(await(promise)))
+	}()
+}
+
+// Devices returns the Stream Decks the page already has access to.  It waits for the browser, so it can't be
+// called from an event handler.
+func Devices() ([]HIDDevice, error) {
+	hid := js.Global().Get("navigator").Get("hid")
+	if hid.Type() == js.TypeUndefined {
+		return nil, errors.New("This browser doesn't support WebHID")
+	}
+	return devices(await(hid.Call("getDevices")))
+}
+
+func devices(list js.Value, err error) ([]HIDDevice, error) {
+	if err != nil {
+		return nil, err
+	}
+	var found []HIDDevice
+	for i := 0; i < list.Length(); i++ {
+		if dev := list.Index(i); dev.Get("vendorId").Int() == vendorID {
+			found = append(found, HIDDevice{dev})
+		}
+	}
+	return found, nil
+}
+
+// transport is a streamdeck.DeviceInterface talking to an open WebHID device.  Reports are queued as they
+// arrive, as the browser can't be kept waiting for them to be read.
+type transport struct {
+	dev     js.Value
+	onInput js.Func
+
+	lock      sync.Mutex
+	queue     [][]byte
+	ready     chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newTransport(dev js.Value) *transport {
+	t := &transport{
+		dev:    dev,
+		ready:  make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+	t.onInput = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		event := args[0]
+		data := event.Get("data")
+		report := make([]byte, 1+data.Get("byteLength").Int())
+		report[0] = byte(event.Get("reportId").Int())
+		bytes := js.Global().Get("Uint8Array").New(data.Get("buffer"), data.Get("byteOffset"), data.Get("byteLength"))
+		js.CopyBytesToGo(report[1:], bytes)
+
+		t.lock.Lock()
+		t.queue = append(t.queue, report)
+		t.lock.Unlock()
+		select {
+		case t.ready <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	dev.Call("addEventListener", "inputreport", t.onInput)
+	return t
+}
+
+// Write sends an output report, whose first byte is the report ID
+func (t *transport) Write(report []byte) (int, error) {
+	return t.send("sendReport", report)
+}
+
+// SendFeatureReport sends a feature report, whose first byte is the report ID
+func (t *transport) SendFeatureReport(report []byte) (int, error) {
+	return t.send("sendFeatureReport", report)
+}
+
+func (t *transport) send(method string, report []byte) (int, error) {
+	if len(report) == 0 {
+		return 0, errors.New("Report has no report ID")
+	}
+	select {
+	case <-t.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+	data := js.Global().Get("Uint8Array").New(len(report) - 1)
+	js.CopyBytesToJS(data, report[1:])
+	if _, err := await(t.dev.Call(method, int(report[0]), data)); err != nil {
+		return 0, err
+	}
+	return len(report), nil
+}
+
+// Read waits for the next input report, starting with its report ID
+func (t *transport) Read(report []byte) (int, error) {
+	for {
+		t.lock.Lock()
+		if len(t.queue) > 0 {
+			next := t.queue[0]
+			t.queue = t.queue[1:]
+			t.lock.Unlock()
+			return copy(report, next), nil
+		}
+		t.lock.Unlock()
+
+		select {
+		case <-t.ready:
+		case <-t.closed:
+			return 0, io.EOF
+		}
+	}
+}
+
+// Close stops listening for reports and closes the device
+func (t *transport) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.closed)
+		t.dev.Call("removeEventListener", "inputreport", t.onInput)
+		t.onInput.Release()
+		_, err = await(t.dev.Call("close"))
+	})
+	return err
+}
+
+// await waits for a promise to settle, giving what it resolved to or why it was rejected
+func await(promise js.Value) (js.Value, error) {
+	done := make(chan struct{})
+	var result js.Value
+	var err error
+	then := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) > 0 {
+			result = args[0]
+		}
+		close(done)
+		return nil
+	})
+	catch := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		err = errors.New("WebHID failed")
+		if len(args) > 0 {
+			err = errors.New(args[0].Call("toString").String())
+		}
+		close(done)
+		return nil
+	})
+	defer then.Release()
+	defer catch.Release()
+	promise.Call("then", then, catch)
+	<-done
+	return result, err
+}
@@ -0,0 +1,160 @@
+package widgets
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// carouselSnapTime is how long a Carousel takes to scroll to the item it snaps to
+const carouselSnapTime = 250 * time.Millisecond
+
+// Carousel is a horizontally scrolling list for a touchscreen, such as the Stream Deck Plus touch strip.  Swiping
+// scrolls it by as many items as the swipe covers and tapping an item scrolls to it; it always comes to rest
+// with an item in the middle, which is the selected item.
+type Carousel struct {
+	lock          sync.Mutex
+	target        Target
+	items         []string
+	itemWidth     int
+	selected      int
+	position      float64 // Scroll position, in items; the item at this position is in the middle
+	width         int     // Width of the target, as of the last render
+	animation     *streamdeck.Animation
+	selectHandler func(int, string)
+}
+
+// NewCarousel creates a Carousel drawn on the given target, with items itemWidth pixels wide
+func NewCarousel(target Target, itemWidth int) *Carousel {
+	c := &Carousel{target: target, itemWidth: itemWidth}
+	c.redraw()
+	return c
+}
+
+// BindTouch makes the carousel respond to swipes and taps on the touchscreen while the page is active.  The
+// carousel is assumed to cover the whole width of the touchscreen.
+func (c *Carousel) BindTouch(page *streamdeck.Page) {
+	page.SetTouchSwipeHandler(func(xstart, ystart, xstop, ystop uint16) {
+		c.lock.Lock()
+		moved := int(math.Round(float64(int(xstart)-int(xstop)) / float64(c.itemWidth)))
+		to := c.selected + moved
+		c.lock.Unlock()
+		c.scrollTo(to, true)
+	})
+	page.SetTouchPushHandler(func(x, y uint16, hold bool) {
+		c.lock.Lock()
+		to := c.selected + int(math.Floor((float64(x)-float64(c.width)/2)/float64(c.itemWidth)+0.5))
+		c.lock.Unlock()
+		c.scrollTo(to, true)
+	})
+}
+
+// SetItems replaces the items, keeping the same item selected where possible
+func (c *Carousel) SetItems(items []string) {
+	c.lock.Lock()
+	c.items = append([]string(nil), items...)
+	selected := c.selected
+	c.lock.Unlock()
+	c.scrollTo(selected, false)
+}
+
+// SetSelectHandler sets the function called when the carousel comes to rest on a different item
+func (c *Carousel) SetSelectHandler(f func(index int, item string)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.selectHandler = f
+}
+
+// GetSelected returns the index of the selected item, or -1 if there are no items
+func (c *Carousel) GetSelected() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.items) == 0 {
+		return -1
+	}
+	return c.selected
+}
+
+// Select scrolls to an item, calling the select handler if the selection changes
+func (c *Carousel) Select(index int) {
+	c.scrollTo(index, true)
+}
+
+// scrollTo animates the carousel to the given item, clamped to the list
+func (c *Carousel) scrollTo(index int, notify bool) {
+	c.lock.Lock()
+	if index >= len(c.items) {
+		index = len(c.items) - 1
+	}
+	if index < 0 {
+		index = 0
+	}
+	changed := index != c.selected
+	c.selected = index
+	from := c.position
+	to := float64(index)
+	old := c.animation
+	c.animation = nil
+	var item string
+	if index < len(c.items) {
+		item = c.items[index]
+	}
+	f := c.selectHandler
+	c.lock.Unlock()
+
+	if old != nil {
+		old.Stop()
+	}
+	a := streamdeck.Animate(func(elapsed time.Duration) bool {
+		t := float64(elapsed) / float64(carouselSnapTime)
+		if t > 1 {
+			t = 1
+		}
+		eased := 1 - (1-t)*(1-t) // Ease out, so it settles gently
+		c.lock.Lock()
+		c.position = from + (to-from)*eased
+		c.lock.Unlock()
+		c.redraw()
+		return t < 1
+	})
+	c.lock.Lock()
+	c.animation = a
+	c.lock.Unlock()
+
+	if changed && notify && f != nil && item != "" {
+		f(index, item)
+	}
+}
+
+func (c *Carousel) redraw() {
+	c.target.Update(c.render)
+}
+
+func (c *Carousel) render(size image.Point) image.Image {
+	c.lock.Lock()
+	c.width = size.X
+	items, itemWidth, position, selected := c.items, c.itemWidth, c.position, c.selected
+	c.lock.Unlock()
+
+	img := newCanvas(size, color.Black)
+	centre := float64(size.X) / 2
+	for i, item := range items {
+		x := int(centre + (float64(i)-position-0.5)*float64(itemWidth))
+		if x+itemWidth < 0 || x > size.X {
+			continue
+		}
+		box := image.Rect(x+2, 2, x+itemWidth-2, size.Y-2)
+		background := color.RGBA{40, 40, 40, 255}
+		if i == selected {
+			background = color.RGBA{0, 96, 192, 255}
+		}
+		draw.Draw(img, box.Intersect(img.Bounds()), image.NewUniform(background), image.Point{0, 0}, draw.Src)
+		drawText(img, image.Rect(box.Min.X, size.Y/4, box.Max.X, size.Y*3/4), item, color.White)
+	}
+	return img
+}
@@ -0,0 +1,290 @@
+package widgets
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+	"time"
+)
+
+// ticker runs a function on an interval in its own goroutine, until stopped
+type ticker struct {
+	lock sync.Mutex
+	stop chan struct{}
+}
+
+func (t *ticker) start(interval time.Duration, f func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	t.stop = stop
+	go func() {
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-tick.C:
+				f()
+			}
+		}
+	}()
+}
+
+func (t *ticker) halt() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+}
+
+// textDisplay draws a line of text onto a Target, only when it changes
+type textDisplay struct {
+	lock   sync.Mutex
+	target Target
+	text   string
+	colour color.Color
+	drawn  bool
+}
+
+func (td *textDisplay) show(text string, colour color.Color) {
+	td.lock.Lock()
+	if td.drawn && text == td.text && colour == td.colour {
+		td.lock.Unlock()
+		return
+	}
+	td.drawn = true
+	td.text = text
+	td.colour = colour
+	td.lock.Unlock()
+	td.target.Update(td.render)
+}
+
+func (td *textDisplay) render(size image.Point) image.Image {
+	td.lock.Lock()
+	text, colour := td.text, td.colour
+	td.lock.Unlock()
+	img := newCanvas(size, color.Black)
+	drawText(img, img.Bounds(), text, colour)
+	return img
+}
+
+// Clock shows the time of day on a Target
+type Clock struct {
+	ticker
+	display textDisplay
+	layout  string
+}
+
+// NewClock creates a Clock drawn on the given target, showing the time in the given time.Format layout, for
+// example "15:04:05".  It starts running straight away.
+func NewClock(target Target, layout string) *Clock {
+	c := &Clock{display: textDisplay{target: target}, layout: layout}
+	c.Start()
+	return c
+}
+
+// Start starts the clock updating
+func (c *Clock) Start() {
+	c.update()
+	c.start(100*time.Millisecond, c.update)
+}
+
+// Stop stops the clock updating
+func (c *Clock) Stop() {
+	c.halt()
+}
+
+func (c *Clock) update() {
+	c.display.show(time.Now().Format(c.layout), color.White)
+}
+
+// stopwatch keeps track of elapsed time across starts and stops
+type stopwatch struct {
+	lock      sync.Mutex
+	running   bool
+	elapsed   time.Duration
+	startedAt time.Time
+}
+
+func (s *stopwatch) getElapsed() time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.running {
+		return s.elapsed + time.Since(s.startedAt)
+	}
+	return s.elapsed
+}
+
+func (s *stopwatch) setRunning(running bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if running == s.running {
+		return
+	}
+	if running {
+		s.startedAt = time.Now()
+	} else {
+		s.elapsed += time.Since(s.startedAt)
+	}
+	s.running = running
+}
+
+func (s *stopwatch) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.elapsed = 0
+	s.startedAt = time.Now()
+}
+
+// formatDuration gives m:ss, or h:mm:ss if there is an hour or more; tenths adds a tenth of a second digit
+func formatDuration(d time.Duration, tenths bool) string {
+	h := int(d / time.Hour)
+	m := int(d/time.Minute) % 60
+	s := int(d/time.Second) % 60
+	text := fmt.Sprintf("%d:%02d", m, s)
+	if h > 0 {
+		text = fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	if tenths {
+		text += fmt.Sprintf(".%d", int(d/(time.Second/10))%10)
+	}
+	return text
+}
+
+// Stopwatch shows the time since it was started on a Target, in minutes, seconds and tenths
+type Stopwatch struct {
+	ticker
+	watch   stopwatch
+	display textDisplay
+}
+
+// NewStopwatch creates a Stopwatch drawn on the given target, stopped at zero
+func NewStopwatch(target Target) *Stopwatch {
+	s := &Stopwatch{display: textDisplay{target: target}}
+	s.update()
+	return s
+}
+
+// Start starts, or resumes, the stopwatch
+func (s *Stopwatch) Start() {
+	s.watch.setRunning(true)
+	s.start(50*time.Millisecond, s.update)
+}
+
+// Stop pauses the stopwatch
+func (s *Stopwatch) Stop() {
+	s.halt()
+	s.watch.setRunning(false)
+	s.update()
+}
+
+// Reset sets the stopwatch back to zero, without stopping or starting it
+func (s *Stopwatch) Reset() {
+	s.watch.reset()
+	s.update()
+}
+
+// GetElapsed returns the time on the stopwatch
+func (s *Stopwatch) GetElapsed() time.Duration {
+	return s.watch.getElapsed()
+}
+
+func (s *Stopwatch) update() {
+	s.display.show(formatDuration(s.watch.getElapsed(), true), color.White)
+}
+
+// Countdown shows the time remaining from a set duration on a Target, turning red once it reaches zero
+type Countdown struct {
+	ticker
+	watch       stopwatch
+	display     textDisplay
+	lock        sync.Mutex
+	duration    time.Duration
+	doneHandler func()
+	done        bool
+}
+
+// NewCountdown creates a Countdown drawn on the given target, stopped at the given duration
+func NewCountdown(target Target, duration time.Duration) *Countdown {
+	c := &Countdown{display: textDisplay{target: target}, duration: duration}
+	c.update()
+	return c
+}
+
+// SetDoneHandler sets the function called when the countdown reaches zero
+func (c *Countdown) SetDoneHandler(f func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.doneHandler = f
+}
+
+// SetDuration changes the duration being counted down from
+func (c *Countdown) SetDuration(duration time.Duration) {
+	c.lock.Lock()
+	c.duration = duration
+	c.lock.Unlock()
+	c.update()
+}
+
+// Start starts, or resumes, the countdown
+func (c *Countdown) Start() {
+	c.watch.setRunning(true)
+	c.start(50*time.Millisecond, c.update)
+}
+
+// Stop pauses the countdown
+func (c *Countdown) Stop() {
+	c.halt()
+	c.watch.setRunning(false)
+	c.update()
+}
+
+// Reset sets the countdown back to its full duration, without stopping or starting it
+func (c *Countdown) Reset() {
+	c.lock.Lock()
+	c.done = false
+	c.lock.Unlock()
+	c.watch.reset()
+	c.update()
+}
+
+// GetRemaining returns the time left on the countdown
+func (c *Countdown) GetRemaining() time.Duration {
+	c.lock.Lock()
+	duration := c.duration
+	c.lock.Unlock()
+	remaining := duration - c.watch.getElapsed()
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining
+}
+
+func (c *Countdown) update() {
+	remaining := c.GetRemaining()
+	if remaining > 0 {
+		// Round up, so that zero is only shown once the time is really up
+		c.display.show(formatDuration(remaining+time.Second-1, false), color.White)
+		return
+	}
+
+	c.halt()
+	c.watch.setRunning(false)
+	c.display.show(formatDuration(0, false), color.RGBA{255, 0, 0, 255})
+	c.lock.Lock()
+	f := c.doneHandler
+	fire := !c.done
+	c.done = true
+	c.lock.Unlock()
+	if fire && f != nil {
+		go f()
+	}
+}
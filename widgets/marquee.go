@@ -0,0 +1,169 @@
+package widgets
+
+import (
+	"image"
+	"image/color"
+	"sync"
+	"time"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// Marquee shows a line of text on a Target, scrolling it from side to side if it is too long to fit.  The text
+// pauses at each end before scrolling on, then starts again from the beginning.  It is driven by the
+// streamdeck package's frame scheduler.
+type Marquee struct {
+	lock             sync.Mutex
+	target           Target
+	text             string
+	textColour       color.Color
+	backgroundColour color.Color
+	speed            float64
+	pause            time.Duration
+	fontScale        float64
+	offset           int
+	overflow         int
+	restart          time.Duration
+	animation        *streamdeck.Animation
+}
+
+// NewMarquee creates a Marquee drawn on the given target, in white on black, scrolling at 40 pixels a second and
+// pausing for a second at each end
+func NewMarquee(target Target, text string) *Marquee {
+	m := &Marquee{
+		target:           target,
+		text:             text,
+		textColour:       color.White,
+		backgroundColour: color.Black,
+		speed:            40,
+		pause:            time.Second,
+		fontScale:        0.4,
+		offset:           -1,
+	}
+	m.animation = streamdeck.Animate(m.frame)
+	return m
+}
+
+// SetText changes the text, starting the scrolling again from the beginning
+func (m *Marquee) SetText(text string) {
+	m.lock.Lock()
+	m.text = text
+	m.offset = -1
+	m.lock.Unlock()
+	m.restartAnimation()
+}
+
+// SetColours sets the text and background colours
+func (m *Marquee) SetColours(textColour, backgroundColour color.Color) {
+	m.lock.Lock()
+	m.textColour = textColour
+	m.backgroundColour = backgroundColour
+	m.offset = -1
+	m.lock.Unlock()
+}
+
+// SetSpeed sets how fast the text scrolls, in pixels per second
+func (m *Marquee) SetSpeed(pixelsPerSecond float64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if pixelsPerSecond > 0 {
+		m.speed = pixelsPerSecond
+	}
+}
+
+// SetPause sets how long the text stays still at each end
+func (m *Marquee) SetPause(pause time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.pause = pause
+}
+
+// SetFontScale sets the height of the text as a fraction of the height of the target, 0.4 by default
+func (m *Marquee) SetFontScale(scale float64) {
+	m.lock.Lock()
+	m.fontScale = scale
+	m.offset = -1
+	m.lock.Unlock()
+}
+
+// Stop stops the text scrolling
+func (m *Marquee) Stop() {
+	m.lock.Lock()
+	a := m.animation
+	m.animation = nil
+	m.lock.Unlock()
+	if a != nil {
+		a.Stop()
+	}
+}
+
+// Start starts the text scrolling again after Stop
+func (m *Marquee) Start() {
+	m.restartAnimation()
+}
+
+func (m *Marquee) restartAnimation() {
+	m.Stop()
+	m.lock.Lock()
+	m.animation = streamdeck.Animate(m.frame)
+	m.lock.Unlock()
+}
+
+// frame works out where the text should be, and redraws it if it has moved
+func (m *Marquee) frame(elapsed time.Duration) bool {
+	m.lock.Lock()
+	overflow, speed, pause := m.overflow, m.speed, m.pause
+	m.lock.Unlock()
+
+	offset := 0
+	if overflow > 0 {
+		scroll := time.Duration(float64(overflow) / speed * float64(time.Second))
+		cycle := pause + scroll + pause
+		t := elapsed % cycle
+		switch {
+		case t < pause:
+			offset = 0
+		case t < pause+scroll:
+			offset = int(float64(t-pause) / float64(time.Second) * speed)
+		default:
+			offset = overflow
+		}
+	}
+
+	m.lock.Lock()
+	changed := offset != m.offset
+	m.offset = offset
+	m.lock.Unlock()
+	if changed {
+		m.target.Update(m.render)
+	}
+	return true
+}
+
+func (m *Marquee) render(size image.Point) image.Image {
+	m.lock.Lock()
+	text, textColour, backgroundColour := m.text, m.textColour, m.backgroundColour
+	fontSize := float64(size.Y) * m.fontScale
+	offset := m.offset
+	if offset < 0 {
+		offset = 0
+	}
+	m.lock.Unlock()
+
+	img := newCanvas(size, backgroundColour)
+	margin := size.X / 20
+	width := textWidth(text, fontSize)
+	overflow := width - (size.X - 2*margin)
+	x := margin - offset
+	if overflow <= 0 {
+		overflow = 0
+		x = (size.X - width) / 2
+	}
+	drawTextAt(img, img.Bounds(), x, fontSize, text, textColour)
+
+	// The overflow is only known once the size of the target is, so it is fed back for the next frame
+	m.lock.Lock()
+	m.overflow = overflow
+	m.lock.Unlock()
+	return img
+}
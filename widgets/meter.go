@@ -0,0 +1,186 @@
+package widgets
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+	"time"
+)
+
+// Orientation is the direction a Meter's bar grows in
+type Orientation int
+
+const (
+	Vertical Orientation = iota
+	Horizontal
+)
+
+// MeterZone colours the part of a Meter's bar up to a level
+type MeterZone struct {
+	UpTo   float64
+	Colour color.Color
+}
+
+// DefaultMeterZones are the usual green, amber and red zones of an audio meter
+var DefaultMeterZones = []MeterZone{
+	{UpTo: 0.7, Colour: color.RGBA{0, 200, 0, 255}},
+	{UpTo: 0.9, Colour: color.RGBA{255, 176, 0, 255}},
+	{UpTo: 1, Colour: color.RGBA{255, 0, 0, 255}},
+}
+
+// Meter shows a level between 0 and 1 as a bar, such as an audio level, with the peak held for a while.  Levels can
+// be fed in as fast as they arrive; the meter is redrawn at most at its frame rate.
+type Meter struct {
+	lock        sync.Mutex
+	target      Target
+	orientation Orientation
+	zones       []MeterZone
+	level       float64
+	peak        float64
+	peakTime    time.Time
+	peakHold    time.Duration
+	interval    time.Duration
+	lastDraw    time.Time
+	pending     bool
+}
+
+// NewMeter creates a new vertical Meter drawn on the given target, using the DefaultMeterZones, holding peaks for
+// 1.5 seconds and redrawing at most 20 times a second
+func NewMeter(target Target) *Meter {
+	m := &Meter{
+		target:   target,
+		zones:    DefaultMeterZones,
+		peakHold: 1500 * time.Millisecond,
+		interval: time.Second / 20,
+	}
+	m.redraw()
+	return m
+}
+
+// SetOrientation sets whether the bar is vertical or horizontal
+func (m *Meter) SetOrientation(o Orientation) {
+	m.lock.Lock()
+	m.orientation = o
+	m.lock.Unlock()
+	m.redraw()
+}
+
+// SetZones sets the colours of the bar, as zones in ascending order of level
+func (m *Meter) SetZones(zones []MeterZone) {
+	m.lock.Lock()
+	m.zones = zones
+	m.lock.Unlock()
+	m.redraw()
+}
+
+// SetPeakHold sets how long the peak marker stays before falling back to the level; zero turns it off
+func (m *Meter) SetPeakHold(d time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.peakHold = d
+}
+
+// SetMaxFrameRate sets how many times a second the meter is redrawn, at most
+func (m *Meter) SetMaxFrameRate(fps int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if fps > 0 {
+		m.interval = time.Second / time.Duration(fps)
+	}
+}
+
+// SetLevel feeds in a new level, between 0 and 1
+func (m *Meter) SetLevel(level float64) {
+	if level < 0 {
+		level = 0
+	} else if level > 1 {
+		level = 1
+	}
+
+	m.lock.Lock()
+	m.level = level
+	now := time.Now()
+	if level >= m.peak || now.Sub(m.peakTime) > m.peakHold {
+		m.peak = level
+		m.peakTime = now
+	}
+
+	if m.pending {
+		m.lock.Unlock()
+		return
+	}
+	wait := m.interval - now.Sub(m.lastDraw)
+	if wait <= 0 {
+		m.lastDraw = now
+		m.lock.Unlock()
+		m.redraw()
+		return
+	}
+	m.pending = true
+	m.lock.Unlock()
+	time.AfterFunc(wait, func() {
+		m.lock.Lock()
+		m.pending = false
+		m.lastDraw = time.Now()
+		m.lock.Unlock()
+		m.redraw()
+	})
+}
+
+func (m *Meter) redraw() {
+	m.target.Update(m.render)
+}
+
+func (m *Meter) render(size image.Point) image.Image {
+	m.lock.Lock()
+	orientation, zones, level := m.orientation, m.zones, m.level
+	peak := 0.0
+	if m.peakHold > 0 {
+		peak = m.peak
+	}
+	m.lock.Unlock()
+
+	length := size.Y
+	if orientation == Horizontal {
+		length = size.X
+	}
+	// bar gives the part of the image between two levels
+	bar := func(from, to float64) image.Rectangle {
+		a, b := int(from*float64(length)), int(to*float64(length))
+		if orientation == Horizontal {
+			return image.Rect(a, 0, b, size.Y)
+		}
+		return image.Rect(0, size.Y-b, size.X, size.Y-a)
+	}
+
+	img := newCanvas(size, color.Black)
+	from := 0.0
+	for _, zone := range zones {
+		if from >= level {
+			break
+		}
+		to := zone.UpTo
+		if to > level {
+			to = level
+		}
+		draw.Draw(img, bar(from, to), image.NewUniform(zone.Colour), image.Point{0, 0}, draw.Src)
+		from = zone.UpTo
+	}
+
+	if peak > 0 {
+		marker := float64(length/32+1) / float64(length)
+		draw.Draw(img, bar(peak-marker, peak), image.NewUniform(m.zoneColour(zones, peak)), image.Point{0, 0}, draw.Src)
+	}
+	return img
+}
+
+// zoneColour gives the colour of the zone a level is in
+func (m *Meter) zoneColour(zones []MeterZone, level float64) color.Color {
+	for _, zone := range zones {
+		if level <= zone.UpTo {
+			return zone.Colour
+		}
+	}
+	return color.White
+}
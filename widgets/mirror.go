@@ -0,0 +1,107 @@
+package widgets
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+	"time"
+
+	"github.com/disintegration/gift"
+
+	"github.com/SKAARHOJ/go-streamdeck/screen"
+)
+
+// Mirror shows a picture taken over and over at a low frame rate, such as a region of the desktop showing a
+// program monitor or a timer in another application.  The picture is scaled to fit the target, keeping its shape.
+type Mirror struct {
+	ticker
+	lock         sync.Mutex
+	target       Target
+	capture      func() (image.Image, error)
+	interval     time.Duration
+	img          image.Image
+	errorHandler func(error)
+}
+
+// NewMirror creates a Mirror drawn on the given target, showing whatever capture returns, twice a second once
+// it is started; capture could give a window's thumbnail, for example
+func NewMirror(target Target, capture func() (image.Image, error)) *Mirror {
+	return &Mirror{target: target, capture: capture, interval: time.Second / 2}
+}
+
+// NewScreenMirror creates a Mirror of a region of the desktop, in screen coordinates, using screen.Capture
+func NewScreenMirror(target Target, region image.Rectangle) *Mirror {
+	return NewMirror(target, func() (image.Image, error) {
+		return screen.Capture(region)
+	})
+}
+
+// SetInterval sets how often the picture is taken; it takes effect the next time the mirror is started
+func (m *Mirror) SetInterval(interval time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if interval > 0 {
+		m.interval = interval
+	}
+}
+
+// SetErrorHandler sets a function to be called when taking the picture fails; the last picture is kept
+func (m *Mirror) SetErrorHandler(f func(error)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.errorHandler = f
+}
+
+// Start starts the mirror updating
+func (m *Mirror) Start() {
+	m.lock.Lock()
+	interval := m.interval
+	m.lock.Unlock()
+	go m.update()
+	m.start(interval, m.update)
+}
+
+// Stop stops the mirror updating, leaving the last picture shown
+func (m *Mirror) Stop() {
+	m.halt()
+}
+
+func (m *Mirror) update() {
+	img, err := m.capture()
+	m.lock.Lock()
+	if err != nil {
+		f := m.errorHandler
+		m.lock.Unlock()
+		if f != nil {
+			f(err)
+		}
+		return
+	}
+	m.img = img
+	m.lock.Unlock()
+	m.target.Update(m.render)
+}
+
+func (m *Mirror) render(size image.Point) image.Image {
+	m.lock.Lock()
+	img := m.img
+	m.lock.Unlock()
+	canvas := newCanvas(size, color.Black)
+	if img == nil || img.Bounds().Empty() {
+		return canvas
+	}
+
+	// Scale to fit, leaving black bars either side or above and below
+	b := img.Bounds()
+	width, height := size.X, b.Dy()*size.X/b.Dx()
+	if height > size.Y {
+		width, height = b.Dx()*size.Y/b.Dy(), size.Y
+	}
+	g := gift.New(gift.Resize(width, height, gift.LinearResampling))
+	scaled := image.NewRGBA(g.Bounds(b))
+	g.Draw(scaled, img)
+	offset := image.Pt((size.X-width)/2, (size.Y-height)/2)
+	draw.Draw(canvas, scaled.Bounds().Add(offset), scaled, image.Point{}, draw.Src)
+	return canvas
+}
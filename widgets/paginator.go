@@ -0,0 +1,213 @@
+package widgets
+
+import (
+	"image"
+	"image/color"
+	"sync"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// Paginator lays a list of any length out over a set of buttons on a Page, with previous and next buttons to
+// page through it.  Pressing an item calls the select handler, and the buttons are redrawn whenever the list
+// changes.
+type Paginator struct {
+	lock          sync.Mutex
+	items         []string
+	first         int
+	slots         []*paginatorButton
+	prev          *paginatorButton
+	next          *paginatorButton
+	renderer      func(int, string, int) image.Image
+	selectHandler func(int, string)
+}
+
+// NewPaginator creates a Paginator on a page, showing items on the given buttons in order, with previous and
+// next buttons on prevKey and nextKey
+func NewPaginator(page *streamdeck.Page, keys []int, prevKey, nextKey int) *Paginator {
+	p := &Paginator{renderer: renderListItem}
+	for i, key := range keys {
+		btn := &paginatorButton{paginator: p, slot: i}
+		p.slots = append(p.slots, btn)
+		page.AddButton(key, btn)
+	}
+	p.prev = &paginatorButton{paginator: p, slot: slotPrev}
+	page.AddButton(prevKey, p.prev)
+	p.next = &paginatorButton{paginator: p, slot: slotNext}
+	page.AddButton(nextKey, p.next)
+	return p
+}
+
+// SetItems replaces the list, staying on the same page of it where possible
+func (p *Paginator) SetItems(items []string) {
+	p.lock.Lock()
+	p.items = append([]string(nil), items...)
+	if p.first >= len(p.items) {
+		p.first = 0
+		if len(p.slots) > 0 && len(p.items) > 0 {
+			p.first = (len(p.items) - 1) / len(p.slots) * len(p.slots)
+		}
+	}
+	p.lock.Unlock()
+	p.redraw()
+}
+
+// SetSelectHandler sets the function called when an item is pressed, with its index in the list
+func (p *Paginator) SetSelectHandler(f func(index int, item string)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.selectHandler = f
+}
+
+// SetItemRenderer changes how items are drawn; by default they are written as text, white on black
+func (p *Paginator) SetItemRenderer(f func(index int, item string, btnSize int) image.Image) {
+	p.lock.Lock()
+	p.renderer = f
+	p.lock.Unlock()
+	p.redraw()
+}
+
+// GetPage returns which page of the list is showing, and how many pages there are
+func (p *Paginator) GetPage() (int, int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.slots) == 0 {
+		return 0, 0
+	}
+	pages := (len(p.items) + len(p.slots) - 1) / len(p.slots)
+	return p.first / len(p.slots), pages
+}
+
+// NextPage moves on to the next page of the list, if there is one
+func (p *Paginator) NextPage() {
+	p.lock.Lock()
+	moved := p.first+len(p.slots) < len(p.items)
+	if moved {
+		p.first += len(p.slots)
+	}
+	p.lock.Unlock()
+	if moved {
+		p.redraw()
+	}
+}
+
+// PrevPage moves back to the previous page of the list, if there is one
+func (p *Paginator) PrevPage() {
+	p.lock.Lock()
+	moved := p.first > 0
+	if moved {
+		p.first -= len(p.slots)
+	}
+	p.lock.Unlock()
+	if moved {
+		p.redraw()
+	}
+}
+
+func (p *Paginator) redraw() {
+	for _, btn := range p.slots {
+		btn.update()
+	}
+	p.prev.update()
+	p.next.update()
+}
+
+func renderListItem(index int, item string, btnSize int) image.Image {
+	img := newCanvas(image.Pt(btnSize, btnSize), color.Black)
+	drawText(img, image.Rect(0, btnSize/4, btnSize, btnSize*3/4), item, color.White)
+	return img
+}
+
+const (
+	slotPrev = -1
+	slotNext = -2
+)
+
+// paginatorButton is one of the buttons of a Paginator; either an item slot, or the previous or next button
+type paginatorButton struct {
+	paginator     *Paginator
+	slot          int
+	lock          sync.Mutex
+	updateHandler func(streamdeck.Button)
+	btnIndex      int
+}
+
+func (btn *paginatorButton) GetImageForButton(btnSize int) image.Image {
+	p := btn.paginator
+	p.lock.Lock()
+	first, count, renderer := p.first, len(p.items), p.renderer
+	var item string
+	index := first + btn.slot
+	if btn.slot >= 0 && index < count {
+		item = p.items[index]
+	}
+	perPage := len(p.slots)
+	p.lock.Unlock()
+
+	switch btn.slot {
+	case slotPrev, slotNext:
+		colour := color.Color(color.White)
+		if (btn.slot == slotPrev && first == 0) || (btn.slot == slotNext && first+perPage >= count) {
+			colour = color.RGBA{64, 64, 64, 255}
+		}
+		label := "<"
+		if btn.slot == slotNext {
+			label = ">"
+		}
+		img := newCanvas(image.Pt(btnSize, btnSize), color.Black)
+		drawText(img, image.Rect(0, btnSize/4, btnSize, btnSize*3/4), label, colour)
+		return img
+	}
+	if index >= count {
+		return newCanvas(image.Pt(btnSize, btnSize), color.Black)
+	}
+	return renderer(index, item, btnSize)
+}
+
+func (btn *paginatorButton) SetButtonIndex(btnIndex int) {
+	btn.btnIndex = btnIndex
+}
+
+func (btn *paginatorButton) GetButtonIndex() int {
+	return btn.btnIndex
+}
+
+func (btn *paginatorButton) RegisterUpdateHandler(f func(streamdeck.Button)) {
+	btn.lock.Lock()
+	defer btn.lock.Unlock()
+	btn.updateHandler = f
+}
+
+func (btn *paginatorButton) update() {
+	btn.lock.Lock()
+	f := btn.updateHandler
+	btn.lock.Unlock()
+	if f != nil {
+		f(btn)
+	}
+}
+
+func (btn *paginatorButton) Pressed() {
+	p := btn.paginator
+	switch btn.slot {
+	case slotPrev:
+		p.PrevPage()
+		return
+	case slotNext:
+		p.NextPage()
+		return
+	}
+
+	p.lock.Lock()
+	index := p.first + btn.slot
+	if index >= len(p.items) {
+		p.lock.Unlock()
+		return
+	}
+	item := p.items[index]
+	f := p.selectHandler
+	p.lock.Unlock()
+	if f != nil {
+		f(index, item)
+	}
+}
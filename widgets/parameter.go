@@ -0,0 +1,185 @@
+package widgets
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// ParameterStyle is how a Parameter shows its value
+type ParameterStyle int
+
+const (
+	// ParameterBar shows the value as a horizontal bar, with the label and value written over it
+	ParameterBar ParameterStyle = iota
+	// ParameterNumeric shows the label above the value as text
+	ParameterNumeric
+)
+
+// Parameter binds an encoder to a value between a minimum and a maximum, changing it by step for each pulse of
+// rotation, and shows the value on a Target such as the button above the encoder or a touchscreen segment
+type Parameter struct {
+	lock          sync.Mutex
+	target        Target
+	value         float64
+	min           float64
+	max           float64
+	step          float64
+	wrap          bool
+	style         ParameterStyle
+	label         string
+	format        string
+	colour        color.Color
+	changeHandler func(float64)
+}
+
+// NewParameter creates a new Parameter drawn on the given target, starting at the minimum value
+func NewParameter(target Target, min, max, step float64) *Parameter {
+	p := &Parameter{
+		target: target,
+		value:  min,
+		min:    min,
+		max:    max,
+		step:   step,
+		format: "%.0f",
+		colour: color.RGBA{0, 128, 255, 255},
+	}
+	p.update()
+	return p
+}
+
+// BindEncoder makes rotating the given encoder change the value while the page is active
+func (p *Parameter) BindEncoder(page *streamdeck.Page, encIndex int) {
+	page.SetEncoderRotateHandler(encIndex, p.Rotate)
+}
+
+// Rotate changes the value by a number of steps, as if the encoder had been turned, and calls the change handler
+func (p *Parameter) Rotate(pulses int) {
+	p.lock.Lock()
+	old := p.value
+	v := p.value + float64(pulses)*p.step
+	if p.wrap {
+		span := p.max - p.min + p.step
+		v = p.min + math.Mod(math.Mod(v-p.min, span)+span, span)
+	}
+	p.value = p.clamp(v)
+	changed := p.value != old
+	v = p.value
+	f := p.changeHandler
+	p.lock.Unlock()
+
+	if !changed {
+		return
+	}
+	p.update()
+	if f != nil {
+		f(v)
+	}
+}
+
+// GetValue returns the current value
+func (p *Parameter) GetValue() float64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.value
+}
+
+// SetValue sets the value without calling the change handler, for example when an external system reports what
+// the real value is
+func (p *Parameter) SetValue(v float64) {
+	p.lock.Lock()
+	p.value = p.clamp(v)
+	p.lock.Unlock()
+	p.update()
+}
+
+// SetChangeHandler sets the function called with the new value whenever the encoder changes it
+func (p *Parameter) SetChangeHandler(f func(float64)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.changeHandler = f
+}
+
+// SetWrap sets whether the value wraps around from the maximum to the minimum and vice versa, rather than stopping
+func (p *Parameter) SetWrap(wrap bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.wrap = wrap
+}
+
+// SetStyle sets how the value is shown
+func (p *Parameter) SetStyle(style ParameterStyle) {
+	p.lock.Lock()
+	p.style = style
+	p.lock.Unlock()
+	p.update()
+}
+
+// SetLabel sets the name shown alongside the value
+func (p *Parameter) SetLabel(label string) {
+	p.lock.Lock()
+	p.label = label
+	p.lock.Unlock()
+	p.update()
+}
+
+// SetFormat sets the fmt format used to show the value, "%.0f" by default
+func (p *Parameter) SetFormat(format string) {
+	p.lock.Lock()
+	p.format = format
+	p.lock.Unlock()
+	p.update()
+}
+
+// SetColour sets the colour of the bar
+func (p *Parameter) SetColour(colour color.Color) {
+	p.lock.Lock()
+	p.colour = colour
+	p.lock.Unlock()
+	p.update()
+}
+
+// clamp limits a value to the range, and must be called with the lock held
+func (p *Parameter) clamp(v float64) float64 {
+	return math.Max(p.min, math.Min(p.max, v))
+}
+
+func (p *Parameter) update() {
+	p.target.Update(p.render)
+}
+
+func (p *Parameter) render(size image.Point) image.Image {
+	p.lock.Lock()
+	value, min, max := p.value, p.min, p.max
+	style, label, colour := p.style, p.label, p.colour
+	text := fmt.Sprintf(p.format, value)
+	p.lock.Unlock()
+
+	img := newCanvas(size, color.Black)
+	switch style {
+	case ParameterNumeric:
+		if label == "" {
+			drawText(img, img.Bounds(), text, color.White)
+		} else {
+			drawText(img, image.Rect(0, 0, size.X, size.Y*2/5), label, color.RGBA{160, 160, 160, 255})
+			drawText(img, image.Rect(0, size.Y*2/5, size.X, size.Y), text, color.White)
+		}
+	default:
+		fraction := 0.0
+		if max > min {
+			fraction = (value - min) / (max - min)
+		}
+		bar := image.Rect(0, size.Y*2/3, int(float64(size.X)*fraction), size.Y)
+		draw.Draw(img, bar, image.NewUniform(colour), image.Point{0, 0}, draw.Src)
+		if label != "" {
+			text = label + " " + text
+		}
+		drawText(img, image.Rect(0, 0, size.X, size.Y*2/3), text, color.White)
+	}
+	return img
+}
@@ -0,0 +1,124 @@
+package widgets
+
+import (
+	"image"
+	"sync"
+
+	streamdeck "github.com/SKAARHOJ/go-streamdeck"
+)
+
+// Target is somewhere a widget draws itself: a button, or an area of the touchscreen or info display.  When the
+// widget changes it calls Update, and the target calls render (now or later) with the size it needs drawing.
+type Target interface {
+	Update(render func(size image.Point) image.Image) error
+}
+
+// ButtonTarget is a Target which is also a streamdeck.Button, so that it can be added to a StreamDeck or a Page
+// like any other button
+type ButtonTarget struct {
+	lock          sync.Mutex
+	render        func(image.Point) image.Image
+	updateHandler func(streamdeck.Button)
+	btnIndex      int
+	actionHandler streamdeck.ButtonActionHandler
+}
+
+// NewButtonTarget creates a new ButtonTarget, to be added to a StreamDeck or Page with AddButton
+func NewButtonTarget() *ButtonTarget {
+	return &ButtonTarget{}
+}
+
+// Update is the Target implementation, asking for the button to be redrawn
+func (t *ButtonTarget) Update(render func(image.Point) image.Image) error {
+	t.lock.Lock()
+	t.render = render
+	f := t.updateHandler
+	t.lock.Unlock()
+	if f != nil {
+		f(t)
+	}
+	return nil
+}
+
+// GetImageForButton is the interface implemention to get the button's image as an image.Image
+func (t *ButtonTarget) GetImageForButton(btnSize int) image.Image {
+	t.lock.Lock()
+	render := t.render
+	t.lock.Unlock()
+	if render == nil {
+		return image.NewRGBA(image.Rect(0, 0, btnSize, btnSize))
+	}
+	return render(image.Pt(btnSize, btnSize))
+}
+
+// SetButtonIndex is the interface implemention to set which button on the Streamdeck this is
+func (t *ButtonTarget) SetButtonIndex(btnIndex int) {
+	t.btnIndex = btnIndex
+}
+
+// GetButtonIndex is the interface implemention to get which button on the Streamdeck this is
+func (t *ButtonTarget) GetButtonIndex() int {
+	return t.btnIndex
+}
+
+// RegisterUpdateHandler is the interface implemention to let the engine give this button a callback to
+// use to request that the button image is updated on the Streamdeck.
+func (t *ButtonTarget) RegisterUpdateHandler(f func(streamdeck.Button)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.updateHandler = f
+}
+
+// SetActionHandler allows a ButtonActionHandler implementation to be
+// set on this button, so that something can happen when the button is pressed.
+func (t *ButtonTarget) SetActionHandler(a streamdeck.ButtonActionHandler) {
+	t.actionHandler = a
+}
+
+// Pressed is the interface implementation for letting the engine notify that the button has been
+// pressed.  This hands-off to the specified ButtonActionHandler if it has been set.
+func (t *ButtonTarget) Pressed() {
+	if t.actionHandler != nil {
+		t.actionHandler.Pressed(t)
+	}
+}
+
+// AreaTarget is a Target drawing straight onto an area of the touchscreen or info display.  It is drawn
+// whenever the widget changes, regardless of which page is active.
+type AreaTarget struct {
+	dev  *streamdeck.Device
+	area image.Rectangle
+}
+
+// NewAreaTarget creates a Target for the given area of the device's touchscreen or info display, in device
+// coordinates
+func NewAreaTarget(dev *streamdeck.Device, area image.Rectangle) *AreaTarget {
+	return &AreaTarget{dev: dev, area: area}
+}
+
+// NewInfoDisplayTarget creates a Target covering the whole of the device's touchscreen or info display
+func NewInfoDisplayTarget(dev *streamdeck.Device) *AreaTarget {
+	pos := dev.GetTouchscreenPosition()
+	return NewAreaTarget(dev, image.Rectangle{Min: pos, Max: pos.Add(dev.GetTouchscreenSize())})
+}
+
+// NewTouchSegmentTarget creates a Target for one of a number of equal-width segments of the touchscreen, for
+// example the segment above each encoder of a Stream Deck Plus
+func NewTouchSegmentTarget(dev *streamdeck.Device, segment, segments int) *AreaTarget {
+	pos := dev.GetTouchscreenPosition()
+	size := dev.GetTouchscreenSize()
+	area := image.Rect(size.X*segment/segments, 0, size.X*(segment+1)/segments, size.Y)
+	return NewAreaTarget(dev, area.Add(pos))
+}
+
+// Update is the Target implementation, drawing the widget onto the area straight away
+func (t *AreaTarget) Update(render func(image.Point) image.Image) error {
+	img := render(t.area.Size())
+	return t.dev.WriteRawImageToAreaUnscaled(t.area.Min.X, t.area.Min.Y, img)
+}
+
+// KeyAboveEncoder gives the index of the button on the bottom row directly above the given encoder, or -1 if
+// there isn't one
+func KeyAboveEncoder(dev *streamdeck.Device, encIndex int) int {
+	return dev.ButtonAt(int(dev.GetButtonRows())-1, encIndex)
+}
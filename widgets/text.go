@@ -0,0 +1,41 @@
+package widgets
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/SKAARHOJ/go-streamdeck/text"
+)
+
+// newCanvas gives an image of the given size filled with a colour
+func newCanvas(size image.Point, colour color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Bounds(), image.NewUniform(colour), image.Point{0, 0}, draw.Src)
+	return img
+}
+
+// drawText draws a single line of text centred in the given rectangle, as large as will fit
+func drawText(img draw.Image, r image.Rectangle, label string, colour color.Color) {
+	if label == "" || r.Dx() <= 0 || r.Dy() <= 0 {
+		return
+	}
+	size := float64(r.Dy()) * 0.8
+	width := textWidth(label, size)
+	if maxWidth := r.Dx() * 9 / 10; width > maxWidth {
+		size = size * float64(maxWidth) / float64(width)
+		width = textWidth(label, size)
+	}
+
+	drawTextAt(img, r, r.Min.X+(r.Dx()-width)/2, size, label, colour)
+}
+
+// drawTextAt draws a single line of text starting at x, vertically centred and clipped to the given rectangle
+func drawTextAt(img draw.Image, r image.Rectangle, x int, size float64, label string, colour color.Color) {
+	y := r.Min.Y + (r.Dy()+int(size*0.7))/2 // Cap height is roughly 70% of the font size
+	text.Draw(img, r, x, y, size, label, colour)
+}
+
+func textWidth(label string, size float64) int {
+	return text.Width(label, size)
+}